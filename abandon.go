@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// stuckSend is an unconfirmed send --abandon-stuck found.  Amount is what it
+// sent, across all its outputs, not counting the fee.
+type stuckSend struct {
+	TXID   string    `json:"txid"`
+	Wallet string    `json:"wallet,omitempty"`
+	Time   time.Time `json:"time"`
+	Age    int64     `json:"age_seconds"`
+	Amount float64   `json:"amount"`
+
+	// Error is the node's reason for refusing to abandon it
+	Error string `json:"error,omitempty"`
+}
+
+// abandonSummary is what --abandon-stuck found, and, with --confirm-abandon,
+// what it abandoned.  Without --confirm-abandon nothing is abandoned, and
+// Sends is what would have been.
+type abandonSummary struct {
+	StuckAfter int64       `json:"stuck_after_seconds"`
+	Confirmed  bool        `json:"confirmed"`
+	Abandoned  int         `json:"abandoned"`
+	Sends      []stuckSend `json:"sends"`
+}
+
+// findStuckSends picks out the sends that have sat unconfirmed, or
+// conflicted, for at least stuckAfter and that the wallet hasn't already
+// abandoned, oldest first.  Age goes by when the wallet made the send, not
+// --time-field, since an unconfirmed send has no block time.  Only what
+// came from source, the node the sends would be abandoned on, counts; a
+// send to several outputs is listed once per output, so those are added up.
+func findStuckSends(txList []*Transaction, source string, stuckAfter time.Duration, now time.Time) []stuckSend {
+	var found = make(map[string]*stuckSend)
+	var keys []string
+	for _, tx := range txList {
+		if tx.source != source || tx.Category != "send" || tx.Confirmations > 0 || tx.Abandoned {
+			continue
+		}
+		var sent = time.Unix(tx.Time, 0)
+		if now.Sub(sent) < stuckAfter {
+			continue
+		}
+		var key = tx.wallet + "/" + tx.TXID
+		var s = found[key]
+		if s == nil {
+			s = &stuckSend{TXID: tx.TXID, Wallet: tx.wallet, Time: sent, Age: int64(now.Sub(sent) / time.Second)}
+			found[key] = s
+			keys = append(keys, key)
+		}
+		s.Amount -= tx.Amount
+	}
+
+	var sends []stuckSend
+	for _, key := range keys {
+		sends = append(sends, *found[key])
+	}
+	sort.SliceStable(sends, func(i, j int) bool { return sends[i].Time.Before(sends[j].Time) })
+	return sends
+}
+
+// abandonStuck calls abandontransaction, in the wallet that made it, for
+// each stuck send, but only when confirm is set.  Abandoning frees the
+// send's inputs for the wallet to spend again.  The node refuses a send
+// still in its own mempool, and can't pull one from any other mempool it
+// reached, so an abandoned send may yet confirm.
+func abandonStuck(u *url.URL, sends []stuckSend, stuckAfter time.Duration, confirm bool) *abandonSummary {
+	var s = &abandonSummary{StuckAfter: int64(stuckAfter / time.Second), Confirmed: confirm, Sends: sends}
+	if !confirm {
+		return s
+	}
+	for i := range s.Sends {
+		var send = &s.Sends[i]
+		var wu = nodeURL(u)
+		if send.Wallet != "" {
+			wu = walletURL(u, send.Wallet)
+		}
+		var err = rpcCall(wu, "abandontransaction", []interface{}{send.TXID}, nil)
+		if err != nil {
+			send.Error = err.Error()
+			continue
+		}
+		s.Abandoned++
+	}
+	return s
+}
+
+// failed counts the sends the node refused to abandon
+func (s *abandonSummary) failed() int {
+	if !s.Confirmed {
+		return 0
+	}
+	return len(s.Sends) - s.Abandoned
+}
+
+// print lists each send with its age and amount, so what was abandoned can
+// be checked against the wallet afterwards
+func (s *abandonSummary) print(w io.Writer) {
+	var stuckAfter = formatAge(time.Duration(s.StuckAfter) * time.Second)
+	if s.Confirmed {
+		fmt.Fprintf(w, "Abandoned %d stuck transactions (unconfirmed for %s or more)\n", s.Abandoned, stuckAfter)
+	} else {
+		fmt.Fprintf(w, "Would abandon %d stuck transactions (unconfirmed for %s or more); add --confirm-abandon to abandon them\n", len(s.Sends), stuckAfter)
+	}
+	for _, send := range s.Sends {
+		var wallet string
+		if send.Wallet != "" {
+			wallet = fmt.Sprintf(" (wallet %q)", send.Wallet)
+		}
+		fmt.Fprintf(w, "  %s%s: age %s, amount %0.8f\n", send.TXID, wallet, formatAge(time.Duration(send.Age)*time.Second), send.Amount)
+		if send.Error != "" {
+			fmt.Fprintf(w, "    not abandoned: %s\n", send.Error)
+		}
+	}
+}
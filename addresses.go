@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+const watchMinConf = 1
+
+// printWatchedAddresses looks up each address in every wallet, since an
+// address only belongs to one of them and the others will just say so.
+// Coinbase outputs still maturing are counted too, and marked, on nodes new
+// enough to include them (23.0 and up); an older node rejects the parameter
+// and is only asked the plain question from then on.
+func printWatchedAddresses(u *url.URL, wallets, addresses []string) {
+	fmt.Println()
+	fmt.Printf("Watched addresses (%d+ confirmations):\n", watchMinConf)
+
+	var withImmature = true
+	var total float64
+	for _, addr := range addresses {
+		var received, immature float64
+		var found bool
+		var lastErr error
+		for _, w := range wallets {
+			var amount float64
+			var err = rpcCall(walletURL(u, w), "getreceivedbyaddress", []interface{}{addr, watchMinConf}, &amount)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			received += amount
+			found = true
+
+			if !withImmature {
+				continue
+			}
+			var all float64
+			err = rpcCall(walletURL(u, w), "getreceivedbyaddress", []interface{}{addr, watchMinConf, true}, &all)
+			if rejectsParam(err) {
+				withImmature = false
+				continue
+			}
+			if err == nil && all > amount {
+				immature += all - amount
+			}
+		}
+
+		if !found {
+			fmt.Printf("%s:\t%s\n", addr, lastErr)
+			continue
+		}
+		received += immature
+		total += received
+		if immature > 0 {
+			fmt.Printf("%s:\t%14.8f  [immature %0.8f]\n", addr, received, immature)
+			continue
+		}
+		fmt.Printf("%s:\t%14.8f\n", addr, received)
+	}
+
+	if len(addresses) > 1 {
+		fmt.Printf("Total received:\t%14.8f\n", total)
+	}
+}
+
+// rejectsParam is whether err is the node refusing a parameter it doesn't
+// know: an invalid parameter, or on older nodes the usage text that comes
+// back for too many of them
+func rejectsParam(err error) bool {
+	var rerr *RPCError
+	return errors.As(err, &rerr) && (rerr.Code == -8 || rerr.Code == -1)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Nerdmaster/dynamo-tx-stats/stats"
+)
+
+// defaultAddrPrefixes are Bitcoin's; coins with their own address formats
+// will want --addr-prefix
+var defaultAddrPrefixes = map[string][]string{
+	"legacy": {"1"},
+	"p2sh":   {"3"},
+	"bech32": {"bc1"},
+}
+
+// prefixMap is a repeatable "type=prefix[,prefix...]" flag
+type prefixMap map[string][]string
+
+func (m prefixMap) String() string {
+	var parts []string
+	for k, v := range m {
+		parts = append(parts, k+"="+strings.Join(v, ","))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, " ")
+}
+
+func (m prefixMap) Set(s string) error {
+	var kind, list, ok = strings.Cut(s, "=")
+	if !ok || kind == "" || list == "" {
+		return fmt.Errorf("expected type=prefix[,prefix...], got %q", s)
+	}
+	m[kind] = append(m[kind], strings.Split(list, ",")...)
+	return nil
+}
+
+func (m prefixMap) reset() {
+	for k := range m {
+		delete(m, k)
+	}
+}
+
+// classifyAddress picks the type whose prefix is the longest match, since
+// one type's prefix can be the start of another's ("b" vs "bc1")
+func classifyAddress(prefixes map[string][]string, addr string) string {
+	var best, bestLen = "unknown", 0
+	for kind, list := range prefixes {
+		for _, p := range list {
+			if len(p) > bestLen && strings.HasPrefix(addr, p) {
+				best, bestLen = kind, len(p)
+			}
+		}
+	}
+	return best
+}
+
+type addrTypeRow struct {
+	Type       string    `json:"type"`
+	Coins      float64   `json:"coins"`
+	Blocks     int64     `json:"blocks"`
+	FirstBlock int64     `json:"first_block"`
+	LastBlock  int64     `json:"last_block"`
+	LastSeen   time.Time `json:"last_seen"`
+}
+
+// addrTypeRows totals the counted generations by address type, largest first
+func addrTypeRows(prefixes map[string][]string, blocks []*Transaction) []addrTypeRow {
+	var buckets = make(map[string]*stats.Bucket)
+	var seen = make(map[string]time.Time)
+	for _, tx := range blocks {
+		var kind = classifyAddress(prefixes, tx.Address)
+		if buckets[kind] == nil {
+			buckets[kind] = &stats.Bucket{}
+		}
+		buckets[kind].Record(tx.Blockheight, tx.Amount)
+		if tx.dt.After(seen[kind]) {
+			seen[kind] = tx.dt
+		}
+	}
+
+	var rows []addrTypeRow
+	for kind, b := range buckets {
+		rows = append(rows, addrTypeRow{Type: kind, Coins: b.Coins, Blocks: b.Blocks, FirstBlock: b.FirstBlock, LastBlock: b.LastBlock, LastSeen: seen[kind]})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Coins != rows[j].Coins {
+			return rows[i].Coins > rows[j].Coins
+		}
+		return rows[i].Type < rows[j].Type
+	})
+	return rows
+}
+
+func (v *reportView) printAddrTypes() {
+	fmt.Println()
+	fmt.Println("Generated by address type:")
+	for _, r := range v.AddrTypes {
+		fmt.Printf("%-10s\t%8.2f\t%4d blocks\tlast %s\n", r.Type+":", r.Coins, r.Blocks, r.LastSeen.Format(dateTimeLayout))
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Nerdmaster/dynamo-tx-stats/window"
+)
+
+// allTimeCount is how many transactions --all-time asks listtransactions
+// for, to reach back to each wallet's first block rather than only as far
+// as the report needs
+const allTimeCount = 1000000
+
+// monthLength is an average month, for the all-time monthly average
+const monthLength = time.Duration(365.25 / 12 * float64(window.Day))
+
+// allTimeStats is --all-time: everything the wallets have earned, not only
+// in the report window.  Since is set when a wallet's history was cut off
+// at allTimeCount, so the figures only reach back that far.
+type allTimeStats struct {
+	Total          float64    `json:"total"`
+	Transactions   int        `json:"transactions"`
+	First          *time.Time `json:"first,omitempty"`
+	Months         float64    `json:"months"`
+	MonthlyAverage float64    `json:"monthly_average"`
+	Since          *time.Time `json:"truncated_since,omitempty"`
+}
+
+// newAllTimeStats totals every countable transaction in txList.  The
+// monthly average is over the months since the first of them, counting a
+// start less than a month ago as a whole month so a new rig's average isn't
+// extrapolated from a few days.  truncated is, per wallet, the oldest
+// transaction of a history that hit the fetch limit.
+func newAllTimeStats(txList []*Transaction, truncated map[string]time.Time, now time.Time) *allTimeStats {
+	var s = &allTimeStats{}
+	for _, tx := range txList {
+		if !countable(tx, now) {
+			continue
+		}
+		s.Total += tx.Amount
+		s.Transactions++
+		if s.First == nil || tx.dt.Before(*s.First) {
+			var first = tx.dt
+			s.First = &first
+		}
+	}
+	if s.First != nil {
+		s.Months = float64(now.Sub(*s.First)) / float64(monthLength)
+		var months = s.Months
+		if months < 1 {
+			months = 1
+		}
+		s.MonthlyAverage = s.Total / months
+	}
+	for _, oldest := range truncated {
+		if s.Since == nil || oldest.After(*s.Since) {
+			var since = oldest
+			s.Since = &since
+		}
+	}
+	return s
+}
+
+func (s *allTimeStats) print(w io.Writer) {
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "--- All-Time Stats ---")
+	if s.First == nil {
+		fmt.Fprintln(w, "No coins generated yet")
+		return
+	}
+	fmt.Fprintf(w, "Total mined:      %0.2f\n", s.Total)
+	fmt.Fprintf(w, "Transactions:     %d\n", s.Transactions)
+	fmt.Fprintf(w, "Mining started:   %s\n", s.First.Format(dateTimeLayout))
+	fmt.Fprintf(w, "Monthly average:  %0.2f (%0.1f months mining)\n", s.MonthlyAverage, s.Months)
+	if s.Since != nil {
+		fmt.Fprintf(w, "(history before %s wasn't fetched; these are UNDER-COUNTED)\n", s.Since.Format(dateTimeLayout))
+	}
+}
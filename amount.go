@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"os"
+	"strconv"
+)
+
+// satoshisPerCoin is the number of base units in one coin
+const satoshisPerCoin = 1e8
+
+// toSatoshis converts a coin amount to whole base units, rounding off the
+// float noise so amounts compare exactly at a threshold
+func toSatoshis(coins float64) int64 {
+	return int64(math.Round(coins * satoshisPerCoin))
+}
+
+// dustSummary counts the generations --min-amount kept out of the block
+// counts
+type dustSummary struct {
+	Count    int     `json:"count"`
+	Coins    float64 `json:"coins"`
+	InTotals bool    `json:"in_totals"`
+}
+
+func (d *dustSummary) record(amount float64) {
+	d.Count++
+	d.Coins += amount
+}
+
+func (d *dustSummary) print(w io.Writer) {
+	var where = "left out of the totals"
+	if d.InTotals {
+		where = "included in the totals"
+	}
+	fmt.Fprintf(w, "Dust generations excluded from block counts: %d (%0.8f coins, %s)\n", d.Count, d.Coins, where)
+}
+
+// decodeAmount converts n to a float64 and reports whether that lost
+// anything: the shortest decimal that round-trips the float must be the
+// same number n spelled out
+func decodeAmount(n json.Number) (float64, bool, error) {
+	if n == "" {
+		return 0, false, nil
+	}
+	var f, err = n.Float64()
+	if err != nil {
+		return 0, false, err
+	}
+	var exact, ok = new(big.Rat).SetString(string(n))
+	if !ok {
+		return f, false, nil
+	}
+	var back, _ = new(big.Rat).SetString(strconv.FormatFloat(f, 'g', -1, 64))
+	return f, back == nil || exact.Cmp(back) != 0, nil
+}
+
+// warnImprecise names each transaction whose amount or fee couldn't be held
+// exactly, since its totals may be off in the last digits
+func warnImprecise(wallet string, list []*Transaction) {
+	for _, tx := range list {
+		if tx.imprecise {
+			fmt.Fprintf(os.Stderr, "WARNING: wallet %q transaction %s has an amount with more digits than can be held exactly; it was rounded to %0.8f\n", wallet, tx.TXID, tx.Amount)
+		}
+	}
+}
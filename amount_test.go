@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDecodeAmount(t *testing.T) {
+	var tests = []struct {
+		in    string
+		want  float64
+		lossy bool
+	}{
+		{"", 0, false},
+		{"5", 5, false},
+		{"0.1", 0.1, false},
+		{"0.00000001", 1e-8, false},
+		{"1e-8", 1e-8, false},
+		{"-12.50000000", -12.5, false},
+		{"0.30000000000000001", 0.3, true},
+		{"0.12345678901234567890", 0.12345678901234568, true},
+	}
+	for _, tt := range tests {
+		var got, lossy, err = decodeAmount(json.Number(tt.in))
+		if err != nil || got != tt.want || lossy != tt.lossy {
+			t.Errorf("decodeAmount(%q) = %v, %v, %v; want %v, %v", tt.in, got, lossy, err, tt.want, tt.lossy)
+		}
+	}
+	if _, _, err := decodeAmount("lots"); err == nil {
+		t.Error("decodeAmount(\"lots\") succeeded")
+	}
+}
+
+// A transaction whose amount was rounded on the way in is named in a warning
+func TestWarnImprecise(t *testing.T) {
+	var list []*Transaction
+	var err error
+	var stderr = capture(t, &os.Stderr, func() {
+		err = json.Unmarshal([]byte(`[
+			{"txid": "aa", "category": "generate", "amount": 5.00000000000000001},
+			{"txid": "bb", "category": "generate", "amount": 5.25}
+		]`), &list)
+		warnImprecise("rig1", list)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stderr, `wallet "rig1" transaction aa has an amount`) || strings.Contains(stderr, "transaction bb") {
+		t.Errorf("warnings: %q", stderr)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+// markAnomalies sets the z-score of each completed day against the mean and
+// standard deviation of the completed days, and flags those past threshold.
+// Today is left out: a partial day would always look like a slump.  So are
+// days some wallet has no data for, which would look like one just the same.
+func (v *reportView) markAnomalies(threshold float64) {
+	var complete []int
+	for i, row := range v.Daily {
+		if row.Projected == nil && !row.Gap {
+			complete = append(complete, i)
+		}
+	}
+	if len(complete) < 2 {
+		return
+	}
+
+	var sum float64
+	for _, i := range complete {
+		sum += v.Daily[i].Coins
+	}
+	var mean = sum / float64(len(complete))
+	var sq float64
+	for _, i := range complete {
+		sq += (v.Daily[i].Coins - mean) * (v.Daily[i].Coins - mean)
+	}
+	var stddev = math.Sqrt(sq / float64(len(complete)))
+	if stddev == 0 {
+		return
+	}
+
+	v.AnomalyThreshold = threshold
+	for _, i := range complete {
+		var z = (v.Daily[i].Coins - mean) / stddev
+		v.Daily[i].ZScore = &z
+		v.Daily[i].Anomaly = math.Abs(z) > threshold
+	}
+}
+
+func (row reportRow) anomalyTag() string {
+	if !row.Anomaly {
+		return ""
+	}
+	return fmt.Sprintf(" [ANOMALY z=%+0.2f]", *row.ZScore)
+}
+
+func (v *reportView) printAnomalyCount(w io.Writer) {
+	var n int
+	for _, row := range v.Daily {
+		if row.Anomaly {
+			n++
+		}
+	}
+	fmt.Fprintf(w, "Anomalous days: %d (|z| > %g)\n", n, v.AnomalyThreshold)
+}
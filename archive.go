@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// archiveSchema is bumped whenever the archive layout changes in a way
+// older code couldn't read
+const archiveSchema = 1
+
+// txArchive is --tx-archive: every transaction the node has listed for each
+// wallet, kept so a report can reach back past what the node still keeps.
+// The node is the authority for everything it still lists; the archive only
+// fills in what's older than the oldest transaction it gives.
+type txArchive struct {
+	Schema  int                               `json:"schema"`
+	Wallets map[string]map[string]*archivedTx `json:"wallets"`
+
+	// Audit is the last audit's counts, for reports to pass on
+	Audit *auditResult `json:"audit,omitempty"`
+
+	// used is the archived transactions this run's report is counting, as
+	// copies taken into the report with when the node last listed each
+	used []*archivedTx
+}
+
+// archivedTx is one archived listing entry, with when the node last listed
+// it
+type archivedTx struct {
+	Tx   *Transaction `json:"tx"`
+	Seen int64        `json:"seen"`
+}
+
+// archiveKey is a listing entry's identity.  A transaction paying the
+// wallet from itself is listed as both a send and a receive of the same
+// output, so category is part of it.
+func archiveKey(tx *Transaction) string {
+	return fmt.Sprintf("%s:%d:%s", tx.TXID, tx.Vout, tx.Category)
+}
+
+func loadArchive(path string) (*txArchive, error) {
+	var a = &txArchive{Schema: archiveSchema, Wallets: make(map[string]map[string]*archivedTx)}
+	var data, err = os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return a, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, a)
+	if err != nil {
+		return nil, err
+	}
+	if a.Schema > archiveSchema {
+		return nil, fmt.Errorf("archive schema %d is newer than this build understands (%d)", a.Schema, archiveSchema)
+	}
+	if a.Wallets == nil {
+		a.Wallets = make(map[string]map[string]*archivedTx)
+	}
+	a.Schema = archiveSchema
+	return a, nil
+}
+
+func (a *txArchive) save(path string) error {
+	var data, err = json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'), 0600)
+}
+
+// merge brings the wallet's archive up to date with live, the node's
+// listing, and returns live with the archived transactions older than any
+// the node still lists added on.  Within the node's span, what it lists
+// replaces what was archived: an entry it no longer lists is dropped, and
+// one it lists differently (a different amount, or as a different category)
+// is logged to w as a conflict.
+func (a *txArchive) merge(wallet string, live []*Transaction, field string, now time.Time, w io.Writer) []*Transaction {
+	var archived = a.Wallets[wallet]
+	if archived == nil {
+		archived = make(map[string]*archivedTx)
+		a.Wallets[wallet] = archived
+	}
+
+	var oldest time.Time
+	var outputs = make(map[string][]*Transaction)
+	for _, tx := range live {
+		if t := txTime(tx, field); oldest.IsZero() || t.Before(oldest) {
+			oldest = t
+		}
+		var out = fmt.Sprintf("%s:%d", tx.TXID, tx.Vout)
+		outputs[out] = append(outputs[out], tx)
+	}
+
+	var merged = live
+	for key, e := range archived {
+		var out = fmt.Sprintf("%s:%d", e.Tx.TXID, e.Tx.Vout)
+		if listed, ok := outputs[out]; ok {
+			if !listsSame(listed, e.Tx) {
+				fmt.Fprintf(w, "Archive conflict in wallet %q: %s was archived as %s %0.8f, the node now lists it as %s; keeping the node's\n", wallet, out, e.Tx.Category, e.Tx.Amount, describeListed(listed))
+			}
+			delete(archived, key)
+			continue
+		}
+		if len(live) > 0 && !txTime(e.Tx, field).Before(oldest) {
+			delete(archived, key)
+			continue
+		}
+		var tx = *e.Tx
+		tx.archived = true
+		merged = append(merged, &tx)
+		a.used = append(a.used, &archivedTx{Tx: &tx, Seen: e.Seen})
+	}
+
+	for _, tx := range live {
+		archived[archiveKey(tx)] = &archivedTx{Tx: tx, Seen: now.Unix()}
+	}
+	return merged
+}
+
+// listsSame is whether the node's entries for an output include the
+// archived one as it was archived
+func listsSame(listed []*Transaction, archived *Transaction) bool {
+	for _, tx := range listed {
+		if tx.Category == archived.Category && toSatoshis(tx.Amount) == toSatoshis(archived.Amount) {
+			return true
+		}
+	}
+	return false
+}
+
+func describeListed(listed []*Transaction) string {
+	var s string
+	for i, tx := range listed {
+		if i > 0 {
+			s += " and "
+		}
+		s += fmt.Sprintf("%s %0.8f", tx.Category, tx.Amount)
+	}
+	return s
+}
+
+// archiveSummary is how much of the report window came from the archive
+// rather than the node, and how stale the archived part might be
+type archiveSummary struct {
+	Live     int        `json:"live"`
+	Archived int        `json:"archived"`
+	Through  *time.Time `json:"through,omitempty"`
+	Verified *time.Time `json:"verified,omitempty"`
+
+	Audit *auditResult `json:"audit,omitempty"`
+}
+
+// summary counts the window's transactions by where they came from.
+// Verified is the least recent time the node listed any archived
+// transaction counted, so everything from the archive was last confirmed
+// by the node no earlier than that.
+func (a *txArchive) summary(txList []*Transaction, begin, now time.Time) *archiveSummary {
+	var s = &archiveSummary{Audit: a.Audit}
+	var through time.Time
+	for _, tx := range txList {
+		if tx.dt.Before(begin) || tx.dt.After(now) {
+			continue
+		}
+		if tx.archived {
+			s.Archived++
+			if tx.dt.After(through) {
+				through = tx.dt
+			}
+			continue
+		}
+		s.Live++
+	}
+	if s.Archived == 0 {
+		return s
+	}
+	s.Through = &through
+	var verified time.Time
+	for _, e := range a.used {
+		if e.Tx.dt.Before(begin) || e.Tx.dt.After(now) {
+			continue
+		}
+		var seen = time.Unix(e.Seen, 0)
+		if verified.IsZero() || seen.Before(verified) {
+			verified = seen
+		}
+	}
+	s.Verified = &verified
+	return s
+}
+
+func (s *archiveSummary) line() string {
+	var line string
+	if s.Archived == 0 {
+		line = fmt.Sprintf("Archive: none of the %d transactions in the window needed it; all are from the node", s.Live)
+	} else {
+		line = fmt.Sprintf("Archive: %d of %d transactions in the window are from --tx-archive (through %s), the rest live from the node; archived data last verified against the node %s",
+			s.Archived, s.Archived+s.Live, s.Through.Format(dateTimeLayout), s.Verified.Format(dateTimeLayout))
+	}
+	if s.Audit != nil {
+		line += "\nArchive " + s.Audit.summary()
+	}
+	return line
+}
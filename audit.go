@@ -0,0 +1,297 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// auditResult is the outcome of checking a --tx-archive against the node.
+// The last one is kept in the archive, counts only, so reports and metrics
+// can show whether the archive has drifted.
+type auditResult struct {
+	Time    time.Time    `json:"time"`
+	Days    int          `json:"days"`
+	Wallets int          `json:"wallets"`
+	Failed  int          `json:"failed,omitempty"`
+	Clean   bool         `json:"clean"`
+	Missing int          `json:"missing"`
+	Extra   int          `json:"extra"`
+	Changed int          `json:"changed"`
+	Fixed   bool         `json:"fixed"`
+	Issues  []auditIssue `json:"issues,omitempty"`
+}
+
+// auditIssue is one output the archive and the node disagree about
+type auditIssue struct {
+	Wallet   string `json:"wallet"`
+	Kind     string `json:"kind"`
+	Output   string `json:"output"`
+	Archived string `json:"archived,omitempty"`
+	Node     string `json:"node,omitempty"`
+}
+
+// auditWallet compares the wallet's archived entries from since on with
+// the node's listing, live.  Within the node's span the archive should hold
+// exactly what the node lists: an output it lists that the archive doesn't
+// is missing, one the archive holds that it doesn't list is extra, and one
+// they hold differently is changed.  What the node has listed since the
+// archive last saw the wallet isn't expected there yet, so isn't missing.
+func (a *txArchive) auditWallet(wallet string, live []*Transaction, field string, since time.Time) []auditIssue {
+	var archived = a.Wallets[wallet]
+	var synced int64
+	for _, e := range archived {
+		if e.Seen > synced {
+			synced = e.Seen
+		}
+	}
+	if oldest, ok := truncatedSince(live, field); ok && oldest.After(since) {
+		since = oldest
+	}
+
+	var listed = make(map[string][]*Transaction)
+	for _, tx := range live {
+		if !txTime(tx, field).Before(since) {
+			var out = fmt.Sprintf("%s:%d", tx.TXID, tx.Vout)
+			listed[out] = append(listed[out], tx)
+		}
+	}
+	var held = make(map[string][]*Transaction)
+	for _, e := range archived {
+		if !txTime(e.Tx, field).Before(since) {
+			var out = fmt.Sprintf("%s:%d", e.Tx.TXID, e.Tx.Vout)
+			held[out] = append(held[out], e.Tx)
+		}
+	}
+
+	var issues []auditIssue
+	for out, txs := range listed {
+		var h, ok = held[out]
+		if !ok {
+			if txTime(txs[0], field).Unix() <= synced {
+				issues = append(issues, auditIssue{Wallet: wallet, Kind: "missing", Output: out, Node: describeListed(txs)})
+			}
+			continue
+		}
+		for _, tx := range h {
+			if !listsSame(txs, tx) {
+				issues = append(issues, auditIssue{Wallet: wallet, Kind: "changed", Output: out, Archived: describeListed(h), Node: describeListed(txs)})
+				break
+			}
+		}
+	}
+	for out, h := range held {
+		if _, ok := listed[out]; !ok {
+			issues = append(issues, auditIssue{Wallet: wallet, Kind: "extra", Output: out, Archived: describeListed(h)})
+		}
+	}
+	return issues
+}
+
+// auditArchive re-fetches each wallet's listing and audits the archive's
+// last days against it.  With fix, a wallet with issues has its archive
+// brought back in line with the node, as a report run's merge would.  A
+// wallet that can't be fetched is reported and counted as failed.
+func auditArchive(u *url.URL, a *txArchive, wallets []string, days int, field string, fix bool, now time.Time) *auditResult {
+	var r = &auditResult{Time: now, Days: days, Wallets: len(wallets), Fixed: fix}
+	var since = getDay(now).AddDate(0, 0, -(days - 1))
+	for _, w := range wallets {
+		var live, err = listTransactions(walletURL(u, w))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to fetch wallet %q from %s: %s\n", w, u.Redacted(), err)
+			r.Failed++
+			continue
+		}
+		var issues = a.auditWallet(w, live, field, since)
+		if fix && len(issues) > 0 {
+			a.merge(w, live, field, now, io.Discard)
+		}
+		r.Issues = append(r.Issues, issues...)
+	}
+	sort.Slice(r.Issues, func(i, j int) bool {
+		if r.Issues[i].Wallet != r.Issues[j].Wallet {
+			return r.Issues[i].Wallet < r.Issues[j].Wallet
+		}
+		return r.Issues[i].Output < r.Issues[j].Output
+	})
+	for _, is := range r.Issues {
+		switch is.Kind {
+		case "missing":
+			r.Missing++
+		case "extra":
+			r.Extra++
+		case "changed":
+			r.Changed++
+		}
+	}
+	r.Clean = len(r.Issues) == 0
+	r.Fixed = fix && !r.Clean
+	return r
+}
+
+// counts is the result without its issues, as the archive keeps it
+func (r *auditResult) counts() *auditResult {
+	var c = *r
+	c.Issues = nil
+	return &c
+}
+
+func (r *auditResult) summary() string {
+	var s = fmt.Sprintf("audit of %d wallet(s) over %d days at %s: ", r.Wallets, r.Days, r.Time.Format(dateTimeLayout))
+	if r.Clean {
+		s += "clean"
+	} else {
+		s += fmt.Sprintf("%d missing, %d extra, %d changed", r.Missing, r.Extra, r.Changed)
+		if r.Fixed {
+			s += " (repaired)"
+		}
+	}
+	if r.Failed > 0 {
+		s += fmt.Sprintf("; %d wallet(s) couldn't be fetched", r.Failed)
+	}
+	return s
+}
+
+func (r *auditResult) print(w io.Writer) {
+	for _, is := range r.Issues {
+		switch is.Kind {
+		case "missing":
+			fmt.Fprintf(w, "%s: %s missing: the node lists %s, the archive has nothing\n", is.Wallet, is.Output, is.Node)
+		case "extra":
+			fmt.Fprintf(w, "%s: %s extra: the archive has %s, the node lists nothing\n", is.Wallet, is.Output, is.Archived)
+		case "changed":
+			fmt.Fprintf(w, "%s: %s changed: the archive has %s, the node lists %s\n", is.Wallet, is.Output, is.Archived, is.Node)
+		}
+	}
+	fmt.Fprintln(w, "Archive "+r.summary())
+}
+
+// auditMetrics is the last audit as metrics, for every exporter
+func auditMetrics(r *auditResult) []reportMetric {
+	var clean float64
+	if r.Clean {
+		clean = 1
+	}
+	return []reportMetric{
+		{"txstats_archive_audit_clean", "Whether the last audit found the archive matching the node (1) or not (0)", clean},
+		{"txstats_archive_audit_discrepancies", "Outputs the last audit found missing, extra, or changed in the archive", float64(r.Missing + r.Extra + r.Changed)},
+		{"txstats_archive_audit_timestamp_seconds", "When the archive was last audited", float64(r.Time.Unix())},
+	}
+}
+
+// runAudit implements "audit", which checks --tx-archive's last --audit-days
+// against the node, and with --fix repairs it.  Drift is an exitAssertion
+// failure, repaired or not, so a cron job hears about it.
+func runAudit(args []string) error {
+	var cfg, err = parseConfig(args)
+	if err != nil {
+		return err
+	}
+	err = applyRPCSettings(cfg)
+	if err != nil {
+		return err
+	}
+	var u *url.URL
+	u, err = nodeFromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	if cfg.TxArchive == "" {
+		return usageError("audit needs a --tx-archive to check")
+	}
+	if cfg.AuditDays < 1 {
+		return usageError(fmt.Sprintf("Invalid --audit-days %d", cfg.AuditDays))
+	}
+	var wallets []string
+	wallets, err = resolveWallets(u, cfg)
+	if err != nil {
+		return err
+	}
+	if len(wallets) == 0 {
+		return usageError("Not enough args")
+	}
+	var a *txArchive
+	a, err = loadArchive(cfg.TxArchive)
+	if err != nil {
+		return failure(exitUsage, "Unable to read transaction archive %q: %s", cfg.TxArchive, err)
+	}
+
+	var r = auditArchive(u, a, wallets, cfg.AuditDays, cfg.TimeField, cfg.AuditFix, clock())
+	if cfg.dryRun {
+		printPlannedOutputs(cfg)
+		return nil
+	}
+	if r.Failed == len(wallets) {
+		return exitWith(exitRPC)
+	}
+	a.Audit = r.counts()
+	err = a.save(cfg.TxArchive)
+	if err != nil {
+		return failure(exitRPC, "Unable to save transaction archive %q: %s", cfg.TxArchive, err)
+	}
+	if cfg.JSON {
+		var enc = json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(r)
+	} else {
+		r.print(os.Stdout)
+	}
+	if !r.Clean {
+		return exitWith(exitAssertion)
+	}
+	return partialResult(r.Failed > 0)
+}
+
+// auditTracker is serve's --audit-every: the archive audited on a timer,
+// with the latest result kept for /metrics
+type auditTracker struct {
+	mu   sync.Mutex
+	last *auditResult
+}
+
+// run audits now and then every interval.  The archive is re-read each
+// time, since report runs keep it up to date, and only written back when
+// --fix has something to repair, so serve doesn't race them for it
+// otherwise.
+func (t *auditTracker) run(u *url.URL, cfg *config, wallets []string, every time.Duration) {
+	for {
+		var a, err = loadArchive(cfg.TxArchive)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: unable to read transaction archive %q for the audit: %s\n", time.Now().Format(dateTimeLayout), cfg.TxArchive, err)
+		} else {
+			var r = auditArchive(u, a, wallets, cfg.AuditDays, cfg.TimeField, cfg.AuditFix, time.Now())
+			if r.Fixed {
+				a.Audit = r.counts()
+				err = a.save(cfg.TxArchive)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Unable to save transaction archive %q: %s\n", cfg.TxArchive, err)
+				}
+			}
+			r.print(os.Stderr)
+			t.mu.Lock()
+			t.last = r.counts()
+			t.mu.Unlock()
+		}
+		time.Sleep(every)
+	}
+}
+
+// families is the latest audit's metrics for /metrics, none before the
+// first audit finishes
+func (t *auditTracker) families() []metricFamily {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.last == nil {
+		return nil
+	}
+	var families []metricFamily
+	for _, m := range auditMetrics(t.last) {
+		families = append(families, metricFamily{name: m.name, help: m.help, kind: "gauge", samples: []metricSample{{value: m.value}}})
+	}
+	return families
+}
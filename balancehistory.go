@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// balanceDay is one row of --balance-history
+type balanceDay struct {
+	day     time.Time
+	delta   float64
+	balance float64
+}
+
+// balanceHistory replays the transactions in time order from a zero
+// balance, a day at a time.  Sends carry negative amounts and fees already;
+// orphaned and conflicted transactions never moved the balance, so they're
+// skipped.
+func balanceHistory(txList []*Transaction, field string) []balanceDay {
+	var sorted []*Transaction
+	for _, tx := range txList {
+		if tx.Category != "orphan" && tx.Confirmations >= 0 {
+			sorted = append(sorted, tx)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return txTime(sorted[i], field).Before(txTime(sorted[j], field)) })
+
+	var days []balanceDay
+	var balance float64
+	for _, tx := range sorted {
+		var day = getDay(txTime(tx, field))
+		balance += tx.Amount + tx.Fee
+		if len(days) == 0 || !days[len(days)-1].day.Equal(day) {
+			days = append(days, balanceDay{day: day})
+		}
+		var last = &days[len(days)-1]
+		last.delta += tx.Amount + tx.Fee
+		last.balance = balance
+	}
+	return days
+}
+
+// printBalanceHistory prints the running balance by day.  It's only the
+// real balance if the history goes back to the wallet's first transaction.
+func printBalanceHistory(w io.Writer, txList []*Transaction, field string, incomplete bool) {
+	if incomplete {
+		fmt.Fprintln(w, "WARNING: the transaction history is incomplete, so balances are relative to the oldest")
+		fmt.Fprintln(w, "transaction fetched, not the wallet's real balance")
+	}
+	fmt.Fprintf(w, "%-10s  %16s  %16s\n", "date", "delta", "running_balance")
+	for _, d := range balanceHistory(txList, field) {
+		fmt.Fprintf(w, "%s  %+16.8f  %16.8f\n", d.day.Format(dateLayout), d.delta, d.balance)
+	}
+}
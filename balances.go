@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// rpcMethodNotFound is the JSON-RPC code a node returns for a method it
+// doesn't implement
+const rpcMethodNotFound = -32601
+
+func isMethodNotFound(err error) bool {
+	var rerr *RPCError
+	return errors.As(err, &rerr) && rerr.Code == rpcMethodNotFound
+}
+
+// BalanceParts is one side (mine or watch-only) of a getbalances result
+type BalanceParts struct {
+	Trusted          float64 `json:"trusted"`
+	UntrustedPending float64 `json:"untrusted_pending"`
+	Immature         float64 `json:"immature"`
+}
+
+func (b BalanceParts) total() float64 {
+	return b.Trusted + b.UntrustedPending + b.Immature
+}
+
+// Balances is the result of getbalances.  WatchOnly is only present when the
+// wallet holds watch-only addresses.
+type Balances struct {
+	Mine      BalanceParts  `json:"mine"`
+	WatchOnly *BalanceParts `json:"watchonly"`
+
+	// legacy is set when the node predates getbalances and the figures were
+	// pieced together from getbalance, which can't tell us about immature
+	// coins
+	legacy bool
+}
+
+func fetchBalances(u *url.URL) (*Balances, error) {
+	var b Balances
+	var err = rpcCall(u, "getbalances", nil, &b)
+	if err == nil || !isMethodNotFound(err) {
+		return &b, err
+	}
+
+	var confirmed, all float64
+	err = rpcCall(u, "getbalance", []interface{}{"*", 1}, &confirmed)
+	if err == nil {
+		err = rpcCall(u, "getbalance", []interface{}{"*", 0}, &all)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Balances{Mine: BalanceParts{Trusted: confirmed, UntrustedPending: all - confirmed}, legacy: true}, nil
+}
+
+func printBalanceParts(label string, b BalanceParts, legacy bool) {
+	var immature = fmt.Sprintf("%14.8f", b.Immature)
+	if legacy {
+		immature = fmt.Sprintf("%14s", "n/a")
+	}
+	fmt.Printf("  %-10s %14.8f %14.8f %s %14.8f\n", label, b.Trusted, b.UntrustedPending, immature, b.total())
+}
+
+// printDetailedBalances prints the getbalances breakdown for each wallet
+func printDetailedBalances(u *url.URL, wallets []string) {
+	fmt.Println()
+	fmt.Println("Balances:")
+	for _, w := range wallets {
+		var b, err = fetchBalances(walletURL(u, w))
+		if err != nil {
+			fmt.Printf("%s:\t%s\n", w, err)
+			continue
+		}
+
+		var note string
+		if b.legacy {
+			note = " (getbalances unsupported; immature coins not shown)"
+		}
+		fmt.Printf("%s:%s\n", w, note)
+		fmt.Printf("  %-10s %14s %14s %14s %14s\n", "", "trusted", "pending", "immature", "total")
+		printBalanceParts("mine", b.Mine, b.legacy)
+		if b.WatchOnly != nil {
+			printBalanceParts("watch-only", *b.WatchOnly, b.legacy)
+		}
+	}
+}
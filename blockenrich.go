@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+)
+
+// BlockInfo is what --block-enrich adds to a block won: the parts of
+// getblock's verbosity 1 answer that describe the block itself.  The txid
+// list that comes with them is left undecoded.
+type BlockInfo struct {
+	Hash       string  `json:"hash"`
+	Height     int64   `json:"height"`
+	Time       int64   `json:"time"`
+	Size       int64   `json:"size"`
+	Weight     int64   `json:"weight"`
+	NTx        int64   `json:"nTx"`
+	Difficulty float64 `json:"difficulty"`
+}
+
+func fetchBlockInfo(u *url.URL, hash string) (*BlockInfo, error) {
+	var b BlockInfo
+	var err = rpcCall(nodeURL(u), "getblock", []interface{}{hash, 1}, &b)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// blockInfoCache holds --block-enrich's lookups by block hash, so a block
+// that pays more than one output, or more than one wallet, is only fetched
+// once.  A nil cache, when --block-enrich is off, knows nothing.
+type blockInfoCache struct {
+	known map[string]*BlockInfo
+}
+
+func newBlockInfoCache() *blockInfoCache {
+	return &blockInfoCache{known: make(map[string]*BlockInfo)}
+}
+
+// load fetches each block in blocks it doesn't already have.  A block whose
+// details can't be fetched is reported and left out.
+func (c *blockInfoCache) load(u *url.URL, blocks []*Transaction) {
+	var failed = make(map[string]bool)
+	for _, tx := range blocks {
+		if c.known[tx.Blockhash] != nil || failed[tx.Blockhash] {
+			continue
+		}
+		var b, err = fetchBlockInfo(u, tx.Blockhash)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to fetch block %s: %s\n", tx.Blockhash, err)
+			failed[tx.Blockhash] = true
+			continue
+		}
+		c.known[tx.Blockhash] = b
+	}
+}
+
+// info is the block with the given hash, nil if it couldn't be fetched
+func (c *blockInfoCache) info(hash string) *BlockInfo {
+	if c == nil {
+		return nil
+	}
+	return c.known[hash]
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+)
+
+// bitcoinHalvingInterval is how many blocks pass between subsidy halvings
+// when no --config subsidy_schedule says otherwise
+const bitcoinHalvingInterval = 210000
+
+// defaultSubsidy is Bitcoin's subsidy at height: 50 coins, halved every
+// bitcoinHalvingInterval blocks until it shifts away to nothing
+func defaultSubsidy(height int64) float64 {
+	var halvings = height / bitcoinHalvingInterval
+	if halvings >= 64 {
+		return 0
+	}
+	return float64(int64(50e8)>>uint(halvings)) / 1e8
+}
+
+type blockTxids struct {
+	Hash   string   `json:"hash"`
+	Height int64    `json:"height"`
+	Tx     []string `json:"tx"`
+}
+
+type rawTxOutputs struct {
+	Vout []struct {
+		Value float64 `json:"value"`
+	} `json:"vout"`
+}
+
+// coinbaseValue returns the total paid out by a block's coinbase.  The
+// block's txid list gives the coinbase's id, and passing the block hash lets
+// getrawtransaction find it without -txindex.
+func coinbaseValue(u *url.URL, hash string) (int64, float64, error) {
+	var block blockTxids
+	var err = rpcCall(nodeURL(u), "getblock", []interface{}{hash, 1}, &block)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(block.Tx) == 0 {
+		return 0, 0, fmt.Errorf("block %s has no transactions", hash)
+	}
+
+	var cb rawTxOutputs
+	err = rpcCall(nodeURL(u), "getrawtransaction", []interface{}{block.Tx[0], true, hash}, &cb)
+	if err != nil {
+		return 0, 0, err
+	}
+	var total float64
+	for _, out := range cb.Vout {
+		total += out.Value
+	}
+	return block.Height, total, nil
+}
+
+// blockFeeSummary is the fee part of the blocks won in the window
+type blockFeeSummary struct {
+	Fees    float64 `json:"fees"`
+	Subsidy float64 `json:"subsidy"`
+	Blocks  int     `json:"blocks"`
+	Failed  int     `json:"failed,omitempty"`
+}
+
+// sumBlockFees splits each distinct block won into subsidy and fees.  The
+// subsidy comes from the configured schedule if there is one.
+func sumBlockFees(u *url.URL, blocks []*Transaction, schedule subsidySchedule) *blockFeeSummary {
+	var s = &blockFeeSummary{}
+	var seen = make(map[string]bool)
+	for _, tx := range blocks {
+		if seen[tx.Blockhash] {
+			continue
+		}
+		seen[tx.Blockhash] = true
+
+		var height, value, err = coinbaseValue(u, tx.Blockhash)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to fetch the coinbase of block %s: %s\n", tx.Blockhash, err)
+			s.Failed++
+			continue
+		}
+		var subsidy = defaultSubsidy(height)
+		if len(schedule) > 0 {
+			subsidy = schedule.rewardAt(height)
+		}
+		s.Subsidy += subsidy
+		s.Fees += value - subsidy
+		s.Blocks++
+	}
+	return s
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// blockFilter is a block's BIP158 basic filter, as getblockfilter gives it
+type blockFilter struct {
+	Filter string `json:"filter"`
+	Header string `json:"header"`
+}
+
+// size is the filter's length in bytes
+func (f *blockFilter) size() int {
+	return len(f.Filter) / 2
+}
+
+// filterIndexHint adds what to do when the node has no filter index, since
+// getblockfilter's own message doesn't say
+func filterIndexHint(err error) error {
+	var rerr *RPCError
+	if errors.As(err, &rerr) && strings.Contains(rerr.Message, "not enabled") {
+		return fmt.Errorf("%w; start the node with -blockfilterindex=1 and let it build the index", err)
+	}
+	return err
+}
+
+func fetchBlockFilter(u *url.URL, hash string) (*blockFilter, error) {
+	var f blockFilter
+	var err = rpcCall(nodeURL(u), "getblockfilter", []interface{}{hash, "basic"}, &f)
+	if err != nil {
+		return nil, filterIndexHint(err)
+	}
+	return &f, nil
+}
+
+func printBlockFilter(hash string, f *blockFilter) {
+	fmt.Printf("Block:          %s\n", hash)
+	fmt.Printf("Filter type:    basic\n")
+	fmt.Printf("Filter size:    %d bytes\n", f.size())
+	fmt.Printf("Filter header:  %s\n", f.Header)
+	fmt.Printf("Filter:         %s\n", f.Filter)
+}
+
+// filterStats is the size of the basic filters of the chain's last blocks
+type filterStats struct {
+	From, To  int64
+	Blocks    int
+	Failed    int
+	Average   float64
+	Max       int
+	MaxHeight int64
+}
+
+// batchOrEach runs the calls in batches of headerBatchSize, or one at a time
+// once the node (or a proxy in front of it) won't take a batch
+func batchOrEach(u *url.URL, method string, params [][]interface{}, results []interface{}) []error {
+	var errs = make([]error, len(params))
+	var noBatch bool
+	for start := 0; start < len(params); start += headerBatchSize {
+		var end = start + headerBatchSize
+		if end > len(params) {
+			end = len(params)
+		}
+		if !noBatch {
+			var batchErrs, err = rpcBatch(u, method, params[start:end], results[start:end])
+			if err == nil {
+				copy(errs[start:], batchErrs)
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "Batched %s failed, calling it one at a time: %s\n", method, err)
+			noBatch = true
+		}
+		for i := start; i < end; i++ {
+			errs[i] = rpcCall(u, method, params[i], results[i])
+		}
+	}
+	return errs
+}
+
+// fetchFilterStats fetches the basic filters of the last n blocks, the tip
+// included.  A block whose filter can't be fetched is reported and left
+// out; if none can be, the first error is returned, since it's most likely
+// the node having no filter index.
+func fetchFilterStats(u *url.URL, n int64) (*filterStats, error) {
+	var tip int64
+	var err = rpcCall(nodeURL(u), "getblockcount", nil, &tip)
+	if err != nil {
+		return nil, err
+	}
+	var from = tip - n + 1
+	if from < 0 {
+		from = 0
+	}
+	var s = &filterStats{From: from, To: tip}
+
+	var count = int(tip - from + 1)
+	var params = make([][]interface{}, count)
+	var results = make([]interface{}, count)
+	var hashes = make([]string, count)
+	for i := range params {
+		params[i] = []interface{}{from + int64(i)}
+		results[i] = &hashes[i]
+	}
+	var errs = batchOrEach(nodeURL(u), "getblockhash", params, results)
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("block %d: %w", from+int64(i), err)
+		}
+	}
+
+	var filters = make([]blockFilter, count)
+	for i, hash := range hashes {
+		params[i] = []interface{}{hash, "basic"}
+		results[i] = &filters[i]
+	}
+	errs = batchOrEach(nodeURL(u), "getblockfilter", params, results)
+	var total int
+	var first error
+	for i, err := range errs {
+		if err != nil {
+			err = filterIndexHint(err)
+			fmt.Fprintf(os.Stderr, "Unable to fetch the filter of block %d: %s\n", from+int64(i), err)
+			if first == nil {
+				first = err
+			}
+			s.Failed++
+			continue
+		}
+		var size = filters[i].size()
+		total += size
+		s.Blocks++
+		if size > s.Max {
+			s.Max, s.MaxHeight = size, from+int64(i)
+		}
+	}
+	if s.Blocks == 0 && first != nil {
+		return nil, first
+	}
+	if s.Blocks > 0 {
+		s.Average = float64(total) / float64(s.Blocks)
+	}
+	return s, nil
+}
+
+func printFilterStats(s *filterStats) {
+	fmt.Printf("Blocks:         %d to %d (%d filters)\n", s.From, s.To, s.Blocks)
+	if s.Failed > 0 {
+		fmt.Printf("Failed:         %d\n", s.Failed)
+	}
+	fmt.Printf("Average size:   %0.1f bytes\n", s.Average)
+	fmt.Printf("Largest:        %d bytes (block %d)\n", s.Max, s.MaxHeight)
+}
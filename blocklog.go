@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// blockLog is serve's rolling list of the last few blocks found, oldest
+// first.  Every block it has taken in the report window is remembered by
+// key, so a block that drops out of the cache and comes back (a refresh that
+// failed, or a reorg that re-mined it) isn't listed twice.  A block older
+// than the window can't come back, as the cache never holds it, so its key
+// is forgotten.
+type blockLog struct {
+	mu      sync.Mutex
+	max     int
+	entries []cachedTx
+	logged  map[string]time.Time
+}
+
+func newBlockLog(max int) *blockLog {
+	return &blockLog{max: max, logged: make(map[string]time.Time)}
+}
+
+// add appends the blocks not already logged, dropping the oldest past max,
+// and returns those it added.  Keys of blocks from before since, the start
+// of the report window, are forgotten.
+func (l *blockLog) add(found []cachedTx, since time.Time) []cachedTx {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for key, t := range l.logged {
+		if t.Before(since) {
+			delete(l.logged, key)
+		}
+	}
+	var added []cachedTx
+	for _, e := range found {
+		if _, ok := l.logged[e.key()]; ok {
+			continue
+		}
+		l.logged[e.key()] = e.t
+		l.entries = append(l.entries, e)
+		added = append(added, e)
+	}
+	if len(l.entries) > l.max {
+		l.entries = append([]cachedTx(nil), l.entries[len(l.entries)-l.max:]...)
+	}
+	return added
+}
+
+func blockLogLine(e cachedTx) string {
+	return fmt.Sprintf("%s  %s  %+0.2f  height %d", e.t.Format("15:04:05"), e.wallet, e.amount, e.height)
+}
+
+func (l *blockLog) write(w io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, e := range l.entries {
+		fmt.Fprintln(w, blockLogLine(e))
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// A block that comes back is only logged once, while it's in the window;
+// past the window its key is forgotten, so the log doesn't grow forever
+func TestBlockLog(t *testing.T) {
+	var start = time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	var block = func(txid string, h int) cachedTx {
+		return cachedTx{wallet: "rig1", txid: txid, height: int64(800000 + h), t: start.Add(time.Duration(h) * time.Hour), amount: 5}
+	}
+	var l = newBlockLog(2)
+
+	if got := l.add([]cachedTx{block("a", 1), block("b", 2), block("c", 3)}, start); len(got) != 3 {
+		t.Errorf("added %d, want 3", len(got))
+	}
+	if len(l.entries) != 2 || l.entries[0].txid != "b" {
+		t.Errorf("kept %+v, want b and c", l.entries)
+	}
+	if got := l.add([]cachedTx{block("a", 1), block("d", 4)}, start); len(got) != 1 || got[0].txid != "d" {
+		t.Errorf("a came back: added %+v, want just d", got)
+	}
+
+	l.add(nil, start.Add(3*time.Hour))
+	if len(l.logged) != 2 {
+		t.Errorf("remembers %d keys, want 2 once a and b are out of the window", len(l.logged))
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type BlockHeader struct {
+	Hash              string  `json:"hash"`
+	Height            int64   `json:"height"`
+	Time              int64   `json:"time"`
+	MedianTime        int64   `json:"mediantime"`
+	Nonce             uint32  `json:"nonce"`
+	Bits              string  `json:"bits"`
+	Difficulty        float64 `json:"difficulty"`
+	Chainwork         string  `json:"chainwork"`
+	NTx               int64   `json:"nTx"`
+	PreviousBlockHash string  `json:"previousblockhash"`
+	NextBlockHash     string  `json:"nextblockhash"`
+}
+
+func fetchBlockHeader(u *url.URL, hash string) (*BlockHeader, error) {
+	var h BlockHeader
+	var err = rpcCall(nodeURL(u), "getblockheader", []interface{}{hash, true}, &h)
+	if err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+func fetchBlockHash(u *url.URL, height int64) (string, error) {
+	var hash string
+	var err = rpcCall(nodeURL(u), "getblockhash", []interface{}{height}, &hash)
+	return hash, err
+}
+
+func printBlockHeader(h *BlockHeader) {
+	fmt.Printf("Hash:          %s\n", h.Hash)
+	fmt.Printf("Height:        %d\n", h.Height)
+	fmt.Printf("Time:          %s\n", time.Unix(h.Time, 0).Format(dateTimeLayout))
+	fmt.Printf("Median time:   %s\n", time.Unix(h.MedianTime, 0).Format(dateTimeLayout))
+	fmt.Printf("Nonce:         %d\n", h.Nonce)
+	fmt.Printf("Bits:          %s\n", h.Bits)
+	fmt.Printf("Difficulty:    %0.4f\n", h.Difficulty)
+	fmt.Printf("Chainwork:     %s\n", h.Chainwork)
+	fmt.Printf("Transactions:  %d\n", h.NTx)
+	fmt.Printf("Previous:      %s\n", h.PreviousBlockHash)
+	fmt.Printf("Next:          %s\n", h.NextBlockHash)
+}
+
+// blockFeeStats is the fee market in one block, from getblockstats.  Fees
+// are in satoshis and rates in sat/vB; the percentiles are p10, p25, p50,
+// p75, and p90, weighted by size.
+type blockFeeStats struct {
+	AvgFee      int64    `json:"avgfee"`
+	MedianFee   int64    `json:"medianfee"`
+	Percentiles [5]int64 `json:"feerate_percentiles"`
+}
+
+func fetchBlockFeeStats(u *url.URL, hash string) (*blockFeeStats, error) {
+	var s blockFeeStats
+	var err = rpcCall(nodeURL(u), "getblockstats", []interface{}{hash, []string{"avgfee", "medianfee", "feerate_percentiles"}}, &s)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// printBlockList lists each block won in the report window, with what's
+// known of it: the getblock details from --block-enrich if there are any,
+// else its header.  A block neither could be fetched for, or past
+// --max-header-lookups, is just shown without the extra columns.
+func printBlockList(headers *headerCache, enriched *blockInfoCache, blocks []*Transaction) {
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i].Blockheight < blocks[j].Blockheight })
+
+	fmt.Println()
+	fmt.Println("Blocks:")
+	for _, tx := range blocks {
+		var when = tx.dt.Format(dateTimeLayout)
+		if b := enriched.info(tx.Blockhash); b != nil {
+			fmt.Printf("%d\t%s\t%8.2f\tdiff %0.2f\t%d tx\t%d B\t%d WU\t%s\n", tx.Blockheight, when, tx.Amount, b.Difficulty, b.NTx, b.Size, b.Weight, b.Hash)
+			continue
+		}
+		var h = headers.header(tx.Blockhash)
+		if h == nil {
+			fmt.Printf("%d\t%s\t%8.2f\n", tx.Blockheight, when, tx.Amount)
+			continue
+		}
+		fmt.Printf("%d\t%s\t%8.2f\tdiff %0.2f\t%d tx\t%s\n", tx.Blockheight, when, tx.Amount, h.Difficulty, h.NTx, h.Hash)
+	}
+	if note := headers.incomplete(); note != "" {
+		fmt.Printf("(%s)\n", note)
+	}
+}
+
+// printBlockFees lists the fees paid by the transactions in each block won.
+// Missing fee data, which a pruned node can't give for old blocks, doesn't
+// stop the listing.
+func printBlockFees(u *url.URL, blocks []*Transaction) {
+	var rows [][]string
+	var seen = make(map[string]bool)
+	for _, tx := range blocks {
+		if seen[tx.Blockhash] {
+			continue
+		}
+		seen[tx.Blockhash] = true
+		var s, err = fetchBlockFeeStats(u, tx.Blockhash)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to fetch fee stats for block %d: %s\n", tx.Blockheight, err)
+			continue
+		}
+		var row = []string{strconv.FormatInt(tx.Blockheight, 10), strconv.FormatInt(s.AvgFee, 10), strconv.FormatInt(s.MedianFee, 10)}
+		for _, p := range s.Percentiles {
+			row = append(row, strconv.FormatInt(p, 10))
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return
+	}
+	fmt.Println()
+	fmt.Println("Block fees (fees in sat, fee rates in sat/vB):")
+	printTable(os.Stdout, []string{"height", "avg fee", "median fee", "p10", "p25", "p50", "p75", "p90"}, rows)
+}
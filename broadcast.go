@@ -0,0 +1,38 @@
+package main
+
+import (
+	"net/url"
+)
+
+// rebroadcastSummary counts how the node took our unconfirmed sends being
+// handed back to it.  Rejected maps each refused txid to the node's reason.
+type rebroadcastSummary struct {
+	Accepted int               `json:"accepted"`
+	Rejected map[string]string `json:"rejected"`
+}
+
+// rebroadcast pushes each unconfirmed send back into the mempool.  A send to
+// several outputs is listed once per output, so each txid is only sent once.
+func rebroadcast(u *url.URL, sends []*Transaction) *rebroadcastSummary {
+	var s = &rebroadcastSummary{Rejected: make(map[string]string)}
+	var seen = make(map[string]bool)
+	for _, tx := range sends {
+		if seen[tx.TXID] {
+			continue
+		}
+		seen[tx.TXID] = true
+
+		var hex string
+		var err = rpcCall(nodeURL(u), "getrawtransaction", []interface{}{tx.TXID, false}, &hex)
+		if err == nil {
+			var txid string
+			err = rpcCall(nodeURL(u), "sendrawtransaction", []interface{}{hex}, &txid)
+		}
+		if err != nil {
+			s.Rejected[tx.TXID] = err.Error()
+			continue
+		}
+		s.Accepted++
+	}
+	return s
+}
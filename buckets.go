@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Nerdmaster/dynamo-tx-stats/stats"
+	"github.com/Nerdmaster/dynamo-tx-stats/window"
+)
+
+// parseBucketSize reads --bucket: a length of time that divides a day evenly,
+// such as 15m, 1h, or 4h, or 1d for the usual daily table
+func parseBucketSize(s string) (time.Duration, error) {
+	var d, err = window.Parse(s, 0)
+	if err != nil {
+		return 0, err
+	}
+	if d < time.Minute || d > 24*time.Hour || (24*time.Hour)%d != 0 {
+		return 0, fmt.Errorf("%s doesn't divide a day evenly into buckets of at least a minute", s)
+	}
+	return d, nil
+}
+
+// bucketRows turns intervals into table rows labeled by their start time.
+// Rates are per hour, as in the daily table, and only the interval still in
+// progress gets a projection.
+func bucketRows(intervals []stats.Interval, size time.Duration, layout string, now time.Time) []reportRow {
+	var rows []reportRow
+	for i, in := range intervals {
+		var row = reportRow{Label: in.Start.Format(layout), Start: in.Start, Coins: in.Coins, Blocks: in.Blocks, WinPercent: in.RoughPercent()}
+		var hours = in.End.Sub(in.Start).Hours()
+		if hours > 0 {
+			row.Rate = in.Coins / hours
+		}
+		var current = i == len(intervals)-1 && now.Sub(in.Start) < size
+		if current && hours > 0 {
+			var projected = row.Rate * size.Hours()
+			row.Projected = &projected
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// printBuckets is the detail table when --bucket is finer than a day; it
+// stands in for the daily table
+func (v *reportView) printBuckets(w io.Writer, width int) {
+	if narrow(width) {
+		for _, row := range v.Buckets {
+			fmt.Fprintf(w, "%s %9.2f  Win%% %0.2f%%%s\n", row.Start.Format("01-02 15:04"), row.Coins, row.WinPercent, v.fiatTag(row))
+			if row.Projected != nil {
+				fmt.Fprintf(w, "            ~ %0.2f expected\n", *row.Projected)
+			}
+		}
+		return
+	}
+	for _, row := range v.Buckets {
+		var projection = ""
+		if row.Projected != nil {
+			projection = fmt.Sprintf(" (~ %0.2f expected)", *row.Projected)
+		}
+		fmt.Fprintf(w, "%s:\t\t%8.2f\t\t%0.2f/h\t\tWin%%: %0.4f%%%s%s\n", row.Label, row.Coins, row.Rate, row.WinPercent, projection, v.fiatTag(row))
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Nerdmaster/dynamo-tx-stats/stats"
+)
+
+func TestParseBucketSize(t *testing.T) {
+	for _, s := range []string{"15m", "1h", "4h", "6h", "1d"} {
+		if _, err := parseBucketSize(s); err != nil {
+			t.Errorf("%s: %v", s, err)
+		}
+	}
+	for _, s := range []string{"7h", "30s", "2d", "soon"} {
+		if _, err := parseBucketSize(s); err == nil {
+			t.Errorf("%s: no error", s)
+		}
+	}
+}
+
+// Only the bucket still in progress is projected, from its rate so far
+func TestBucketRowsProjection(t *testing.T) {
+	var start = time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	var now = start.Add(5 * time.Hour)
+	var intervals = []stats.Interval{
+		{Bucket: stats.Bucket{Coins: 8}, Start: start, End: start.Add(4 * time.Hour)},
+		{Bucket: stats.Bucket{Coins: 3}, Start: start.Add(4 * time.Hour), End: now},
+	}
+	var rows = bucketRows(intervals, 4*time.Hour, "15:04", now)
+	if rows[0].Label != "00:00" || rows[0].Rate != 2 || rows[0].Projected != nil {
+		t.Errorf("finished bucket: got %+v", rows[0])
+	}
+	if rows[1].Label != "04:00" || rows[1].Rate != 3 || rows[1].Projected == nil || *rows[1].Projected != 12 {
+		t.Errorf("current bucket: got %+v", rows[1])
+	}
+}
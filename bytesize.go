@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var byteSuffixes = []struct {
+	suffix string
+	mult   int64
+}{
+	{"GIB", 1 << 30}, {"MIB", 1 << 20}, {"KIB", 1 << 10},
+	{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+	{"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10},
+	{"B", 1},
+}
+
+// parseByteSize reads sizes like "64MB", "512k", or "1048576".  Units are
+// binary, so "1MB" is 1024*1024 bytes.
+func parseByteSize(s string) (int64, error) {
+	var upper = strings.ToUpper(strings.TrimSpace(s))
+	var mult int64 = 1
+	for _, b := range byteSuffixes {
+		if strings.HasSuffix(upper, b.suffix) {
+			upper = strings.TrimSpace(strings.TrimSuffix(upper, b.suffix))
+			mult = b.mult
+			break
+		}
+	}
+
+	var n, err = strconv.ParseFloat(upper, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(n * float64(mult)), nil
+}
+
+func formatByteSize(n int64) string {
+	switch {
+	case n >= 1<<30 && n%(1<<30) == 0:
+		return fmt.Sprintf("%dGB", n>>30)
+	case n >= 1<<20 && n%(1<<20) == 0:
+		return fmt.Sprintf("%dMB", n>>20)
+	case n >= 1<<10 && n%(1<<10) == 0:
+		return fmt.Sprintf("%dKB", n>>10)
+	}
+	return fmt.Sprintf("%dB", n)
+}
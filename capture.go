@@ -0,0 +1,383 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// capturedCall is one RPC round trip as --debug-capture writes it: where it
+// went, without credentials, what was asked, and what came back.  Error is
+// set instead of Response when the call never got a reply.
+type capturedCall struct {
+	Host     string          `json:"host"`
+	Path     string          `json:"path"`
+	Method   string          `json:"method"`
+	Params   json.RawMessage `json:"params"`
+	Response *RPCResponse    `json:"response,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// key is what replay matches a call on
+func (c capturedCall) key() string {
+	return c.Host + c.Path + " " + c.Method + " " + string(c.Params)
+}
+
+// captureManifest is the run's parameters, written beside the calls so
+// replay can run the same report over them
+type captureManifest struct {
+	Version    string    `json:"version"`
+	Captured   time.Time `json:"captured"`
+	Anonymized bool      `json:"anonymized"`
+	Config     *config   `json:"config"`
+}
+
+// rpcCapture, when set, is handed every RPC round trip to write out for
+// --debug-capture
+var rpcCapture *captureDir
+
+type captureDir struct {
+	dir       string
+	anonymize bool
+
+	mu    sync.Mutex
+	seq   int
+	names map[string]string
+}
+
+// openCapture creates dir if need be and starts capturing into it
+func openCapture(dir string, anonymize bool) (*captureDir, error) {
+	var err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+	return &captureDir{dir: dir, anonymize: anonymize, names: make(map[string]string)}, nil
+}
+
+// pseudonym is the name a wallet is written under: itself, or with
+// --anonymize a stand-in numbered in the order wallets are first seen
+func (c *captureDir) pseudonym(name string) string {
+	if !c.anonymize {
+		return name
+	}
+	if p, ok := c.names[name]; ok {
+		return p
+	}
+	var p = fmt.Sprintf("wallet-%d", len(c.names)+1)
+	c.names[name] = p
+	return p
+}
+
+// record writes one call.  A failure to write is reported but doesn't stop
+// the run; the capture is a side effect, not the point.
+func (c *captureDir) record(u *url.URL, method string, params []interface{}, resp *RPCResponse, callErr error) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var call = capturedCall{Host: u.Host, Path: u.Path, Method: method}
+	call.Params, _ = json.Marshal(redactParams(method, params))
+	var walletPrefix = walletPath("")
+	if strings.HasPrefix(u.Path, walletPrefix) {
+		call.Path = walletPath(c.pseudonym(strings.TrimPrefix(u.Path, walletPrefix)))
+	}
+	if callErr != nil && resp.Error == nil {
+		call.Error = callErr.Error()
+	} else {
+		var saved = *resp
+		if method == "listwallets" && c.anonymize {
+			saved.Result = c.pseudonymizeList(saved.Result)
+		}
+		call.Response = &saved
+	}
+
+	c.seq++
+	var name = fmt.Sprintf("%04d-%s-%s.json", c.seq, time.Now().Format("20060102T150405.000"), method)
+	var err = writeJSONFile(filepath.Join(c.dir, name), call)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to write debug capture %q: %s\n", name, err)
+	}
+}
+
+// pseudonymizeList swaps the wallet names in a listwallets result
+func (c *captureDir) pseudonymizeList(raw json.RawMessage) json.RawMessage {
+	var names []string
+	if json.Unmarshal(raw, &names) != nil {
+		return raw
+	}
+	for i, n := range names {
+		names[i] = c.pseudonym(n)
+	}
+	var out, _ = json.Marshal(names)
+	return out
+}
+
+// writeManifest writes the run's config, stripped of credentials and
+// anything else replay can't or mustn't use, as of now.  The wallet list is
+// the resolved one, so replay needs neither the wallets file nor
+// --auto-wallets' filters.  It's written as soon as capturing starts, and
+// again once the report's wallets and clock are settled.
+func (c *captureDir) writeManifest(cfg *config, wallets []string, now time.Time) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var clean = *cfg
+	clean.URL = stripUserinfo(cfg.URL)
+	clean.FailoverURLs = nil
+	for _, fu := range cfg.FailoverURLs {
+		clean.FailoverURLs = append(clean.FailoverURLs, stripUserinfo(fu))
+	}
+	clean.User, clean.Password, clean.CookieFile = "", "", ""
+	clean.WalletAuth = nil
+	clean.MergeURLs = ""
+	if cfg.MergeURLs != "" {
+		var urls []string
+		for _, mu := range strings.Split(cfg.MergeURLs, ",") {
+			urls = append(urls, stripUserinfo(strings.TrimSpace(mu)))
+		}
+		clean.MergeURLs = strings.Join(urls, ",")
+	}
+	clean.priceSource = priceSource{}
+	clean.FiatCurrencies = ""
+	clean.DailyReportWebhook, clean.BlockWebhook = nil, nil
+	clean.ESURL, clean.ESAPIKey = "", ""
+	clean.Sinks = nil
+	for _, s := range cfg.Sinks {
+		if kind, target, ok := strings.Cut(s, ":"); ok && kind == "pushgateway" {
+			s = kind + ":" + stripUserinfo(target)
+		}
+		clean.Sinks = append(clean.Sinks, s)
+	}
+	clean.AutoWallets, clean.WalletsFile, clean.WalletFilter, clean.WalletExclude = false, "", "", ""
+
+	clean.Wallets = nil
+	for _, w := range wallets {
+		clean.Wallets = append(clean.Wallets, c.pseudonym(w))
+	}
+	if cfg.CompareWallet != "" {
+		var a, b, err = parseCompareWallets(cfg.CompareWallet)
+		if err == nil {
+			clean.CompareWallet = c.pseudonym(a) + ":" + c.pseudonym(b)
+		}
+	}
+	if len(cfg.WalletDays) > 0 {
+		clean.WalletDays = make(map[string]int)
+		for w, d := range cfg.WalletDays {
+			clean.WalletDays[c.pseudonym(w)] = d
+		}
+	}
+	clean.Sources = nil
+	for _, s := range cfg.Sources {
+		s.URL, s.User, s.Password = stripUserinfo(s.URL), "", ""
+		s.priceSource = priceSource{}
+		var named []string
+		for _, w := range s.Wallets {
+			named = append(named, c.pseudonym(w))
+		}
+		s.Wallets = named
+		clean.Sources = append(clean.Sources, s)
+	}
+
+	var m = captureManifest{Version: versionString(), Captured: now, Anonymized: c.anonymize, Config: &clean}
+	var err = writeJSONFile(filepath.Join(c.dir, "manifest.json"), m)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to write debug capture manifest: %s\n", err)
+	}
+}
+
+// stripUserinfo drops any credentials embedded in a URL
+func stripUserinfo(raw string) string {
+	var u, err = url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	u.User = nil
+	return u.String()
+}
+
+func writeJSONFile(path string, v interface{}) error {
+	var data, err = json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0600)
+}
+
+// rpcReplay, when set, answers every RPC call from a capture instead of
+// sending it to the node
+var rpcReplay *replaySet
+
+// replaySet holds a capture's calls, queued by what was asked.  A call
+// that's asked more often than it was captured gets its last answer again.
+type replaySet struct {
+	mu    sync.Mutex
+	calls map[string][]capturedCall
+}
+
+// loadReplay reads every call in a capture directory, in the order they
+// were made
+func loadReplay(dir string) (*replaySet, error) {
+	var paths, err = filepath.Glob(filepath.Join(dir, "[0-9]*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	var r = &replaySet{calls: make(map[string][]capturedCall)}
+	for _, p := range paths {
+		var data []byte
+		data, err = os.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		var call capturedCall
+		err = json.Unmarshal(data, &call)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(p), err)
+		}
+		var params interface{}
+		err = json.Unmarshal(call.Params, &params)
+		if err == nil {
+			call.Params, err = json.Marshal(params)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: params: %w", filepath.Base(p), err)
+		}
+		r.calls[call.key()] = append(r.calls[call.key()], call)
+	}
+	if len(r.calls) == 0 {
+		return nil, errors.New("no captured calls")
+	}
+	return r, nil
+}
+
+// answer fills resp with the captured reply to this call
+func (r *replaySet) answer(u *url.URL, method string, params []interface{}, resp *RPCResponse) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var p, _ = json.Marshal(redactParams(method, params))
+	var key = capturedCall{Host: u.Host, Path: u.Path, Method: method, Params: p}.key()
+	var queue = r.calls[key]
+	if len(queue) == 0 {
+		return fmt.Errorf("no captured response for %s %s on %s", method, p, u.Path)
+	}
+	var call = queue[0]
+	if len(queue) > 1 {
+		r.calls[key] = queue[1:]
+	}
+	if call.Response == nil {
+		return errors.New(call.Error)
+	}
+	*resp = *call.Response
+	return nil
+}
+
+// clock is the time a run takes as now; replay pins it to the capture's
+var clock = time.Now
+
+// runReplay runs the captured config over the captured calls, so a report
+// can be reproduced without the node it came from
+func runReplay(args []string) error {
+	var fs = flag.NewFlagSet("replay", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s replay <capture dir>\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "Re-runs the report written to the directory by --debug-capture, from the captured responses alone")
+	}
+	var err = fs.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return exitWith(exitUsage)
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitWith(exitUsage)
+	}
+	var dir = fs.Arg(0)
+
+	var data []byte
+	data, err = os.ReadFile(filepath.Join(dir, "manifest.json"))
+	if err != nil {
+		return failure(exitUsage, "Unable to read capture manifest: %s", err)
+	}
+	var cfg *config
+	cfg, err = parseConfig(nil)
+	if err != nil {
+		return err
+	}
+	var m = captureManifest{Config: cfg}
+	err = json.Unmarshal(data, &m)
+	if err != nil {
+		return failure(exitUsage, "Unable to read capture manifest: %s", err)
+	}
+	err = setExitCodes(cfg)
+	if err != nil {
+		return err
+	}
+	rpcReplay, err = loadReplay(dir)
+	if err != nil {
+		return failure(exitUsage, "Unable to read capture %q: %s", dir, err)
+	}
+
+	// The report's buckets depend on the clock and the time zone, so both
+	// are put back as they were; the state file, the archive, and every
+	// sink and file the report was sent to are the capturing machine's
+	// business, not the replay's
+	if cfg.Timezone != "" {
+		var loc *time.Location
+		loc, err = time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			return usageError(fmt.Sprintf("Invalid timezone %q in capture: %s", cfg.Timezone, err))
+		}
+		time.Local = loc
+	} else {
+		var name, offset = m.Captured.Zone()
+		time.Local = time.FixedZone(name, offset)
+	}
+	var captured = m.Captured.In(time.Local)
+	clock = func() time.Time { return captured }
+	cfg.User, cfg.CookieFile = "replay", ""
+	for i := range cfg.Sources {
+		cfg.Sources[i].User = "replay"
+	}
+	cfg.StateFile, cfg.Splay, cfg.SplayRandom = "", 0, false
+	replayToStdout(cfg)
+	return runConfig(cfg)
+}
+
+// replayToStdout turns off everything a replayed run would send or write
+// anywhere but stdout, so a replay can't index into the capturing
+// machine's Elasticsearch, push its metrics, fire its webhooks, or
+// overwrite its files.  Of the sinks, only the stdout ones are kept.
+func replayToStdout(cfg *config) {
+	var sinks stringList
+	for _, s := range cfg.Sinks {
+		var spec, err = parseOutputSpec(s)
+		if err == nil && spec.stdout() && spec.kind != "csv" && spec.kind != "pushgateway" && spec.kind != "textfile" {
+			sinks = append(sinks, s)
+		}
+	}
+	if len(cfg.Sinks) > 0 && len(sinks) == 0 {
+		sinks = stringList{"text"}
+	}
+	cfg.Sinks = sinks
+	cfg.Textfile, cfg.Output, cfg.TxArchive = "", "", ""
+	cfg.ESURL, cfg.ESAPIKey = "", ""
+	cfg.DailyReportWebhook, cfg.BlockWebhook = nil, nil
+	cfg.DailyReport, cfg.DailyReportDir = "", ""
+	cfg.saveConfig = ""
+}
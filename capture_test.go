@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// A capture replayed must print the report the capturing run printed, to
+// the byte but for the JSON report's timings, without the node, and without sending anything to the
+// capturing run's sinks.  None of the run's secrets may reach the capture.
+func TestCaptureReplayRoundTrip(t *testing.T) {
+	var now = time.Now()
+	var local = time.Local
+	defer func() { rpcCapture, rpcReplay, clock, time.Local = nil, nil, time.Now, local }()
+
+	var secrets = []string{"node-secret", "es-secret-key", "gateway-secret"}
+	var dir = t.TempDir()
+	for _, format := range []string{"text", "json"} {
+		t.Run(format, func(t *testing.T) {
+			// Each run gets its own servers, closed before time.Local is put
+			// back, as their goroutines read it
+			var node = newWalletNode(t, []map[string]interface{}{
+				generation("aa", 800020, 5, now.Add(-50*time.Hour)),
+				generation("bb", 800060, 5.25, now.Add(-26*time.Hour)),
+				generation("cc", 800090, 4.75, now.Add(-3*time.Hour)),
+			})
+			var sent int32
+			var sink = newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&sent, 1)
+				w.Write([]byte(`{"errors": false, "items": []}`))
+			}))
+			var pushgateway = strings.Replace(sink.URL, "://", "://gw:gateway-secret@", 1)
+			var capDir = filepath.Join(dir, format)
+			var args = []string{"--url", node.URL, "--user", "u", "--password", "node-secret", "--wallet", "rig1", "--days", "3",
+				"--sink", format, "--sink", "pushgateway:" + pushgateway, "--es-url", sink.URL, "--es-api-key", "es-secret-key",
+				"--debug-capture", capDir}
+			var captured, replayed string
+			var err error
+			captured = capture(t, &os.Stdout, func() { err = run(args) })
+			if err != nil {
+				t.Fatalf("capture run: %v", err)
+			}
+			rpcCapture = nil
+			var before = atomic.LoadInt32(&sent)
+			if before == 0 {
+				t.Fatal("the capturing run sent nothing to its sinks")
+			}
+
+			// the replay sets time.Local
+			node.drain()
+			sink.drain()
+			var asked = node.called("listtransactions")
+			replayed = capture(t, &os.Stdout, func() { err = run([]string{"replay", capDir}) })
+			if err != nil {
+				t.Fatalf("replay: %v", err)
+			}
+			if format == "json" {
+				captured, replayed = withoutTiming(t, captured), withoutTiming(t, replayed)
+			}
+			if replayed != captured {
+				t.Errorf("replayed report differs:\n--- captured\n%s\n--- replayed\n%s", captured, replayed)
+			}
+			if node.called("listtransactions") != asked {
+				t.Error("the replay called the node")
+			}
+			if after := atomic.LoadInt32(&sent); after != before {
+				t.Errorf("the replay sent %d requests to the capturing run's sinks", after-before)
+			}
+
+			var files, _ = filepath.Glob(filepath.Join(capDir, "*.json"))
+			for _, f := range files {
+				var data, _ = os.ReadFile(f)
+				for _, s := range secrets {
+					if strings.Contains(string(data), s) {
+						t.Errorf("%s holds %q", filepath.Base(f), s)
+					}
+				}
+			}
+			var manifest, _ = os.ReadFile(filepath.Join(capDir, "manifest.json"))
+			if !strings.Contains(string(manifest), `"es_url": ""`) {
+				t.Errorf("manifest.json keeps the Elasticsearch URL:\n%s", manifest)
+			}
+		})
+		rpcReplay, clock, time.Local = nil, time.Now, local
+	}
+}
+
+// withoutTiming drops the JSON report's "timing" sections, the one part of
+// it that depends on how long the run took
+func withoutTiming(t *testing.T, report string) string {
+	var v interface{}
+	var err = json.Unmarshal([]byte(report), &v)
+	if err != nil {
+		t.Fatalf("report isn't JSON: %v\n%s", err, report)
+	}
+	var drop func(v interface{})
+	drop = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			delete(v, "timing")
+			for _, e := range v {
+				drop(e)
+			}
+		case []interface{}:
+			for _, e := range v {
+				drop(e)
+			}
+		}
+	}
+	drop(v)
+	var data, _ = json.MarshalIndent(v, "", "  ")
+	return string(data)
+}
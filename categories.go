@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/Nerdmaster/dynamo-tx-stats/stats"
+)
+
+// categoryOrder is the order --split-categories prints the categories
+// listtransactions uses in; any other comes after them, alphabetically
+var categoryOrder = []string{"generate", "immature", "orphan", "receive", "send"}
+
+// categoryRow is one day's, or --bucket's, part of a category
+type categoryRow struct {
+	Label        string    `json:"label"`
+	Start        time.Time `json:"start"`
+	Coins        float64   `json:"coins"`
+	Transactions int64     `json:"transactions"`
+}
+
+// categoryView is one category's stats under --split-categories.  Amounts
+// are signed as the node gives them, so sends total below zero.
+type categoryView struct {
+	Category      string        `json:"category"`
+	Transactions  int64         `json:"transactions"`
+	Total         float64       `json:"total"`
+	DailyAverage  float64       `json:"daily_average"`
+	HourlyAverage float64       `json:"hourly_average"`
+	Rows          []categoryRow `json:"rows"`
+}
+
+// newCategoryViews splits the window's transactions by category, each with
+// its own totals and its own table at the report's bucket size.  Every
+// transaction counts, confirmed or not, generated or not: this is the whole
+// wallet's activity, where the report proper only counts what was earned.
+func newCategoryViews(txList []*Transaction, begin time.Time, days int, bucketSize time.Duration, layout string, now time.Time) []categoryView {
+	var accs = make(map[string]*stats.Accumulator)
+	for _, tx := range txList {
+		if tx.dt.After(now) {
+			continue
+		}
+		var acc = accs[tx.Category]
+		if acc == nil {
+			acc = stats.NewAccumulator(begin, days)
+		}
+		if acc.Add(stats.Transaction{TXID: tx.TXID, Vout: tx.Vout, Amount: tx.Amount, Blockheight: tx.Blockheight, Time: tx.dt}) {
+			accs[tx.Category] = acc
+		}
+	}
+
+	var categories []string
+	for c := range accs {
+		categories = append(categories, c)
+	}
+	var rank = func(c string) int {
+		for i, o := range categoryOrder {
+			if c == o {
+				return i
+			}
+		}
+		return len(categoryOrder)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		var ri, rj = rank(categories[i]), rank(categories[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return categories[i] < categories[j]
+	})
+
+	if bucketSize > 24*time.Hour {
+		bucketSize = 24 * time.Hour
+	}
+	var views []categoryView
+	for _, c := range categories {
+		var acc = accs[c]
+		var total = acc.Snapshot().Total
+		var v = categoryView{Category: c, Transactions: total.Blocks, Total: total.Coins}
+		v.DailyAverage = total.Coins / float64(days)
+		v.HourlyAverage = v.DailyAverage / 24
+		for _, in := range acc.Intervals(bucketSize, now) {
+			v.Rows = append(v.Rows, categoryRow{Label: in.Start.Format(layout), Start: in.Start, Coins: in.Coins, Transactions: in.Blocks})
+		}
+		views = append(views, v)
+	}
+	return views
+}
+
+func (v *categoryView) print(w io.Writer) {
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "=== Category: %s ===\n", v.Category)
+	fmt.Fprintf(w, "%d transactions, total %0.2f\n", v.Transactions, v.Total)
+	fmt.Fprintf(w, "Daily average: %0.2f\n", v.DailyAverage)
+	fmt.Fprintf(w, "Hourly average: %0.2f\n", v.HourlyAverage)
+	for _, r := range v.Rows {
+		fmt.Fprintf(w, "%s:\t\t%8.2f\t\t(%d tx)\n", r.Label, r.Coins, r.Transactions)
+	}
+}
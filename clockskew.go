@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// nodeClock keeps the last reading of the node's clock, from the Date
+// header on its RPC replies.  The header only has whole seconds, which is
+// plenty for catching a clock that's minutes out.
+var nodeClock struct {
+	mu     sync.Mutex
+	offset time.Duration
+	known  bool
+}
+
+// noteNodeDate records how far the node's Date header is from the local
+// clock, taken at the middle of the request.  A reply without a usable
+// header is ignored.
+func noteNodeDate(header string, sent, received time.Time) {
+	var t, err = http.ParseTime(header)
+	if err != nil {
+		return
+	}
+	var local = sent.Add(received.Sub(sent) / 2)
+	nodeClock.mu.Lock()
+	nodeClock.offset, nodeClock.known = t.Sub(local.Truncate(time.Second)), true
+	nodeClock.mu.Unlock()
+}
+
+// nodeClockOffset is how far ahead of the local clock the node's is, and
+// whether there's been a reply to tell
+func nodeClockOffset() (time.Duration, bool) {
+	nodeClock.mu.Lock()
+	defer nodeClock.mu.Unlock()
+	return nodeClock.offset, nodeClock.known
+}
+
+// checkClockSkew warns when the local clock is more than max away from the
+// node's, and returns the node's offset for --use-node-time.  Any reply
+// carries the Date header, an error included, so a node that won't answer
+// uptime still gives a reading; one that gives none just isn't checked.
+func checkClockSkew(u *url.URL, max time.Duration) time.Duration {
+	if _, known := nodeClockOffset(); !known {
+		rpcCall(nodeURL(u), "uptime", nil, nil)
+	}
+	var offset, known = nodeClockOffset()
+	if !known {
+		return 0
+	}
+	if offset > max || -offset > max {
+		var dir = "behind"
+		if offset < 0 {
+			dir = "ahead of"
+		}
+		var skew = offset
+		if skew < 0 {
+			skew = -skew
+		}
+		fmt.Fprintf(os.Stderr, "WARNING: the local clock is %s %s the node's; projections and ages use the local clock unless --use-node-time is given\n", formatAge(skew), dir)
+	}
+	return offset
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/Nerdmaster/dynamo-tx-stats/stats"
+)
+
+// noCoinbaseTag is the group for coinbases with nothing printable in them
+const noCoinbaseTag = "(none)"
+
+// minCoinbaseTag is the shortest printable run taken as a tag, so the odd
+// byte of extranonce that happens to be a letter isn't
+const minCoinbaseTag = 4
+
+// coinbaseTag pulls the longest printable ASCII run out of a coinbase
+// scriptSig, after the BIP34 height push at its start, or "" if there's
+// none long enough
+func coinbaseTag(script string) string {
+	var raw, err = hex.DecodeString(script)
+	if err != nil || len(raw) == 0 {
+		return ""
+	}
+	if n := int(raw[0]); n <= 0x4b && 1+n <= len(raw) {
+		raw = raw[1+n:]
+	}
+	var best, start = "", -1
+	for i := 0; i <= len(raw); i++ {
+		if i < len(raw) && raw[i] >= 0x20 && raw[i] < 0x7f {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 && i-start > len(best) {
+			best = string(raw[start:i])
+		}
+		start = -1
+	}
+	if len(best) < minCoinbaseTag {
+		return ""
+	}
+	return best
+}
+
+type rawCoinbase struct {
+	Vin []struct {
+		Coinbase string `json:"coinbase"`
+	} `json:"vin"`
+}
+
+// fetchCoinbaseTag reads the tag from a generated transaction.  Passing its
+// block hash lets getrawtransaction find it without -txindex.
+func fetchCoinbaseTag(u *url.URL, txid, blockhash string) (string, error) {
+	var tx rawCoinbase
+	var err = rpcCall(nodeURL(u), "getrawtransaction", []interface{}{txid, true, blockhash}, &tx)
+	if err != nil {
+		return "", err
+	}
+	if len(tx.Vin) == 0 || tx.Vin[0].Coinbase == "" {
+		return "", fmt.Errorf("%s isn't a coinbase", txid)
+	}
+	return coinbaseTag(tx.Vin[0].Coinbase), nil
+}
+
+// coinbaseTagRow is one tag's share of the window's blocks
+type coinbaseTagRow struct {
+	Tag    string  `json:"tag"`
+	Coins  float64 `json:"coins"`
+	Blocks int64   `json:"blocks"`
+}
+
+// coinbaseTagRows groups the blocks won by coinbase tag, most coins first.
+// A coinbase never changes, so each is fetched once and kept in cache,
+// which the state file carries from run to run; one that can't be fetched
+// is left out and counted as failed.
+func coinbaseTagRows(u *url.URL, blocks []*Transaction, cache map[string]coinbaseTagEntry, now time.Time) ([]coinbaseTagRow, int) {
+	var buckets = make(map[string]*stats.Bucket)
+	var failed int
+	for _, tx := range blocks {
+		var entry, ok = cache[tx.TXID]
+		if !ok {
+			var tag, err = fetchCoinbaseTag(u, tx.TXID, tx.Blockhash)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Unable to fetch the coinbase of %s: %s\n", tx.TXID, err)
+				failed++
+				continue
+			}
+			entry = coinbaseTagEntry{Tag: tag}
+		}
+		entry.Seen = now.Unix()
+		cache[tx.TXID] = entry
+
+		var tag = entry.Tag
+		if tag == "" {
+			tag = noCoinbaseTag
+		}
+		if buckets[tag] == nil {
+			buckets[tag] = &stats.Bucket{}
+		}
+		buckets[tag].Record(tx.Blockheight, tx.Amount)
+	}
+
+	var rows []coinbaseTagRow
+	for tag, b := range buckets {
+		rows = append(rows, coinbaseTagRow{Tag: tag, Coins: b.Coins, Blocks: b.Blocks})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Coins != rows[j].Coins {
+			return rows[i].Coins > rows[j].Coins
+		}
+		return rows[i].Tag < rows[j].Tag
+	})
+	return rows, failed
+}
+
+func (v *reportView) printCoinbaseTags() {
+	fmt.Println()
+	fmt.Println("Generated by coinbase tag:")
+	for _, r := range v.CoinbaseTags {
+		fmt.Printf("%8.2f\t%4d blocks\t%q\n", r.Coins, r.Blocks, r.Tag)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// parseCompareWallets splits --compare-wallet's "wallet1:wallet2"
+func parseCompareWallets(s string) (string, string, error) {
+	var parts = strings.Split(s, ":")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("%q isn't of the form wallet1:wallet2", s)
+	}
+	if parts[0] == parts[1] {
+		return "", "", fmt.Errorf("%q names the same wallet twice", s)
+	}
+	return parts[0], parts[1], nil
+}
+
+// compareDay is one row of --compare-wallet
+type compareDay struct {
+	day  time.Time
+	a, b float64
+}
+
+// compareWallets totals each of the two wallets' countable transactions by
+// day over the report window.  Only days where either wallet has something
+// get a row, so a wallet that missed a day shows a zero beside the other's
+// total rather than the day vanishing.
+func compareWallets(txList []*Transaction, a, b string, field string, begin, now time.Time) []compareDay {
+	var byDay = make(map[time.Time]*compareDay)
+	for _, tx := range txList {
+		if tx.wallet != a && tx.wallet != b {
+			continue
+		}
+		tx.dt = txTime(tx, field)
+		if tx.dt.Before(begin) || !countable(tx, now) {
+			continue
+		}
+		var day = getDay(tx.dt)
+		var row = byDay[day]
+		if row == nil {
+			row = &compareDay{day: day}
+			byDay[day] = row
+		}
+		if tx.wallet == a {
+			row.a += tx.Amount
+		} else {
+			row.b += tx.Amount
+		}
+	}
+
+	var rows []compareDay
+	for _, row := range byDay {
+		rows = append(rows, *row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].day.Before(rows[j].day) })
+	return rows
+}
+
+// printTable writes rows under header with each column padded to its widest
+// cell.  The first column is left-aligned and the rest, being numbers, are
+// right-aligned.
+func printTable(w io.Writer, header []string, rows [][]string) {
+	var widths = make([]int, len(header))
+	for _, row := range append([][]string{header}, rows...) {
+		for i, cell := range row {
+			if n := utf8.RuneCountInString(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	var line = func(row []string) {
+		var cells = make([]string, len(row))
+		for i, cell := range row {
+			var pad = strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell))
+			if i == 0 {
+				cells[i] = cell + pad
+			} else {
+				cells[i] = pad + cell
+			}
+		}
+		fmt.Fprintln(w, strings.Join(cells, " | "))
+	}
+	line(header)
+	var rule = make([]string, len(widths))
+	for i, n := range widths {
+		rule[i] = strings.Repeat("-", n)
+	}
+	fmt.Fprintln(w, strings.Join(rule, "-+-"))
+	for _, row := range rows {
+		line(row)
+	}
+}
+
+// printComparison is the --compare-wallet table.  The delta is the second
+// wallet less the first, and the percentage is relative to the first, so
+// it's blank on days the first wallet earned nothing.
+func printComparison(w io.Writer, a, b string, rows []compareDay) {
+	var cells [][]string
+	var totalA, totalB float64
+	for _, row := range rows {
+		totalA += row.a
+		totalB += row.b
+		cells = append(cells, compareCells(row.day.Format(dateLayout), row.a, row.b))
+	}
+	cells = append(cells, compareCells("total", totalA, totalB))
+	printTable(w, []string{"date", a + "_amount", b + "_amount", "delta", "delta%"}, cells)
+}
+
+// compareCells formats one row of the comparison table
+func compareCells(label string, a, b float64) []string {
+	var pct = "-"
+	if a != 0 {
+		pct = fmt.Sprintf("%+.2f%%", (b-a)/a*100)
+	}
+	return []string{label, fmt.Sprintf("%.8f", a), fmt.Sprintf("%.8f", b), fmt.Sprintf("%+.8f", b-a), pct}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Two nodes agreeing on the wallet exit 0; any difference, an entry only on
+// one side or an amount or confirmations changed, is an assertion failure
+// with each difference listed under -v
+func TestCompareNodes(t *testing.T) {
+	var now = time.Now()
+	var shared = []map[string]interface{}{
+		generation("aa", 800020, 5, now.Add(-50*time.Hour)),
+		generation("bb", 800060, 5.25, now.Add(-26*time.Hour)),
+	}
+	var a = newWalletNode(t, shared)
+	var same = newWalletNode(t, shared)
+
+	var code int
+	var out = capture(t, &os.Stdout, func() { code = exitCode(run([]string{"compare-nodes", a.URL, same.URL, "rig1"})) })
+	if code != exitOK || !strings.Contains(out, "2 transaction(s) on A, 2 on B, 0 difference(s)") {
+		t.Errorf("identical nodes: exit %d, output %q", code, out)
+	}
+
+	var changed = generation("bb", 800060, 5.5, now.Add(-26*time.Hour))
+	changed["confirmations"] = 3
+	var b = newWalletNode(t, []map[string]interface{}{
+		shared[0],
+		changed,
+		generation("cc", 800090, 4.75, now.Add(-3*time.Hour)),
+	})
+	out = capture(t, &os.Stdout, func() { code = exitCode(run([]string{"compare-nodes", "-v", a.URL, b.URL, "rig1"})) })
+	if code != exitAssertion {
+		t.Errorf("differing nodes: exit %d, want %d", code, exitAssertion)
+	}
+	for _, want := range []string{
+		"2 transaction(s) on A, 3 on B, 3 difference(s)",
+		"bb:0:in amount 5.25000000 vs 5.50000000",
+		"bb:0:in confirmations 41 vs 3",
+		"cc:0:in only in B (4.75000000 generate)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("no %q in:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,653 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Nerdmaster/dynamo-tx-stats/window"
+)
+
+// stringList is a repeatable string flag
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+func (l *stringList) reset() {
+	*l = nil
+}
+
+// repeatable is a flag whose values build up a list.  Before the command
+// line is parsed the second time, over a --config file, each one given on
+// it is reset, so its values replace the file's rather than landing twice.
+type repeatable interface {
+	reset()
+}
+
+// config holds everything that shapes a run.  It's filled from flags and
+// positional arguments, optionally layered over a --config file, and can be
+// written back out with --save-config so a report can be reproduced later.
+type config struct {
+	URL string `json:"url"`
+	// FailoverURLs are standby nodes with the same wallets, tried in order
+	// when the node at URL can't be reached
+	FailoverURLs []string `json:"failover_urls"`
+	User         string   `json:"user"`
+	Password     string   `json:"password"`
+	CookieFile   string   `json:"cookie_file"`
+	ReportDays   int      `json:"report_days"`
+	Wallets      []string `json:"wallets"`
+	AsOf         string   `json:"as_of"`
+
+	// WalletDays gives some wallets their own report window; it's only
+	// set from the config file
+	WalletDays map[string]int `json:"wallet_days"`
+
+	MaxResponse string        `json:"max_response"`
+	RPCIDPrefix string        `json:"rpc_id_prefix"`
+	RPCRate     float64       `json:"rpc_rate_limit"`
+	MaxSkew     time.Duration `json:"max_clock_skew"`
+	UseNodeTime bool          `json:"use_node_time"`
+	PathPrefix  string        `json:"path_prefix"`
+	WalletAuth  stringList    `json:"wallet_auth"`
+
+	Sources    []sourceConfig `json:"sources"`
+	SumSources bool           `json:"sum_sources"`
+	MergeURLs  string         `json:"merge_urls"`
+
+	WalletsFile   string `json:"wallets_file"`
+	AutoWallets   bool   `json:"auto_wallets"`
+	WalletFilter  string `json:"wallet_filter"`
+	WalletExclude string `json:"wallet_exclude"`
+	WalletGlob    string `json:"wallet_glob"`
+
+	SkipEmptyWallets bool `json:"skip_empty_wallets"`
+
+	TimeField     string        `json:"time_field"`
+	RescanWindow  time.Duration `json:"rescan_window"`
+	RescanMinTxs  int           `json:"rescan_min_txs"`
+	RescanMinSpan time.Duration `json:"rescan_min_span"`
+
+	BucketFormat     string `json:"bucket_format"`
+	HourBucketFormat string `json:"hour_bucket_format"`
+	DateFormat       string `json:"date_format"`
+	DateTimeFormat   string `json:"datetime_format"`
+	Bucket           string `json:"bucket"`
+	HourRows         int    `json:"hour_rows"`
+	CollapseEmpty    *bool  `json:"collapse_empty,omitempty"`
+
+	Narrow    bool `json:"narrow"`
+	Wide      bool `json:"wide"`
+	Sparkline bool `json:"sparkline"`
+	Plain     bool `json:"plain"`
+	Seconds   bool `json:"seconds"`
+
+	AddrPrefixes prefixMap `json:"addr_prefixes"`
+
+	Title    string     `json:"title"`
+	Operator string     `json:"operator"`
+	JSON     bool       `json:"json"`
+	Sinks    stringList `json:"sinks"`
+	Textfile string     `json:"textfile"`
+	ESURL    string     `json:"es_url"`
+	ESIndex  string     `json:"es_index"`
+	ESAPIKey string     `json:"es_api_key"`
+	HTML     bool       `json:"html"`
+
+	HealthCheck    bool    `json:"health_check"`
+	BlockHeader    string  `json:"block_header"`
+	BlockFilter    string  `json:"block_filter"`
+	FilterStats    int64   `json:"filter_stats"`
+	CPFPCheck      string  `json:"cpfp_check"`
+	MempoolEntry   string  `json:"mempool_entry"`
+	TxProof        string  `json:"tx_proof"`
+	LabelAddresses string  `json:"label_to_addresses"`
+	SendFee        string  `json:"estimate_send_fee"`
+	CreatePSBT     string  `json:"create_psbt"`
+	SignTest       string  `json:"sign_test"`
+	Consolidate    bool    `json:"consolidate"`
+	FeeRateSats    float64 `json:"fee_rate_sats"`
+	Interactive    bool    `json:"-"`
+	BackupPath     string  `json:"-"`
+	BackupCheck    bool    `json:"-"`
+
+	RefillKeypool bool   `json:"refill_keypool"`
+	KeypoolSize   int64  `json:"keypool_size"`
+	Passphrase    string `json:"-"`
+
+	ScanDescriptor string `json:"scan_descriptor"`
+	Confirm        bool   `json:"-"`
+
+	HeightToHash   int64  `json:"-"`
+	SinceBlockhash string `json:"since_blockhash"`
+	SinceHeight    int64  `json:"since_height"`
+
+	BlockStats       bool `json:"block_stats"`
+	BlockEnrich      bool `json:"block_enrich"`
+	ByAddrType       bool `json:"by_addrtype"`
+	EnrichAddresses  bool `json:"enrich_addresses"`
+	Heatmap          bool `json:"heatmap"`
+	BlockFees        bool `json:"block_fees"`
+	ShowSubsidy      bool `json:"show_subsidy"`
+	RewardBreakdown  bool `json:"reward_breakdown"`
+	Hashrate         bool `json:"estimate_hashrate"`
+	Profitability    bool `json:"profitability"`
+	MaxHeaderLookups int  `json:"max_header_lookups"`
+
+	HeatmapWeight string `json:"heatmap_weight"`
+
+	PropagationStats bool   `json:"propagation_stats"`
+	SortBy           string `json:"sort_by"`
+
+	Strict        bool `json:"strict"`
+	NoExitOnError bool `json:"no_exit_on_error"`
+	// Lenient and WalletOverlap are for the check that each wallet's
+	// listing really is that wallet's
+	Lenient       bool    `json:"lenient"`
+	WalletOverlap float64 `json:"wallet_overlap_threshold"`
+
+	ExitZero          bool   `json:"exit_zero"`
+	ExitCodesFile     string `json:"exit_codes_file"`
+	ExitCodeUsage     int    `json:"exit_code_usage"`
+	ExitCodeError     int    `json:"exit_code_error"`
+	ExitCodeStale     int    `json:"exit_code_stale"`
+	ExitCodeAssertion int    `json:"exit_code_assertion"`
+	ExitCodePartial   int    `json:"exit_code_partial"`
+	ExitCodeDegraded  int    `json:"exit_code_degraded"`
+
+	DegradationCheck string        `json:"degradation_check"`
+	StaleAfter       time.Duration `json:"stale_after"`
+
+	FilterLabels    stringList `json:"filter_labels"`
+	SetLabels       stringList `json:"set_labels"`
+	PoolAddresses   stringList `json:"pool_addresses"`
+	PoolMode        bool       `json:"pool_mode"`
+	PoolHashrate    string     `json:"pool_hashrate"`
+	FirstN          int        `json:"first_n"`
+	LastN           int        `json:"last_n"`
+	BalanceHistory  bool       `json:"balance_history"`
+	AllTime         bool       `json:"all_time"`
+	SplitCategories bool       `json:"split_categories"`
+	CompareWallet   string     `json:"compare_wallet"`
+	MinAmount       float64    `json:"min_amount"`
+	MaxBlockAge     int64      `json:"max_block_age"`
+	ReorgDetect     bool       `json:"reorg_detect"`
+	CoinbaseTags    bool       `json:"coinbase_tags"`
+	DustInTotals    bool       `json:"dust_in_totals"`
+
+	Timing           bool   `json:"timing"`
+	VerboseTiming    bool   `json:"verbose_timing"`
+	VerboseSchema    bool   `json:"verbose_schema"`
+	IncludeWatchonly bool   `json:"include_watchonly"`
+	SortDir          string `json:"sort_dir"`
+	NodeInfo         bool   `json:"node_info"`
+	ZMQInfo          bool   `json:"zmq_info"`
+	LocalAddresses   bool   `json:"local_addresses"`
+	MiningInfo       bool   `json:"mining_info"`
+	UTXOSet          bool   `json:"utxo_set"`
+	UTXOSetIndex     bool   `json:"utxo_set_index"`
+	BannedPeers      bool   `json:"banned_peers"`
+	Peers            bool   `json:"peers"`
+	PeerLatency      bool   `json:"peer_latency"`
+	Weekly           bool   `json:"weekly"`
+
+	AnomalyDetect    bool    `json:"anomaly_detect"`
+	AnomalyThreshold float64 `json:"anomaly_threshold"`
+
+	Weekdays  bool   `json:"weekdays"`
+	WeekStart string `json:"week_start"`
+
+	Goal       float64 `json:"goal"`
+	GoalPeriod string  `json:"goal_period"`
+	Split      string  `json:"split"`
+	SplitDaily bool    `json:"split_daily"`
+
+	PowerWatts float64 `json:"power_watts"`
+	KWhPrice   float64 `json:"kwh_price"`
+	CoinPrice  float64 `json:"coin_price"`
+
+	FiatCurrencies string `json:"fiat_currencies"`
+	priceSource
+	TariffFile string       `json:"tariff_file"`
+	Tariff     []tariffBand `json:"tariff"`
+
+	DetailedBalance bool       `json:"detailed_balance"`
+	IncludeReceives bool       `json:"include_receives"`
+	LockedUTXOs     bool       `json:"locked_utxos"`
+	LockUTXOs       stringList `json:"lock_utxos"`
+	UnlockUTXOs     stringList `json:"unlock_utxos"`
+	WatchAddresses  stringList `json:"watch_addresses"`
+
+	SubsidySchedule subsidySchedule `json:"subsidy_schedule"`
+	TxGraph         bool            `json:"tx_graph"`
+
+	MonitorBroadcast bool `json:"monitor_broadcast"`
+
+	AbandonStuck   bool          `json:"abandon_stuck"`
+	StuckAfter     time.Duration `json:"stuck_after"`
+	ConfirmAbandon bool          `json:"confirm_abandon"`
+
+	Output     string `json:"output"`
+	PrunedNode bool   `json:"pruned_node"`
+
+	Splay       time.Duration `json:"splay"`
+	SplayRandom bool          `json:"splay_random"`
+
+	Listen   string        `json:"listen"`
+	Refresh  time.Duration `json:"refresh"`
+	FIFO     string        `json:"fifo"`
+	BlockLog int           `json:"block_log"`
+
+	DebugListen string `json:"debug_listen"`
+	DebugPprof  bool   `json:"debug_pprof"`
+
+	TXIDFile string `json:"txid_file"`
+
+	RescanFrom  int64 `json:"-"`
+	RescanAbort bool  `json:"rescan_abort"`
+
+	DailyReport        string     `json:"daily_report"`
+	DailyReportDir     string     `json:"daily_report_dir"`
+	DailyReportWebhook stringList `json:"daily_report_webhook"`
+	Timezone           string     `json:"timezone"`
+	BlockWebhook       stringList `json:"block_webhook"`
+
+	StateFile         string `json:"state_file"`
+	TxArchive         string `json:"tx_archive"`
+	ProjectionHistory int    `json:"projection_history"`
+	ProjectionHour    int    `json:"projection_hour"`
+
+	// AuditDays, AuditFix, and AuditEvery are for checking the archive
+	// against the node, with audit or serve
+	AuditDays  int           `json:"audit_days"`
+	AuditFix   bool          `json:"audit_fix"`
+	AuditEvery time.Duration `json:"audit_every"`
+
+	showVersion bool
+	configFile  string
+	saveConfig  string
+	dryRun      bool
+	walletFlags stringList
+	urlFlags    stringList
+
+	simulate        bool
+	simSeed         int64
+	simBlocksPerDay float64
+	simReward       float64
+
+	debugCapture string
+	anonymize    bool
+}
+
+// optionalBool is a boolean flag that's left nil unless it's given, so a
+// default can depend on something else
+type optionalBool struct {
+	p **bool
+}
+
+func (o optionalBool) String() string {
+	if o.p == nil || *o.p == nil {
+		return ""
+	}
+	return strconv.FormatBool(**o.p)
+}
+
+func (o optionalBool) Set(s string) error {
+	var v, err = strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	*o.p = &v
+	return nil
+}
+
+func (o optionalBool) IsBoolFlag() bool {
+	return true
+}
+
+// headerList is a repeatable "Name: value" flag that fills a header map
+type headerList map[string]string
+
+func (h headerList) String() string {
+	var parts []string
+	for k, v := range h {
+		parts = append(parts, k+": "+v)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (h headerList) Set(s string) error {
+	var k, v, ok = strings.Cut(s, ":")
+	if !ok {
+		return fmt.Errorf("expected \"Name: value\", got %q", s)
+	}
+	h[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	return nil
+}
+
+func (h headerList) reset() {
+	for k := range h {
+		delete(h, k)
+	}
+}
+
+func bindFlags(fs *flag.FlagSet, cfg *config) {
+	if cfg.PriceHeaders == nil {
+		cfg.PriceHeaders = make(map[string]string)
+	}
+	fs.Var(headerList(cfg.PriceHeaders), "price-header", "send `\"Name: value\"` with the --price-url request, e.g. for an API key (repeatable)")
+	fs.Var(&cfg.urlFlags, "url", "the node's RPC `url`, such as http://127.0.0.1:8332; repeat it for standby nodes with the same wallets, tried in order when the first can't be reached")
+	fs.StringVar(&cfg.User, "user", "", "RPC `username`")
+	fs.StringVar(&cfg.Password, "password", "", "RPC `password`")
+	fs.StringVar(&cfg.CookieFile, "cookie-file", "", "log in with the credentials in bitcoind's cookie `path` (<datadir>/.cookie, as __cookie__:<token>) instead of --user and --password")
+	window.DaysVar(fs, &cfg.ReportDays, "days", 0, "report on the last `N` days, today included; also takes a length in whole days, such as 2w")
+	fs.Var(&cfg.walletFlags, "wallet", "report on wallet `name` (repeatable)")
+	fs.BoolVar(&cfg.showVersion, "version", false, "print version information and exit")
+	fs.StringVar(&cfg.configFile, "config", "", "read settings from a JSON `file` written by --save-config; flags and arguments given on the command line take precedence")
+	fs.StringVar(&cfg.saveConfig, "save-config", "", "write the effective settings to a JSON `file` that --config can replay")
+	fs.BoolVar(&cfg.dryRun, "dry-run", false, "print the RPC calls and outputs a run would make, without contacting the node")
+	fs.BoolVar(&cfg.simulate, "simulate", false, "report on made-up wallet histories instead of a node's, marked SIMULATED throughout; takes [<report days> [<Wallet Name(s)...>]] in place of the usual arguments")
+	fs.Int64Var(&cfg.simSeed, "simulate-seed", 1, "with --simulate, the random `seed`; the same seed, days, and wallets always give the same data")
+	fs.Float64Var(&cfg.simBlocksPerDay, "simulate-blocks", 4, "with --simulate, how many blocks each wallet finds a day on average")
+	fs.Float64Var(&cfg.simReward, "simulate-reward", 0, "with --simulate, the reward of each block found, before fees (0 is the subsidy at the simulated tip)")
+	fs.StringVar(&cfg.debugCapture, "debug-capture", "", "write each RPC request and response, without credentials, and the run's settings to `dir` for a bug report; \"replay dir\" reruns the report from them")
+	fs.BoolVar(&cfg.anonymize, "anonymize", false, "with --debug-capture, write wallet names as wallet-1, wallet-2, ...")
+	fs.StringVar(&cfg.MaxResponse, "max-response", "64MB", "largest RPC response `size` to accept, e.g. 64MB")
+	fs.BoolVar(&cfg.SumSources, "sum-sources", false, "with multiple sources in the config file, add their coins together in the combined table")
+	fs.StringVar(&cfg.MergeURLs, "merge-urls", "", "also fetch the same wallets from each node in this comma-separated `list` of URLs, counting each transaction once and showing per-node totals")
+	window.DurationVar(fs, &cfg.MaxSkew, "max-clock-skew", 2*time.Minute, "warn when the local clock and the node's (from its replies' Date header) differ by more than `duration`")
+	fs.BoolVar(&cfg.UseNodeTime, "use-node-time", false, "take \"now\" from the node's clock rather than the local one")
+	fs.Float64Var(&cfg.RPCRate, "rpc-rate-limit", 0, "send at most `N` RPC requests a second (0 is unlimited); --timing shows how long the limit held the run up")
+	fs.StringVar(&cfg.RPCIDPrefix, "rpc-id-prefix", "", "number each RPC request's ID as `prefix`-0001, -0002, ... instead of using \"curltest\", to match calls against the node's debug log")
+	fs.StringVar(&cfg.PathPrefix, "path-prefix", "", "put `path` in front of every RPC endpoint, e.g. /bitcoin/rpc for a proxy serving /bitcoin/rpc/wallet/<name>")
+	fs.Var(&cfg.WalletAuth, "wallet-auth", "use separate credentials, given as `wallet:user:pass`, for one wallet's RPC calls (repeatable)")
+	fs.StringVar(&cfg.WalletsFile, "wallets-file", "", "read additional wallet names from `file`, one per line (# starts a comment)")
+	fs.BoolVar(&cfg.AutoWallets, "auto-wallets", false, "add every wallet the node has loaded (via listwallets) to the wallet list")
+	fs.StringVar(&cfg.WalletFilter, "wallet-filter", "", "with --auto-wallets, only use discovered wallets whose names match `regex`")
+	fs.StringVar(&cfg.WalletExclude, "wallet-exclude", "", "with --auto-wallets, skip discovered wallets whose names match `regex`")
+	fs.StringVar(&cfg.WalletGlob, "wallet-glob", "", "add every wallet in the node's wallet directory, loaded or not (via listwalletdir), whose name matches the shell `pattern`, such as \"miner_*\"; it's an error if none does")
+	fs.BoolVar(&cfg.SkipEmptyWallets, "skip-empty-wallets", false, "with --auto-wallets, leave out discovered wallets that have no transactions")
+	fs.StringVar(&cfg.AsOf, "as-of", "", "build the report as though it were run at `time` (\"YYYY-MM-DD HH:MM\", local time)")
+	fs.StringVar(&cfg.TimeField, "time-field", "timereceived", "transaction `field` that decides which bucket it lands in: timereceived, blocktime, or time")
+	window.DurationVar(fs, &cfg.RescanWindow, "rescan-window", 5*time.Minute, "rescan warning: how close together timereceived values must be, as a `duration`")
+	fs.IntVar(&cfg.RescanMinTxs, "rescan-min-txs", 50, "rescan warning: how many transactions must share a --rescan-window (0 disables the warning)")
+	window.DurationVar(fs, &cfg.RescanMinSpan, "rescan-min-span", 7*24*time.Hour, "rescan warning: how far apart in block time those transactions must be, as a `duration`")
+	fs.StringVar(&cfg.BucketFormat, "bucket-format", "2006-01-02", "Go time `layout` for daily bucket labels")
+	fs.StringVar(&cfg.HourBucketFormat, "hour-bucket-format", "15:04", "Go time `layout` for hourly bucket labels")
+	fs.StringVar(&cfg.DateFormat, "date-format", "2006-01-02", "Go time `layout` for dates shown in text and HTML output; must tell every day apart")
+	fs.StringVar(&cfg.DateTimeFormat, "datetime-format", "2006-01-02 15:04:05", "Go time `layout` for times shown in text and HTML output; must tell every second apart")
+	fs.StringVar(&cfg.Bucket, "bucket", "1d", "bucket `size` of the detail table: 15m, 1h, 4h, or any other step that divides a day, or 1d; starts from midnight, and the summary is unaffected")
+	fs.IntVar(&cfg.HourRows, "hour-rows", 48, "fold runs of empty hours into one line when the hourly table would be longer than `N` rows (0 never folds)")
+	fs.Var(optionalBool{&cfg.CollapseEmpty}, "collapse-empty", "always fold runs of empty hours into one line, or with =false never, whatever --hour-rows says")
+	fs.BoolVar(&cfg.Narrow, "narrow", false, "use the narrow layout regardless of terminal width")
+	fs.BoolVar(&cfg.Wide, "wide", false, "use the wide layout regardless of terminal width")
+	fs.BoolVar(&cfg.Sparkline, "sparkline", false, "mark each day with a block character (▁ to █) showing where its total falls in the window's range")
+	fs.BoolVar(&cfg.Seconds, "seconds", false, "give ages and gaps in text output as whole seconds rather than \"3h 42m\", for scripts (JSON always uses seconds)")
+	fs.BoolVar(&cfg.Plain, "plain", false, "plain ASCII output for dumb terminals and logs: no color, unicode, or progress lines (NO_COLOR turns off just color)")
+	fs.StringVar(&cfg.Title, "title", "", "report `title` shown in the header")
+	fs.StringVar(&cfg.Operator, "operator", "", "operator `name` shown in the header")
+	fs.BoolVar(&cfg.JSON, "json", false, "write the report as JSON")
+	fs.BoolVar(&cfg.HTML, "html", false, "write the report as an HTML page")
+	fs.Var(&cfg.Sinks, "sink", "send the report to `sink`: text, json, or html (each optionally :path), csv:path to append a row, pushgateway:url, or textfile:path; repeatable, replaces --json and --html")
+	fs.StringVar(&cfg.ESURL, "es-url", "", "also bulk-index the window's transactions and the report's rows into the Elasticsearch or OpenSearch cluster at `url`; re-runs replace their documents")
+	fs.StringVar(&cfg.ESIndex, "es-index", "txstats", "with --es-url, the `index` to write to")
+	fs.StringVar(&cfg.ESAPIKey, "es-api-key", "", "with --es-url, authenticate with this API `key` (the base64 id:key form)")
+	fs.StringVar(&cfg.Textfile, "textfile", "", "also write the report's metrics, as the pushgateway sink names them, to `path` for node_exporter's textfile collector (replaced atomically)")
+	fs.BoolVar(&cfg.HealthCheck, "health-check", false, "run node and wallet health checks instead of the report (as a JSON array with --json); exits 0 (pass), 1 (warn), or 2 (fail)")
+	fs.BoolVar(&cfg.AnomalyDetect, "anomaly-detect", false, "flag days whose total is unusually far from the window's mean (by z-score)")
+	fs.Float64Var(&cfg.AnomalyThreshold, "anomaly-threshold", 2.0, "with --anomaly-detect, the |z| above which a day is flagged")
+	fs.BoolVar(&cfg.Weekly, "weekly", false, "add a table of weekly totals")
+	fs.BoolVar(&cfg.Weekdays, "weekdays", false, "add a table of day-of-week averages")
+	fs.Int64Var(&cfg.HeightToHash, "height-to-hash", -1, "print the hash of the block at `height` and exit")
+	fs.StringVar(&cfg.SinceBlockhash, "since-blockhash", "", "only fetch transactions in blocks after `hash` (via listsinceblock) rather than the last 100000")
+	fs.Int64Var(&cfg.SinceHeight, "since-height", 0, "like --since-blockhash, but given as a block `height`")
+	fs.BoolVar(&cfg.IncludeWatchonly, "include-watchonly", false, "also fetch the transactions of watch-only addresses in legacy wallets (listtransactions' include_watchonly), marking them in --first-n and --last-n")
+	fs.StringVar(&cfg.BlockHeader, "block-header", "", "print the header of the block with the given `hash` and exit")
+	fs.StringVar(&cfg.BlockFilter, "block-filter", "", "print the BIP158 basic filter of the block with the given `hash`, and its filter header (via getblockfilter; the node needs -blockfilterindex), and exit")
+	fs.Int64Var(&cfg.FilterStats, "filter-stats", 0, "fetch the basic filters of the last `N` blocks and print their average and largest size, and exit")
+	fs.StringVar(&cfg.ScanDescriptor, "scan-descriptor", "", "scan the whole UTXO set (via scantxoutset) for outputs matching `descriptor`, print them, and exit; slow, so it needs --confirm")
+	fs.BoolVar(&cfg.Confirm, "confirm", false, "go ahead with a slow operation such as --scan-descriptor")
+	fs.StringVar(&cfg.CPFPCheck, "cpfp-check", "", "compare the fee rate of unconfirmed transaction `txid` with that of its mempool package, say whether it needs a CPFP child, and exit")
+	fs.StringVar(&cfg.MempoolEntry, "mempool-entry", "", "print what the node's mempool holds for transaction `txid` (via getmempoolentry): its size, fees, when it entered, and what it depends on, or that it isn't there, and exit")
+	fs.StringVar(&cfg.TxProof, "tx-proof", "", "print a merkle proof, via gettxoutproof, that transaction `txid` is in a block, with the block's hash and whether verifytxoutproof accepts it, and exit")
+	fs.StringVar(&cfg.LabelAddresses, "label-to-addresses", "", "print every address the first wallet files under `label` (via getaddressesbylabel), with its purpose (receive, send, or refund), and exit")
+	fs.StringVar(&cfg.SendFee, "estimate-send-fee", "", "print the fee the first wallet (or the node's default wallet) would pay to send `address:amount,...`, via fundrawtransaction, and exit; nothing is signed or broadcast")
+	fs.BoolVar(&cfg.Consolidate, "consolidate", false, "list the first wallet's (or the node's default wallet's) UTXOs and print what one transaction spending them all to a single output would cost at --fee-rate-sats, and what it would leave, then exit; nothing is built, signed, or broadcast")
+	fs.Float64Var(&cfg.FeeRateSats, "fee-rate-sats", 0, "the fee `rate`, in sat/vB, for --consolidate")
+	fs.StringVar(&cfg.SignTest, "sign-test", "", "have the first wallet (or the node's default wallet) sign the raw transaction `hex` with signrawtransactionwithwallet, print whether signing was complete, how many inputs were signed, and each unsigned input's error, then exit; the signed transaction is discarded, never broadcast")
+	fs.StringVar(&cfg.CreatePSBT, "create-psbt", "", "have the first wallet (or the node's default wallet) fund a PSBT paying the `outputs` JSON, as walletcreatefundedpsbt takes it, and print it with its fee, change position, and estimated size, then exit; nothing is signed or broadcast")
+	fs.StringVar(&cfg.BackupPath, "backup-wallet", "", "have the node back the first wallet (or its default wallet) up to `path` on its own filesystem, via backupwallet, and exit")
+	fs.BoolVar(&cfg.BackupCheck, "backup-verify", false, "with --backup-wallet, load the backup as a wallet of its own to check it's readable, then unload it")
+	fs.BoolVar(&cfg.RefillKeypool, "refill-keypool", false, "top up each legacy wallet's keypool (via keypoolrefill) before the report; descriptor wallets are skipped")
+	fs.Int64Var(&cfg.KeypoolSize, "keypool-size", 0, "with --refill-keypool, refill to `N` keys rather than the node's -keypool setting")
+	fs.StringVar(&cfg.Passphrase, "passphrase", "", "with --refill-keypool, unlock encrypted wallets with `passphrase` first, locking them again after")
+	fs.BoolVar(&cfg.Interactive, "interactive", false, "instead of the report, read commands (stats, wallets, balance, tx, health) at a dynamo> prompt")
+	fs.BoolVar(&cfg.Interactive, "i", false, "shorthand for --interactive")
+	fs.BoolVar(&cfg.NodeInfo, "node-info", false, "print node version, sync, and connection details and exit")
+	fs.BoolVar(&cfg.UTXOSet, "utxo-set", false, "print UTXO set statistics (via gettxoutsetinfo, which can take minutes) and exit")
+	fs.BoolVar(&cfg.UTXOSetIndex, "utxo-set-index", false, "like --utxo-set, but answered quickly from the node's coinstatsindex")
+	fs.BoolVar(&cfg.MiningInfo, "mining-info", false, "print the chain height, difficulty, network hashrate, and mempool size (via getmininginfo) and exit")
+	fs.BoolVar(&cfg.LocalAddresses, "local-addresses", false, "print the addresses the node advertises to peers (from getnetworkinfo) with their ports and scores, flagging any that may not be reachable from the internet, and exit")
+	fs.BoolVar(&cfg.ZMQInfo, "zmq-info", false, "print the node's configured ZMQ topics and addresses (via getzmqnotifications) and exit")
+	fs.BoolVar(&cfg.Peers, "peers", false, "with --node-info, also list connected peers with the message types behind their traffic (via getpeerinfo), marking heavy headers or inv senders")
+	fs.BoolVar(&cfg.PeerLatency, "peer-latency", false, "rank connected peers by ping time (via getpeerinfo), listing the 5 lowest, those over 1s, and those whose blocks lag their headers, and exit")
+	fs.BoolVar(&cfg.BannedPeers, "banned-peers", false, "with --node-info, also list banned peers (via listbanned)")
+	fs.BoolVar(&cfg.ExitZero, "exit-zero", false, "always exit 0, even on failure (errors are still printed), e.g. for cron jobs that mail on failure")
+	fs.StringVar(&cfg.ExitCodesFile, "exit-codes-file", "", "write the exit code used for each condition to `path` as JSON")
+	fs.IntVar(&cfg.ExitCodeUsage, "exit-code-usage", exitUsage, "exit `code` for usage errors")
+	fs.IntVar(&cfg.ExitCodeError, "exit-code-error", exitRPC, "exit `code` for RPC or output failures")
+	fs.IntVar(&cfg.ExitCodeStale, "exit-code-stale", exitStale, "exit `code` for stale-block alerts")
+	fs.IntVar(&cfg.ExitCodeAssertion, "exit-code-assertion", exitAssertion, "exit `code` for assertion failures")
+	fs.IntVar(&cfg.ExitCodePartial, "exit-code-partial", exitPartial, "exit `code` when only part of the data could be fetched")
+	fs.IntVar(&cfg.ExitCodeDegraded, "exit-code-degraded", exitDegraded, "exit `code` when --degradation-check flags a drop")
+	window.DurationVar(fs, &cfg.StaleAfter, "stale-after", 0, "alert, and exit with --exit-code-stale's code, when the wallets haven't won a block for `duration`")
+	fs.StringVar(&cfg.DegradationCheck, "degradation-check", "", "compare each wallet's daily average over a short window to a long one and flag drops, given as `short=3d,long=14d,threshold=15%` (also min-blocks=N, default 3, and by=wallet or by=label)")
+	fs.BoolVar(&cfg.Lenient, "lenient", false, "only warn, rather than stop, when two wallets' listings overlap past --wallet-overlap-threshold")
+	fs.Float64Var(&cfg.WalletOverlap, "wallet-overlap-threshold", 1, "stop when two wallets share at least this `fraction` of their listed transactions, the sign of an endpoint that ignores the wallet path; 1 is only identical listings, 0 turns the check off")
+	fs.BoolVar(&cfg.NoExitOnError, "no-exit-on-error", false, "list the wallets that couldn't be fetched, with their errors, in an --- Errors --- section at the end of the run (failed wallets are always left out, and the run exits with --exit-code-partial's code)")
+	fs.BoolVar(&cfg.Strict, "strict", false, "treat suspect input, such as a wallet named twice, as an error rather than a warning")
+	fs.IntVar(&cfg.FirstN, "first-n", 0, "instead of the report, list the oldest `N` transactions by time received")
+	fs.IntVar(&cfg.LastN, "last-n", 0, "instead of the report, list the newest `N` transactions by time received")
+	fs.StringVar(&cfg.CompareWallet, "compare-wallet", "", "instead of the report, compare two wallets day by day, given as `wallet1:wallet2`")
+	fs.BoolVar(&cfg.SplitCategories, "split-categories", false, "add a section for each transaction category in the window (generate, immature, receive, send, ...) with its own totals, averages, and daily or --bucket table")
+	fs.BoolVar(&cfg.AllTime, "all-time", false, "fetch each wallet's whole history (up to 1,000,000 transactions) and add all-time totals below the report: coins mined, transactions, when mining started, and the monthly average since")
+	fs.BoolVar(&cfg.BalanceHistory, "balance-history", false, "instead of the report, replay every transaction from a zero balance and list the running balance by day")
+	fs.Var(&cfg.FilterLabels, "filter-label", "only count transactions with this `label`; \"\" or \"(unlabeled)\" matches unlabeled ones (repeatable)")
+	fs.Var(&cfg.SetLabels, "set-label", "relabel `address:label` with setlabel, in the wallet that owns the address, before the report is built (repeatable; an empty label clears it)")
+	fs.Var(&cfg.PoolAddresses, "pool-address", "count receives to `address`, or labeled with it, as earnings alongside blocks won, for a rig mining to a pool (repeatable)")
+	fs.BoolVar(&cfg.PoolMode, "pool-mode", false, "take every generated transaction as a pool payout, and summarize them: average payout, payouts a day, and the longest gap between them")
+	fs.StringVar(&cfg.PoolHashrate, "pool-hashrate", "", "with --pool-mode, estimate the pool's fee from what `rate` (hashes/s, such as 120T) would earn solo at the current difficulty and subsidy")
+	fs.Float64Var(&cfg.MinAmount, "min-amount", 0, "don't count generations below `amount` as blocks won (0 counts everything)")
+	fs.BoolVar(&cfg.CoinbaseTags, "coinbase-tags", false, "group the window's blocks by the printable tag in their coinbase scriptSig (one getrawtransaction per block, cached in --state-file)")
+	fs.BoolVar(&cfg.ReorgDetect, "reorg-detect", false, "check each confirmed transaction's block hash in the window against the chain's (via getblockhash) and list any a reorg left behind")
+	fs.Int64Var(&cfg.MaxBlockAge, "max-block-age", 0, "drop generations whose block is more than `blocks` behind the chain tip, whatever their timestamps (0 keeps everything)")
+	fs.BoolVar(&cfg.DustInTotals, "dust-in-totals", false, "still add generations below --min-amount to the coin totals")
+	fs.BoolVar(&cfg.BlockFees, "block-fees", false, "add the fee income in blocks won, from each coinbase's value less the subsidy")
+	fs.BoolVar(&cfg.Profitability, "profitability", false, "add each day's earnings per unit of network difficulty (from the headers of the blocks won, else getdifficulty), to tell lucky days from easy ones")
+	fs.BoolVar(&cfg.Hashrate, "estimate-hashrate", false, "estimate the hashrate behind the blocks won, with a 95% range, from their count and difficulty")
+	fs.IntVar(&cfg.MaxHeaderLookups, "max-header-lookups", 2000, "fetch at most `N` block headers per run for --estimate-hashrate and --block-stats, noting the difficulty data as incomplete past it (0 for no limit; headers already in the --state-file don't count)")
+	fs.BoolVar(&cfg.ShowSubsidy, "show-subsidy", false, "list each block won with the subsidy expected at its height, marking any that differ")
+	fs.BoolVar(&cfg.RewardBreakdown, "reward-breakdown", false, "split each block won in the window into the subsidy due at its height and fees (the rest of what the wallet was paid), with each block's fee share")
+	fs.BoolVar(&cfg.Heatmap, "heatmap", false, "add an hour-of-day profile of the whole window")
+	fs.BoolVar(&cfg.PropagationStats, "propagation-stats", false, "add how long the window's transactions took from first seen to mined, with a histogram by minute")
+	fs.StringVar(&cfg.HeatmapWeight, "heatmap-weight", "amount", "weight the --heatmap display by coin `amount` or block count (blocks)")
+	fs.BoolVar(&cfg.Timing, "timing", false, "add a footer showing where the run's time went: wall time, per-wallet fetches, and RPC calls by method")
+	fs.BoolVar(&cfg.VerboseTiming, "verbose-timing", false, "print each RPC request's connect, TLS, and first-byte times to stderr")
+	fs.BoolVar(&cfg.VerboseSchema, "verbose-schema", false, "note on stderr, once each, transaction fields the node sends that txstats doesn't know, to spot changes in its output")
+	fs.StringVar(&cfg.SortBy, "sort-by", "date", "order days and hours by `key`: date, amount, or rate")
+	fs.StringVar(&cfg.SortDir, "sort-dir", "", "sort `direction`, asc or desc (default desc for amount and rate, asc for date)")
+	fs.BoolVar(&cfg.ByAddrType, "by-addrtype", false, "add totals of generated coins by mining address type (legacy, p2sh, bech32)")
+	fs.BoolVar(&cfg.EnrichAddresses, "enrich-addresses", false, "look up each address in the window's transactions with getaddressinfo, and show how their outputs are spread across types (P2PKH, P2SH, P2WPKH, P2WSH, P2TR)")
+	if cfg.AddrPrefixes == nil {
+		cfg.AddrPrefixes = make(prefixMap)
+	}
+	fs.Var(cfg.AddrPrefixes, "addr-prefix", "classify addresses starting with the given prefixes as `type=prefix[,prefix...]` for --by-addrtype; replaces the Bitcoin defaults (repeatable)")
+	fs.BoolVar(&cfg.BlockEnrich, "block-enrich", false, "list each block won in the report window with its height, time, size, weight, transaction count, and difficulty (via getblock)")
+	fs.BoolVar(&cfg.BlockStats, "block-stats", false, "list each block won in the report window with its header details, and the fees and fee rates paid in it (via getblockstats)")
+	fs.Float64Var(&cfg.PowerWatts, "power-watts", 0, "rig power draw in `watts`; enables the power cost section")
+	fs.Float64Var(&cfg.KWhPrice, "kwh-price", 0, "flat electricity `price` per kWh, used when no tariff schedule is configured")
+	fs.StringVar(&cfg.PriceURL, "price-url", "", "when --coin-price isn't given, fetch it from this JSON API `url`; {currency} or {CURRENCY} in it stands for the currency, USD for the coin price")
+	fs.StringVar(&cfg.PricePath, "price-path", "", "dot-separated `path` to the price in the --price-url response, e.g. result.last; it may hold {currency} or {CURRENCY} too")
+	fs.StringVar(&cfg.PriceCoinGecko, "price-coingecko", "", "when --coin-price isn't given, fetch the USD price of this CoinGecko coin `id`")
+	fs.StringVar(&cfg.TariffFile, "tariff", "", "read a time-of-use tariff schedule (a JSON list of bands) from `file`")
+	fs.StringVar(&cfg.FiatCurrencies, "fiat-currencies", "", "also show each day's or bucket's earnings in each of this comma-separated `list` of currencies (e.g. USD,EUR,GBP), at the coin's current price from --price-coingecko or --price-url (with a {currency} placeholder for more than one), or --coin-price for one")
+	fs.Float64Var(&cfg.CoinPrice, "coin-price", 0, "value of one coin in the same currency as the power price, for net profit")
+	fs.BoolVar(&cfg.DetailedBalance, "detailed-balance", false, "add each wallet's trusted, pending, and immature balances (via getbalances)")
+	fs.BoolVar(&cfg.IncludeReceives, "include-receives", false, "add what each wallet received in the window other than block rewards")
+	fs.BoolVar(&cfg.LockedUTXOs, "locked-utxos", false, "add the count and value of each wallet's locked UTXOs (via listlockunspent)")
+	fs.Var(&cfg.LockUTXOs, "lock-utxo", "lock the UTXO `txid:vout` in the first wallet (or the node's default wallet) against spending, until the node restarts, listing the locked UTXOs before and after, then exit (repeatable)")
+	fs.Var(&cfg.UnlockUTXOs, "unlock-utxo", "unlock the UTXO `txid:vout` in the first wallet (or the node's default wallet), listing the locked UTXOs before and after, then exit (repeatable)")
+	fs.Var(&cfg.WatchAddresses, "watch-address", "report the amount received by `address` (repeatable)")
+	fs.BoolVar(&cfg.TxGraph, "tx-graph", false, "graph how the window's send transactions spend earlier outputs (uses getrawtransaction)")
+	fs.BoolVar(&cfg.MonitorBroadcast, "monitor-broadcast", false, "re-broadcast the window's unconfirmed sends (via sendrawtransaction) and report how many the node accepted")
+	fs.BoolVar(&cfg.AbandonStuck, "abandon-stuck", false, "list the sends unconfirmed for --stuck-after or longer, with their age and amount; with --confirm-abandon, abandon them (via abandontransaction) so their inputs can be spent again")
+	window.DurationVar(fs, &cfg.StuckAfter, "stuck-after", 24*time.Hour, "how long a send must have gone unconfirmed, as a `duration`, for --abandon-stuck")
+	fs.BoolVar(&cfg.ConfirmAbandon, "confirm-abandon", false, "actually call abandontransaction for --abandon-stuck, rather than only listing what it would abandon")
+	fs.BoolVar(&cfg.PrunedNode, "pruned-node", false, "the node is pruned: fetch with listsinceblock and skip features that need getrawtransaction")
+	fs.StringVar(&cfg.Output, "output", "", "write the --tx-graph DOT graph to `file` instead of stdout")
+	window.DurationVar(fs, &cfg.Splay, "splay", 0, "wait up to `duration` (fixed per host) before contacting the node, so cron jobs across a fleet don't all hit it at once; with serve, jitter each refresh instead")
+	fs.BoolVar(&cfg.SplayRandom, "splay-random", false, "pick the --splay wait at random each time rather than from the host name")
+	fs.StringVar(&cfg.Listen, "listen", "127.0.0.1:8080", "with serve, the `address` to answer Grafana JSON datasource requests, and Prometheus scrapes of /metrics, on")
+	fs.StringVar(&cfg.DebugListen, "debug-listen", "", "with serve, answer /debug/vars (expvar counters) on this loopback `address`, apart from --listen")
+	fs.BoolVar(&cfg.DebugPprof, "debug-pprof", false, "with --debug-listen, also answer /debug/pprof")
+	window.DurationVar(fs, &cfg.Refresh, "refresh", time.Minute, "with serve, how often to re-fetch transactions, as a `duration`")
+	fs.StringVar(&cfg.FIFO, "fifo", "", "with serve, also write a line of stats after each refresh to the named pipe at `path`, made if it isn't there, for tail -f or cat")
+	fs.IntVar(&cfg.BlockLog, "block-log", 20, "with serve, print each newly found block and keep the last `N` for /blocks (0 disables)")
+	fs.StringVar(&cfg.TXIDFile, "txid-file", "", "instead of the report, look up each txid listed in `path` (one per line) with gettransaction")
+	fs.Int64Var(&cfg.RescanFrom, "rescan-from", -1, "instead of the report, rescan each wallet from block `height` (via rescanblockchain), showing progress")
+	fs.BoolVar(&cfg.RescanAbort, "rescan-abort", false, "with --rescan-from, Ctrl-C asks the node to abort the rescan (via abortrescan) instead of exiting")
+	fs.StringVar(&cfg.DailyReport, "daily-report", "", "with serve, send a summary of the previous day every day at `HH:MM` local time")
+	fs.StringVar(&cfg.DailyReportDir, "daily-report-dir", "", "write --daily-report summaries to dated files in `dir`")
+	fs.Var(&cfg.DailyReportWebhook, "daily-report-webhook", "post --daily-report summaries as JSON to a Slack or Discord webhook `url` (repeatable)")
+	fs.Var(&cfg.BlockWebhook, "block-webhook", "with serve, post each newly found block as JSON to `url` (repeatable)")
+	fs.StringVar(&cfg.Timezone, "timezone", "", "IANA time zone `name` that days and hours are counted in, instead of the system's")
+	fs.StringVar(&cfg.StateFile, "state-file", "", "keep history between runs (such as projection snapshots) in `file`")
+	fs.StringVar(&cfg.TxArchive, "tx-archive", "", "keep every transaction the node lists in `file`, and fill in the report with those older than the node still keeps; the node's own listing wins where both have a transaction")
+	window.DaysVar(fs, &cfg.AuditDays, "audit-days", 30, "with audit or --audit-every, check the last `N` days of the --tx-archive against the node")
+	fs.BoolVar(&cfg.AuditFix, "fix", false, "with audit or --audit-every, bring the --tx-archive back in line with the node where they differ")
+	window.DurationVar(fs, &cfg.AuditEvery, "audit-every", 0, "with serve, audit the --tx-archive against the node at start and then every `duration`, logging drift and exporting the result at /metrics")
+	window.DaysVar(fs, &cfg.ProjectionHistory, "projection-history", 0, "with --state-file, compare the last `N` days' recorded projections to their actual totals")
+	fs.IntVar(&cfg.ProjectionHour, "projection-hour", 12, "hour of the day whose projection --projection-history compares against")
+	fs.Float64Var(&cfg.Goal, "goal", 0, "track production against a target of `amount` per --goal-period (0 is off)")
+	fs.StringVar(&cfg.GoalPeriod, "goal-period", "month", "the `period` --goal is for: day, week (starting on --week-start), or month")
+	fs.StringVar(&cfg.Split, "split", "", "divide the report period total between `name=percent,...` (percentages adding up to 100), in coins and, with a coin price, in fiat; also in JSON and as columns of a csv sink")
+	fs.BoolVar(&cfg.SplitDaily, "split-daily", false, "with --split, divide each day's total too")
+	fs.StringVar(&cfg.WeekStart, "week-start", "monday", "first day of the week for --weekly and --weekdays; monday gives ISO weeks")
+}
+
+// systemLocal is the machine's own time zone, which a run without --timezone
+// is back in whatever an earlier one in the process set
+var systemLocal = time.Local
+
+// parseConfig builds the run's config from the command line.  When --config
+// is given, the file is loaded over the defaults and the command line is
+// parsed a second time so anything given explicitly still wins; a
+// repeatable flag given there replaces the file's list.
+// The flag package has already complained about a bad flag by the time
+// Parse returns, so that error carries no message of its own, and -h is a
+// successful run.
+func parseConfig(args []string) (*config, error) {
+	var cfg = &config{}
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	flag.CommandLine.Usage = func() { usage("") }
+	bindFlags(flag.CommandLine, cfg)
+	var err = parseFlags(args)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.configFile != "" {
+		err = loadConfigFile(cfg.configFile, cfg)
+		if err != nil {
+			return nil, usageError(fmt.Sprintf("Unable to read config file %q: %s", cfg.configFile, err))
+		}
+		flag.CommandLine.Visit(func(f *flag.Flag) {
+			if r, ok := f.Value.(repeatable); ok {
+				r.reset()
+			}
+		})
+		err = parseFlags(args)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = setExitCodes(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var loc = systemLocal
+	if cfg.Timezone != "" {
+		loc, err = time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			return nil, usageError(fmt.Sprintf("Invalid --timezone %q: %s", cfg.Timezone, err))
+		}
+	}
+	// Other goroutines may be reading it, so it's only written to change it
+	if time.Local != loc {
+		time.Local = loc
+	}
+
+	if len(cfg.walletFlags) > 0 {
+		cfg.Wallets = cfg.walletFlags
+	}
+	if len(cfg.urlFlags) > 0 {
+		cfg.URL, cfg.FailoverURLs = cfg.urlFlags[0], cfg.urlFlags[1:]
+	}
+	var rest = flag.Args()
+	if cfg.simulate {
+		rest = simulatedArgs(rest)
+	} else if len(rest) > 0 && len(rest) < 5 {
+		// the positional form has always needed all of its arguments, and
+		// said so before looking at any of them
+		return nil, usageError("Not enough args")
+	}
+	err = applyPositional(cfg, rest)
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.simulate {
+		noteLegacyForm(rest)
+	}
+
+	return cfg, nil
+}
+
+func parseFlags(args []string) error {
+	var err = flag.CommandLine.Parse(args)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		return &exitError{code: exitUsage}
+	}
+	return err
+}
+
+func loadConfigFile(path string, cfg *config) error {
+	var data, err = os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, cfg)
+}
+
+// save writes the config as JSON.  It includes the RPC credentials, so the
+// file is only readable by its owner.
+func (cfg *config) save(path string) error {
+	var data, err = json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0600)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// A repeatable flag given with --config has to come out once, replacing the
+// file's list, and a list the command line leaves alone keeps the file's
+func TestParseConfigRepeatableFlags(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "config.json")
+	var file = `{"sinks": ["csv:file.csv"], "watch_addresses": ["addr-file"], "addr_prefixes": {"p2pkh": ["1"]}}`
+	var err = os.WriteFile(path, []byte(file), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg *config
+	cfg, err = parseConfig([]string{"--config", path, "--sink", "csv:cli.csv", "--lock-utxo", "a:0", "--lock-utxo", "b:1",
+		"--addr-prefix", "p2wpkh=bc1q", "--price-header", "X-Key: k", "--wallet", "w1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var checks = []struct {
+		name      string
+		got, want interface{}
+	}{
+		{"sinks", []string(cfg.Sinks), []string{"csv:cli.csv"}},
+		{"watch_addresses", []string(cfg.WatchAddresses), []string{"addr-file"}},
+		{"lock_utxos", []string(cfg.LockUTXOs), []string{"a:0", "b:1"}},
+		{"addr_prefixes", map[string][]string(cfg.AddrPrefixes), map[string][]string{"p2wpkh": {"bc1q"}}},
+		{"price_headers", cfg.PriceHeaders, map[string]string{"X-Key": "k"}},
+		{"wallets", cfg.Wallets, []string{"w1"}},
+	}
+	for _, c := range checks {
+		if !reflect.DeepEqual(c.got, c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, c.got, c.want)
+		}
+	}
+}
+
+// Nothing a run sets is left for the next one in the process, as there is
+// with replay: not the time zone, the exit codes, the wallet credentials, or
+// --all-time's listing size
+func TestParseConfigFresh(t *testing.T) {
+	var saved = time.Local
+	defer func() { time.Local = saved }()
+
+	var cfg, err = parseConfig([]string{"--timezone", "Asia/Tokyo", "--exit-code-stale=9", "--wallet-auth", "rig1:u:p"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = applyRPCSettings(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listCount = allTimeCount
+	if time.Local.String() != "Asia/Tokyo" || mappedExitCode(exitStale) != 9 || walletAuth["rig1"] == nil {
+		t.Fatalf("first run: zone %s, stale exits %d, rig1 auth %v", time.Local, mappedExitCode(exitStale), walletAuth["rig1"])
+	}
+
+	cfg, err = parseConfig(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = applyRPCSettings(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Local != systemLocal {
+		t.Errorf("zone %s, want the system's", time.Local)
+	}
+	if code := mappedExitCode(exitStale); code != exitStale {
+		t.Errorf("stale exits %d, want %d", code, exitStale)
+	}
+	if len(walletAuth) != 0 {
+		t.Errorf("wallet auth kept: %v", walletAuth)
+	}
+	if listCount != listTransactionsCount {
+		t.Errorf("listtransactions asks for %d, want %d", listCount, listTransactionsCount)
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// inputWeights are the weight units spending one output of each type adds
+// to a transaction, signature included: a P2SH output is taken to be
+// wrapped P2WPKH, what wallets mostly put there.  Anything else is weighed
+// as P2PKH, the heaviest single-key spend, so the fee errs high.
+var inputWeights = map[string]int64{
+	"P2PKH":  592,
+	"P2SH":   364,
+	"P2WPKH": 272,
+	"P2TR":   230,
+}
+
+// The rest of a consolidation's weight: version, locktime, and the input
+// and output counts; the segwit marker and flag when any input has a
+// witness; and the one P2WPKH output everything is paid to
+const (
+	txOverheadWeight    = 40
+	segwitMarkerWeight  = 2
+	p2wpkhOutputWeight  = 124
+	maxStandardTxWeight = 400000
+)
+
+// unspentOutput is the part of a listunspent entry the consolidation needs
+type unspentOutput struct {
+	Amount       float64 `json:"amount"`
+	ScriptPubKey string  `json:"scriptPubKey"`
+	Spendable    bool    `json:"spendable"`
+}
+
+// consolidation is --consolidate: every spendable UTXO the wallet has, as
+// the inputs of one transaction paying a single output
+type consolidation struct {
+	inputs   int
+	skipped  int
+	total    float64
+	types    map[string]int
+	guessed  int
+	weight   int64
+	vsize    int64
+	feeRate  float64
+	fee      float64
+	net      float64
+	tooLarge bool
+}
+
+// simulateConsolidation lists the wallet's UTXOs and works out, from the
+// type of each, the size of a transaction spending them all and its fee at
+// feeRate sat/vB.  Nothing is built, signed, or sent; an output the wallet
+// can't sign for, such as a watch-only one, is left out.
+func simulateConsolidation(wu *url.URL, feeRate float64) (*consolidation, error) {
+	var utxos []unspentOutput
+	var err = rpcCall(wu, "listunspent", nil, &utxos)
+	if err != nil {
+		return nil, err
+	}
+
+	var c = &consolidation{types: make(map[string]int), feeRate: feeRate}
+	var weight, segwit = int64(0), false
+	for _, o := range utxos {
+		if !o.Spendable {
+			c.skipped++
+			continue
+		}
+		var kind = scriptType(strings.ToLower(o.ScriptPubKey))
+		var w, ok = inputWeights[kind]
+		if !ok {
+			w = inputWeights["P2PKH"]
+			c.guessed++
+		}
+		segwit = segwit || ok && kind != "P2PKH"
+		weight += w
+		c.types[kind]++
+		c.inputs++
+		c.total += o.Amount
+	}
+	if c.inputs == 0 {
+		return c, nil
+	}
+
+	weight += txOverheadWeight + p2wpkhOutputWeight + 4*int64(varIntSize(c.inputs)-1)
+	if segwit {
+		weight += segwitMarkerWeight
+	}
+	c.weight = weight
+	c.vsize = (weight + 3) / 4
+	c.tooLarge = weight > maxStandardTxWeight
+	c.fee = math.Ceil(float64(c.vsize)*feeRate) / satoshisPerCoin
+	c.net = c.total - c.fee
+	return c, nil
+}
+
+// varIntSize is the bytes a transaction's input count takes
+func varIntSize(n int) int {
+	switch {
+	case n < 0xfd:
+		return 1
+	case n <= 0xffff:
+		return 3
+	}
+	return 5
+}
+
+func printConsolidation(c *consolidation) {
+	if c.inputs == 0 {
+		fmt.Println("Nothing to consolidate: the wallet has no spendable UTXOs")
+		return
+	}
+	var kinds []string
+	for kind := range c.types {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	var parts []string
+	for _, kind := range kinds {
+		parts = append(parts, fmt.Sprintf("%d %s", c.types[kind], kind))
+	}
+
+	fmt.Printf("UTXOs:       %d (%s)\n", c.inputs, strings.Join(parts, ", "))
+	if c.skipped > 0 {
+		fmt.Printf("Left out:    %d the wallet can't spend\n", c.skipped)
+	}
+	fmt.Printf("Total in:    %14.8f\n", c.total)
+	fmt.Printf("Size:        %d vB (%d WU), estimated, to one P2WPKH output\n", c.vsize, c.weight)
+	fmt.Printf("Fee:         %14.8f at %0.2f sat/vB\n", c.fee, c.feeRate)
+	if c.net > 0 {
+		fmt.Printf("Net:         %14.8f (%0.2f%% lost to the fee)\n", c.net, c.fee/c.total*100)
+	} else {
+		fmt.Println("Net:         nothing; the fee would take it all")
+	}
+	if c.guessed > 0 {
+		fmt.Printf("(%d input(s) of a type whose size isn't known were sized as P2PKH, so the fee may run high)\n", c.guessed)
+	}
+	if c.tooLarge {
+		fmt.Printf("(over the %d WU standard transaction limit, so the node wouldn't relay it; consolidate in batches)\n", maxStandardTxWeight)
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+// readCookieFile reads the credentials bitcoind writes to .cookie in its
+// data directory when no rpcuser is set, as "__cookie__:<token>".  The node
+// makes a new one each time it starts, so it's read on each run rather than
+// saved with --save-config.
+func readCookieFile(path string) (user, password string, err error) {
+	var data []byte
+	data, err = os.ReadFile(path)
+	if err != nil {
+		return "", "", err
+	}
+	var ok bool
+	user, password, ok = strings.Cut(strings.TrimSpace(string(data)), ":")
+	if !ok || user == "" || password == "" {
+		return "", "", errors.New("not in user:password form")
+	}
+	return user, password, nil
+}
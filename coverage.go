@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+)
+
+// walletCoverage is how much of the report window one wallet's data covers,
+// so that a wallet missing part of the window isn't mistaken for one that
+// mined little in it.  A wallet whose fetch failed covers none of it; one
+// whose history was cut off at listtransactions' page size, or that was
+// created inside the window, covers it only from then on.
+type walletCoverage struct {
+	Wallet  string     `json:"wallet"`
+	From    *time.Time `json:"from,omitempty"`
+	Through *time.Time `json:"through,omitempty"`
+	Percent float64    `json:"percent"`
+	Reason  string     `json:"reason,omitempty"`
+}
+
+// coverageTracker notes what limits each wallet's data as the wallets are
+// fetched, before the report window is settled
+type coverageTracker struct {
+	wallets []string
+	failed  map[string]bool
+	from    map[string]time.Time
+	reason  map[string]string
+}
+
+func newCoverageTracker() *coverageTracker {
+	return &coverageTracker{failed: make(map[string]bool), from: make(map[string]time.Time), reason: make(map[string]string)}
+}
+
+func (c *coverageTracker) fail(wallet string) {
+	c.wallets = append(c.wallets, wallet)
+	c.failed[wallet] = true
+}
+
+func (c *coverageTracker) fetched(wallet string) {
+	c.wallets = append(c.wallets, wallet)
+}
+
+// limit notes that the wallet has no data before from; of several limits,
+// the latest wins
+func (c *coverageTracker) limit(wallet string, from time.Time, reason string) {
+	if old, ok := c.from[wallet]; ok && !from.After(old) {
+		return
+	}
+	c.from[wallet], c.reason[wallet] = from, reason
+}
+
+// coverage works out each wallet's share of the window from begin to now
+func (c *coverageTracker) coverage(begin, now time.Time) []walletCoverage {
+	var window = now.Sub(begin)
+	var out = make([]walletCoverage, len(c.wallets))
+	for i, w := range c.wallets {
+		out[i].Wallet = w
+		if c.failed[w] {
+			out[i].Reason = "fetch failed"
+			continue
+		}
+		var from, through = begin, now
+		if limit, ok := c.from[w]; ok && limit.After(begin) {
+			from = limit
+			out[i].Reason = c.reason[w]
+		}
+		if from.After(now) {
+			from = now
+		}
+		out[i].From, out[i].Through = &from, &through
+		out[i].Percent = 100
+		if window > 0 && from.After(begin) {
+			out[i].Percent = 100 * float64(through.Sub(from)) / float64(window)
+		}
+	}
+	return out
+}
+
+// walletBirth asks the node when the wallet was created, which nodes with
+// descriptor wallets give as getwalletinfo's birthtime
+func walletBirth(u *url.URL) (time.Time, bool) {
+	var info struct {
+		BirthTime int64 `json:"birthtime"`
+	}
+	if rpcCall(u, "getwalletinfo", nil, &info) != nil || info.BirthTime <= 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(info.BirthTime, 0), true
+}
+
+// markCoverageGaps flags the days some wallet has no data for, or only part
+// of.  A failed wallet is missing from every day alike, so it doesn't make
+// one day a gap more than another.
+func (v *reportView) markCoverageGaps() {
+	for i := range v.Daily {
+		for _, c := range v.Coverage {
+			if c.From != nil && c.From.After(v.Daily[i].Start) {
+				v.Daily[i].Gap = true
+			}
+		}
+	}
+}
+
+// fullCoverage is whether every wallet covers the whole window
+func (v *reportView) fullCoverage() bool {
+	for _, c := range v.Coverage {
+		if c.Percent < 100 {
+			return false
+		}
+	}
+	return true
+}
+
+func (row reportRow) gapTag() string {
+	if !row.Gap {
+		return ""
+	}
+	return " [partial data]"
+}
+
+func (v *reportView) printCoverage(w io.Writer) {
+	fmt.Fprintln(w, "Data coverage (averages and totals are low by what's missing):")
+	for _, c := range v.Coverage {
+		if c.From == nil {
+			fmt.Fprintf(w, "  %s: 0%% (%s)\n", c.Wallet, c.Reason)
+			continue
+		}
+		if c.Percent < 100 {
+			fmt.Fprintf(w, "  %s: %0.1f%% (from %s; %s)\n", c.Wallet, c.Percent, c.From.Format(dateTimeLayout), c.Reason)
+		}
+	}
+}
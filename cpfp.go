@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// cpfpConfTarget is the confirmation target, in blocks, whose fee estimate a
+// package has to meet before it's left to confirm on its own
+const cpfpConfTarget = 6
+
+// MempoolEntry is the part of getmempoolentry (and the verbose
+// getmempoolancestors and getmempooldescendants) that CPFP analysis and
+// --mempool-entry need.  Older nodes give the fee, modifiedfee, size, and ancestorfees fields;
+// newer ones give vsize and the fees object instead, so both are read.
+type MempoolEntry struct {
+	Size            int64    `json:"size"`
+	VSize           int64    `json:"vsize"`
+	Fee             float64  `json:"fee"`
+	ModifiedFee     float64  `json:"modifiedfee"`
+	AncestorCount   int64    `json:"ancestorcount"`
+	AncestorSize    int64    `json:"ancestorsize"`
+	AncestorFees    int64    `json:"ancestorfees"`
+	DescendantSize  int64    `json:"descendantsize"`
+	DescendantCount int64    `json:"descendantcount"`
+	Time            int64    `json:"time"`
+	Height          int64    `json:"height"`
+	Depends         []string `json:"depends"`
+	Fees            struct {
+		Base     float64 `json:"base"`
+		Modified float64 `json:"modified"`
+	} `json:"fees"`
+}
+
+// vbytes is the entry's virtual size, from whichever field the node gave
+func (e MempoolEntry) vbytes() int64 {
+	if e.VSize > 0 {
+		return e.VSize
+	}
+	return e.Size
+}
+
+// fee is what the miner would actually collect, in coins: the modified fee,
+// which includes any prioritisetransaction bump, from whichever field the
+// node gave
+func (e MempoolEntry) fee() float64 {
+	if e.Fees.Modified != 0 {
+		return e.Fees.Modified
+	}
+	if e.ModifiedFee != 0 {
+		return e.ModifiedFee
+	}
+	if e.Fees.Base != 0 {
+		return e.Fees.Base
+	}
+	return e.Fee
+}
+
+// feeRate is fee coins over vbytes, in sat/vB
+func feeRate(fee float64, vbytes int64) float64 {
+	if vbytes <= 0 {
+		return 0
+	}
+	return float64(toSatoshis(fee)) / float64(vbytes)
+}
+
+// cpfpPackage is an unconfirmed transaction with everything it's tied to in
+// the mempool
+type cpfpPackage struct {
+	txid        string
+	entry       MempoolEntry
+	ancestors   map[string]MempoolEntry
+	descendants map[string]MempoolEntry
+
+	// estimate is the cpfpConfTarget fee estimate in sat/vB, 0 if the node
+	// couldn't give one
+	estimate float64
+}
+
+// fetchCPFPPackage looks up txid and its in-mempool relatives.  A missing
+// fee estimate only means the verdict can't be given.
+func fetchCPFPPackage(u *url.URL, txid string) (*cpfpPackage, error) {
+	var p = &cpfpPackage{txid: txid}
+	var err = rpcCall(nodeURL(u), "getmempoolentry", []interface{}{txid}, &p.entry)
+	if err != nil {
+		return nil, err
+	}
+	err = rpcCall(nodeURL(u), "getmempoolancestors", []interface{}{txid, true}, &p.ancestors)
+	if err != nil {
+		return nil, err
+	}
+	err = rpcCall(nodeURL(u), "getmempooldescendants", []interface{}{txid, true}, &p.descendants)
+	if err != nil {
+		return nil, err
+	}
+
+	var est struct {
+		FeeRate float64 `json:"feerate"`
+	}
+	if rpcCall(nodeURL(u), "estimatesmartfee", []interface{}{cpfpConfTarget}, &est) == nil {
+		p.estimate = satPerVB(est.FeeRate)
+	}
+	return p, nil
+}
+
+// sum totals the fees and vbytes of the transaction and the given relatives
+func (p *cpfpPackage) sum(sets ...map[string]MempoolEntry) (float64, int64) {
+	var fee, size = p.entry.fee(), p.entry.vbytes()
+	for _, set := range sets {
+		for _, e := range set {
+			fee += e.fee()
+			size += e.vbytes()
+		}
+	}
+	return fee, size
+}
+
+// printCPFP shows the transaction's own fee rate beside its ancestor
+// package's and the whole package's.  A miner takes the ancestors along
+// with it, so the ancestor package rate is what it's judged on until a
+// descendant pays enough to pull the lot in; CPFP is called for when even
+// the whole package falls short of the fee estimate.
+func printCPFP(p *cpfpPackage) {
+	var ownRate = feeRate(p.entry.fee(), p.entry.vbytes())
+	var ancFee, ancSize = p.sum(p.ancestors)
+	var allFee, allSize = p.sum(p.ancestors, p.descendants)
+	var ancRate, allRate = feeRate(ancFee, ancSize), feeRate(allFee, allSize)
+
+	fmt.Printf("Transaction:      %s\n", p.txid)
+	fmt.Printf("Own:              %8.2f sat/vB  (%0.8f over %d vB)\n", ownRate, p.entry.fee(), p.entry.vbytes())
+	fmt.Printf("With ancestors:   %8.2f sat/vB  (%d ancestors, %0.8f over %d vB)\n", ancRate, len(p.ancestors), ancFee, ancSize)
+	fmt.Printf("Full package:     %8.2f sat/vB  (%d descendants, %0.8f over %d vB)\n", allRate, len(p.descendants), allFee, allSize)
+	if p.estimate <= 0 {
+		fmt.Printf("CPFP needed:      unknown; the node has no %d-block fee estimate\n", cpfpConfTarget)
+		return
+	}
+	fmt.Printf("%d-block estimate: %8.2f sat/vB\n", cpfpConfTarget, p.estimate)
+
+	var rate = ancRate
+	if allRate > rate {
+		rate = allRate
+	}
+	if rate >= p.estimate {
+		fmt.Println("CPFP needed:      no")
+		return
+	}
+	// A child of size childSize paying childFee brings the package to the
+	// estimate when (allFee + childFee) / (allSize + childSize) = estimate;
+	// a typical one-in one-out child is about 110 vB
+	const childSize = 110
+	var childSats = p.estimate*float64(allSize+childSize) - float64(toSatoshis(allFee))
+	fmt.Printf("CPFP needed:      yes; a %d vB child would need to pay about %d sat (%0.2f sat/vB)\n", childSize, int64(childSats+0.5), childSats/childSize)
+}
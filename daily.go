@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// reportSink is somewhere a finished report can be delivered
+type reportSink interface {
+	name() string
+	deliver(day time.Time, text string) error
+}
+
+// fileSink writes each report to its own dated file in dir
+type fileSink struct {
+	dir string
+}
+
+func (s fileSink) name() string {
+	return "file " + s.dir
+}
+
+func (s fileSink) deliver(day time.Time, text string) error {
+	var path = filepath.Join(s.dir, "report-"+day.Format("2006-01-02")+".txt")
+	return os.WriteFile(path, []byte(text), 0644)
+}
+
+// webhookSink posts the report as JSON.  The text goes in both "text" and
+// "content" so the same URL shape works for Slack and Discord.
+type webhookSink struct {
+	url string
+}
+
+func (s webhookSink) name() string {
+	var u, err = url.Parse(s.url)
+	if err != nil {
+		return "webhook"
+	}
+	return "webhook " + u.Host
+}
+
+func (s webhookSink) deliver(day time.Time, text string) error {
+	return postJSON(s.url, map[string]string{"text": text, "content": text})
+}
+
+// postJSON posts v to target and fails on any non-2xx response
+func postJSON(target string, v interface{}) error {
+	var body, err = json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var req *http.Request
+	req, err = http.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent())
+
+	var r *http.Response
+	r, err = http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	r.Body.Close()
+	if r.StatusCode/100 != 2 {
+		return fmt.Errorf("%s", r.Status)
+	}
+	return nil
+}
+
+func configuredSinks(cfg *config) []reportSink {
+	var sinks []reportSink
+	if cfg.DailyReportDir != "" {
+		sinks = append(sinks, fileSink{dir: cfg.DailyReportDir})
+	}
+	for _, u := range cfg.DailyReportWebhook {
+		sinks = append(sinks, webhookSink{url: u})
+	}
+	return sinks
+}
+
+// parseClock reads an "HH:MM" time of day
+func parseClock(s string) (int, int, error) {
+	var t, err = time.Parse("15:04", s)
+	if err != nil {
+		return 0, 0, fmt.Errorf("expected HH:MM, got %q", s)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// dailyReportDue returns the day the report would cover if one is due at
+// now: yesterday, once today's send time has passed.  The send time is
+// compared on the wall clock rather than made into an instant, as Go
+// doesn't promise which side of a DST gap or which of a repeated hour
+// time.Date picks: a send time in a gap is passed as the clock jumps over
+// it, and one in a repeated hour the first time round.  Either way each day
+// has one send time.
+func dailyReportDue(now time.Time, hour, minute int) (time.Time, bool) {
+	if now.Hour()*60+now.Minute() < hour*60+minute {
+		return time.Time{}, false
+	}
+	return getDay(now).AddDate(0, 0, -1), true
+}
+
+// renderDailyReport renders the summary for the day starting at day
+func renderDailyReport(u *url.URL, cfg *config, wallets []string, day time.Time) (string, error) {
+	var end = day.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	var txList, report, err = collectReport(u, wallets, end, 1, cfg.TimeField)
+	if err != nil {
+		return "", err
+	}
+	var view = newReportView(cfg, wallets, txList, report, end)
+	// a DST day is 23 or 25 hours long
+	var hours = day.AddDate(0, 0, 1).Sub(day).Hours()
+	for i := range view.Daily {
+		view.Daily[i].Projected = nil
+		view.Daily[i].Need = nil
+		view.Daily[i].Rate = view.Daily[i].Coins / hours
+	}
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "Daily summary for %s\n", day.Format(dateLayout))
+	view.printSummary(&buf)
+	view.printDaily(&buf, 0)
+	return buf.String(), nil
+}
+
+// dailyReporter sends the daily report through every sink when it's due.
+// The last day sent is kept in the state file when there is one, so a
+// restart later the same day doesn't send it again.
+type dailyReporter struct {
+	u            *url.URL
+	cfg          *config
+	wallets      []string
+	sinks        []reportSink
+	hour, minute int
+
+	state    *stateFile
+	lastSent string
+}
+
+func newDailyReporter(u *url.URL, cfg *config, wallets []string, hour, minute int) *dailyReporter {
+	var d = &dailyReporter{u: u, cfg: cfg, wallets: wallets, sinks: configuredSinks(cfg), hour: hour, minute: minute}
+	if cfg.StateFile != "" {
+		var err error
+		d.state, err = loadState(cfg.StateFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to read state file %q, daily reports may repeat after a restart: %s\n", cfg.StateFile, err)
+		} else {
+			d.lastSent = d.state.report(wallets).LastDailyReport
+		}
+	}
+	return d
+}
+
+// check sends the report if it's due at now and hasn't been sent yet
+func (d *dailyReporter) check(now time.Time) {
+	var day, due = dailyReportDue(now, d.hour, d.minute)
+	var label = day.Format("2006-01-02")
+	if !due || label <= d.lastSent {
+		return
+	}
+
+	var text, err = renderDailyReport(d.u, d.cfg, d.wallets, day)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: unable to build the daily report for %s, will retry: %s\n", now.Format("2006-01-02 15:04:05"), label, err)
+		return
+	}
+	for _, s := range d.sinks {
+		err = s.deliver(day, text)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: unable to deliver the daily report for %s to %s: %s\n", now.Format("2006-01-02 15:04:05"), label, s.name(), err)
+			serveDailyReportFails.Add(1)
+			continue
+		}
+		serveDailyReports.Add(1)
+	}
+
+	d.lastSent = label
+	if d.state != nil {
+		d.state.report(d.wallets).LastDailyReport = label
+		err = d.state.save(d.cfg.StateFile, now)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to save state file %q: %s\n", d.cfg.StateFile, err)
+		}
+	}
+}
+
+// runDailyReports checks once a minute whether the daily report is due
+func runDailyReports(u *url.URL, cfg *config, wallets []string, hour, minute int) {
+	var d = newDailyReporter(u, cfg, wallets, hour, minute)
+	for now := time.Now(); ; now = <-time.After(time.Minute) {
+		d.check(now)
+	}
+}
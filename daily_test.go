@@ -0,0 +1,109 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useLocal sets time.Local to name for the rest of the test.  Call it
+// before starting any server, so the servers are closed before it's put
+// back, as their goroutines read it.
+func useLocal(t *testing.T, name string) *time.Location {
+	var loc, err = time.LoadLocation(name)
+	if err != nil {
+		t.Skip(err)
+	}
+	var saved = time.Local
+	time.Local = loc
+	t.Cleanup(func() { time.Local = saved })
+	return loc
+}
+
+func TestDailyReportDue(t *testing.T) {
+	var loc = useLocal(t, "America/New_York")
+	var edt, est = time.FixedZone("EDT", -4*3600), time.FixedZone("EST", -5*3600)
+	var tests = []struct {
+		name         string
+		now          time.Time
+		hour, minute int
+		due          bool
+		day          string
+	}{
+		{"before", time.Date(2024, 6, 5, 8, 59, 0, 0, loc), 9, 0, false, ""},
+		{"at", time.Date(2024, 6, 5, 9, 0, 0, 0, loc), 9, 0, true, "2024-06-04"},
+		{"spring forward, before the gap", time.Date(2024, 3, 10, 1, 59, 0, 0, loc), 2, 30, false, ""},
+		{"spring forward, after the gap", time.Date(2024, 3, 10, 7, 0, 0, 0, time.UTC).In(loc), 2, 30, true, "2024-03-09"},
+		{"fall back, first 01:30", time.Date(2024, 11, 3, 1, 30, 0, 0, edt).In(loc), 1, 30, true, "2024-11-02"},
+		{"fall back, second 01:30", time.Date(2024, 11, 3, 1, 30, 0, 0, est).In(loc), 1, 30, true, "2024-11-02"},
+		{"fall back, first 01:29", time.Date(2024, 11, 3, 1, 29, 0, 0, edt).In(loc), 1, 30, false, ""},
+	}
+	for _, tt := range tests {
+		var day, due = dailyReportDue(tt.now, tt.hour, tt.minute)
+		if due != tt.due || (due && day.Format("2006-01-02") != tt.day) {
+			t.Errorf("%s (%s): got %v %s, want %v %s", tt.name, tt.now, due, day.Format("2006-01-02"), tt.due, tt.day)
+		}
+	}
+}
+
+type countingSink struct {
+	sent []string
+}
+
+func (s *countingSink) name() string { return "test" }
+
+func (s *countingSink) deliver(day time.Time, text string) error {
+	s.sent = append(s.sent, day.Format("2006-01-02")+"\n"+text)
+	return nil
+}
+
+// Across a restart and the repeated fall-back hour, the report goes once a
+// day; and the 25-hour day's rate is per hour of it
+func TestDailyReportOnce(t *testing.T) {
+	// parseConfig sets the zone, and useLocal puts it back afterwards
+	useLocal(t, "UTC")
+	var cfg, err = parseConfig([]string{"--timezone", "America/New_York", "--state-file", filepath.Join(t.TempDir(), "state.json")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loc = time.Local
+	var est = time.FixedZone("EST", -5*3600)
+	var day = time.Date(2024, 11, 3, 0, 0, 0, 0, loc)
+	var node = newWalletNode(t, []map[string]interface{}{
+		generation("aa", 800050, 12.5, day.Add(3*time.Hour)),
+		generation("bb", 800060, 12.5, day.Add(20*time.Hour)),
+	})
+	var wallets = []string{"rig1"}
+
+	var sink = &countingSink{}
+	var start = func() *dailyReporter {
+		var d = newDailyReporter(node.url(), cfg, wallets, 1, 30)
+		d.sinks = []reportSink{sink}
+		return d
+	}
+	var d = start()
+	d.check(time.Date(2024, 11, 4, 1, 29, 0, 0, loc))
+	d.check(time.Date(2024, 11, 4, 1, 30, 0, 0, loc))
+	d.check(time.Date(2024, 11, 4, 12, 0, 0, 0, loc))
+	d = start()
+	d.check(time.Date(2024, 11, 4, 13, 0, 0, 0, loc))
+	if len(sink.sent) != 1 || !strings.HasPrefix(sink.sent[0], "2024-11-03\n") {
+		t.Fatalf("sent %d reports, want just the one for 2024-11-03: %q", len(sink.sent), sink.sent)
+	}
+	if !strings.Contains(sink.sent[0], "1.00/h") {
+		t.Errorf("25 coins over the 25-hour day should be 1.00/h:\n%s", sink.sent[0])
+	}
+
+	// On the fall-back day itself the send time comes round twice; the
+	// report goes the first time only
+	sink.sent = nil
+	d = start()
+	d.lastSent = "2024-11-01"
+	d.check(time.Date(2024, 11, 3, 1, 29, 0, 0, loc))
+	d.check(time.Date(2024, 11, 3, 5, 30, 0, 0, time.UTC).In(loc))
+	d.check(time.Date(2024, 11, 3, 1, 30, 0, 0, est).In(loc))
+	if len(sink.sent) != 1 || !strings.HasPrefix(sink.sent[0], "2024-11-02\n") {
+		t.Errorf("across the repeated hour: sent %q", sink.sent)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"expvar"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"time"
+)
+
+// The counters serve keeps for --debug-listen's /debug/vars, besides the
+// RPC totals and cache figures, which are read when asked for
+var (
+	serveRefreshes        = expvar.NewInt("refreshes")
+	serveRefreshFailures  = expvar.NewInt("refresh_failures")
+	serveLastRefresh      = expvar.NewFloat("last_refresh_seconds")
+	serveNotifications    = expvar.NewInt("notifications_sent")
+	serveNotifyFailures   = expvar.NewInt("notification_failures")
+	serveDailyReports     = expvar.NewInt("daily_reports_sent")
+	serveDailyReportFails = expvar.NewInt("daily_report_failures")
+)
+
+// publishServeVars adds the figures that live in rpcStats and the cache
+func publishServeVars(c *txCache) {
+	expvar.Publish("rpc_calls", expvar.Func(func() interface{} { return rpcStats.count() }))
+	expvar.Publish("rpc_seconds", expvar.Func(func() interface{} {
+		rpcStats.mu.Lock()
+		defer rpcStats.mu.Unlock()
+		return rpcStats.elapsed.Seconds()
+	}))
+	expvar.Publish("rpc_bytes", expvar.Func(func() interface{} {
+		rpcStats.mu.Lock()
+		defer rpcStats.mu.Unlock()
+		return rpcStats.bytes
+	}))
+	expvar.Publish("cached_transactions", expvar.Func(func() interface{} {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		return len(c.entries)
+	}))
+	expvar.Publish("reorg_removals", expvar.Func(func() interface{} {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		return c.reorged
+	}))
+}
+
+// checkDebugListen makes sure the debug listener can't end up where the
+// dashboard's public: it has to be a loopback address, and not --listen's
+func checkDebugListen(addr, listen string) error {
+	var host, _, err = net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if host != "localhost" {
+		var ip = net.ParseIP(host)
+		if ip == nil || !ip.IsLoopback() {
+			return fmt.Errorf("%q isn't a loopback address", host)
+		}
+	}
+	if addr == listen {
+		return fmt.Errorf("%s is also --listen", addr)
+	}
+	return nil
+}
+
+// serveDebug answers /debug/vars, and with withPprof /debug/pprof, on addr.
+// It has a mux of its own, so none of it is reachable through --listen.
+func serveDebug(addr string, withPprof bool) {
+	var mux = http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+	if withPprof {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+	fmt.Fprintf(os.Stderr, "Serving debug endpoints on %s\n", addr)
+	var err = http.ListenAndServe(addr, mux)
+	fmt.Fprintf(os.Stderr, "%s: debug endpoints stopped: %s\n", time.Now().Format("2006-01-02 15:04:05"), err)
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Nerdmaster/dynamo-tx-stats/window"
+)
+
+// degradationSpec is --degradation-check: how the recent earnings of each
+// wallet, or each label, are held against their longer run
+type degradationSpec struct {
+	short, long int
+	threshold   float64
+	minBlocks   int
+	byLabel     bool
+}
+
+// parseDegradationSpec reads key=value pairs, such as
+// "short=3d,long=14d,threshold=15%".  Any key left out keeps its default.
+func parseDegradationSpec(s string) (*degradationSpec, error) {
+	var spec = &degradationSpec{short: 3, long: 14, threshold: 15, minBlocks: 3}
+	for _, item := range strings.Split(s, ",") {
+		var key, value, ok = strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			return nil, fmt.Errorf("%q is not key=value", item)
+		}
+		value = strings.TrimSpace(value)
+		var err error
+		switch strings.TrimSpace(key) {
+		case "short":
+			spec.short, err = window.Days(value)
+		case "long":
+			spec.long, err = window.Days(value)
+		case "threshold":
+			spec.threshold, err = strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+			if err == nil && (spec.threshold <= 0 || spec.threshold >= 100) {
+				err = fmt.Errorf("threshold %q must be between 0%% and 100%%", value)
+			}
+		case "min-blocks":
+			spec.minBlocks, err = strconv.Atoi(value)
+			if err == nil && spec.minBlocks < 1 {
+				err = fmt.Errorf("min-blocks %q must be at least 1", value)
+			}
+		case "by":
+			switch value {
+			case "wallet":
+				spec.byLabel = false
+			case "label":
+				spec.byLabel = true
+			default:
+				err = fmt.Errorf("by %q must be wallet or label", value)
+			}
+		default:
+			err = fmt.Errorf("unknown key %q", key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if spec.short < 1 {
+		return nil, errors.New("the short window must be at least a day")
+	}
+	if spec.long <= spec.short {
+		return nil, fmt.Errorf("the long window (%dd) must be longer than the short one (%dd)", spec.long, spec.short)
+	}
+	return spec, nil
+}
+
+// degradationRow is one wallet's or label's short-window daily average set
+// against its long-window one.  A skipped row says why instead.
+type degradationRow struct {
+	Name         string  `json:"name"`
+	ShortBlocks  int     `json:"short_blocks"`
+	LongBlocks   int     `json:"long_blocks"`
+	ShortAverage float64 `json:"short_daily_average"`
+	LongAverage  float64 `json:"long_daily_average"`
+	Change       float64 `json:"change_percent"`
+	Degraded     bool    `json:"degraded"`
+	Skipped      string  `json:"skipped,omitempty"`
+}
+
+type degradationView struct {
+	By        string           `json:"by"`
+	ShortDays int              `json:"short_days"`
+	LongDays  int              `json:"long_days"`
+	Threshold float64          `json:"threshold_percent"`
+	MinBlocks int              `json:"min_blocks"`
+	Rows      []degradationRow `json:"rows"`
+	Degraded  int              `json:"degraded"`
+}
+
+// newDegradationView compares each wallet's, or label's, blocks over the
+// last short days to the last long days, both windows ending now and both
+// counted from the one listing already fetched.  covered is, per wallet,
+// the earliest time its listing is complete from; a wallet whose history
+// doesn't reach back over the long window would look degraded only because
+// its older blocks are missing, so it's skipped, as is any entry with too
+// few blocks in either window for the averages to mean much.
+func newDegradationView(spec *degradationSpec, txList []*Transaction, wallets []string, covered map[string]time.Time, now time.Time) *degradationView {
+	var v = &degradationView{By: "wallet", ShortDays: spec.short, LongDays: spec.long, Threshold: spec.threshold, MinBlocks: spec.minBlocks}
+	var shortStart = now.Add(-time.Duration(spec.short) * window.Day)
+	var longStart = now.Add(-time.Duration(spec.long) * window.Day)
+
+	var key = func(tx *Transaction) string { return tx.wallet }
+	var names = append([]string(nil), wallets...)
+	if spec.byLabel {
+		v.By = "label"
+		key = func(tx *Transaction) string {
+			if tx.Label == "" {
+				return "(unlabeled)"
+			}
+			return tx.Label
+		}
+		names = nil
+	}
+
+	var rows = make(map[string]*degradationRow)
+	var coins = make(map[string][2]float64)
+	for _, name := range names {
+		rows[name] = &degradationRow{Name: name}
+	}
+	for _, tx := range txList {
+		if !countable(tx, now) || tx.dt.Before(longStart) {
+			continue
+		}
+		var name = key(tx)
+		var r = rows[name]
+		if r == nil {
+			r = &degradationRow{Name: name}
+			rows[name] = r
+			names = append(names, name)
+		}
+		var c = coins[name]
+		r.LongBlocks++
+		c[1] += tx.Amount
+		if !tx.dt.Before(shortStart) {
+			r.ShortBlocks++
+			c[0] += tx.Amount
+		}
+		coins[name] = c
+	}
+	if spec.byLabel {
+		sort.Strings(names)
+	}
+
+	// A label's transactions can come from any wallet, so its history is
+	// only as complete as the least complete wallet's
+	var coveredAll time.Time
+	for _, t := range covered {
+		if t.After(coveredAll) {
+			coveredAll = t
+		}
+	}
+
+	for _, name := range names {
+		var r = rows[name]
+		var since = covered[name]
+		if spec.byLabel {
+			since = coveredAll
+		}
+		switch {
+		case since.After(longStart):
+			r.Skipped = fmt.Sprintf("history only reaches back to %s, inside the %dd window", since.Format(dateTimeLayout), spec.long)
+		case r.ShortBlocks < spec.minBlocks:
+			r.Skipped = fmt.Sprintf("%d blocks in the last %dd, fewer than %d", r.ShortBlocks, spec.short, spec.minBlocks)
+		case r.LongBlocks < spec.minBlocks:
+			r.Skipped = fmt.Sprintf("%d blocks in the last %dd, fewer than %d", r.LongBlocks, spec.long, spec.minBlocks)
+		}
+		if r.Skipped == "" {
+			r.ShortAverage = coins[name][0] / float64(spec.short)
+			r.LongAverage = coins[name][1] / float64(spec.long)
+			r.Change = (r.ShortAverage - r.LongAverage) / r.LongAverage * 100
+			r.Degraded = -r.Change >= spec.threshold
+			if r.Degraded {
+				v.Degraded++
+			}
+		}
+		v.Rows = append(v.Rows, *r)
+	}
+	return v
+}
+
+func (v *degradationView) print(w io.Writer) {
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Degradation check: the last %dd against the last %dd, by %s, flagging a drop of %s%% or more\n", v.ShortDays, v.LongDays, v.By, strconv.FormatFloat(v.Threshold, 'f', -1, 64))
+	var cells [][]string
+	var skipped []string
+	for _, r := range v.Rows {
+		if r.Skipped != "" {
+			skipped = append(skipped, fmt.Sprintf("%s skipped: %s", r.Name, r.Skipped))
+			continue
+		}
+		var alert = "-"
+		if r.Degraded {
+			alert = "DEGRADED"
+		}
+		cells = append(cells, []string{r.Name, fmt.Sprintf("%d", r.ShortBlocks), fmt.Sprintf("%0.2f", r.ShortAverage), fmt.Sprintf("%d", r.LongBlocks), fmt.Sprintf("%0.2f", r.LongAverage), fmt.Sprintf("%+0.1f%%", r.Change), alert})
+	}
+	if len(cells) > 0 {
+		printTable(w, []string{v.By, fmt.Sprintf("%dd blocks", v.ShortDays), fmt.Sprintf("%dd avg/day", v.ShortDays), fmt.Sprintf("%dd blocks", v.LongDays), fmt.Sprintf("%dd avg/day", v.LongDays), "change", "alert"}, cells)
+	}
+	for _, s := range skipped {
+		fmt.Fprintf(w, "Note: %s\n", s)
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+type txDiffKind int
+
+const (
+	diffOnlyA txDiffKind = iota
+	diffOnlyB
+	diffAmount
+	diffCategory
+	diffConfirmations
+)
+
+var txDiffKindNames = []string{"only-a", "only-b", "amount", "category", "confirmations"}
+
+func (k txDiffKind) String() string {
+	return txDiffKindNames[k]
+}
+
+type txDiff struct {
+	kind   txDiffKind
+	key    string
+	wallet string
+	a, b   *Transaction
+}
+
+// when returns the time the differing transaction was received, from
+// whichever side has it
+func (d txDiff) when() time.Time {
+	if d.a != nil {
+		return time.Unix(d.a.TimeReceived, 0)
+	}
+	return time.Unix(d.b.TimeReceived, 0)
+}
+
+func (d txDiff) describe() string {
+	switch d.kind {
+	case diffOnlyA:
+		return fmt.Sprintf("%s %s only in A (%0.8f %s)", d.when().Format(dateTimeLayout), d.key, d.a.Amount, d.a.Category)
+	case diffOnlyB:
+		return fmt.Sprintf("%s %s only in B (%0.8f %s)", d.when().Format(dateTimeLayout), d.key, d.b.Amount, d.b.Category)
+	case diffAmount:
+		return fmt.Sprintf("%s %s amount %0.8f vs %0.8f", d.when().Format(dateTimeLayout), d.key, d.a.Amount, d.b.Amount)
+	case diffCategory:
+		return fmt.Sprintf("%s %s category %s vs %s", d.when().Format(dateTimeLayout), d.key, d.a.Category, d.b.Category)
+	}
+	return fmt.Sprintf("%s %s confirmations %d vs %d", d.when().Format(dateTimeLayout), d.key, d.a.Confirmations, d.b.Confirmations)
+}
+
+// txDiffKey identifies a wallet entry across two listings.  A self-send
+// shows up as both a send and a receive of the same output, so the
+// direction is part of the key; a category change in the same direction
+// (generate turning orphan, say) is then reported as a change rather than
+// a removal plus an addition.
+func txDiffKey(tx *Transaction) string {
+	var dir = "in"
+	if tx.Category == "send" {
+		dir = "out"
+	}
+	return fmt.Sprintf("%s:%d:%s", tx.TXID, tx.Vout, dir)
+}
+
+func indexTransactions(list []*Transaction) map[string]*Transaction {
+	var m = make(map[string]*Transaction, len(list))
+	for _, tx := range list {
+		m[txDiffKey(tx)] = tx
+	}
+	return m
+}
+
+// diffTransactions compares two listings of the same wallet.  A single entry
+// can differ in several ways at once; each is reported separately.  The
+// result is ordered by time received.
+func diffTransactions(a, b []*Transaction) []txDiff {
+	var ma, mb = indexTransactions(a), indexTransactions(b)
+	var diffs []txDiff
+
+	for key, ta := range ma {
+		var tb, ok = mb[key]
+		if !ok {
+			diffs = append(diffs, txDiff{kind: diffOnlyA, key: key, a: ta})
+			continue
+		}
+		if ta.Amount != tb.Amount {
+			diffs = append(diffs, txDiff{kind: diffAmount, key: key, a: ta, b: tb})
+		}
+		if ta.Category != tb.Category {
+			diffs = append(diffs, txDiff{kind: diffCategory, key: key, a: ta, b: tb})
+		}
+		if ta.Confirmations != tb.Confirmations {
+			diffs = append(diffs, txDiff{kind: diffConfirmations, key: key, a: ta, b: tb})
+		}
+	}
+	for key, tb := range mb {
+		if _, ok := ma[key]; !ok {
+			diffs = append(diffs, txDiff{kind: diffOnlyB, key: key, b: tb})
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		var ti, tj = diffs[i].when(), diffs[j].when()
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		if diffs[i].key != diffs[j].key {
+			return diffs[i].key < diffs[j].key
+		}
+		return diffs[i].kind < diffs[j].kind
+	})
+	return diffs
+}
+
+// printDiffSummary prints a per-day count of each kind of difference
+func printDiffSummary(diffs []txDiff) {
+	var days []string
+	var counts = make(map[string][]int)
+	for _, d := range diffs {
+		var day = d.when().Format(dateLayout)
+		if counts[day] == nil {
+			counts[day] = make([]int, len(txDiffKindNames))
+			days = append(days, day)
+		}
+		counts[day][d.kind]++
+	}
+
+	for _, day := range days {
+		fmt.Printf("%s:", day)
+		for kind, n := range counts[day] {
+			fmt.Printf("\t%s %d", txDiffKind(kind), n)
+		}
+		fmt.Println()
+	}
+}
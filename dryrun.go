@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+func printPlannedCall(u *url.URL, method string, params []interface{}) {
+	var safe = *u
+	safe.User = nil
+	var p, _ = json.Marshal(redactParams(method, params))
+	var as string
+	if u.User != nil {
+		for w, auth := range walletAuth {
+			if auth == u.User && u.Path == walletPath(w) {
+				as = "  (as " + auth.Username() + ")"
+			}
+		}
+	}
+	fmt.Printf("RPC   %s  %s %s%s\n", safe.String(), method, p, as)
+}
+
+func printPlannedOutputs(cfg *config) {
+	if cfg.saveConfig != "" {
+		fmt.Printf("WRITE %s (config)\n", cfg.saveConfig)
+	}
+	if cfg.StateFile != "" {
+		fmt.Printf("WRITE %s (state)\n", cfg.StateFile)
+	}
+	if cfg.TxGraph && cfg.Output != "" {
+		fmt.Printf("WRITE %s (transaction graph)\n", cfg.Output)
+	}
+	if cfg.ESURL != "" {
+		fmt.Printf("WRITE %s/_bulk (Elasticsearch index %s)\n", strings.TrimSuffix(cfg.ESURL, "/"), cfg.ESIndex)
+	}
+	if len(cfg.Sinks) == 0 {
+		fmt.Println("WRITE stdout (report)")
+		if cfg.Textfile != "" {
+			fmt.Printf("WRITE %s (metrics textfile)\n", cfg.Textfile)
+		}
+		return
+	}
+	var specs, _ = outputSpecs(cfg)
+	for _, s := range specs {
+		fmt.Printf("WRITE %s (report)\n", s)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// plainSeconds, when set by --seconds, has formatAge give whole seconds for
+// scripts to read rather than a human-friendly age
+var plainSeconds bool
+
+// formatAge renders an age or gap as its two largest units, "3h 42m" or
+// "2d 5h", dropping a second unit that's zero and truncating rather than
+// rounding, so 59m59s isn't called an hour.  Anything under a minute is in
+// seconds.  A negative age, which is clock skew between here and the node,
+// keeps its sign rather than being passed off as zero.
+func formatAge(d time.Duration) string {
+	if plainSeconds {
+		return strconv.FormatInt(int64(d/time.Second), 10)
+	}
+	if d < 0 {
+		return "-" + formatAge(-d)
+	}
+	var secs = int64(d / time.Second)
+	var units = []struct {
+		size   int64
+		suffix string
+	}{{86400, "d"}, {3600, "h"}, {60, "m"}, {1, "s"}}
+	for i, u := range units[:len(units)-1] {
+		if secs < u.size {
+			continue
+		}
+		var next = units[i+1]
+		var major, minor = secs / u.size, secs % u.size / next.size
+		if minor == 0 {
+			return fmt.Sprintf("%d%s", major, u.suffix)
+		}
+		return fmt.Sprintf("%d%s %d%s", major, u.suffix, minor, next.suffix)
+	}
+	return fmt.Sprintf("%ds", secs)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// These strings end up in alerts, so each magnitude is pinned
+func TestFormatAge(t *testing.T) {
+	var tests = []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0s"},
+		{999 * time.Millisecond, "0s"},
+		{45 * time.Second, "45s"},
+		{time.Minute, "1m"},
+		{90 * time.Second, "1m 30s"},
+		{59*time.Minute + 59*time.Second, "59m 59s"},
+		{time.Hour, "1h"},
+		{3*time.Hour + 42*time.Minute + 30*time.Second, "3h 42m"},
+		{24 * time.Hour, "1d"},
+		{2*24*time.Hour + 5*time.Hour + 59*time.Minute, "2d 5h"},
+		{400 * 24 * time.Hour, "400d"},
+		{-90 * time.Second, "-1m 30s"},
+		{-5 * time.Second, "-5s"},
+	}
+	for _, tt := range tests {
+		if got := formatAge(tt.d); got != tt.want {
+			t.Errorf("formatAge(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+
+	plainSeconds = true
+	defer func() { plainSeconds = false }()
+	if got := formatAge(3*time.Hour + 42*time.Minute); got != "13320" {
+		t.Errorf("--seconds: got %q, want 13320", got)
+	}
+	if got := formatAge(-5 * time.Second); got != "-5" {
+		t.Errorf("--seconds, negative: got %q, want -5", got)
+	}
+}
@@ -0,0 +1,250 @@
+// Package dynstats fetches wallet transactions from a node's JSON-RPC
+// interface, for programs that want txstats' data without running the
+// binary and scraping its output.
+//
+//	var client, err = dynstats.NewClient("http://127.0.0.1:8332", dynstats.WithAuth("user", "pass"))
+//	if err != nil {
+//		return err
+//	}
+//	var txs []dynstats.Transaction
+//	txs, err = client.ListTransactions(ctx, "rig1", dynstats.Since(time.Now().AddDate(0, 0, -7)))
+//
+// The module is versioned with git tags, following semantic versioning:
+// nothing exported here is removed or changes meaning without a new major
+// version.
+package dynstats
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Nerdmaster/dynamo-tx-stats/internal/paging"
+)
+
+// ErrHistoryChanged is returned, wrapped, alongside the transactions read
+// when the wallet's history moved by more than a page while it was paged
+// through, so some transactions may be missing.  Listing again usually
+// fills them in.
+var ErrHistoryChanged = errors.New("the wallet's history changed while it was paged through")
+
+// DefaultPageSize is how many transactions each listtransactions call asks
+// for unless WithPageSize says otherwise
+const DefaultPageSize = 1000
+
+// Client talks to one node.  It's safe for concurrent use.
+type Client struct {
+	url      *url.URL
+	http     *http.Client
+	pageSize int
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithAuth sets the RPC username and password, replacing any given in the URL
+func WithAuth(user, password string) Option {
+	return func(c *Client) {
+		c.url.User = url.UserPassword(user, password)
+	}
+}
+
+// WithTLSConfig sets the TLS configuration used for https URLs, e.g. to
+// trust a node's self-signed certificate
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(c *Client) {
+		c.http.Transport = &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: cfg}
+	}
+}
+
+// WithTimeout limits how long each RPC call may take.  A context deadline
+// applies as well, whichever is sooner.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.http.Timeout = d
+	}
+}
+
+// WithHTTPClient replaces the HTTP client outright.  Options applied after
+// it, such as WithTimeout, modify the given client.
+func WithHTTPClient(h *http.Client) Option {
+	return func(c *Client) {
+		c.http = h
+	}
+}
+
+// WithPageSize sets how many transactions are fetched per listtransactions
+// call
+func WithPageSize(n int) Option {
+	return func(c *Client) {
+		if n > 0 {
+			c.pageSize = n
+		}
+	}
+}
+
+// NewClient returns a Client for the node at rawURL, such as
+// "http://127.0.0.1:8332".  Credentials may be given in the URL or with
+// WithAuth.  A path in the URL, as for a node behind a reverse proxy, is
+// kept in front of the node and wallet endpoints.
+func NewClient(rawURL string, opts ...Option) (*Client, error) {
+	var u, err = url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid URL %q: scheme must be http or https", rawURL)
+	}
+	var c = &Client{url: u, http: &http.Client{}, pageSize: DefaultPageSize}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// RPCError is the error object a node returns in place of a result
+type RPCError struct {
+	Method  string
+	Code    int
+	Message string
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("%s: %s (code %d)", e.Method, e.Message, e.Code)
+}
+
+// call runs one JSON-RPC method against path ("/" for the node, or a
+// wallet's endpoint) and decodes its result into result
+func (c *Client) call(ctx context.Context, path, method string, params []interface{}, result interface{}) error {
+	var body, err = json.Marshal(map[string]interface{}{"jsonrpc": "1.0", "id": "dynstats", "method": method, "params": params})
+	if err != nil {
+		return err
+	}
+	var u = *c.url
+	u.Path = strings.TrimSuffix(c.url.Path, "/") + path
+
+	var req *http.Request
+	req, err = http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain")
+
+	var r *http.Response
+	r, err = c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+	var data []byte
+	data, err = io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	err = json.Unmarshal(data, &resp)
+	if err != nil && r.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: HTTP %s", method, r.Status)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	if resp.Error != nil {
+		return &RPCError{Method: method, Code: resp.Error.Code, Message: resp.Error.Message}
+	}
+	if result == nil || len(resp.Result) == 0 {
+		return nil
+	}
+	err = json.Unmarshal(resp.Result, result)
+	if err != nil {
+		return fmt.Errorf("%s: unexpected result: %w", method, err)
+	}
+	return nil
+}
+
+// ListOption narrows what ListTransactions returns
+type ListOption func(*listOptions)
+
+type listOptions struct {
+	since     time.Time
+	watchOnly bool
+}
+
+// Since only returns transactions received at or after t, and stops paging
+// once the node's history goes back past it
+func Since(t time.Time) ListOption {
+	return func(o *listOptions) {
+		o.since = t
+	}
+}
+
+// IncludeWatchOnly also returns transactions to the wallet's watch-only
+// addresses
+func IncludeWatchOnly() ListOption {
+	return func(o *listOptions) {
+		o.watchOnly = true
+	}
+}
+
+// ListTransactions returns the wallet's transactions, oldest first, paging
+// through listtransactions until the history runs out or goes back past
+// Since.  Transactions that arrive or are reorged away between pages are
+// neither listed twice nor skipped, unless more than a page of them did; then
+// what was read is returned with an error wrapping ErrHistoryChanged.
+func (c *Client) ListTransactions(ctx context.Context, wallet string, opts ...ListOption) ([]Transaction, error) {
+	var o listOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var shifted = -1
+	var p = paging.Pager[rawTransaction]{
+		Fetch: func(skip, count int) ([]rawTransaction, error) {
+			var page []rawTransaction
+			var err = c.call(ctx, "/wallet/"+url.PathEscape(wallet), "listtransactions", []interface{}{"*", count, skip, o.watchOnly}, &page)
+			return page, err
+		},
+		Key: func(r rawTransaction) string {
+			return fmt.Sprintf("%s:%d:%s", r.TXID, r.Vout, r.Category)
+		},
+		Older: func(r rawTransaction) bool {
+			return time.Unix(r.TimeReceived, 0).Before(o.since)
+		},
+		PageSize: c.pageSize,
+		Shifted: func(skip int) {
+			if shifted < 0 {
+				shifted = skip
+			}
+		},
+	}
+	var raw, err = p.All()
+	if err != nil {
+		return nil, err
+	}
+
+	var out = make([]Transaction, len(raw))
+	for i, r := range raw {
+		out[i] = r.transaction()
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].TimeReceived.Before(out[j].TimeReceived) })
+	if shifted >= 0 {
+		return out, fmt.Errorf("listtransactions around %d back: %w", shifted, ErrHistoryChanged)
+	}
+	return out, nil
+}
@@ -0,0 +1,89 @@
+package dynstats_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/Nerdmaster/dynamo-tx-stats/dynstats"
+)
+
+// fakeNode answers listtransactions for wallet "rig1" from history, newest
+// last, the way a node pages it: count entries, skip back from the newest
+func fakeNode(history []map[string]interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if r.URL.Path != "/wallet/rig1" || req.Method != "listtransactions" {
+			json.NewEncoder(w).Encode(map[string]interface{}{"error": map[string]interface{}{"code": -18, "message": "Requested wallet does not exist or is not loaded"}})
+			return
+		}
+		var count, skip = int(req.Params[1].(float64)), int(req.Params[2].(float64))
+		var end = len(history) - skip
+		if end < 0 {
+			end = 0
+		}
+		var start = end - count
+		if start < 0 {
+			start = 0
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": history[start:end]})
+	}))
+}
+
+func reward(txid string, t int64) map[string]interface{} {
+	return map[string]interface{}{"txid": txid, "category": "generate", "generated": true, "amount": 5, "confirmations": 120, "time": t, "timereceived": t}
+}
+
+func ExampleNewClient() {
+	var client, err = dynstats.NewClient("http://127.0.0.1:8332", dynstats.WithAuth("user", "pass"), dynstats.WithTimeout(30*time.Second))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(client != nil)
+
+	_, err = dynstats.NewClient("ftp://127.0.0.1:8332")
+	fmt.Println(err)
+	// Output:
+	// true
+	// invalid URL "ftp://127.0.0.1:8332": scheme must be http or https
+}
+
+func ExampleClient_ListTransactions() {
+	var day = time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC).Unix()
+	var node = fakeNode([]map[string]interface{}{
+		reward("aa01", day),
+		reward("aa02", day+86400),
+		reward("aa03", day+2*86400),
+		reward("aa04", day+3*86400),
+		reward("aa05", day+4*86400),
+	})
+	defer node.Close()
+
+	var client, err = dynstats.NewClient(node.URL, dynstats.WithPageSize(2))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	var txs []dynstats.Transaction
+	txs, err = client.ListTransactions(context.Background(), "rig1", dynstats.Since(time.Unix(day+86400, 0)))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, tx := range txs {
+		fmt.Println(tx.TXID, tx.TimeReceived.UTC().Format("2006-01-02"), tx.Amount, tx.Mature())
+	}
+	// Output:
+	// aa02 2026-10-02 5 true
+	// aa03 2026-10-03 5 true
+	// aa04 2026-10-04 5 true
+	// aa05 2026-10-05 5 true
+}
@@ -0,0 +1,77 @@
+package dynstats
+
+import (
+	"time"
+)
+
+// Transaction is one entry of a wallet's history.  A transaction paying
+// several of the wallet's outputs is listed once per output, told apart by
+// Vout.
+type Transaction struct {
+	TXID          string
+	Vout          int64
+	Address       string
+	Category      string
+	Label         string
+	Amount        float64
+	Fee           float64
+	Confirmations int64
+
+	// Generated is set for coinbase outputs: the block rewards txstats counts
+	Generated bool
+
+	// BlockHash, BlockHeight, and BlockTime are empty until it's mined
+	BlockHash   string
+	BlockHeight int64
+	BlockTime   time.Time
+
+	Time         time.Time
+	TimeReceived time.Time
+}
+
+// Mature reports whether the transaction is a block reward that txstats
+// would count: generated, and buried deeply enough not to be reorged away
+// by a single stale block
+func (tx Transaction) Mature() bool {
+	return tx.Generated && tx.Confirmations >= 2
+}
+
+// rawTransaction is listtransactions' JSON form
+type rawTransaction struct {
+	TXID          string  `json:"txid"`
+	Vout          int64   `json:"vout"`
+	Address       string  `json:"address"`
+	Category      string  `json:"category"`
+	Label         string  `json:"label"`
+	Amount        float64 `json:"amount"`
+	Fee           float64 `json:"fee"`
+	Confirmations int64   `json:"confirmations"`
+	Generated     bool    `json:"generated"`
+	Blockhash     string  `json:"blockhash"`
+	Blockheight   int64   `json:"blockheight"`
+	Blocktime     int64   `json:"blocktime"`
+	Time          int64   `json:"time"`
+	TimeReceived  int64   `json:"timereceived"`
+}
+
+func (r rawTransaction) transaction() Transaction {
+	var tx = Transaction{
+		TXID:          r.TXID,
+		Vout:          r.Vout,
+		Address:       r.Address,
+		Category:      r.Category,
+		Label:         r.Label,
+		Amount:        r.Amount,
+		Fee:           r.Fee,
+		Confirmations: r.Confirmations,
+		Generated:     r.Generated,
+		BlockHash:     r.Blockhash,
+		BlockHeight:   r.Blockheight,
+		Time:          time.Unix(r.Time, 0),
+		TimeReceived:  time.Unix(r.TimeReceived, 0),
+	}
+	if r.Blocktime != 0 {
+		tx.BlockTime = time.Unix(r.Blocktime, 0)
+	}
+	return tx
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// edgeTXIDLength is how much of each txid the --first-n/--last-n table shows
+const edgeTXIDLength = 16
+
+// printEdgeTransactions prints the oldest first transactions and the newest
+// last, by time received.  If the two overlap, each transaction is printed once.
+func printEdgeTransactions(w io.Writer, txList []*Transaction, first, last int) {
+	var sorted = append([]*Transaction(nil), txList...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].TimeReceived < sorted[j].TimeReceived })
+
+	var rows = func(title string, list []*Transaction) {
+		fmt.Fprintln(w, title)
+		fmt.Fprintf(w, "%-19s  %-*s  %14s  %-9s  %s\n", "datetime", edgeTXIDLength, "txid", "amount", "category", "confirmations")
+		for _, tx := range list {
+			var id = tx.TXID
+			if len(id) > edgeTXIDLength {
+				id = id[:edgeTXIDLength]
+			}
+			var watch string
+			if tx.IsWatchonly {
+				watch = "  (watch-only)"
+			}
+			fmt.Fprintf(w, "%s  %-*s  %14.8f  %-9s  %d%s\n", time.Unix(tx.TimeReceived, 0).Format(dateTimeLayout), edgeTXIDLength, id, tx.Amount, tx.Category, tx.Confirmations, watch)
+		}
+	}
+
+	if first > 0 && last > 0 && first+last >= len(sorted) {
+		rows(fmt.Sprintf("All %d transactions:", len(sorted)), sorted)
+		return
+	}
+	if first > 0 {
+		if first > len(sorted) {
+			first = len(sorted)
+		}
+		rows(fmt.Sprintf("First %d transactions:", first), sorted[:first])
+	}
+	if last > 0 {
+		if last > len(sorted) {
+			last = len(sorted)
+		}
+		if first > 0 {
+			fmt.Fprintln(w)
+		}
+		rows(fmt.Sprintf("Last %d transactions:", last), sorted[len(sorted)-last:])
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// esBatchSize is how many documents go in each _bulk request
+const esBatchSize = 500
+
+// ESBulkWriter indexes documents into an Elasticsearch or OpenSearch index
+// through the _bulk API, a batch at a time.  Each document is indexed under
+// an ID of its own choosing, so indexing the same report again replaces
+// its documents rather than adding copies.
+type ESBulkWriter struct {
+	URL    string
+	Index  string
+	APIKey string
+
+	buf     bytes.Buffer
+	pending int
+	Indexed int
+}
+
+// Add queues doc to be indexed as id, sending the batch once it's full
+func (w *ESBulkWriter) Add(id string, doc interface{}) error {
+	var action = map[string]map[string]string{"index": {"_index": w.Index, "_id": id}}
+	var enc = json.NewEncoder(&w.buf)
+	var err = enc.Encode(action)
+	if err == nil {
+		err = enc.Encode(doc)
+	}
+	if err != nil {
+		return err
+	}
+	w.pending++
+	if w.pending >= esBatchSize {
+		return w.Flush()
+	}
+	return nil
+}
+
+// esBulkResponse is the part of a _bulk reply that says what went wrong.
+// The request as a whole succeeds even when documents in it are rejected,
+// so each item has to be looked at.
+type esBulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		ID     string `json:"_id"`
+		Status int    `json:"status"`
+		Error  *struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+// Flush sends whatever is queued
+func (w *ESBulkWriter) Flush() error {
+	if w.pending == 0 {
+		return nil
+	}
+	var req, err = http.NewRequest(http.MethodPost, strings.TrimSuffix(w.URL, "/")+"/_bulk", &w.buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("User-Agent", userAgent())
+	if w.APIKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+w.APIKey)
+	}
+
+	var r *http.Response
+	r, err = http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+	if r.StatusCode/100 != 2 {
+		return fmt.Errorf("%s", r.Status)
+	}
+	var resp esBulkResponse
+	err = json.NewDecoder(r.Body).Decode(&resp)
+	if err != nil {
+		return fmt.Errorf("unexpected _bulk reply: %w", err)
+	}
+
+	var sent = w.pending
+	w.buf.Reset()
+	w.pending = 0
+	var rejected int
+	var first string
+	for _, item := range resp.Items {
+		for _, result := range item {
+			if result.Error == nil {
+				continue
+			}
+			if rejected == 0 {
+				first = fmt.Sprintf("%s: %s: %s", result.ID, result.Error.Type, result.Error.Reason)
+			}
+			rejected++
+		}
+	}
+	w.Indexed += sent - rejected
+	if resp.Errors || rejected > 0 {
+		return fmt.Errorf("%d of %d documents rejected, the first %s", rejected, sent, first)
+	}
+	return nil
+}
+
+// esTransaction is a transaction's document.  @timestamp is the time the
+// report files it under, per --time-field, for Kibana's time filter.
+type esTransaction struct {
+	Timestamp     string  `json:"@timestamp"`
+	Type          string  `json:"type"`
+	TXID          string  `json:"txid"`
+	Vout          int64   `json:"vout"`
+	Wallet        string  `json:"wallet"`
+	Category      string  `json:"category"`
+	Address       string  `json:"address,omitempty"`
+	Label         string  `json:"label,omitempty"`
+	Amount        float64 `json:"amount"`
+	Fee           float64 `json:"fee,omitempty"`
+	Confirmations int64   `json:"confirmations"`
+	Blockheight   int64   `json:"blockheight,omitempty"`
+	Blockhash     string  `json:"blockhash,omitempty"`
+	Counted       bool    `json:"counted"`
+}
+
+// esBucket is a daily (or --bucket) row's document
+type esBucket struct {
+	Timestamp  string   `json:"@timestamp"`
+	Type       string   `json:"type"`
+	Size       string   `json:"size"`
+	Wallets    []string `json:"wallets"`
+	Label      string   `json:"label"`
+	Coins      float64  `json:"coins"`
+	Rate       float64  `json:"rate"`
+	Blocks     int64    `json:"blocks"`
+	WinPercent float64  `json:"win_percent"`
+	Projected  *float64 `json:"projected,omitempty"`
+}
+
+// indexReport bulk-indexes the window's transactions and the report's rows.
+// A transaction is indexed as txid:vout, since a transaction paying the
+// wallet more than once is listed once per output; a row by its size, the
+// wallets it covers, and its start, so re-runs replace it as it fills in.
+func indexReport(w *ESBulkWriter, txList []*Transaction, v *reportView, begin, now time.Time) error {
+	for _, tx := range txList {
+		if tx.dt.Before(begin) || tx.dt.After(now) {
+			continue
+		}
+		var err = w.Add(fmt.Sprintf("%s:%d", tx.TXID, tx.Vout), esTransaction{
+			Timestamp:     tx.dt.Format(time.RFC3339),
+			Type:          "transaction",
+			TXID:          tx.TXID,
+			Vout:          tx.Vout,
+			Wallet:        tx.wallet,
+			Category:      tx.Category,
+			Address:       tx.Address,
+			Label:         tx.Label,
+			Amount:        tx.Amount,
+			Fee:           tx.Fee,
+			Confirmations: tx.Confirmations,
+			Blockheight:   tx.Blockheight,
+			Blockhash:     tx.Blockhash,
+			Counted:       countable(tx, now),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	var rows, size = v.Daily, "1d"
+	if len(v.Buckets) > 0 {
+		rows, size = v.Buckets, v.BucketSize
+	}
+	var wallets = strings.Join(v.Wallets, ",")
+	for _, row := range rows {
+		var start = row.Start.Format(time.RFC3339)
+		var err = w.Add("bucket:"+size+":"+wallets+":"+start, esBucket{
+			Timestamp:  start,
+			Type:       "bucket",
+			Size:       size,
+			Wallets:    v.Wallets,
+			Label:      row.Label,
+			Coins:      row.Coins,
+			Rate:       row.Rate,
+			Blocks:     row.Blocks,
+			WinPercent: row.WinPercent,
+			Projected:  row.Projected,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
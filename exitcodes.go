@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Exit codes are part of the tool's interface: scripts retry on some and
+// page on others, so a code's meaning never changes once it's listed here.
+// The --exit-code-* flags can renumber them for one run; these constants
+// are the conditions, and exitCode maps them to what the process returns.
+const (
+	exitOK        = 0
+	exitUsage     = 1
+	exitRPC       = 2
+	exitStale     = 3
+	exitAssertion = 4
+	exitPartial   = 5
+	exitDegraded  = 6
+)
+
+var exitCodeHelp = []struct {
+	code int
+	name string
+	text string
+}{
+	{exitOK, "ok", "success"},
+	{exitUsage, "usage", "usage error: bad flags, arguments, or config; retrying won't help"},
+	{exitRPC, "error", "RPC or output failure: the node couldn't be reached or refused every request, or the report couldn't be written"},
+	{exitStale, "stale", "stale-block alert: the wallets haven't won a block within --stale-after"},
+	{exitAssertion, "assertion", "assertion failure: a check of the data didn't hold (e.g. compare-nodes found differences)"},
+	{exitPartial, "partial", "partial data: some wallets or calls failed, and the report covers the rest"},
+	{exitDegraded, "degraded", "degradation: --degradation-check found a wallet or label earning less of late than over the longer window"},
+}
+
+// exitCodeMap renumbers conditions for --exit-code-*; a condition that
+// isn't in it exits with its own code
+var exitCodeMap = make(map[int]int)
+
+// exitZero is --exit-zero: report failures, but always exit 0
+var exitZero bool
+
+func mappedExitCode(condition int) int {
+	if code, ok := exitCodeMap[condition]; ok {
+		return code
+	}
+	return condition
+}
+
+// setExitCodes applies the --exit-code-* flags and writes --exit-codes-file
+func setExitCodes(cfg *config) error {
+	exitCodeMap = make(map[int]int)
+	var overrides = map[int]int{
+		exitUsage:     cfg.ExitCodeUsage,
+		exitRPC:       cfg.ExitCodeError,
+		exitStale:     cfg.ExitCodeStale,
+		exitAssertion: cfg.ExitCodeAssertion,
+		exitDegraded:  cfg.ExitCodeDegraded,
+		exitPartial:   cfg.ExitCodePartial,
+	}
+	for _, c := range exitCodeHelp {
+		var code, ok = overrides[c.code]
+		if !ok {
+			continue
+		}
+		if code < 0 || code > 125 {
+			return usageError(fmt.Sprintf("Invalid --exit-code-%s %d: must be 0 to 125", c.name, code))
+		}
+		exitCodeMap[c.code] = code
+	}
+	exitZero = cfg.ExitZero
+
+	if cfg.ExitCodesFile == "" {
+		return nil
+	}
+	var codes = make(map[string]int)
+	for _, c := range exitCodeHelp {
+		codes[c.name] = mappedExitCode(c.code)
+		if exitZero {
+			codes[c.name] = exitOK
+		}
+	}
+	var data, err = json.MarshalIndent(codes, "", "  ")
+	if err == nil {
+		err = os.WriteFile(cfg.ExitCodesFile, append(data, '\n'), 0644)
+	}
+	if err != nil {
+		return failure(exitUsage, "Unable to write exit codes file %q: %s", cfg.ExitCodesFile, err)
+	}
+	return nil
+}
+
+func printExitCodes(w io.Writer) {
+	fmt.Fprintln(w, "Exit codes:")
+	for _, c := range exitCodeHelp {
+		fmt.Fprintf(w, "  %d  %s\n", mappedExitCode(c.code), c.text)
+	}
+	fmt.Fprintln(w, "  (--health-check instead exits 0, 1, or 2 for pass, warn, or fail)")
+	fmt.Fprintln(w, "  (--exit-code-usage, -error, -stale, -assertion, -partial, and -degraded renumber these; --exit-zero makes every exit 0)")
+}
+
+// exitError carries the exit code a failure maps to.  An empty message means
+// whatever needed saying has already been printed.
+type exitError struct {
+	code      int
+	message   string
+	showUsage bool
+
+	// literal codes, such as --health-check's, aren't renumbered
+	literal bool
+}
+
+func (e *exitError) Error() string {
+	return e.message
+}
+
+func usageError(message string) error {
+	return &exitError{code: exitUsage, message: message, showUsage: true}
+}
+
+func failure(code int, format string, args ...interface{}) error {
+	return &exitError{code: code, message: fmt.Sprintf(format, args...)}
+}
+
+// exitWith is for a code whose explanation, if any, is already on screen
+func exitWith(code int) error {
+	if code == exitOK {
+		return nil
+	}
+	return &exitError{code: code}
+}
+
+// exitLiteral is exitWith for a code that has its own documented meaning,
+// which --exit-code-* mustn't renumber
+func exitLiteral(code int) error {
+	if code == exitOK {
+		return nil
+	}
+	return &exitError{code: code, literal: true}
+}
+
+// exitCode reports err on stderr and returns the code it maps to.  Errors
+// that didn't come through exitError are treated as usage errors, since
+// that's what an unexpected setup problem almost always is.
+func exitCode(err error) int {
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	var e *exitError
+	if !errors.As(err, &e) {
+		e = &exitError{code: exitUsage, message: err.Error()}
+	}
+	if e.showUsage {
+		usage(e.message)
+	} else if e.message != "" {
+		fmt.Fprintln(os.Stderr, e.message)
+	}
+	switch {
+	case exitZero:
+		return exitOK
+	case e.literal:
+		return e.code
+	}
+	return mappedExitCode(e.code)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Each condition exits with its own code, or with what its --exit-code-*
+// flag renumbers it to
+func TestExitCodes(t *testing.T) {
+	var conditions = []struct {
+		flag string
+		err  error
+		code int
+	}{
+		{"usage", usageError("Invalid --days"), exitUsage},
+		{"error", failure(exitRPC, "Unable to reach the node"), exitRPC},
+		{"stale", exitWith(exitStale), exitStale},
+		{"assertion", exitWith(exitAssertion), exitAssertion},
+		{"partial", partialResult(true), exitPartial},
+		{"degraded", exitWith(exitDegraded), exitDegraded},
+	}
+	for i, c := range conditions {
+		var remapped = 40 + i
+		for _, args := range [][]string{nil, {fmt.Sprintf("--exit-code-%s=%d", c.flag, remapped)}, {"--exit-zero"}} {
+			var _, err = parseConfig(args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want = c.code
+			switch {
+			case len(args) == 0:
+			case args[0] == "--exit-zero":
+				want = exitOK
+			default:
+				want = remapped
+			}
+			var got int
+			capture(t, &os.Stderr, func() { got = exitCode(c.err) })
+			if got != want {
+				t.Errorf("%s %v: exit %d, want %d", c.flag, args, got, want)
+			}
+		}
+	}
+
+	if code := exitCode(partialResult(false)); code != exitOK {
+		t.Errorf("no partial data: exit %d, want 0", code)
+	}
+	var _, err = parseConfig([]string{"--exit-code-stale=126"})
+	if err == nil || !strings.Contains(err.Error(), "Invalid --exit-code-stale 126") {
+		t.Errorf("--exit-code-stale=126: got %v, want a usage error", err)
+	}
+}
+
+// --stale-after exits with the stale code once the newest block won is
+// older than it, whatever the rest of the report
+func TestStaleAfterExit(t *testing.T) {
+	var now = time.Now()
+	var tests = []struct {
+		name string
+		txs  []map[string]interface{}
+		args []string
+		code int
+		text string
+	}{
+		{"recent block", []map[string]interface{}{generation("aa", 800090, 5, now.Add(-time.Hour))}, nil, exitOK, ""},
+		{"old block", []map[string]interface{}{generation("aa", 800000, 5, now.Add(-60*time.Hour))}, nil, exitStale, "STALE: last block won"},
+		{"old block, remapped", []map[string]interface{}{generation("aa", 800000, 5, now.Add(-60*time.Hour))}, []string{"--exit-code-stale", "9"}, 9, "STALE: last block won"},
+		{"no block", nil, nil, exitStale, "STALE: no block won in the report window"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var node = newWalletNode(t, tt.txs)
+			var args = append([]string{"--url", node.URL, "--user", "u", "--password", "p", "--wallet", "rig1", "--days", "3", "--stale-after", "2d"}, tt.args...)
+			var code int
+			var out = capture(t, &os.Stdout, func() {
+				capture(t, &os.Stderr, func() { code = exitCode(run(args)) })
+			})
+			if code != tt.code {
+				t.Errorf("exit %d, want %d", code, tt.code)
+			}
+			if (tt.text == "" && strings.Contains(out, "STALE")) || !strings.Contains(out, tt.text) {
+				t.Errorf("report %q, want %q in it", out, tt.text)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// rpcFailover, when set, sends each request for the node to whichever of
+// its endpoints is serving the run
+var rpcFailover *failover
+
+// failover is the node's endpoints, as --url gives them, in the order
+// they're tried.  The first to answer serves the whole run; another is only
+// tried when it stops answering, so data from two nodes isn't mixed unless
+// there's no other way to finish.
+type failover struct {
+	mu        sync.Mutex
+	endpoints []*url.URL
+	active    int
+	answered  bool
+	served    []string
+}
+
+// newFailover parses the endpoints.  Only their scheme and host are used;
+// the credentials, --path-prefix, and wallet paths are the same for all.
+func newFailover(raw []string) (*failover, error) {
+	var f = &failover{}
+	for _, r := range raw {
+		var u, err = url.Parse(r)
+		if err != nil || u.Host == "" {
+			return nil, usageError(fmt.Sprintf("Invalid URL %q", r))
+		}
+		f.endpoints = append(f.endpoints, u)
+	}
+	return f, nil
+}
+
+// forNode is whether u is a request for the node rather than, say, one of
+// --merge-urls' other nodes
+func (f *failover) forNode(u *url.URL) bool {
+	for _, e := range f.endpoints {
+		if e.Scheme == u.Scheme && e.Host == u.Host {
+			return true
+		}
+	}
+	return false
+}
+
+// at is u sent to endpoint i instead
+func (f *failover) at(u *url.URL, i int) *url.URL {
+	var eu = *u
+	eu.Scheme, eu.Host = f.endpoints[i].Scheme, f.endpoints[i].Host
+	return &eu
+}
+
+// post sends the request to the endpoint serving the run, moving on to the
+// next, and the one after, when it can't be reached or fails with a server
+// error.  Once one has answered, having to leave it is warned about loudly,
+// since the run's data then comes from more than one node.
+func (f *failover) post(u *url.URL, method string, data io.Reader, resp interface{}) error {
+	var body, err = io.ReadAll(data)
+	if err != nil {
+		return err
+	}
+	for tried := 1; ; tried++ {
+		var i = f.current()
+		var down bool
+		down, err = postOnce(f.at(u, i), method, bytes.NewReader(body), resp)
+		if !down {
+			f.serve(i)
+			return err
+		}
+		if tried == len(f.endpoints) {
+			return fmt.Errorf("no endpoint answered; the last, %s: %w", f.endpoints[i].Host, err)
+		}
+		f.moveOn(i, err)
+	}
+}
+
+func (f *failover) current() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.active
+}
+
+// moveOn makes the endpoint after i the active one, unless another request
+// has already moved on from i
+func (f *failover) moveOn(i int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.active != i {
+		return
+	}
+	f.active = (i + 1) % len(f.endpoints)
+	var from, to = f.endpoints[i].Host, f.endpoints[f.active].Host
+	if f.answered {
+		fmt.Fprintf(os.Stderr, "WARNING: %s stopped answering mid-run (%s); switching to %s, so this run's data comes from more than one node\n", from, err, to)
+	} else {
+		fmt.Fprintf(os.Stderr, "%s is unavailable (%s); trying %s\n", from, err, to)
+	}
+}
+
+// serve notes that endpoint i answered
+func (f *failover) serve(i int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.answered = true
+	var host = f.endpoints[i].Host
+	if len(f.served) == 0 || f.served[len(f.served)-1] != host {
+		f.served = append(f.served, host)
+	}
+}
+
+// servedBy is the endpoints that answered the run, in the order they took
+// over
+func (f *failover) servedBy() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.served...)
+}
+
+// endpointLine is the report header's line saying which endpoint served
+// the data
+func endpointLine(served []string) string {
+	if len(served) == 1 {
+		return "Endpoint: " + served[0]
+	}
+	return fmt.Sprintf("Endpoints: %s (failed over mid-run; the data is from more than one node)", strings.Join(served, ", then "))
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// PriceFetcher looks up what one coin is worth right now in each of the
+// given currencies, keyed by the currency codes as given
+type PriceFetcher interface {
+	FetchPrice(currencies []string) (map[string]float64, error)
+}
+
+// fixedPrice is a PriceFetcher for a price given outright with --coin-price,
+// which can only be in the one currency
+type fixedPrice struct {
+	currency string
+	price    float64
+}
+
+func (p fixedPrice) FetchPrice(currencies []string) (map[string]float64, error) {
+	var prices = make(map[string]float64)
+	for _, c := range currencies {
+		if c != p.currency {
+			return nil, fmt.Errorf("--coin-price is in %s, not %s", p.currency, c)
+		}
+		prices[c] = p.price
+	}
+	return prices, nil
+}
+
+// newFiatFetcher picks where --fiat-currencies' rates come from: the price
+// source the coin price comes from, or, without one, a --coin-price taken
+// to be in the only currency asked for.  The coin is never assumed: with
+// neither there's no telling what it's worth.
+func newFiatFetcher(cfg *config, currencies []string) (PriceFetcher, error) {
+	switch {
+	case cfg.priceSource.configured():
+		if len(currencies) > 1 && !cfg.priceSource.perCurrency() {
+			return nil, fmt.Errorf("--price-url and --price-path give one price, so for more than one currency they need a {currency} or {CURRENCY} placeholder")
+		}
+		return cfg.priceSource, nil
+	case cfg.CoinPrice > 0 && len(currencies) == 1:
+		return fixedPrice{currency: currencies[0], price: cfg.CoinPrice}, nil
+	case cfg.CoinPrice > 0:
+		return nil, fmt.Errorf("--coin-price is one currency's price; for %s give a price source with --price-coingecko or --price-url", strings.Join(currencies, ", "))
+	}
+	return nil, fmt.Errorf("no price source for the coin: give --price-coingecko, --price-url and --price-path, or --coin-price for a single currency")
+}
+
+// parseFiatCurrencies reads --fiat-currencies: a comma-separated list of
+// currency codes, such as "USD,EUR,GBP", upper-cased and without repeats
+func parseFiatCurrencies(s string) ([]string, error) {
+	var list []string
+	var seen = make(map[string]bool)
+	for _, c := range strings.Split(s, ",") {
+		c = strings.ToUpper(strings.TrimSpace(c))
+		if c == "" || strings.Trim(c, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+			return nil, fmt.Errorf("%q isn't a currency code", c)
+		}
+		if !seen[c] {
+			seen[c] = true
+			list = append(list, c)
+		}
+	}
+	return list, nil
+}
+
+// lookupFiatRates fetches the coin's price in each currency.  Like the coin
+// price, the rates are a nice-to-have, so a failure is reported and the
+// report goes on in coins alone.
+func lookupFiatRates(cfg *config, p PriceFetcher, currencies []string) map[string]float64 {
+	if cfg.dryRun {
+		if src, ok := p.(priceSource); ok {
+			var u, _ = src.resolve(currencies)
+			var seen = make(map[string]bool)
+			for _, c := range currencies {
+				if cu := fillCurrency(u, c); !seen[cu] {
+					seen[cu] = true
+					fmt.Printf("HTTP  %s  (%s rates)\n", cu, strings.Join(currencies, ", "))
+				}
+			}
+		}
+		return nil
+	}
+
+	var rates, err = p.FetchPrice(currencies)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to fetch %s rates: %s\n", strings.Join(currencies, ", "), err)
+		return nil
+	}
+	return rates
+}
+
+// fiatRate is what one coin was worth in a currency when the report ran
+type fiatRate struct {
+	Currency string  `json:"currency"`
+	Rate     float64 `json:"rate"`
+}
+
+// addFiat sets the rates on the view, in the order they were asked for, and
+// each daily and bucket row's earnings at them
+func (v *reportView) addFiat(currencies []string, rates map[string]float64) {
+	for _, c := range currencies {
+		v.FiatRates = append(v.FiatRates, fiatRate{c, rates[c]})
+	}
+	var convert = func(coins float64) map[string]float64 {
+		var values = make(map[string]float64, len(currencies))
+		for _, c := range currencies {
+			values[c] = coins * rates[c]
+		}
+		return values
+	}
+	v.FiatTotal = convert(v.Total)
+	for i := range v.Daily {
+		v.Daily[i].Fiat = convert(v.Daily[i].Coins)
+	}
+	for i := range v.Buckets {
+		v.Buckets[i].Fiat = convert(v.Buckets[i].Coins)
+	}
+}
+
+// fiatValues lists values in the order of the view's rates, as "1234.56 USD"
+func (v *reportView) fiatValues(values map[string]float64) string {
+	var parts = make([]string, len(v.FiatRates))
+	for i, r := range v.FiatRates {
+		parts[i] = fmt.Sprintf("%0.2f %s", values[r.Currency], r.Currency)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// fiatTag is a row's earnings in each currency, for the text tables
+func (v *reportView) fiatTag(row reportRow) string {
+	if len(row.Fiat) == 0 {
+		return ""
+	}
+	return " [" + v.fiatValues(row.Fiat) + "]"
+}
+
+func (v *reportView) printFiat(w io.Writer) {
+	var parts = make([]string, len(v.FiatRates))
+	for i, r := range v.FiatRates {
+		parts[i] = fmt.Sprintf("%0.2f %s", r.Rate, r.Currency)
+	}
+	fmt.Fprintf(w, "Coin price: %s\n", strings.Join(parts, ", "))
+	fmt.Fprintf(w, "Report period total in fiat: %s\n", v.fiatValues(v.FiatTotal))
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPriceSourceFetchPrice(t *testing.T) {
+	var requests int
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/all":
+			fmt.Fprint(w, `{"dynamo": {"usd": 0.5, "eur": "0.45"}}`)
+		case "/ticker/DYNUSD":
+			fmt.Fprint(w, `{"result": {"last": 0.5}}`)
+		case "/ticker/DYNEUR":
+			fmt.Fprint(w, `{"result": {"last": 0.45}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	var tests = []struct {
+		name     string
+		src      priceSource
+		requests int
+	}{
+		{"currency in the path", priceSource{PriceURL: srv.URL + "/all", PricePath: "dynamo.{currency}"}, 1},
+		{"currency in the url", priceSource{PriceURL: srv.URL + "/ticker/DYN{CURRENCY}", PricePath: "result.last"}, 2},
+	}
+	for _, tt := range tests {
+		requests = 0
+		var prices, err = tt.src.FetchPrice([]string{"USD", "EUR"})
+		if err != nil {
+			t.Errorf("%s: %s", tt.name, err)
+			continue
+		}
+		if prices["USD"] != 0.5 || prices["EUR"] != 0.45 {
+			t.Errorf("%s: got %v", tt.name, prices)
+		}
+		if requests != tt.requests {
+			t.Errorf("%s: %d requests, want %d", tt.name, requests, tt.requests)
+		}
+	}
+
+	var _, err = priceSource{PriceURL: srv.URL + "/all", PricePath: "dynamo.{currency}"}.FetchPrice([]string{"GBP"})
+	if err == nil || !strings.Contains(err.Error(), `no "gbp" in the object at dynamo`) {
+		t.Errorf("missing currency: got %v", err)
+	}
+}
+
+func TestNewFiatFetcher(t *testing.T) {
+	var tests = []struct {
+		name       string
+		cfg        config
+		currencies []string
+		err        string
+	}{
+		{"nothing configured", config{}, []string{"USD"}, "no price source"},
+		{"coin price, one currency", config{CoinPrice: 2}, []string{"EUR"}, ""},
+		{"coin price, two currencies", config{CoinPrice: 2}, []string{"USD", "EUR"}, "one currency's price"},
+		{"coingecko", config{priceSource: priceSource{PriceCoinGecko: "dynamo"}}, []string{"USD", "EUR"}, ""},
+		{"fixed url, two currencies", config{priceSource: priceSource{PriceURL: "http://x", PricePath: "p"}}, []string{"USD", "EUR"}, "placeholder"},
+		{"fixed url, one currency", config{priceSource: priceSource{PriceURL: "http://x", PricePath: "p"}}, []string{"USD"}, ""},
+	}
+	for _, tt := range tests {
+		var _, err = newFiatFetcher(&tt.cfg, tt.currencies)
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%s: %s", tt.name, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("%s: got %v, want an error about %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+// stubPrices is a PriceFetcher with the rates fixed in advance
+type stubPrices map[string]float64
+
+func (s stubPrices) FetchPrice(currencies []string) (map[string]float64, error) {
+	return s, nil
+}
+
+func TestAddFiat(t *testing.T) {
+	var currencies = []string{"USD", "EUR"}
+	var rates = lookupFiatRates(&config{}, stubPrices{"USD": 2, "EUR": 1.5}, currencies)
+	var v = &reportView{Total: 10, Daily: []reportRow{{Label: "d1", Coins: 4}, {Label: "d2", Coins: 6}}}
+	v.addFiat(currencies, rates)
+
+	if got := v.fiatValues(v.FiatTotal); got != "20.00 USD, 15.00 EUR" {
+		t.Errorf("total: got %q", got)
+	}
+	if got := v.fiatTag(v.Daily[0]); got != " [8.00 USD, 6.00 EUR]" {
+		t.Errorf("day 1: got %q", got)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// fifoWriter is serve's --fifo: a named pipe that gets a line of stats
+// after each refresh, for whoever is reading it.  Writes happen on their
+// own goroutine, since opening a pipe blocks until there's a reader, and
+// only the newest line is kept while waiting for one, so a slow or absent
+// reader never holds up the refreshes.
+type fifoWriter struct {
+	path  string
+	lines chan string
+}
+
+// openFIFO makes the named pipe at path, unless one is already there, and
+// starts writing to it
+func openFIFO(path string) (*fifoWriter, error) {
+	var info, err = os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		err = makeFIFO(path)
+		if err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	} else if info.Mode()&os.ModeNamedPipe == 0 {
+		return nil, fmt.Errorf("%s exists and isn't a named pipe", path)
+	}
+
+	var f = &fifoWriter{path: path, lines: make(chan string, 1)}
+	go f.run()
+	return f, nil
+}
+
+// send queues line, replacing any line still waiting for a reader
+func (f *fifoWriter) send(line string) {
+	for {
+		select {
+		case f.lines <- line:
+			return
+		default:
+		}
+		select {
+		case <-f.lines:
+		default:
+		}
+	}
+}
+
+// run writes each line as it comes.  A reader going away shows up as EPIPE
+// rather than SIGPIPE, which Go only delivers for stdout and stderr, so it
+// just means waiting for the next reader.
+func (f *fifoWriter) run() {
+	var out *os.File
+	for line := range f.lines {
+		if out == nil {
+			out = f.waitForReader()
+			if out == nil {
+				continue
+			}
+		}
+		var _, err = out.WriteString(line + "\n")
+		if err == nil {
+			continue
+		}
+		out.Close()
+		out = nil
+		if !errors.Is(err, syscall.EPIPE) {
+			fmt.Fprintf(os.Stderr, "Unable to write to FIFO %s: %s\n", f.path, err)
+		}
+	}
+}
+
+// waitForReader opens the pipe for writing, saying so first if that means
+// blocking until something opens it for reading
+func (f *fifoWriter) waitForReader() *os.File {
+	var out, err = openFIFONonblocking(f.path)
+	if err == nil {
+		return out
+	}
+	fmt.Fprintln(os.Stderr, "Waiting for FIFO reader...")
+	out, err = os.OpenFile(f.path, os.O_WRONLY, 0)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to open FIFO %s: %s\n", f.path, err)
+		return nil
+	}
+	return out
+}
+
+// fifoLine is one refresh's stats: the window's coins and blocks in all,
+// then each wallet's
+func fifoLine(c *txCache, wallets []string, days int, now time.Time) string {
+	var total float64
+	var blocks int64
+	var each []string
+	for _, w := range wallets {
+		var r = c.snapshot(w)
+		total += r.Total.Coins
+		blocks += r.Total.Blocks
+		each = append(each, fmt.Sprintf("%s %0.8f/%d", w, r.Total.Coins, r.Total.Blocks))
+	}
+	return fmt.Sprintf("%s  %d days: %0.8f in %d blocks  [%s]", now.Format(dateTimeLayout), days, total, blocks, strings.Join(each, ", "))
+}
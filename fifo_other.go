@@ -0,0 +1,18 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+var errNoFIFO = errors.New("named pipes aren't supported on this platform")
+
+func makeFIFO(path string) error {
+	return errNoFIFO
+}
+
+func openFIFONonblocking(path string) (*os.File, error) {
+	return nil, errNoFIFO
+}
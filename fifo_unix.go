@@ -0,0 +1,29 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+func makeFIFO(path string) error {
+	return syscall.Mkfifo(path, 0600)
+}
+
+// openFIFONonblocking opens the pipe for writing if it already has a reader,
+// and fails (with ENXIO) rather than waiting for one if not.  The file is
+// switched back to blocking once it's open, so writes wait for the reader
+// rather than failing when the pipe is full.
+func openFIFONonblocking(path string) (*os.File, error) {
+	var fd, err = syscall.Open(path, syscall.O_WRONLY|syscall.O_NONBLOCK|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, err
+	}
+	err = syscall.SetNonblock(fd, false)
+	if err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+	return os.NewFile(uintptr(fd), path), nil
+}
@@ -1,3 +1,3 @@
-module txstats
+module github.com/Nerdmaster/dynamo-tx-stats
 
-go 1.17
+go 1.18
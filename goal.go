@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// goalView tracks production against --goal over the current --goal-period,
+// which runs on the wall clock in the report's time zone
+type goalView struct {
+	Period         string    `json:"period"`
+	Start          time.Time `json:"start"`
+	End            time.Time `json:"end"`
+	Goal           float64   `json:"goal"`
+	Actual         float64   `json:"actual"`
+	PercentDone    float64   `json:"percent_done"`
+	PercentElapsed float64   `json:"percent_elapsed"`
+	Remaining      float64   `json:"remaining"`
+	DaysLeft       float64   `json:"days_left"`
+	Met            bool      `json:"met"`
+
+	// NeededPerDay is the daily rate that would still hit the goal; absent
+	// once it's met or there's no time left
+	NeededPerDay *float64 `json:"needed_per_day,omitempty"`
+
+	seen map[string]bool
+}
+
+// goalPeriodStart returns the bounds of the day, week, or month holding now
+func goalPeriodStart(period string, now time.Time, weekStartDay time.Weekday) (time.Time, time.Time, error) {
+	switch period {
+	case "day":
+		var start = getDay(now)
+		return start, start.AddDate(0, 0, 1), nil
+	case "week":
+		var start = weekStart(now, weekStartDay)
+		return start, start.AddDate(0, 0, 7), nil
+	case "month":
+		var start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+		return start, start.AddDate(0, 1, 0), nil
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("%q is not day, week, or month", period)
+}
+
+func newGoalView(goal float64, period string, start, end time.Time) *goalView {
+	return &goalView{Period: period, Start: start, End: end, Goal: goal, seen: make(map[string]bool)}
+}
+
+// add counts a transaction toward the goal if it falls in the period
+func (g *goalView) add(tx *Transaction) {
+	var key = fmt.Sprintf("%s:%d", tx.TXID, tx.Vout)
+	if tx.dt.Before(g.Start) || !tx.dt.Before(g.End) || g.seen[key] {
+		return
+	}
+	g.seen[key] = true
+	g.Actual += tx.Amount
+}
+
+// finish works out the progress as of now.  Elapsed time is measured on
+// the real clock, so a DST day counts as the 23 or 25 hours it was.
+func (g *goalView) finish(now time.Time) {
+	if g.Goal > 0 {
+		g.PercentDone = g.Actual / g.Goal * 100
+	}
+	g.PercentElapsed = float64(now.Sub(g.Start)) / float64(g.End.Sub(g.Start)) * 100
+	g.DaysLeft = g.End.Sub(now).Hours() / 24
+	if g.DaysLeft < 0 {
+		g.DaysLeft = 0
+	}
+	g.Met = g.Actual >= g.Goal
+	if !g.Met {
+		g.Remaining = g.Goal - g.Actual
+	}
+	if !g.Met && g.DaysLeft > 0 {
+		var needed = g.Remaining / g.DaysLeft
+		g.NeededPerDay = &needed
+	}
+}
+
+func (g *goalView) print(w io.Writer) {
+	fmt.Fprintf(w, "Goal (%s to date): %0.2f of %0.2f (%0.1f%%), %0.1f%% of the %s elapsed\n", g.Period, g.Actual, g.Goal, g.PercentDone, g.PercentElapsed, g.Period)
+	switch {
+	case g.Met:
+		fmt.Fprintf(w, "Goal met, %0.2f over\n", g.Actual-g.Goal)
+	case g.NeededPerDay == nil:
+		fmt.Fprintf(w, "Goal missed by %0.2f\n", g.Remaining)
+	default:
+		fmt.Fprintf(w, "Needed: %0.2f/day over the remaining %0.1f days\n", *g.NeededPerDay, g.DaysLeft)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestGoalPeriodStart(t *testing.T) {
+	var loc, err = time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	var saved = time.Local
+	time.Local = loc
+	defer func() { time.Local = saved }()
+
+	var now = time.Date(2024, 3, 10, 15, 30, 0, 0, loc)
+	var tests = []struct {
+		period     string
+		start, end time.Time
+	}{
+		{"day", time.Date(2024, 3, 10, 0, 0, 0, 0, loc), time.Date(2024, 3, 11, 0, 0, 0, 0, loc)},
+		{"week", time.Date(2024, 3, 4, 0, 0, 0, 0, loc), time.Date(2024, 3, 11, 0, 0, 0, 0, loc)},
+		{"month", time.Date(2024, 3, 1, 0, 0, 0, 0, loc), time.Date(2024, 4, 1, 0, 0, 0, 0, loc)},
+	}
+	for _, tt := range tests {
+		var start, end, err = goalPeriodStart(tt.period, now, time.Monday)
+		if err != nil || !start.Equal(tt.start) || !end.Equal(tt.end) {
+			t.Errorf("%s: got %v to %v, %v; want %v to %v", tt.period, start, end, err, tt.start, tt.end)
+		}
+	}
+	if _, _, err = goalPeriodStart("year", now, time.Monday); err == nil {
+		t.Error("year: no error")
+	}
+
+	// The DST day is 23 hours long, so by 15:30 it's 14.5 of them through
+	var start, end, _ = goalPeriodStart("day", now, time.Monday)
+	var g = newGoalView(100, "day", start, end)
+	g.finish(now)
+	if want := 14.5 / 23 * 100; math.Abs(g.PercentElapsed-want) > 1e-9 {
+		t.Errorf("DST day: %0.4f%% elapsed, want %0.4f%%", g.PercentElapsed, want)
+	}
+}
+
+func TestGoalProgress(t *testing.T) {
+	var start = time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	var end = start.AddDate(0, 1, 0)
+	var tx = func(txid string, vout int64, amount float64, at time.Time) *Transaction {
+		return &Transaction{TXID: txid, Vout: vout, Amount: amount, dt: at}
+	}
+
+	var tests = []struct {
+		name   string
+		now    time.Time
+		goal   float64
+		txs    []*Transaction
+		actual float64
+		met    bool
+		needed float64 // 0 for none
+	}{
+		{
+			name: "on the way",
+			now:  start.AddDate(0, 0, 10),
+			goal: 15000,
+			txs: []*Transaction{
+				tx("a", 0, 3000, start.Add(time.Hour)),
+				tx("a", 1, 1000, start.Add(time.Hour)),
+				tx("a", 1, 1000, start.Add(time.Hour)), // the same output listed twice
+				tx("b", 0, 500, start.Add(-time.Minute)),
+				tx("c", 0, 500, end),
+			},
+			actual: 4000,
+			needed: 11000.0 / 20,
+		},
+		{
+			name:   "met",
+			now:    start.AddDate(0, 0, 10),
+			goal:   1000,
+			txs:    []*Transaction{tx("a", 0, 1500, start.Add(time.Hour))},
+			actual: 1500,
+			met:    true,
+		},
+		{
+			name:   "no time left",
+			now:    end.Add(time.Hour),
+			goal:   1000,
+			txs:    []*Transaction{tx("a", 0, 600, start.Add(time.Hour))},
+			actual: 600,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var g = newGoalView(tt.goal, "month", start, end)
+			for _, tx := range tt.txs {
+				g.add(tx)
+			}
+			g.finish(tt.now)
+			if g.Actual != tt.actual || g.Met != tt.met {
+				t.Errorf("got actual %v, met %v; want %v, %v", g.Actual, g.Met, tt.actual, tt.met)
+			}
+			if g.Met && g.Remaining != 0 || !g.Met && g.Remaining != tt.goal-tt.actual {
+				t.Errorf("remaining %v", g.Remaining)
+			}
+			if tt.needed == 0 {
+				if g.NeededPerDay != nil {
+					t.Errorf("needed %v/day, want none", *g.NeededPerDay)
+				}
+			} else if g.NeededPerDay == nil || *g.NeededPerDay != tt.needed {
+				t.Errorf("needed %v/day, want %v", g.NeededPerDay, tt.needed)
+			}
+			if g.DaysLeft < 0 {
+				t.Errorf("%v days left", g.DaysLeft)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Nerdmaster/dynamo-tx-stats/stats"
+)
+
+// hashrateView is --estimate-hashrate's part of the summary.  Hashrates are
+// in hashes per second.
+type hashrateView struct {
+	Blocks     int64   `json:"blocks"`
+	Difficulty float64 `json:"difficulty"`
+	Hashrate   float64 `json:"hashrate"`
+	Low        float64 `json:"low"`
+	High       float64 `json:"high"`
+
+	// DifficultyFrom says where the difficulty came from: the headers of
+	// the blocks won, or the node's current difficulty when there were none
+	DifficultyFrom string `json:"difficulty_from"`
+
+	// Incomplete notes headers left out for --max-header-lookups
+	Incomplete string `json:"incomplete,omitempty"`
+}
+
+// newHashrateView estimates the hashrate behind the blocks won between
+// begin and now.  The difficulty is the average over the blocks' own
+// headers, so a retarget inside the window is weighted by when the blocks
+// actually came; a window without blocks falls back on getmininginfo.
+func newHashrateView(u *url.URL, headers *headerCache, blocks []*Transaction, begin, now time.Time) (*hashrateView, error) {
+	var seen = make(map[string]bool)
+	var total float64
+	var counted int64
+	for _, tx := range blocks {
+		if seen[tx.Blockhash] {
+			continue
+		}
+		seen[tx.Blockhash] = true
+		var h = headers.header(tx.Blockhash)
+		if h == nil {
+			continue
+		}
+		total += h.Difficulty
+		counted++
+	}
+
+	var v = &hashrateView{Blocks: int64(len(seen)), DifficultyFrom: "headers", Incomplete: headers.incomplete()}
+	if counted > 0 {
+		v.Difficulty = total / float64(counted)
+	} else {
+		var info struct {
+			Difficulty float64 `json:"difficulty"`
+		}
+		var err = rpcCall(nodeURL(u), "getmininginfo", nil, &info)
+		if err != nil {
+			return nil, err
+		}
+		v.Difficulty, v.DifficultyFrom = info.Difficulty, "getmininginfo"
+	}
+
+	var e = stats.EstimateHashrate(v.Blocks, v.Difficulty, now.Sub(begin))
+	v.Hashrate, v.Low, v.High = e.Hashrate, e.Low, e.High
+	return v, nil
+}
+
+// formatHashrate scales hashes per second to the largest unit that keeps
+// the number at least 1, to three significant figures.  The units are the
+// ones --pool-hashrate reads.
+func formatHashrate(h float64) string {
+	var units = []string{"H/s", "kH/s", "MH/s", "GH/s", "TH/s", "PH/s", "EH/s", "ZH/s"}
+	var i = 0
+	for h >= 1000 && i < len(units)-1 {
+		h /= 1000
+		i++
+	}
+	var s = strconv.FormatFloat(h, 'f', sigDecimals(h), 64)
+	// rounding can carry into the next unit, as 999.7 does
+	if s == "1000" && i < len(units)-1 {
+		h /= 1000
+		i++
+		s = strconv.FormatFloat(h, 'f', sigDecimals(h), 64)
+	}
+	return s + " " + units[i]
+}
+
+// sigDecimals is how many decimal places show v, from 1 to 999, to three
+// significant figures
+func sigDecimals(v float64) int {
+	switch {
+	case v >= 99.95:
+		return 0
+	case v >= 9.995:
+		return 1
+	}
+	return 2
+}
+
+func (v *reportView) printHashrate(w io.Writer) {
+	var h = v.Hashrate
+	fmt.Fprintf(w, "Effective hashrate: %s (95%%: %s%s%s, from %d blocks at difficulty %0.2f)\n",
+		formatHashrate(h.Hashrate), formatHashrate(h.Low), v.style.dash(), formatHashrate(h.High), h.Blocks, h.Difficulty)
+	if h.Incomplete != "" {
+		fmt.Fprintf(w, "  (%s)\n", h.Incomplete)
+	}
+	if h.Blocks < 10 {
+		fmt.Fprintln(w, "  (few blocks, so the range is wide; a longer window narrows it)")
+	}
+}
@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func TestFormatHashrate(t *testing.T) {
+	var tests = map[float64]string{
+		0:       "0.00 H/s",
+		12.345:  "12.3 H/s",
+		999:     "999 H/s",
+		999.7:   "1.00 kH/s",
+		1234567: "1.23 MH/s",
+		4.5e14:  "450 TH/s",
+		6.45e20: "645 EH/s",
+		3e25:    "30000 ZH/s",
+	}
+	for h, want := range tests {
+		if got := formatHashrate(h); got != want {
+			t.Errorf("formatHashrate(%g) = %q, want %q", h, got, want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+)
+
+// headerBatchSize is how many getblockheader calls go in one JSON-RPC batch
+const headerBatchSize = 100
+
+// headerCache looks up the headers of blocks won for the difficulty
+// features, --estimate-hashrate and --block-stats.  A header never changes
+// once its block is in the chain, so it's fetched once and kept in known,
+// which the state file carries from run to run; misses are fetched in
+// batches, and no more than limit of them in a run.
+type headerCache struct {
+	known map[string]*BlockHeader
+	limit int
+
+	// hits and misses count lookups answered from known and from the node;
+	// skipped counts those left out once limit was reached
+	hits, misses, skipped int
+	batches               int
+	noBatch               bool
+}
+
+// newHeaderCache starts from the state file's headers, if there's a state
+// file.  A limit of 0 means no limit.
+func newHeaderCache(state *stateFile, limit int) *headerCache {
+	var c = &headerCache{known: make(map[string]*BlockHeader), limit: limit}
+	if state != nil {
+		if state.Headers == nil {
+			state.Headers = c.known
+		}
+		c.known = state.Headers
+	}
+	return c
+}
+
+// load makes sure the header of every block in blocks is known, as far as
+// the limit allows.  A header that can't be fetched is reported and left
+// out, as is every one past the limit.
+func (c *headerCache) load(u *url.URL, blocks []*Transaction) {
+	var missing []string
+	var seen = make(map[string]bool)
+	for _, tx := range blocks {
+		if seen[tx.Blockhash] {
+			continue
+		}
+		seen[tx.Blockhash] = true
+		if c.known[tx.Blockhash] != nil {
+			c.hits++
+			continue
+		}
+		if c.limit > 0 && c.misses+len(missing) >= c.limit {
+			c.skipped++
+			continue
+		}
+		missing = append(missing, tx.Blockhash)
+	}
+	c.misses += len(missing)
+
+	for len(missing) > 0 {
+		var n = len(missing)
+		if n > headerBatchSize {
+			n = headerBatchSize
+		}
+		c.fetch(u, missing[:n])
+		missing = missing[n:]
+	}
+}
+
+// fetch looks up hashes in one batch, or one at a time if the node (or a
+// proxy in front of it) won't take batches
+func (c *headerCache) fetch(u *url.URL, hashes []string) {
+	var params = make([][]interface{}, len(hashes))
+	var results = make([]interface{}, len(hashes))
+	var headers = make([]BlockHeader, len(hashes))
+	for i, hash := range hashes {
+		params[i] = []interface{}{hash, true}
+		results[i] = &headers[i]
+	}
+
+	var errs []error
+	if !c.noBatch {
+		var err error
+		errs, err = rpcBatch(nodeURL(u), "getblockheader", params, results)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Batched getblockheader failed, fetching headers one at a time: %s\n", err)
+			c.noBatch = true
+		} else {
+			c.batches++
+		}
+	}
+	if c.noBatch {
+		errs = make([]error, len(hashes))
+		for i := range hashes {
+			errs[i] = rpcCall(nodeURL(u), "getblockheader", params[i], results[i])
+		}
+	}
+
+	for i, hash := range hashes {
+		if errs[i] != nil {
+			fmt.Fprintf(os.Stderr, "Unable to fetch header for block %s: %s\n", hash, errs[i])
+			continue
+		}
+		if rpcRecorder != nil {
+			continue
+		}
+		// nextblockhash is the one part that can change, so it isn't kept
+		var h = headers[i]
+		h.NextBlockHash = ""
+		c.known[hash] = &h
+	}
+}
+
+// header is the known header of the block with the given hash, nil if it
+// couldn't be fetched or was past the limit
+func (c *headerCache) header(hash string) *BlockHeader {
+	return c.known[hash]
+}
+
+// incomplete is the note for output built on the headers when some were
+// left out for the limit
+func (c *headerCache) incomplete() string {
+	if c.skipped == 0 {
+		return ""
+	}
+	return fmt.Sprintf("difficulty data incomplete: %d block header(s) past the --max-header-lookups limit of %d were skipped", c.skipped, c.limit)
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"os"
+	"time"
+)
+
+type checkStatus int
+
+const (
+	statusPass checkStatus = iota
+	statusWarn
+	statusFail
+)
+
+func (s checkStatus) String() string {
+	switch s {
+	case statusPass:
+		return "PASS"
+	case statusWarn:
+		return "WARN"
+	}
+	return "FAIL"
+}
+
+// checkResult is one line of the health check.  value is the measurement
+// the status was judged on, if there is one, for --json consumers.
+type checkResult struct {
+	name    string
+	status  checkStatus
+	message string
+	value   interface{}
+}
+
+type blockchainInfo struct {
+	Chain                string  `json:"chain"`
+	Blocks               int64   `json:"blocks"`
+	Headers              int64   `json:"headers"`
+	InitialBlockDownload bool    `json:"initialblockdownload"`
+	VerificationProgress float64 `json:"verificationprogress"`
+	MedianTime           int64   `json:"mediantime"`
+	Pruned               bool    `json:"pruned"`
+}
+
+type walletInfo struct {
+	WalletName  string `json:"walletname"`
+	Format      string `json:"format"`
+	KeypoolSize int64  `json:"keypoolsize"`
+	TxCount     int64  `json:"txcount"`
+	Descriptors bool   `json:"descriptors"`
+
+	// Scanning is false, or a scanProgress while a rescan runs
+	Scanning json.RawMessage `json:"scanning"`
+}
+
+func checkSync(u *url.URL) checkResult {
+	var r = checkResult{name: "sync"}
+	var info blockchainInfo
+	var err = rpcCall(nodeURL(u), "getblockchaininfo", nil, &info)
+	switch {
+	case err != nil:
+		r.status, r.message = statusFail, fmt.Sprintf("unable to reach node: %s", err)
+	case info.InitialBlockDownload:
+		r.status, r.message = statusFail, fmt.Sprintf("initial block download in progress (%0.2f%%)", info.VerificationProgress*100)
+	case info.Headers-info.Blocks > 2:
+		r.status, r.message = statusWarn, fmt.Sprintf("%d blocks behind headers", info.Headers-info.Blocks)
+	default:
+		r.status, r.message = statusPass, fmt.Sprintf("synced at height %d", info.Blocks)
+	}
+	if err == nil {
+		r.value = info.Headers - info.Blocks
+	}
+	return r
+}
+
+func checkConnections(u *url.URL) checkResult {
+	var r = checkResult{name: "connections"}
+	var count int64
+	var err = rpcCall(nodeURL(u), "getconnectioncount", nil, &count)
+	switch {
+	case err != nil:
+		r.status, r.message = statusFail, err.Error()
+	case count == 0:
+		r.status, r.message = statusFail, "no peer connections"
+	case count < 3:
+		r.status, r.message = statusWarn, fmt.Sprintf("only %d peer connection(s)", count)
+	default:
+		r.status, r.message = statusPass, fmt.Sprintf("%d peer connections", count)
+	}
+	if err == nil {
+		r.value = count
+	}
+	return r
+}
+
+// checkBans only ever warns: bans aren't a fault, but a node that refuses
+// listbanned or has long-forgotten bans is worth a look
+func checkBans(u *url.URL) checkResult {
+	var r = checkResult{name: "banned peers"}
+	var bans, err = fetchBanned(u)
+	if err != nil {
+		r.status, r.message = statusWarn, err.Error()
+		return r
+	}
+
+	var stale int
+	var now = time.Now()
+	for _, b := range bans {
+		if b.stale(now) {
+			stale++
+		}
+	}
+	r.value = len(bans)
+	r.status, r.message = statusPass, fmt.Sprintf("%d banned", len(bans))
+	if stale > 0 {
+		r.status, r.message = statusWarn, fmt.Sprintf("%d banned, %d active for over 30 days", len(bans), stale)
+	}
+	return r
+}
+
+func checkKeypool(u *url.URL, wallet string) checkResult {
+	var r = checkResult{name: wallet + ": keypool"}
+	var info walletInfo
+	var err = rpcCall(walletURL(u, wallet), "getwalletinfo", nil, &info)
+	switch {
+	case err != nil:
+		r.status, r.message = statusFail, err.Error()
+	case info.KeypoolSize == 0:
+		r.status, r.message = statusFail, "keypool is empty"
+	case info.KeypoolSize < 100:
+		r.status, r.message = statusWarn, fmt.Sprintf("keypool is low (%d keys)", info.KeypoolSize)
+	default:
+		r.status, r.message = statusPass, fmt.Sprintf("%d keys", info.KeypoolSize)
+	}
+	if err == nil {
+		r.value = info.KeypoolSize
+	}
+	return r
+}
+
+// checkBalance compares the wallet's reported balance against the sum of its
+// transaction history.  This is only meaningful when the history isn't
+// truncated, so a full page of results is reported as a warning.  The node
+// repeats a send's whole fee on each of its entries, one per output, so
+// each transaction's fee is only counted once; and getbalance is asked to
+// take in watch-only coins just when listtransactions was.
+func checkBalance(u *url.URL, wallet string, txList []*Transaction) checkResult {
+	var r = checkResult{name: wallet + ": balance"}
+	var balance float64
+	var err = rpcCall(walletURL(u, wallet), "getbalance", []interface{}{"*", 1, includeWatchonly}, &balance)
+	if err != nil {
+		r.status, r.message = statusFail, err.Error()
+		return r
+	}
+
+	var expected float64
+	var feePaid = make(map[string]bool)
+	for _, tx := range txList {
+		if tx.Confirmations < 1 || tx.Category == "orphan" || tx.Category == "immature" {
+			continue
+		}
+		expected += tx.Amount
+		if tx.Fee != 0 && !feePaid[tx.TXID] {
+			feePaid[tx.TXID] = true
+			expected += tx.Fee
+		}
+	}
+
+	var diff = balance - expected
+	r.value = diff
+	switch {
+	case len(txList) >= listCount:
+		r.status, r.message = statusWarn, fmt.Sprintf("balance %0.8f; history is truncated, unable to verify", balance)
+	case math.Abs(diff) >= 0.000000005:
+		r.status, r.message = statusWarn, fmt.Sprintf("balance %0.8f differs from transaction history (%0.8f) by %0.8f", balance, expected, diff)
+	default:
+		r.status, r.message = statusPass, fmt.Sprintf("balance %0.8f matches transaction history", balance)
+	}
+	return r
+}
+
+func checkRecentActivity(wallet string, txList []*Transaction, now time.Time) checkResult {
+	var r = checkResult{name: wallet + ": recent activity"}
+	var latest int64
+	for _, tx := range txList {
+		if tx.TimeReceived > latest {
+			latest = tx.TimeReceived
+		}
+	}
+
+	var since = now.Sub(time.Unix(latest, 0))
+	if latest != 0 {
+		r.value = int64(since.Seconds())
+	}
+	switch {
+	case latest == 0:
+		r.status, r.message = statusWarn, "no transactions found"
+	case since > 24*time.Hour:
+		r.status, r.message = statusWarn, fmt.Sprintf("no transactions in the last 24h (last was %s ago)", formatAge(since))
+	default:
+		r.status, r.message = statusPass, fmt.Sprintf("last transaction %s ago", formatAge(since))
+	}
+	return r
+}
+
+func checkOrphans(wallet string, txList []*Transaction) checkResult {
+	var r = checkResult{name: wallet + ": orphans"}
+	var count int
+	for _, tx := range txList {
+		if tx.Category == "orphan" {
+			count++
+		}
+	}
+
+	r.value = count
+	if count > 0 {
+		r.status, r.message = statusWarn, fmt.Sprintf("%d orphaned transaction(s)", count)
+	} else {
+		r.status, r.message = statusPass, "no orphaned transactions"
+	}
+	return r
+}
+
+func runHealthChecks(u *url.URL, wallets []string) []checkResult {
+	var results = []checkResult{checkSync(u), checkConnections(u), checkBans(u)}
+	var now = time.Now()
+	for _, w := range wallets {
+		var txList, err = listTransactions(walletURL(u, w))
+		if err != nil {
+			results = append(results, checkResult{name: w + ": transactions", status: statusFail, message: err.Error()})
+			continue
+		}
+		results = append(results,
+			checkBalance(u, w, txList),
+			checkKeypool(u, w),
+			checkRecentActivity(w, txList, now),
+			checkOrphans(w, txList),
+		)
+	}
+
+	return results
+}
+
+// worstStatus returns the process exit code for a set of results: 0 if
+// everything passed, 1 on any warning, 2 on any failure
+func worstStatus(results []checkResult) int {
+	var worst = statusPass
+	for _, r := range results {
+		if r.status > worst {
+			worst = r.status
+		}
+	}
+	return int(worst)
+}
+
+// printHealthCheck prints a line per check and returns the exit code
+func printHealthCheck(results []checkResult) int {
+	for _, r := range results {
+		fmt.Printf("%s\t%s: %s\n", r.status, r.name, r.message)
+	}
+	return worstStatus(results)
+}
+
+// writeHealthCheckJSON writes the results as a JSON array and returns the
+// exit code.  If the JSON can't be written, that's a failure in its own right.
+func writeHealthCheckJSON(w io.Writer, results []checkResult) int {
+	type jsonCheck struct {
+		Check   string      `json:"check"`
+		Status  string      `json:"status"`
+		Message string      `json:"message"`
+		Value   interface{} `json:"value"`
+	}
+	var out = make([]jsonCheck, len(results))
+	for i, r := range results {
+		out[i] = jsonCheck{Check: r.name, Status: r.status.String(), Message: r.message, Value: r.value}
+	}
+
+	var enc = json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	var err = enc.Encode(out)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to write health check: %s\n", err)
+		return int(statusFail)
+	}
+	return worstStatus(results)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// A send to two addresses and a send to self each list their fee on every
+// entry; a healthy wallet's balance must still match
+func TestCheckBalanceFees(t *testing.T) {
+	var asked []interface{}
+	var node = newFakeNode(t, map[string]fakeMethod{
+		"getbalance": func(_ string, params []interface{}) (interface{}, *RPCError) {
+			asked = params
+			return 50 - 3 - 0.0002 - 0.0001, nil
+		},
+	})
+	var txList = []*Transaction{
+		{TXID: "gen", Category: "generate", Amount: 50, Confirmations: 200},
+		{TXID: "pay", Category: "send", Vout: 0, Amount: -1, Fee: -0.0002, Confirmations: 10},
+		{TXID: "pay", Category: "send", Vout: 1, Amount: -2, Fee: -0.0002, Confirmations: 10},
+		{TXID: "self", Category: "send", Vout: 0, Amount: -4, Fee: -0.0001, Confirmations: 5},
+		{TXID: "self", Category: "receive", Vout: 0, Amount: 4, Confirmations: 5},
+		{TXID: "new", Category: "immature", Amount: 50, Confirmations: 3},
+	}
+	for _, watchonly := range []bool{false, true} {
+		includeWatchonly = watchonly
+		var r = checkBalance(node.url(), "rig1", txList)
+		if r.status != statusPass {
+			t.Errorf("got %s: %s", r.status, r.message)
+		}
+		if len(asked) != 3 || asked[2] != watchonly {
+			t.Errorf("--include-watchonly %v: getbalance asked with %v", watchonly, asked)
+		}
+	}
+	includeWatchonly = false
+
+	txList = append(txList, &Transaction{TXID: "lost", Category: "send", Amount: -1, Confirmations: 1})
+	if r := checkBalance(node.url(), "rig1", txList); r.status != statusWarn || !strings.Contains(r.message, "differs") {
+		t.Errorf("a mismatch: got %s: %s", r.status, r.message)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Nerdmaster/dynamo-tx-stats/stats"
+)
+
+// heatmapBarWidth is how many characters the busiest hour's bar gets
+const heatmapBarWidth = 40
+
+// heatmapView is the hour-of-day profile over the whole window.  Both
+// weightings are kept so JSON consumers get both, whichever is displayed.
+type heatmapView struct {
+	Weight string      `json:"weight"`
+	Amount [24]float64 `json:"amount"`
+	Blocks [24]int64   `json:"blocks"`
+}
+
+func parseHeatmapWeight(s string) (string, error) {
+	switch s {
+	case "amount", "blocks":
+		return s, nil
+	}
+	return "", fmt.Errorf("expected amount or blocks, got %q", s)
+}
+
+func newHeatmapView(report stats.Report, weight string) *heatmapView {
+	var v = &heatmapView{Weight: weight}
+	for h, b := range report.HourOfDay() {
+		v.Amount[h] = b.Coins
+		v.Blocks[h] = b.Blocks
+	}
+	return v
+}
+
+// values returns the displayed weighting
+func (v *heatmapView) values() [24]float64 {
+	if v.Weight == "amount" {
+		return v.Amount
+	}
+	var out [24]float64
+	for h, n := range v.Blocks {
+		out[h] = float64(n)
+	}
+	return out
+}
+
+func (v *heatmapView) print(w io.Writer) {
+	var values = v.values()
+	var max float64
+	for _, x := range values {
+		if x > max {
+			max = x
+		}
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Hour of day (by %s):\n", v.Weight)
+	for h, x := range values {
+		var n int
+		if max > 0 {
+			n = int(x / max * heatmapBarWidth)
+		}
+		var value = fmt.Sprintf("%8.2f", x)
+		if v.Weight == "blocks" {
+			value = fmt.Sprintf("%8d", v.Blocks[h])
+		}
+		fmt.Fprintf(w, "%02d:00 %s %s\n", h, value, strings.Repeat("#", n))
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Nerdmaster/dynamo-tx-stats/stats"
+)
+
+// heatmapReport has two blocks at 09:00, on different days, one at 14:00,
+// and only a pool payout at 03:00
+func heatmapReport() stats.Report {
+	var start = time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	var acc = stats.NewAccumulator(start, 2)
+	acc.Add(stats.Transaction{TXID: "a", Amount: 5, Blockheight: 100, Time: start.Add(9 * time.Hour)})
+	acc.Add(stats.Transaction{TXID: "b", Amount: 5, Blockheight: 240, Time: start.Add(33*time.Hour + 20*time.Minute)})
+	acc.Add(stats.Transaction{TXID: "c", Amount: 5, Blockheight: 130, Time: start.Add(14 * time.Hour)})
+	acc.Add(stats.Transaction{TXID: "d", Amount: 20, Time: start.Add(3 * time.Hour), Payout: true})
+	return acc.Snapshot()
+}
+
+func TestHeatmapWeight(t *testing.T) {
+	var tests = []struct {
+		weight string
+		lines  []string
+	}{
+		{"amount", []string{
+			"Hour of day (by amount):",
+			"03:00    20.00 ########################################",
+			"09:00    10.00 ####################",
+			"14:00     5.00 ##########",
+			"15:00     0.00 ",
+		}},
+		// The payout-only hour has coins but no blocks, so it's empty
+		// by blocks
+		{"blocks", []string{
+			"Hour of day (by blocks):",
+			"03:00        0 ",
+			"09:00        2 ########################################",
+			"14:00        1 ####################",
+		}},
+	}
+	for _, tt := range tests {
+		var weight, err = parseHeatmapWeight(tt.weight)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var v = newHeatmapView(heatmapReport(), weight)
+		if v.Amount[3] != 20 || v.Blocks[3] != 0 || v.Amount[9] != 10 || v.Blocks[9] != 2 {
+			t.Errorf("%s: got amounts %v, blocks %v", tt.weight, v.Amount, v.Blocks)
+		}
+		var buf bytes.Buffer
+		v.print(&buf)
+		var out = buf.String()
+		for _, line := range tt.lines {
+			if !strings.Contains(out, "\n"+line+"\n") {
+				t.Errorf("%s: no line %q in:\n%s", tt.weight, line, out)
+			}
+		}
+	}
+
+	var _, err = parseHeatmapWeight("coins")
+	if err == nil {
+		t.Error("--heatmap-weight coins was accepted")
+	}
+}
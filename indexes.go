@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+)
+
+// nodeIndex is one of the node's optional indexes, as getindexinfo reports
+// it
+type nodeIndex struct {
+	Synced          bool  `json:"synced"`
+	BestBlockHeight int64 `json:"best_block_height"`
+}
+
+// indexKeys maps the node option that turns an index on, which is how it's
+// named to the user, to getindexinfo's name for it
+var indexKeys = map[string]string{
+	"txindex":          "txindex",
+	"coinstatsindex":   "coinstatsindex",
+	"blockfilterindex": "basic block filter index",
+}
+
+// missingIndex says why feature can't be used when the node doesn't have
+// index, or, with synced, hasn't finished building it; it's "" when the
+// feature can go ahead.  A node from before getindexinfo (0.21) can't say,
+// nor can one that won't answer it, so the feature is left to find out from
+// its own calls as it always has.
+func missingIndex(u *url.URL, feature, index string, synced bool) string {
+	var indexes map[string]nodeIndex
+	var err = rpcCall(nodeURL(u), "getindexinfo", nil, &indexes)
+	if isMethodNotFound(err) {
+		return ""
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to check the node's indexes for %s: %s\n", feature, err)
+		return ""
+	}
+
+	var i, ok = indexes[indexKeys[index]]
+	if !ok {
+		return fmt.Sprintf("Feature %s requires %s (not available on this node; start it with -%s=1)", feature, index, index)
+	}
+	if synced && !i.Synced {
+		return fmt.Sprintf("Feature %s requires %s (still being built, up to block %d so far)", feature, index, i.BestBlockHeight)
+	}
+	return ""
+}
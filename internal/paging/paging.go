@@ -0,0 +1,96 @@
+// Package paging reads back through a listing that's paged from its newest
+// end, as listtransactions is, while the listing may change between pages.
+// It's shared by txstats and the dynstats client, so both page the same way.
+package paging
+
+// Pager pages back through a listing.  Pages are counted back from the
+// newest entry, so one that arrives between calls pushes the rest along and
+// the next page repeats the end of the last, and one a reorg takes away
+// pulls them back so the next page starts past where it should.  Each page
+// after the first overlaps the last by one entry to catch this: repeats are
+// dropped as they come, and a page that doesn't reach back to the overlap
+// is read once more with a page of slack.
+//
+// The overlap is what finds a shift, not the entries' block heights: a
+// wallet's blocks are rarely contiguous, so a gap in heights between pages
+// is the normal case, not a sign that anything was skipped.
+type Pager[T any] struct {
+	// Fetch returns count entries starting skip back from the newest,
+	// oldest first
+	Fetch func(skip, count int) ([]T, error)
+
+	// Key tells entries apart
+	Key func(T) string
+
+	// Older reports whether an entry is older than anything wanted.  It's
+	// left out, and the page it's on is the last one read.
+	Older func(T) bool
+
+	// PageSize is how many entries each Fetch asks for, and Limit how far
+	// back to go at most, or 0 for no limit
+	PageSize int
+	Limit    int
+
+	// Shifted, if set, is told when the listing moved by more than a page
+	// between calls, so entries around skip may be missing
+	Shifted func(skip int)
+}
+
+// All returns every entry that isn't Older, newest page first, each page
+// oldest first
+func (p Pager[T]) All() ([]T, error) {
+	var all []T
+	var seen = make(map[string]bool)
+	var boundary string
+	for skip := 0; p.Limit == 0 || skip < p.Limit; skip += p.PageSize {
+		var from, count = skip, p.PageSize
+		if boundary != "" {
+			from, count = skip-1, p.PageSize+1
+		}
+		var page, err = p.Fetch(from, count)
+		if err != nil {
+			return nil, err
+		}
+		if boundary != "" && !p.has(page, boundary) {
+			var back = p.PageSize
+			if back > from {
+				back = from
+			}
+			page, err = p.Fetch(from-back, count+back)
+			if err != nil {
+				return nil, err
+			}
+			if !p.has(page, boundary) && p.Shifted != nil {
+				p.Shifted(skip)
+			}
+		}
+
+		var older bool
+		for _, e := range page {
+			var key = p.Key(e)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if p.Older != nil && p.Older(e) {
+				older = true
+				continue
+			}
+			all = append(all, e)
+		}
+		if older || len(page) < count || len(page) == 0 {
+			break
+		}
+		boundary = p.Key(page[0])
+	}
+	return all, nil
+}
+
+func (p Pager[T]) has(page []T, key string) bool {
+	for _, e := range page {
+		if p.Key(e) == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+)
+
+// unlockSeconds is how long walletpassphrase unlocks a wallet for; the
+// wallet is locked again straight after, so this only bounds a crash
+const unlockSeconds = 60
+
+// refillKeypool tops up a legacy wallet's keypool, unlocking it first with
+// passphrase if one's given.  Descriptor wallets derive addresses as they
+// need them, so they're left alone.
+func refillKeypool(u *url.URL, wallet string, size int64, passphrase string) error {
+	var wu = walletURL(u, wallet)
+	var info walletInfo
+	var err = rpcCall(wu, "getwalletinfo", nil, &info)
+	if err != nil {
+		return err
+	}
+	if info.Descriptors {
+		fmt.Fprintf(os.Stderr, "%s: descriptor wallet, no keypool to refill; skipped\n", wallet)
+		return nil
+	}
+
+	if passphrase != "" {
+		err = rpcCall(wu, "walletpassphrase", []interface{}{passphrase, unlockSeconds}, nil)
+		if err != nil {
+			return err
+		}
+		defer rpcCall(wu, "walletlock", nil, nil)
+	}
+	var params []interface{}
+	if size > 0 {
+		params = []interface{}{size}
+	}
+	err = rpcCall(wu, "keypoolrefill", params, nil)
+	if err != nil {
+		return err
+	}
+	err = rpcCall(wu, "getwalletinfo", nil, &info)
+	if err != nil {
+		return err
+	}
+	if rpcRecorder == nil {
+		fmt.Fprintf(os.Stderr, "%s: Keypool refilled to %d keys\n", wallet, info.KeypoolSize)
+	}
+	return nil
+}
+
+// redactParams hides a passphrase from anything that prints or saves a call
+func redactParams(method string, params []interface{}) []interface{} {
+	if method != "walletpassphrase" || len(params) == 0 {
+		return params
+	}
+	var safe = append([]interface{}(nil), params...)
+	safe[0] = "********"
+	return safe
+}
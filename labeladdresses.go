@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+)
+
+// rpcWalletInvalidLabelName is the node's error code for a label no address
+// in the wallet has
+const rpcWalletInvalidLabelName = -11
+
+// labelAddress is one address filed under a label, and what the wallet
+// holds it for: "receive" for its own, "send" for someone else's it has
+// paid, "refund" for a refund address
+type labelAddress struct {
+	Address string
+	Purpose string
+}
+
+// fetchLabelAddresses asks the wallet at wu, via getaddressesbylabel, which
+// addresses are filed under label, in address order.  A label no address
+// has gives no addresses rather than an error.
+func fetchLabelAddresses(wu *url.URL, label string) ([]labelAddress, error) {
+	var result map[string]struct {
+		Purpose string `json:"purpose"`
+	}
+	var err = rpcCall(wu, "getaddressesbylabel", []interface{}{label}, &result)
+	var rerr *RPCError
+	if errors.As(err, &rerr) && rerr.Code == rpcWalletInvalidLabelName {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var list = make([]labelAddress, 0, len(result))
+	for addr, info := range result {
+		list = append(list, labelAddress{Address: addr, Purpose: info.Purpose})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Address < list[j].Address })
+	return list, nil
+}
+
+// printLabelAddresses lists the addresses filed under label
+func printLabelAddresses(label string, list []labelAddress) {
+	if len(list) == 0 {
+		fmt.Printf("No addresses labeled %q\n", label)
+		return
+	}
+	fmt.Printf("Addresses labeled %q:\n", label)
+	for _, a := range list {
+		fmt.Printf("  %-62s %s\n", a.Address, a.Purpose)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// legacyNoticeEnv, set to anything, silences the notice about the
+// positional form, for fleets whose scripts can't all be changed at once
+const legacyNoticeEnv = "TXSTATS_NO_LEGACY_NOTICE"
+
+// applyPositional reads the legacy <url> <username> <password> <report days>
+// <wallets...> arguments into cfg, exactly as they've always been read, so
+// scripts using them keep working: the report days are a plain whole
+// number, without --days' units.  Each overrides the matching flag or
+// config file setting.
+func applyPositional(cfg *config, rest []string) error {
+	if len(rest) >= 1 {
+		cfg.URL = rest[0]
+	}
+	if len(rest) >= 3 {
+		cfg.User, cfg.Password = rest[1], rest[2]
+	}
+	if len(rest) >= 4 {
+		var rdstr = rest[3]
+		cfg.ReportDays, _ = strconv.Atoi(rdstr)
+		if cfg.ReportDays == 0 {
+			return usageError(fmt.Sprintf("Invalid reporting days value %q", rdstr))
+		}
+		if cfg.ReportDays < 2 {
+			return usageError("Reporting days must be at least 2")
+		}
+	}
+	if len(rest) >= 5 {
+		cfg.Wallets = rest[4:]
+	}
+	return nil
+}
+
+// modernInvocation is the flags that do what the positional arguments rest
+// did.  The password, whether its own argument or in the URL, is left for
+// the caller to fill in, so the notice doesn't put it in a log.
+func modernInvocation(rest []string) string {
+	var parts = []string{filepath.Base(os.Args[0])}
+	if len(rest) >= 1 {
+		var raw = rest[0]
+		if u, err := url.Parse(raw); err == nil {
+			raw = u.Redacted()
+		}
+		parts = append(parts, "--url", shellQuote(raw))
+	}
+	if len(rest) >= 3 {
+		parts = append(parts, "--user", shellQuote(rest[1]), "--password", "<password>")
+	}
+	if len(rest) >= 4 {
+		parts = append(parts, "--days", shellQuote(rest[3]))
+	}
+	if len(rest) >= 5 {
+		for _, w := range rest[4:] {
+			parts = append(parts, "--wallet", shellQuote(w))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// noteLegacyForm tells the user, once, how to say the same thing with
+// flags.  It only writes to stderr, so stdout and the exit code are just
+// what they'd be without it.
+func noteLegacyForm(rest []string) {
+	if len(rest) == 0 || os.Getenv(legacyNoticeEnv) != "" {
+		return
+	}
+	fmt.Fprintln(os.Stderr, "NOTICE: positional <url> <username> <password> <report days> <wallets> arguments are deprecated; with flags this is:")
+	fmt.Fprintf(os.Stderr, "  %s\n", modernInvocation(rest))
+	fmt.Fprintf(os.Stderr, "(set %s=1 to silence this notice)\n", legacyNoticeEnv)
+}
+
+// shellSafe is what a shell argument can hold without quoting
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_./:@%+=,-]+$`)
+
+// shellQuote quotes s for a POSIX shell when it needs it
+func shellQuote(s string) string {
+	if shellSafe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
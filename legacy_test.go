@@ -0,0 +1,84 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The positional form keeps its old errors, in their old order, and exit
+// codes at every arity, and only gains a notice on stderr once it's been
+// read in full
+func TestLegacyArities(t *testing.T) {
+	var now = time.Now()
+	var node = newWalletNode(t, []map[string]interface{}{
+		generation("aa", 800050, 5, now.Add(-26*time.Hour)),
+		generation("bb", 800090, 5.25, now.Add(-3*time.Hour)),
+	})
+	var nodeURL = strings.Replace(node.URL, "://", "://u:p@", 1)
+
+	var tests = []struct {
+		args   []string
+		code   int
+		err    string
+		notice string
+	}{
+		{nil, exitUsage, "Not enough args", ""},
+		{[]string{node.URL}, exitUsage, "Not enough args", ""},
+		{[]string{node.URL, "u"}, exitUsage, "Not enough args", ""},
+		{[]string{node.URL, "u", "p"}, exitUsage, "Not enough args", ""},
+		{[]string{node.URL, "u", "p", "soon"}, exitUsage, "Not enough args", ""},
+		{[]string{node.URL, "u", "p", "soon", "rig1"}, exitUsage, `Invalid reporting days value "soon"`, ""},
+		{[]string{node.URL, "u", "p", "2w", "rig1"}, exitUsage, `Invalid reporting days value "2w"`, ""},
+		{[]string{node.URL, "u", "p", "1", "rig1"}, exitUsage, "Reporting days must be at least 2", ""},
+		{[]string{"http://node:9341/%zz", "u", "p", "1", "rig1"}, exitUsage, "Reporting days must be at least 2", ""},
+		{[]string{"http://node:9341/%zz", "u", "p", "3", "rig1"}, exitUsage, `Invalid URL "http://node:9341/%zz"`, "--wallet rig1"},
+		{[]string{nodeURL, "u", "p", "3", "rig1"}, exitOK, "", "--url " + strings.Replace(node.URL, "://", "://u:xxxxx@", 1) + " --user u --password <password> --days 3 --wallet rig1"},
+	}
+	for _, tt := range tests {
+		var code int
+		var stderr = capture(t, &os.Stderr, func() {
+			capture(t, &os.Stdout, func() { code = exitCode(run(tt.args)) })
+		})
+		if code != tt.code {
+			t.Errorf("%q: exit %d, want %d", tt.args, code, tt.code)
+		}
+		if tt.err != "" && !strings.Contains(stderr, tt.err) {
+			t.Errorf("%q: stderr doesn't say %q:\n%s", tt.args, tt.err, stderr)
+		}
+		var noticed = strings.Contains(stderr, "NOTICE: positional")
+		if noticed != (tt.notice != "") || !strings.Contains(stderr, tt.notice) {
+			t.Errorf("%q: want notice %q:\n%s", tt.args, tt.notice, stderr)
+		}
+		if strings.Contains(stderr, "u:p@") {
+			t.Errorf("%q: the notice shows the URL's password:\n%s", tt.args, stderr)
+		}
+		if strings.Count(stderr, "NOTICE: positional") > 1 {
+			t.Errorf("%q: noticed more than once:\n%s", tt.args, stderr)
+		}
+	}
+}
+
+// The two forms print the same report, and the notice can be silenced
+func TestLegacySameReport(t *testing.T) {
+	var now = time.Now()
+	var node = newWalletNode(t, []map[string]interface{}{
+		generation("aa", 800050, 5, now.Add(-26*time.Hour)),
+		generation("bb", 800090, 5.25, now.Add(-3*time.Hour)),
+	})
+	var flags, legacy, stderr string
+	flags = capture(t, &os.Stdout, func() {
+		run([]string{"--url", node.URL, "--user", "u", "--password", "p", "--days", "3", "--wallet", "rig1"})
+	})
+	t.Setenv(legacyNoticeEnv, "1")
+	stderr = capture(t, &os.Stderr, func() {
+		legacy = capture(t, &os.Stdout, func() { run([]string{node.URL, "u", "p", "3", "rig1"}) })
+	})
+	if legacy != flags {
+		t.Errorf("reports differ:\n--- flags\n%s\n--- positional\n%s", flags, legacy)
+	}
+	if stderr != "" {
+		t.Errorf("with %s set, stderr has:\n%s", legacyNoticeEnv, stderr)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+)
+
+// lowAddressScore is the highest score an address can have with no peer
+// having confirmed it.  The node starts an address it found on an
+// interface at 1, one it bound to at 2, one mapped through UPnP or NAT-PMP
+// at 3, and one given with -externalip at 4, and adds 1 each time a peer
+// says it sees the node there, so an address still at 1 has only the
+// interface's word for it.
+const lowAddressScore = 1
+
+// localAddressNote is what's worth flagging about a local address, or ""
+func localAddressNote(addr string, score int64) string {
+	var ip = net.ParseIP(addr)
+	switch {
+	case ip != nil && (ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast()):
+		return "  [PRIVATE: not reachable from the internet]"
+	case score <= lowAddressScore:
+		return "  [LOW: no peer has confirmed it; may not be reachable, e.g. behind NAT]"
+	}
+	return ""
+}
+
+// printLocalAddresses lists the addresses the node advertises to peers,
+// flagging those that may not be reachable.  A mining node wants inbound
+// connections so its blocks get out fast, and one behind NAT often has no
+// usable address at all without a port forward.
+func printLocalAddresses(u *url.URL) error {
+	var info networkInfo
+	var err = rpcCall(nodeURL(u), "getnetworkinfo", nil, &info)
+	if err != nil {
+		return err
+	}
+
+	if len(info.LocalAddresses) == 0 {
+		fmt.Println("No local addresses: the node isn't advertising one, so peers can't connect in")
+		fmt.Println("(is -listen off, or is the node behind NAT without -externalip or a port forward?)")
+	}
+	var low int
+	for _, a := range info.LocalAddresses {
+		var note = localAddressNote(a.Address, a.Score)
+		if note != "" {
+			low++
+		}
+		fmt.Printf("%-40s port %-5d score %d%s\n", a.Address, a.Port, a.Score, note)
+	}
+	if info.ConnectionsIn != nil {
+		fmt.Printf("Inbound connections: %d of %d\n", *info.ConnectionsIn, info.Connections)
+		if *info.ConnectionsIn == 0 {
+			fmt.Println("(none inbound, so the node may not be reachable from outside)")
+		}
+	}
+	if len(info.LocalAddresses) > 0 && low == len(info.LocalAddresses) {
+		fmt.Println("(no address a peer has confirmed; check the port forward, or set -externalip)")
+	}
+	return nil
+}
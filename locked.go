@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+)
+
+type LockedUnspent struct {
+	TXID string `json:"txid"`
+	Vout int64  `json:"vout"`
+}
+
+type txOut struct {
+	Value float64 `json:"value"`
+}
+
+// printLockedUTXOs lists how much of each wallet is locked away from coin
+// selection.  listlockunspent only gives outpoints, so each one is valued
+// with gettxout; an outpoint that's since been spent has no value to add.
+func printLockedUTXOs(u *url.URL, wallets []string) {
+	fmt.Println()
+	for _, w := range wallets {
+		var locked []LockedUnspent
+		var err = rpcCall(walletURL(u, w), "listlockunspent", nil, &locked)
+		if err != nil {
+			fmt.Printf("%s: Locked UTXOs: %s\n", w, err)
+			continue
+		}
+		if len(locked) == 0 {
+			fmt.Printf("%s: Locked UTXOs: 0\n", w)
+			continue
+		}
+
+		var total float64
+		for _, l := range locked {
+			var out *txOut
+			err = rpcCall(nodeURL(u), "gettxout", []interface{}{l.TXID, l.Vout, true}, &out)
+			if err != nil {
+				fmt.Printf("%s: locked UTXO %s:%d: %s\n", w, l.TXID, l.Vout, err)
+				continue
+			}
+			if out != nil {
+				total += out.Value
+			}
+		}
+		fmt.Printf("WARNING: %s: Locked UTXOs: %d (%0.2f coins locked, not spendable)\n", w, len(locked), total)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// parseOutpoint reads a --lock-utxo or --unlock-utxo value, txid:vout
+func parseOutpoint(s string) (LockedUnspent, error) {
+	var i = strings.LastIndex(s, ":")
+	if i < 0 {
+		return LockedUnspent{}, fmt.Errorf("expected txid:vout, got %q", s)
+	}
+	var txid, vout = s[:i], s[i+1:]
+	var raw, err = hex.DecodeString(txid)
+	if err != nil || len(raw) != 32 {
+		return LockedUnspent{}, fmt.Errorf("%q isn't a txid (64 hex digits)", txid)
+	}
+	var n int64
+	n, err = strconv.ParseInt(vout, 10, 32)
+	if err != nil || n < 0 {
+		return LockedUnspent{}, fmt.Errorf("%q isn't an output index", vout)
+	}
+	return LockedUnspent{TXID: strings.ToLower(txid), Vout: n}, nil
+}
+
+// parseOutpoints reads every --lock-utxo or --unlock-utxo value given
+func parseOutpoints(values []string) ([]LockedUnspent, error) {
+	var outs []LockedUnspent
+	for _, s := range values {
+		var o, err = parseOutpoint(s)
+		if err != nil {
+			return nil, err
+		}
+		outs = append(outs, o)
+	}
+	return outs, nil
+}
+
+// utxoLockChange is a --lock-utxo and --unlock-utxo run: the wallet's locked
+// outpoints before and after, and what was asked of it in between
+type utxoLockChange struct {
+	before   []LockedUnspent
+	after    []LockedUnspent
+	locked   []LockedUnspent
+	unlocked []LockedUnspent
+}
+
+// changeUTXOLocks locks and unlocks the given outpoints with lockunspent,
+// listing the wallet's locked outpoints either side.  The node takes each
+// list whole or not at all: locking one that's already locked, or
+// unlocking one that isn't, fails the lot.  A lock lasts until the node
+// restarts, as lockunspent isn't asked to persist it.
+func changeUTXOLocks(wu *url.URL, lock, unlock []LockedUnspent) (*utxoLockChange, error) {
+	var c = &utxoLockChange{locked: lock, unlocked: unlock}
+	var err = rpcCall(wu, "listlockunspent", nil, &c.before)
+	if err != nil {
+		return nil, err
+	}
+	var set = func(unlocking bool, outs []LockedUnspent) error {
+		if len(outs) == 0 {
+			return nil
+		}
+		var ok bool
+		var err = rpcCall(wu, "lockunspent", []interface{}{unlocking, outs}, &ok)
+		if err == nil && !ok && rpcRecorder == nil {
+			err = fmt.Errorf("lockunspent: the node didn't change them")
+		}
+		return err
+	}
+	err = set(false, lock)
+	if err != nil {
+		return nil, fmt.Errorf("locking: %w", err)
+	}
+	err = set(true, unlock)
+	if err != nil {
+		return nil, fmt.Errorf("unlocking: %w", err)
+	}
+	err = rpcCall(wu, "listlockunspent", nil, &c.after)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func printOutpoints(heading string, outs []LockedUnspent) {
+	fmt.Printf("%s: %d\n", heading, len(outs))
+	var sorted = append([]LockedUnspent(nil), outs...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].TXID != sorted[j].TXID {
+			return sorted[i].TXID < sorted[j].TXID
+		}
+		return sorted[i].Vout < sorted[j].Vout
+	})
+	for _, o := range sorted {
+		fmt.Printf("  %s:%d\n", o.TXID, o.Vout)
+	}
+}
+
+func printUTXOLockChange(c *utxoLockChange) {
+	printOutpoints("Locked before", c.before)
+	if len(c.locked) > 0 {
+		printOutpoints("Locked", c.locked)
+	}
+	if len(c.unlocked) > 0 {
+		printOutpoints("Unlocked", c.unlocked)
+	}
+	printOutpoints("Locked after", c.after)
+}
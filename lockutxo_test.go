@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParseOutpoint(t *testing.T) {
+	var txid = strings.Repeat("ab", 32)
+	var tests = []struct {
+		in   string
+		want LockedUnspent
+		err  string
+	}{
+		{txid + ":0", LockedUnspent{TXID: txid, Vout: 0}, ""},
+		{txid + ":17", LockedUnspent{TXID: txid, Vout: 17}, ""},
+		{strings.Repeat("aB", 32) + ":1", LockedUnspent{TXID: txid, Vout: 1}, ""},
+		{txid, LockedUnspent{}, `expected txid:vout, got "` + txid + `"`},
+		{"abcd:0", LockedUnspent{}, `"abcd" isn't a txid (64 hex digits)`},
+		{strings.Repeat("zz", 32) + ":0", LockedUnspent{}, `"` + strings.Repeat("zz", 32) + `" isn't a txid (64 hex digits)`},
+		{txid + ":-1", LockedUnspent{}, `"-1" isn't an output index`},
+		{txid + ":one", LockedUnspent{}, `"one" isn't an output index`},
+		{txid + ":", LockedUnspent{}, `"" isn't an output index`},
+	}
+	for _, tt := range tests {
+		var got, err = parseOutpoint(tt.in)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("%q: got error %v, want %q", tt.in, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%q: got %+v, %v; want %+v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+// --lock-utxo asks lockunspent to lock, with unlock false, and --unlock-utxo
+// to unlock, with unlock true, on the first wallet
+func TestLockUTXOCalls(t *testing.T) {
+	var a, b = strings.Repeat("aa", 32), strings.Repeat("bb", 32)
+	var mu sync.Mutex
+	var calls []string
+	var node = newFakeNode(t, map[string]fakeMethod{
+		"listlockunspent": func(string, []interface{}) (interface{}, *RPCError) {
+			return []LockedUnspent{{TXID: b, Vout: 1}}, nil
+		},
+		"lockunspent": func(path string, params []interface{}) (interface{}, *RPCError) {
+			mu.Lock()
+			defer mu.Unlock()
+			var o = params[1].([]interface{})[0].(map[string]interface{})
+			calls = append(calls, fmt.Sprintf("%s %v %.2s", path, params[0], o["txid"]))
+			return true, nil
+		},
+	})
+
+	var err error
+	var out = capture(t, &os.Stdout, func() {
+		err = run([]string{"--url", node.URL, "--user", "u", "--password", "p", "--wallet", "rig1",
+			"--lock-utxo", a + ":0", "--unlock-utxo", b + ":1"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want = []string{"/wallet/rig1 false aa", "/wallet/rig1 true bb"}
+	if strings.Join(calls, "; ") != strings.Join(want, "; ") {
+		t.Errorf("lockunspent calls: got %q, want %q", calls, want)
+	}
+	if !strings.Contains(out, "Locked: 1\n  "+a+":0\n") || !strings.Contains(out, "Unlocked: 1\n  "+b+":1\n") {
+		t.Errorf("output:\n%s", out)
+	}
+
+	var code int
+	capture(t, &os.Stderr, func() {
+		code = exitCode(run([]string{"--url", node.URL, "--user", "u", "--password", "p", "--lock-utxo", a + ":0", "--unlock-utxo", a + ":0"}))
+	})
+	if code != exitUsage {
+		t.Errorf("the same outpoint to both: exit %d, want %d", code, exitUsage)
+	}
+}
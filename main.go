@@ -1,22 +1,25 @@
 package main
 
 import (
-	"bytes"
 	"encoding/json"
+	"flag"
 	"fmt"
-	"io"
-	"net/http"
 	"net/url"
 	"os"
-	"strconv"
+	"path"
+	"regexp"
 	"strings"
 	"time"
+
+	"github.com/Nerdmaster/dynamo-tx-stats/stats"
 )
 
 type Transaction struct {
 	Address       string  `json:"address"`
 	Category      string  `json:"category"`
 	Amount        float64 `json:"amount"`
+	Fee           float64 `json:"fee"`
+	Vout          int64   `json:"vout"`
 	Label         string  `json:"label"`
 	Confirmations int64   `json:"confirmations"`
 	Generated     bool    `json:"generated"`
@@ -25,182 +28,1707 @@ type Transaction struct {
 	Blockindex    int64   `json:"blockindex"`
 	Blocktime     int64   `json:"blocktime"`
 	TXID          string  `json:"txid"`
+	IsWatchonly   bool    `json:"involvesWatchonly"`
+	Abandoned     bool    `json:"abandoned"`
 	dt            time.Time
+	source        string
+	wallet        string
+	imprecise     bool
+	archived      bool
+	pool          bool
 	Time          int64 `json:"time"`
 	TimeReceived  int64 `json:"timereceived"`
 }
 
-type StatData struct {
-	firstBlock int64
-	lastBlock  int64
-	duration   time.Duration
-	blocks     int64
-	coins      float64
+func getDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
 }
 
-func (s *StatData) record(tx *Transaction) {
-	if s.firstBlock == 0 || tx.Blockheight < s.firstBlock {
-		s.firstBlock = tx.Blockheight
+// parseAsOf reads an --as-of time in local time
+func parseAsOf(s string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02", time.RFC3339} {
+		var t, err = time.ParseInLocation(layout, s, time.Local)
+		if err == nil {
+			return t, nil
+		}
 	}
-	if tx.Blockheight > s.lastBlock {
-		s.lastBlock = tx.Blockheight
+	return time.Time{}, fmt.Errorf("unrecognized time %q; use \"YYYY-MM-DD HH:MM\"", s)
+}
+
+func usage(message string) {
+	if message != "" {
+		fmt.Fprintln(os.Stderr, message)
+		fmt.Fprintln(os.Stderr)
 	}
-	s.coins += tx.Amount
-	s.blocks++
+	fmt.Fprintf(os.Stderr, "Usage: %s [flags] --url <url> [--url <standby url>...] --user <username> --password <password> --days <report days> --wallet <name> [--wallet <name>...]\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s [flags] --url <url> --cookie-file <path> --days <report days> --wallet <name> [--wallet <name>...]\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s --config <file> [flags]\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s [flags] <url> <username> <password> <report days> <Wallet Name(s)...>   (deprecated)\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s compare-nodes [flags] <url-a> <url-b> <wallet>\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s serve [flags] <url> <username> <password> <days to keep> <Wallet Name(s)...>\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s diff [flags] <a.json> <b.json>\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s audit [flags] --tx-archive <file> --url <url> --user <username> --password <password> --wallet <name> [--wallet <name>...]\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s --simulate [flags] [<report days> [<Wallet Name(s)...>]]\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s replay <capture dir>\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s version\n", os.Args[0])
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Flags:")
+	flag.PrintDefaults()
+	fmt.Fprintln(os.Stderr)
+	printExitCodes(os.Stderr)
+}
+
+// listTransactionsCount is how many transactions listtransactions is asked
+// for; a wallet that returns exactly this many probably has older ones.  It's
+// a var so a test can fill the page without a hundred thousand of them.
+var listTransactionsCount = 100000
+
+// listCount is how many listtransactions is asked for this run: usually
+// listTransactionsCount, but allTimeCount with --all-time
+var listCount = listTransactionsCount
+
+// includeWatchonly, when set by --include-watchonly, has listtransactions
+// list the wallet's watch-only addresses' transactions too.  A legacy wallet
+// leaves them out unless asked; listsinceblock is always asked.
+var includeWatchonly bool
+
+func listTransactions(u *url.URL) ([]*Transaction, error) {
+	var txList []*Transaction
+	var err = rpcCall(u, "listtransactions", []interface{}{"*", listCount, 0, includeWatchonly}, &txList)
+	return txList, err
 }
 
-func (s *StatData) roughPercent() float64 {
-	if s.blocks == 0 {
-		return 0
+// unlabeled is the --filter-label spelling of the empty label, for shells
+// and config files where "" is awkward
+const unlabeled = "(unlabeled)"
+
+// filterByLabel keeps only the transactions whose label is one of labels
+func filterByLabel(list []*Transaction, labels []string) []*Transaction {
+	var keep = make(map[string]bool)
+	for _, l := range labels {
+		if l == unlabeled {
+			l = ""
+		}
+		keep[l] = true
+	}
+	var out []*Transaction
+	for _, tx := range list {
+		if keep[tx.Label] {
+			out = append(out, tx)
+		}
 	}
-	return 100.0 * (float64(s.blocks) / float64(s.lastBlock-s.firstBlock+1))
+	return out
 }
 
-func getDay(t time.Time) time.Time {
-	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+// truncatedSince returns the time of the oldest transaction in a full page
+// of listtransactions results; anything before it may be missing
+func truncatedSince(list []*Transaction, field string) (time.Time, bool) {
+	if len(list) < listCount {
+		return time.Time{}, false
+	}
+	return oldestTxTime(list, field), true
 }
 
-func usage(message string) {
-	fmt.Fprintln(os.Stderr, message)
-	fmt.Fprintln(os.Stderr)
-	fmt.Fprintf(os.Stderr, "Usage: %s <url> <username> <password> <report days> <Wallet Name(s)...>\n", os.Args[0])
-	os.Exit(1)
+// oldestTxTime is the time of the list's oldest transaction
+func oldestTxTime(list []*Transaction, field string) time.Time {
+	var oldest = txTime(list[0], field)
+	for _, tx := range list[1:] {
+		if t := txTime(tx, field); t.Before(oldest) {
+			oldest = t
+		}
+	}
+	return oldest
 }
 
-func fetchTX(u *url.URL) []*Transaction {
-	var resp struct {
-		Results []*Transaction `json:"result"`
+// listSinceBlock returns the wallet's transactions in blocks after hash,
+// plus any still unconfirmed, and those a reorg has since taken out of the
+// chain
+func listSinceBlock(u *url.URL, hash string) ([]*Transaction, []*Transaction, error) {
+	var result struct {
+		Transactions []*Transaction `json:"transactions"`
+		Removed      []*Transaction `json:"removed"`
 	}
+	var err = rpcCall(u, "listsinceblock", []interface{}{hash, 1, false, true}, &result)
+	return result.Transactions, result.Removed, err
+}
 
-	var data = bytes.NewBufferString(`{"jsonrpc":"1.0","id":"curltest","method":"listtransactions","params":["*", 100000, 0]}`)
-	var err = doPost(u, data, &resp)
+// dropReorged takes the generations listsinceblock reported as removed out
+// of the accumulator, logging each, and returns how many fell in the
+// window.  A removed transaction that was mined again has positive
+// confirmations and still counts.
+func dropReorged(acc *stats.Accumulator, removed []*Transaction, field string, begin, now time.Time) int {
+	var n int
+	for _, tx := range removed {
+		if !tx.Generated || tx.Confirmations > 0 {
+			continue
+		}
+		tx.dt = txTime(tx, field)
+		acc.Remove(tx.TXID)
+		if tx.dt.Before(begin) || tx.dt.After(now) {
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "Reorged out: %s (%0.8f at %s)\n", tx.TXID, tx.Amount, tx.dt.Format(dateTimeLayout))
+		n++
+	}
+	return n
+}
+
+// txTime returns the transaction's time according to --time-field
+func txTime(tx *Transaction, field string) time.Time {
+	switch field {
+	case "blocktime":
+		if tx.Blocktime != 0 {
+			return time.Unix(tx.Blocktime, 0)
+		}
+	case "time":
+		return time.Unix(tx.Time, 0)
+	}
+	return time.Unix(tx.TimeReceived, 0)
+}
+
+// countable reports whether tx is a matured block reward, or a
+// --pool-address payout, received by now
+func countable(tx *Transaction, now time.Time) bool {
+	return !tx.dt.After(now) && (tx.Generated || tx.pool) && tx.Confirmations >= 2
+}
+
+// applyRPCSettings sets up the RPC layer from the flags that tune it
+func applyRPCSettings(cfg *config) error {
+	// Nothing is kept from an earlier run in the same process, as replay's
+	// is, or the next one's in a test
+	listCount = listTransactionsCount
+	walletAuth = make(map[string]*url.Userinfo)
+	rpcFailover, rpcRecorder, rpcCapture, rpcSimulation = nil, nil, nil, nil
+
+	var err error
+	maxResponseSize, err = parseByteSize(cfg.MaxResponse)
+	if err != nil {
+		return usageError("Invalid --max-response: " + err.Error())
+	}
+	rpcIDPrefix = cfg.RPCIDPrefix
+	rpcPathPrefix = cleanPathPrefix(cfg.PathPrefix)
+	traceRPC = cfg.VerboseTiming
+	verboseSchema = cfg.VerboseSchema
+	includeWatchonly = cfg.IncludeWatchonly
+	if len(cfg.FailoverURLs) > 0 {
+		rpcFailover, err = newFailover(append([]string{cfg.URL}, cfg.FailoverURLs...))
+		if err != nil {
+			return err
+		}
+	}
+	if cfg.RPCRate < 0 {
+		return usageError(fmt.Sprintf("Invalid --rpc-rate-limit %g", cfg.RPCRate))
+	}
+	rpcLimit = newRateLimiter(cfg.RPCRate)
+	plainSeconds = cfg.Seconds
+	err = checkTimeLayouts(cfg)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Unable to POST to URL %q: %s", u.String(), err)
-		os.Exit(2)
+		return err
+	}
+	dateLayout, dateTimeLayout = cfg.DateFormat, cfg.DateTimeFormat
+	for _, wa := range cfg.WalletAuth {
+		var wallet, auth, err = parseWalletAuth(wa)
+		if err != nil {
+			return usageError("Invalid --wallet-auth: " + err.Error())
+		}
+		walletAuth[wallet] = auth
+	}
+	if cfg.simulate {
+		err = startSimulation(cfg)
+		if err != nil {
+			return err
+		}
+	}
+	if cfg.dryRun {
+		rpcRecorder = printPlannedCall
+	}
+	if cfg.debugCapture != "" && !cfg.dryRun {
+		rpcCapture, err = openCapture(cfg.debugCapture, cfg.anonymize)
+		if err != nil {
+			return failure(exitUsage, "Unable to create debug capture directory %q: %s", cfg.debugCapture, err)
+		}
+		rpcCapture.writeManifest(cfg, cfg.Wallets, clock())
 	}
+	return nil
+}
 
-	return resp.Results
+// nodeFromConfig returns the node's URL with the credentials filled in
+func nodeFromConfig(cfg *config) (*url.URL, error) {
+	if cfg.URL == "" || (cfg.User == "" && cfg.CookieFile == "") {
+		return nil, usageError("Not enough args")
+	}
+	if cfg.CookieFile != "" && (cfg.User != "" || cfg.Password != "") {
+		return nil, usageError("--cookie-file can't be used with --user or --password")
+	}
+	var u, err = url.Parse(cfg.URL)
+	if err != nil {
+		return nil, usageError(fmt.Sprintf("Invalid URL %q: %s", cfg.URL, err))
+	}
+	u.User = url.UserPassword(cfg.User, cfg.Password)
+	if cfg.CookieFile != "" {
+		var user, password string
+		user, password, err = readCookieFile(cfg.CookieFile)
+		if err != nil {
+			return nil, failure(exitUsage, "Unable to read cookie file %q: %s", cfg.CookieFile, err)
+		}
+		u.User = url.UserPassword(user, password)
+	}
+	return u, nil
+}
+
+// resolveWallets gathers the wallet list from the arguments, --wallets-file,
+// and --auto-wallets
+func resolveWallets(u *url.URL, cfg *config) ([]string, error) {
+	var wallets = append([]string(nil), cfg.Wallets...)
+	if cfg.WalletsFile != "" {
+		var fromFile, err = readListFile(cfg.WalletsFile)
+		if err != nil {
+			return nil, usageError(fmt.Sprintf("Unable to read wallets file %q: %s", cfg.WalletsFile, err))
+		}
+		wallets = append(wallets, fromFile...)
+	}
+
+	var dupes []string
+	wallets, dupes = dedupeWallets(wallets)
+	if len(dupes) > 0 && cfg.Strict {
+		return nil, usageError(fmt.Sprintf("Wallet(s) named more than once: %s", strings.Join(dupes, ", ")))
+	}
+	if len(dupes) > 0 {
+		fmt.Fprintf(os.Stderr, "WARNING: wallet(s) named more than once, counting each once: %s\n", strings.Join(dupes, ", "))
+	}
+
+	if cfg.AutoWallets {
+		var walletFilter, err = compileWalletPattern("wallet-filter", cfg.WalletFilter)
+		if err != nil {
+			return nil, err
+		}
+		var walletExclude *regexp.Regexp
+		walletExclude, err = compileWalletPattern("wallet-exclude", cfg.WalletExclude)
+		if err != nil {
+			return nil, err
+		}
+		var found []string
+		found, err = discoverWallets(u, walletFilter, walletExclude)
+		if err != nil {
+			return nil, failure(exitRPC, "Unable to list wallets: %s", err)
+		}
+		found = describeWallets(u, found, cfg.SkipEmptyWallets)
+		if cfg.dryRun {
+			fmt.Println("RPC   (each discovered wallet also gets the per-wallet calls below)")
+		}
+		// A discovered wallet that was also named is expected, not a mistake
+		wallets, _ = dedupeWallets(append(wallets, found...))
+	}
+	if cfg.WalletGlob != "" {
+		if _, err := path.Match(cfg.WalletGlob, ""); err != nil {
+			return nil, usageError(fmt.Sprintf("Invalid --wallet-glob pattern %q: %s", cfg.WalletGlob, err))
+		}
+		var found, err = globWallets(u, cfg.WalletGlob)
+		if err != nil {
+			return nil, failure(exitRPC, "Unable to list the wallet directory: %s", err)
+		}
+		if len(found) == 0 && !cfg.dryRun {
+			return nil, failure(exitUsage, "No wallets match --wallet-glob %q", cfg.WalletGlob)
+		}
+		if cfg.dryRun {
+			fmt.Println("RPC   (each wallet matching --wallet-glob also gets the per-wallet calls below)")
+		}
+		wallets, _ = dedupeWallets(append(wallets, found...))
+	}
+	if len(wallets) == 0 && !cfg.dryRun {
+		return nil, failure(exitUsage, "No wallets to report on")
+	}
+	return wallets, nil
 }
 
 func main() {
-	if len(os.Args) < 6 {
-		usage("Not enough args")
+	os.Exit(exitCode(run(os.Args[1:])))
+}
+
+// run is the whole program short of exiting: every failure comes back as an
+// error carrying its exit code
+func run(args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "version":
+			printVersion()
+			return nil
+		case "compare-nodes":
+			return runCompareNodes(args[1:])
+		case "serve":
+			return runServe(args[1:])
+		case "diff":
+			return runDiff(args[1:])
+		case "replay":
+			return runReplay(args[1:])
+		case "audit":
+			return runAudit(args[1:])
+		}
+	}
+
+	var cfg, err = parseConfig(args)
+	if err != nil {
+		return err
+	}
+	if cfg.showVersion {
+		printVersion()
+		return nil
+	}
+	return runConfig(cfg)
+}
+
+// runConfig is everything after the flags and config file have been read,
+// so replay can run a captured config the same way
+func runConfig(cfg *config) error {
+	var err = applyRPCSettings(cfg)
+	if err != nil {
+		return err
+	}
+	if cfg.Splay < 0 {
+		return usageError(fmt.Sprintf("Invalid --splay %s", cfg.Splay))
+	}
+	// The timing footer is about the run's own work, not the wait
+	splay(cfg)
+	var started = time.Now()
+
+	if len(cfg.Sources) > 0 {
+		var now = clock()
+		if cfg.AsOf != "" {
+			now, err = parseAsOf(cfg.AsOf)
+			if err != nil {
+				return usageError("Invalid --as-of: " + err.Error())
+			}
+		}
+		return runSources(cfg, now)
+	}
+
+	var u *url.URL
+	u, err = nodeFromConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	if cfg.saveConfig != "" && !cfg.dryRun {
+		err = cfg.save(cfg.saveConfig)
+		if err != nil {
+			return failure(exitUsage, "Unable to save config to %q: %s", cfg.saveConfig, err)
+		}
+	}
+
+	if cfg.Interactive {
+		if cfg.dryRun {
+			return usageError("--interactive can't be used with --dry-run")
+		}
+		return runInteractive(u, cfg)
+	}
+
+	if cfg.BlockHeader != "" {
+		var h *BlockHeader
+		h, err = fetchBlockHeader(u, cfg.BlockHeader)
+		if err != nil {
+			return failure(exitRPC, "Unable to fetch block header %q: %s", cfg.BlockHeader, err)
+		}
+		if cfg.dryRun {
+			printPlannedOutputs(cfg)
+			return nil
+		}
+		printBlockHeader(h)
+		return nil
+	}
+
+	if cfg.BlockFilter != "" {
+		if why := missingIndex(u, "--block-filter", "blockfilterindex", false); why != "" {
+			return failure(exitRPC, "%s", why)
+		}
+		var f *blockFilter
+		f, err = fetchBlockFilter(u, cfg.BlockFilter)
+		if err != nil {
+			return failure(exitRPC, "Unable to fetch the filter of block %q: %s", cfg.BlockFilter, err)
+		}
+		if cfg.dryRun {
+			printPlannedOutputs(cfg)
+			return nil
+		}
+		printBlockFilter(cfg.BlockFilter, f)
+		return nil
+	}
+
+	if cfg.FilterStats < 0 {
+		return usageError(fmt.Sprintf("Invalid --filter-stats %d", cfg.FilterStats))
+	}
+	if cfg.FilterStats > 0 {
+		if why := missingIndex(u, "--filter-stats", "blockfilterindex", true); why != "" {
+			return failure(exitRPC, "%s", why)
+		}
+		var s *filterStats
+		s, err = fetchFilterStats(u, cfg.FilterStats)
+		if err != nil {
+			return failure(exitRPC, "Unable to fetch block filters: %s", err)
+		}
+		if cfg.dryRun {
+			printPlannedOutputs(cfg)
+			return nil
+		}
+		printFilterStats(s)
+		return partialResult(s.Failed > 0)
+	}
+
+	if cfg.ScanDescriptor != "" {
+		if !cfg.Confirm && !cfg.dryRun {
+			return failure(exitUsage, "--scan-descriptor reads the whole UTXO set and can take several minutes; add --confirm to run it")
+		}
+		if !cfg.dryRun {
+			fmt.Fprintln(os.Stderr, "Scanning the UTXO set; this can take several minutes")
+		}
+		var r *scanResult
+		r, err = scanDescriptor(u, cfg.ScanDescriptor)
+		if err != nil {
+			return failure(exitRPC, "Unable to scan the UTXO set: %s", err)
+		}
+		if cfg.dryRun {
+			printPlannedOutputs(cfg)
+			return nil
+		}
+		if !r.Success {
+			return failure(exitRPC, "The UTXO set scan was aborted")
+		}
+		printScanResult(r)
+		return nil
+	}
+
+	if cfg.CPFPCheck != "" {
+		var p *cpfpPackage
+		p, err = fetchCPFPPackage(u, cfg.CPFPCheck)
+		if err != nil {
+			return failure(exitRPC, "Unable to fetch the mempool package of %q: %s", cfg.CPFPCheck, err)
+		}
+		if cfg.dryRun {
+			printPlannedOutputs(cfg)
+			return nil
+		}
+		printCPFP(p)
+		return nil
+	}
+
+	if cfg.MempoolEntry != "" {
+		var e *MempoolEntry
+		e, err = fetchMempoolEntry(u, cfg.MempoolEntry)
+		if err != nil {
+			return failure(exitRPC, "Unable to look up %q in the mempool: %s", cfg.MempoolEntry, err)
+		}
+		if cfg.dryRun {
+			printPlannedOutputs(cfg)
+			return nil
+		}
+		printMempoolEntry(cfg.MempoolEntry, e, clock())
+		return nil
+	}
+
+	if cfg.TxProof != "" {
+		var wallet string
+		if len(cfg.Wallets) > 0 {
+			wallet = cfg.Wallets[0]
+		}
+		var p *txProof
+		p, err = fetchTxProof(u, cfg.TxProof, wallet)
+		if err != nil {
+			// Without txindex a proof can still be had for a transaction
+			// with unspent outputs, or one the wallet has, so the index
+			// only explains the failure rather than ruling out the attempt
+			if why := missingIndex(u, "--tx-proof", "txindex", true); why != "" {
+				return failure(exitRPC, "Unable to get a proof for %q: %s\n%s", cfg.TxProof, err, why)
+			}
+			return failure(exitRPC, "Unable to get a proof for %q: %s", cfg.TxProof, err)
+		}
+		if cfg.dryRun {
+			printPlannedOutputs(cfg)
+			return nil
+		}
+		printTxProof(p)
+		if !p.valid {
+			return exitWith(exitAssertion)
+		}
+		return nil
+	}
+
+	if cfg.LabelAddresses != "" {
+		var wu = nodeURL(u)
+		if len(cfg.Wallets) > 0 {
+			wu = walletURL(u, cfg.Wallets[0])
+		}
+		var list []labelAddress
+		list, err = fetchLabelAddresses(wu, cfg.LabelAddresses)
+		if err != nil {
+			return failure(exitRPC, "Unable to look up the addresses labeled %q: %s", cfg.LabelAddresses, err)
+		}
+		if cfg.dryRun {
+			printPlannedOutputs(cfg)
+			return nil
+		}
+		printLabelAddresses(cfg.LabelAddresses, list)
+		return nil
+	}
+
+	if cfg.SendFee != "" {
+		var outs []sendOutput
+		outs, err = parseSendOutputs(cfg.SendFee)
+		if err != nil {
+			return usageError("Invalid --estimate-send-fee: " + err.Error())
+		}
+		var wu = nodeURL(u)
+		if len(cfg.Wallets) > 0 {
+			wu = walletURL(u, cfg.Wallets[0])
+		}
+		var e *sendFeeEstimate
+		e, err = estimateSendFee(wu, outs)
+		if err != nil {
+			return failure(exitRPC, "Unable to estimate the send fee: %s", err)
+		}
+		if cfg.dryRun {
+			printPlannedOutputs(cfg)
+			return nil
+		}
+		printSendFee(e)
+		return nil
+	}
+
+	if cfg.FeeRateSats != 0 && !cfg.Consolidate {
+		return usageError("--fee-rate-sats only applies to --consolidate")
+	}
+	if cfg.Consolidate {
+		if cfg.FeeRateSats <= 0 {
+			return usageError("--consolidate needs a --fee-rate-sats above zero")
+		}
+		var wu = nodeURL(u)
+		if len(cfg.Wallets) > 0 {
+			wu = walletURL(u, cfg.Wallets[0])
+		}
+		var c *consolidation
+		c, err = simulateConsolidation(wu, cfg.FeeRateSats)
+		if err != nil {
+			return failure(exitRPC, "Unable to list the wallet's UTXOs: %s", err)
+		}
+		if cfg.dryRun {
+			printPlannedOutputs(cfg)
+			return nil
+		}
+		printConsolidation(c)
+		return nil
+	}
+
+	if cfg.SignTest != "" {
+		var wu = nodeURL(u)
+		if len(cfg.Wallets) > 0 {
+			wu = walletURL(u, cfg.Wallets[0])
+		}
+		err = checkRawHex(cfg.SignTest)
+		if err != nil {
+			return usageError("Invalid --sign-test: " + err.Error())
+		}
+		var t *signTest
+		t, err = runSignTest(wu, cfg.SignTest)
+		if err != nil {
+			return failure(exitRPC, "Unable to test signing: %s", err)
+		}
+		if cfg.dryRun {
+			printPlannedOutputs(cfg)
+			return nil
+		}
+		printSignTest(t)
+		return nil
+	}
+
+	if cfg.CreatePSBT != "" {
+		var outputs json.RawMessage
+		outputs, err = parsePSBTOutputs(cfg.CreatePSBT)
+		if err != nil {
+			return usageError("Invalid --create-psbt: " + err.Error())
+		}
+		var wu = nodeURL(u)
+		if len(cfg.Wallets) > 0 {
+			wu = walletURL(u, cfg.Wallets[0])
+		}
+		var p *fundedPSBT
+		p, err = createFundedPSBT(wu, outputs)
+		if err != nil {
+			return failure(exitRPC, "Unable to create the PSBT: %s", err)
+		}
+		if cfg.dryRun {
+			printPlannedOutputs(cfg)
+			return nil
+		}
+		printFundedPSBT(p)
+		return nil
+	}
+
+	if len(cfg.LockUTXOs) > 0 || len(cfg.UnlockUTXOs) > 0 {
+		var lock, unlock []LockedUnspent
+		lock, err = parseOutpoints(cfg.LockUTXOs)
+		if err != nil {
+			return usageError("Invalid --lock-utxo: " + err.Error())
+		}
+		unlock, err = parseOutpoints(cfg.UnlockUTXOs)
+		if err != nil {
+			return usageError("Invalid --unlock-utxo: " + err.Error())
+		}
+		for _, l := range lock {
+			for _, ul := range unlock {
+				if l == ul {
+					return usageError(fmt.Sprintf("%s:%d given to both --lock-utxo and --unlock-utxo", l.TXID, l.Vout))
+				}
+			}
+		}
+		var wu = nodeURL(u)
+		if len(cfg.Wallets) > 0 {
+			wu = walletURL(u, cfg.Wallets[0])
+		}
+		var c *utxoLockChange
+		c, err = changeUTXOLocks(wu, lock, unlock)
+		if err != nil {
+			return failure(exitRPC, "Unable to change the wallet's UTXO locks: %s", err)
+		}
+		if cfg.dryRun {
+			printPlannedOutputs(cfg)
+			return nil
+		}
+		printUTXOLockChange(c)
+		return nil
 	}
 
-	var urlString, user, pass, rdstr = os.Args[1], os.Args[2], os.Args[3], os.Args[4]
-	var reportDays, _ = strconv.Atoi(rdstr)
-	if reportDays == 0 {
-		usage(fmt.Sprintf("Invalid reporting days value %q", rdstr))
+	if cfg.BackupCheck && cfg.BackupPath == "" {
+		return usageError("--backup-verify needs --backup-wallet")
 	}
+	if cfg.BackupPath != "" {
+		var wu = nodeURL(u)
+		if len(cfg.Wallets) > 0 {
+			wu = walletURL(u, cfg.Wallets[0])
+		}
+		var b *walletBackup
+		b, err = backupWallet(wu, cfg.BackupPath)
+		if err != nil {
+			return failure(exitRPC, "Unable to back the wallet up to %q: %s", cfg.BackupPath, err)
+		}
+		if cfg.BackupCheck {
+			err = b.verify(u)
+			if err != nil {
+				return failure(exitRPC, "Unable to verify the backup %q: %s", cfg.BackupPath, err)
+			}
+		}
+		if cfg.dryRun {
+			printPlannedOutputs(cfg)
+			return nil
+		}
+		printBackup(b)
+		return nil
+	}
+
+	if cfg.HeightToHash >= 0 {
+		var hash string
+		hash, err = fetchBlockHash(u, cfg.HeightToHash)
+		if err != nil {
+			return failure(exitRPC, "Unable to fetch the hash of block %d: %s", cfg.HeightToHash, err)
+		}
+		if cfg.dryRun {
+			printPlannedOutputs(cfg)
+			return nil
+		}
+		fmt.Println(hash)
+		return nil
+	}
+
+	if cfg.UTXOSet || cfg.UTXOSetIndex {
+		if cfg.UTXOSetIndex {
+			if why := missingIndex(u, "--utxo-set-index", "coinstatsindex", true); why != "" {
+				return failure(exitRPC, "%s", why)
+			}
+		}
+		err = printUTXOSet(u, cfg.UTXOSetIndex)
+		if err != nil {
+			return failure(exitRPC, "Unable to fetch UTXO set info: %s", err)
+		}
+		if cfg.dryRun {
+			printPlannedOutputs(cfg)
+		}
+		return nil
+	}
+
+	if cfg.MiningInfo {
+		err = printMiningInfo(u)
+		if err != nil {
+			return failure(exitRPC, "Unable to fetch mining info: %s", err)
+		}
+		if cfg.dryRun {
+			printPlannedOutputs(cfg)
+		}
+		return nil
+	}
+
+	if cfg.LocalAddresses {
+		err = printLocalAddresses(u)
+		if err != nil {
+			return failure(exitRPC, "Unable to fetch network info: %s", err)
+		}
+		if cfg.dryRun {
+			printPlannedOutputs(cfg)
+		}
+		return nil
+	}
+
+	if cfg.ZMQInfo {
+		err = printZMQInfo(u)
+		if err != nil {
+			return failure(exitRPC, "Unable to fetch ZMQ notifications: %s", err)
+		}
+		if cfg.dryRun {
+			printPlannedOutputs(cfg)
+		}
+		return nil
+	}
+
+	if cfg.PeerLatency {
+		err = printPeerLatency(u)
+		if err != nil {
+			return failure(exitRPC, "Unable to fetch peer info: %s", err)
+		}
+		if cfg.dryRun {
+			printPlannedOutputs(cfg)
+		}
+		return nil
+	}
+
+	if cfg.NodeInfo {
+		err = printNodeInfo(u, cfg.BannedPeers, cfg.Peers)
+		if err != nil {
+			return failure(exitRPC, "Unable to fetch node info: %s", err)
+		}
+		if cfg.dryRun {
+			printPlannedOutputs(cfg)
+		}
+		return nil
+	}
+
+	var picked []string
+	if cfg.ReportDays != 0 && len(cfg.Wallets) == 0 && cfg.WalletsFile == "" && !cfg.AutoWallets && cfg.WalletGlob == "" && cfg.CompareWallet == "" && canPickWallets(cfg) {
+		picked, err = pickWallets(u, os.Stdin, os.Stderr)
+		if err != nil {
+			return err
+		}
+		cfg.Wallets = picked
+	}
+	if cfg.ReportDays == 0 || (len(cfg.Wallets) == 0 && cfg.WalletsFile == "" && !cfg.AutoWallets && cfg.WalletGlob == "" && cfg.CompareWallet == "") {
+		return usageError("Not enough args")
+	}
+	var reportDays = cfg.ReportDays
 	if reportDays < 2 {
-		usage("Reporting days must be at least 2")
+		return usageError("Reporting days must be at least 2")
+	}
+	var now = clock()
+	if rpcReplay == nil && rpcSimulation == nil {
+		var offset = checkClockSkew(u, cfg.MaxSkew)
+		if cfg.UseNodeTime {
+			now = now.Add(offset)
+		}
+	}
+	if cfg.AsOf != "" {
+		now, err = parseAsOf(cfg.AsOf)
+		if err != nil {
+			return usageError("Invalid --as-of: " + err.Error())
+		}
 	}
-	var wallets = os.Args[5:]
 
-	// Lazy-man's deduping: use a map and rewrite the whole thing!
-	var uniqueWallets = make(map[string]bool)
-	for _, w := range wallets {
-		uniqueWallets[w] = true
+	err = checkBucketFormats(cfg, now)
+	if err != nil {
+		return err
+	}
+	if cfg.Narrow && cfg.Wide {
+		return usageError("--narrow and --wide can't be used together")
+	}
+	switch cfg.TimeField {
+	case "timereceived", "blocktime", "time":
+	default:
+		return usageError(fmt.Sprintf("Invalid --time-field %q", cfg.TimeField))
+	}
+
+	var weekStartDay time.Weekday
+	weekStartDay, err = parseWeekday(cfg.WeekStart)
+	if err != nil {
+		return usageError(err.Error())
+	}
+
+	if cfg.Goal < 0 {
+		return usageError(fmt.Sprintf("Invalid --goal %g", cfg.Goal))
+	}
+	var goal *goalView
+	if cfg.Goal > 0 {
+		var start, end time.Time
+		start, end, err = goalPeriodStart(cfg.GoalPeriod, now, weekStartDay)
+		if err != nil {
+			return usageError("Invalid --goal-period: " + err.Error())
+		}
+		goal = newGoalView(cfg.Goal, cfg.GoalPeriod, start, end)
+	}
+
+	if cfg.SplitDaily && cfg.Split == "" {
+		return usageError("--split-daily needs --split")
+	}
+	var split []splitPart
+	if cfg.Split != "" {
+		split, err = parseSplit(cfg.Split)
+		if err != nil {
+			return usageError("Invalid --split: " + err.Error())
+		}
+	}
+
+	if cfg.ConfirmAbandon && !cfg.AbandonStuck {
+		return usageError("--confirm-abandon needs --abandon-stuck")
 	}
-	wallets = nil
-	for k := range uniqueWallets {
-		wallets = append(wallets, k)
+	if cfg.AbandonStuck && cfg.StuckAfter <= 0 {
+		return usageError(fmt.Sprintf("Invalid --stuck-after %s", cfg.StuckAfter))
+	}
+	if cfg.StaleAfter < 0 {
+		return usageError(fmt.Sprintf("Invalid --stale-after %s", cfg.StaleAfter))
+	}
+
+	if cfg.PoolHashrate != "" && !cfg.PoolMode {
+		return usageError("--pool-hashrate needs --pool-mode")
+	}
+	var poolHashrate float64
+	if cfg.PoolHashrate != "" {
+		poolHashrate, err = parseHashrate(cfg.PoolHashrate)
+		if err != nil {
+			return usageError("Invalid --pool-hashrate: " + err.Error())
+		}
+	}
+
+	var power *tariff
+	if cfg.PowerWatts > 0 {
+		var bands = cfg.Tariff
+		if cfg.TariffFile != "" {
+			bands, err = loadTariffFile(cfg.TariffFile)
+			if err != nil {
+				return usageError(fmt.Sprintf("Unable to read tariff file %q: %s", cfg.TariffFile, err))
+			}
+		}
+		if len(bands) > 0 {
+			power, err = newTariff(bands)
+			if err != nil {
+				return usageError(fmt.Sprintf("Invalid tariff schedule: %s", err))
+			}
+		} else {
+			power = flatTariff(cfg.KWhPrice)
+		}
+	}
+
+	cfg.CoinPrice = lookupPrice(cfg, "the", cfg.CoinPrice, cfg.priceSource)
+
+	var fiatCurrencies []string
+	var fiatRates map[string]float64
+	if cfg.FiatCurrencies != "" {
+		fiatCurrencies, err = parseFiatCurrencies(cfg.FiatCurrencies)
+		if err != nil {
+			return usageError(fmt.Sprintf("Invalid --fiat-currencies %q: %s", cfg.FiatCurrencies, err))
+		}
+		var fetcher PriceFetcher
+		fetcher, err = newFiatFetcher(cfg, fiatCurrencies)
+		if err != nil {
+			return usageError("--fiat-currencies: " + err.Error())
+		}
+		fiatRates = lookupFiatRates(cfg, fetcher, fiatCurrencies)
 	}
 
-	var u, err = url.Parse(urlString)
+	if cfg.FirstN < 0 || cfg.LastN < 0 {
+		return usageError("--first-n and --last-n can't be negative")
+	}
+	if cfg.MinAmount < 0 {
+		return usageError(fmt.Sprintf("Invalid --min-amount %g", cfg.MinAmount))
+	}
+
+	var outputs []outputSpec
+	outputs, err = outputSpecs(cfg)
+	if err != nil {
+		return usageError("Invalid --sink: " + err.Error())
+	}
+	if cfg.ESURL != "" && cfg.ESIndex == "" {
+		return usageError("--es-url needs an --es-index")
+	}
+
+	var sortDesc bool
+	sortDesc, err = parseSortOrder(cfg.SortBy, cfg.SortDir)
+	if err != nil {
+		return usageError(err.Error())
+	}
+
+	var bucketSize time.Duration
+	bucketSize, err = parseBucketSize(cfg.Bucket)
 	if err != nil {
-		usage(fmt.Sprintf("Invalid URL %q: %s", urlString, err))
+		return usageError("Invalid --bucket: " + err.Error())
+	}
+
+	var heatmapWeight string
+	heatmapWeight, err = parseHeatmapWeight(cfg.HeatmapWeight)
+	if err != nil {
+		return usageError("Invalid --heatmap-weight: " + err.Error())
+	}
+
+	if cfg.AnomalyThreshold <= 0 {
+		return usageError(fmt.Sprintf("Invalid --anomaly-threshold %g", cfg.AnomalyThreshold))
+	}
+	if cfg.WalletOverlap < 0 || cfg.WalletOverlap > 1 {
+		return usageError(fmt.Sprintf("Invalid --wallet-overlap-threshold %g", cfg.WalletOverlap))
+	}
+	var degradation *degradationSpec
+	if cfg.DegradationCheck != "" {
+		degradation, err = parseDegradationSpec(cfg.DegradationCheck)
+		if err != nil {
+			return usageError("Invalid --degradation-check: " + err.Error())
+		}
+	}
+
+	err = cfg.SubsidySchedule.validate()
+	if err != nil {
+		return usageError(err.Error())
+	}
+
+	if cfg.ProjectionHistory > 0 && cfg.StateFile == "" {
+		return usageError("--projection-history requires --state-file")
+	}
+	if cfg.ProjectionHour < 0 || cfg.ProjectionHour > 23 {
+		return usageError(fmt.Sprintf("Invalid --projection-hour %d", cfg.ProjectionHour))
+	}
+	var state *stateFile
+	if cfg.StateFile != "" {
+		state, err = loadState(cfg.StateFile)
+		if err != nil {
+			return failure(exitUsage, "Unable to read state file %q: %s", cfg.StateFile, err)
+		}
+	}
+
+	var wallets []string
+	var compareA, compareB string
+	if cfg.CompareWallet != "" {
+		compareA, compareB, err = parseCompareWallets(cfg.CompareWallet)
+		if err != nil {
+			return usageError("Invalid --compare-wallet: " + err.Error())
+		}
+		wallets = []string{compareA, compareB}
+	} else {
+		wallets, err = resolveWallets(u, cfg)
+		if err != nil {
+			return err
+		}
+	}
+	rpcCapture.writeManifest(cfg, wallets, now)
+	if cfg.KeypoolSize < 0 {
+		return usageError(fmt.Sprintf("Invalid --keypool-size %d", cfg.KeypoolSize))
+	}
+	if cfg.Passphrase != "" && !cfg.RefillKeypool {
+		return usageError("--passphrase needs --refill-keypool")
+	}
+	if cfg.RefillKeypool {
+		for _, w := range wallets {
+			err = refillKeypool(u, w, cfg.KeypoolSize, cfg.Passphrase)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Unable to refill the keypool of wallet %q: %s\n", w, err)
+			}
+		}
+	}
+	var relabeled int
+	if len(cfg.SetLabels) > 0 {
+		relabeled, err = setLabels(u, wallets, cfg.SetLabels)
+		if err != nil {
+			return err
+		}
+	}
+	reportDays, err = widestWindow(reportDays, wallets, cfg.WalletDays)
+	if err != nil {
+		return usageError(err.Error())
+	}
+	if reportDays != cfg.ReportDays {
+		cfg.ReportDays = reportDays
+		err = checkBucketFormats(cfg, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	if cfg.HealthCheck {
+		var results = runHealthChecks(u, wallets)
+		if cfg.dryRun {
+			printPlannedOutputs(cfg)
+			return nil
+		}
+		if cfg.JSON {
+			return exitLiteral(writeHealthCheckJSON(os.Stdout, results))
+		}
+		return exitLiteral(printHealthCheck(results))
+	}
+
+	if cfg.TXIDFile != "" {
+		return runTXIDFile(u, cfg, wallets)
+	}
+
+	if cfg.RescanFrom >= 0 {
+		return runRescan(u, cfg, wallets)
+	}
+
+	// A wallet that can't be fetched is left out of the report rather than
+	// sinking it, but the run then exits with exitPartial
+	var since = cfg.SinceBlockhash
+	if cfg.SinceHeight > 0 {
+		if since != "" {
+			return usageError("--since-blockhash and --since-height can't be used together")
+		}
+		since, err = fetchBlockHash(u, cfg.SinceHeight)
+		if err != nil {
+			return failure(exitRPC, "Unable to fetch the hash of block %d: %s", cfg.SinceHeight, err)
+		}
+		if cfg.dryRun {
+			since = fmt.Sprintf("(hash of block %d)", cfg.SinceHeight)
+		}
+	}
+	checkPruned(u, cfg)
+	if cfg.MaxBlockAge < 0 {
+		return usageError(fmt.Sprintf("Invalid --max-block-age %d", cfg.MaxBlockAge))
+	}
+	var blockAge *blockAgeSummary
+	if cfg.MaxBlockAge > 0 {
+		blockAge, err = newBlockAgeSummary(u, cfg.MaxBlockAge)
+		if err != nil {
+			return failure(exitRPC, "Unable to fetch the chain tip for --max-block-age: %s", err)
+		}
+	}
+	if cfg.PrunedNode {
+		skipPrunedFeatures(cfg)
+	}
+
+	if cfg.AllTime {
+		if since != "" {
+			return usageError("--all-time can't be used with --since-blockhash or --since-height")
+		}
+		listCount = allTimeCount
+	}
+
+	// A pruned node gets listsinceblock even without a starting block,
+	// since it's the cheaper call there
+	var fetch = listTransactions
+	var removed []*Transaction
+	if since != "" || cfg.PrunedNode {
+		fetch = func(u *url.URL) ([]*Transaction, error) {
+			var list, gone, err = listSinceBlock(u, since)
+			removed = append(removed, gone...)
+			return list, err
+		}
+	}
+
+	var mergeURLs []*url.URL
+	mergeURLs, err = parseMergeURLs(cfg.MergeURLs, cfg.User, cfg.Password)
+	if err != nil {
+		return usageError("Invalid --merge-urls: " + err.Error())
+	}
+	var sources = []string{u.Redacted()}
+	for _, mu := range mergeURLs {
+		sources = append(sources, mu.Redacted())
+	}
+
+	if degradation != nil && since != "" {
+		return usageError("--degradation-check can't be used with --since-blockhash or --since-height")
+	}
+
+	var archive *txArchive
+	if cfg.TxArchive != "" && since != "" {
+		return usageError("--tx-archive can't be used with --since-blockhash or --since-height")
+	}
+	if cfg.TxArchive != "" && !cfg.dryRun {
+		archive, err = loadArchive(cfg.TxArchive)
+		if err != nil {
+			return failure(exitUsage, "Unable to read transaction archive %q: %s", cfg.TxArchive, err)
+		}
 	}
 
-	u.User = url.UserPassword(user, pass)
 	var txList []*Transaction
+	var fetched []string
+	var truncated = make(map[string]time.Time)
+	var coverage = newCoverageTracker()
+	var births = make(map[string]time.Time)
+	var walletTimings []walletTiming
+	var nodeLists = make(map[string][]*Transaction)
+	var partial bool
+	// A failed wallet is left out whether or not --no-exit-on-error is
+	// given; the flag gathers the failures up at the end of the run
+	var walletErrors []walletError
+	if cfg.NoExitOnError {
+		defer func() { printWalletErrors(os.Stderr, walletErrors) }()
+	}
 	for _, w := range wallets {
-		u.Path = "/wallet/" + w
-		txList = append(txList, fetchTX(u)...)
+		var fetchWallet = fetch
+		if days, ok := cfg.WalletDays[w]; ok && since == "" {
+			var cutoff = getDay(now).AddDate(0, 0, -(days - 1))
+			fetchWallet = func(u *url.URL) ([]*Transaction, error) { return listTransactionsSince(u, cutoff, cfg.TimeField) }
+		}
+		var fetchStart, calls = time.Now(), rpcStats.count()
+		var list, err = fetchWallet(walletURL(u, w))
+		if err != nil {
+			walletTimings = append(walletTimings, walletTiming{Wallet: w, Seconds: time.Since(fetchStart).Seconds(), RPCCalls: rpcStats.count() - calls})
+			fmt.Fprintf(os.Stderr, "Unable to fetch wallet %q from %s: %s\n", w, u.Redacted(), err)
+			walletErrors = append(walletErrors, walletError{wallet: w, source: u.Redacted(), err: err})
+			coverage.fail(w)
+			continue
+		}
+		coverage.fetched(w)
+		nodeLists[w] = list
+		var live = list
+		if archive != nil {
+			list = archive.merge(w, list, cfg.TimeField, now, os.Stderr)
+		}
+		for _, tx := range list {
+			tx.source = sources[0]
+		}
+		for i, mu := range mergeURLs {
+			var more, err = fetchWallet(walletURL(mu, w))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Unable to fetch wallet %q from %s: %s\n", w, mu.Redacted(), err)
+				walletErrors = append(walletErrors, walletError{wallet: w, source: mu.Redacted(), err: err})
+				partial = true
+				continue
+			}
+			for _, tx := range more {
+				tx.source = sources[i+1]
+			}
+			list = append(list, more...)
+		}
+		for _, tx := range list {
+			tx.wallet = w
+		}
+		markPoolPayouts(list, cfg.PoolAddresses, cfg.PoolMode)
+		warnImprecise(w, list)
+		walletTimings = append(walletTimings, walletTiming{Wallet: w, Seconds: time.Since(fetchStart).Seconds(), RPCCalls: rpcStats.count() - calls})
+		if oldest, ok := truncatedSince(live, cfg.TimeField); ok && since == "" {
+			if len(list) > len(live) {
+				oldest = oldestTxTime(list, cfg.TimeField)
+			}
+			truncated[w] = oldest
+			coverage.limit(w, oldest, "history truncated")
+		}
+		if birth, ok := walletBirth(walletURL(u, w)); ok && since == "" {
+			births[w] = birth
+			coverage.limit(w, birth, "wallet created")
+		}
+		txList = append(txList, list...)
+		fetched = append(fetched, w)
+	}
+	if len(fetched) == 0 && len(wallets) > 0 {
+		return exitWith(exitRPC)
+	}
+	partial = partial || len(fetched) < len(wallets)
+	wallets = fetched
+	if archive != nil {
+		err = archive.save(cfg.TxArchive)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to save transaction archive %q: %s\n", cfg.TxArchive, err)
+		}
+	}
+	err = checkWalletScoping(wallets, nodeLists, cfg.WalletOverlap, cfg.Lenient)
+	if err != nil {
+		return err
+	}
+	if len(cfg.FilterLabels) > 0 {
+		txList = filterByLabel(txList, cfg.FilterLabels)
+	}
+	var unmerged = txList
+	if len(mergeURLs) > 0 {
+		txList = dedupeTransactions(txList)
+	}
+	if cfg.dryRun {
+		if cfg.BlockStats || cfg.Hashrate || cfg.Profitability {
+			fmt.Println("RPC   (getblockheader, batched, per block won in the report window and not cached in the state file)")
+		}
+		if cfg.Profitability {
+			fmt.Println("RPC   (getdifficulty, for --profitability's days without a block)")
+		}
+		if cfg.BlockStats {
+			fmt.Println("RPC   (one getblockstats per block won in the report window)")
+		}
+		if cfg.BlockEnrich {
+			fmt.Println("RPC   (one getblock per distinct block won in the report window)")
+		}
+		if poolHashrate > 0 {
+			fmt.Println("RPC   (getmininginfo, for the --pool-hashrate fee estimate)")
+		}
+		if cfg.BlockFees {
+			fmt.Println("RPC   (getblock and getrawtransaction per block won in the report window)")
+		}
+		if cfg.CoinbaseTags {
+			fmt.Println("RPC   (one getrawtransaction per block won in the report window and not cached in the state file)")
+		}
+		if cfg.ReorgDetect {
+			fmt.Println("RPC   (one getblockhash per block height in the report window)")
+		}
+		if cfg.TxGraph {
+			fmt.Println("RPC   (one getrawtransaction per send in the report window)")
+		}
+		if cfg.MonitorBroadcast {
+			fmt.Println("RPC   (getrawtransaction and sendrawtransaction per unconfirmed send in the report window)")
+		}
+		if cfg.AbandonStuck && cfg.ConfirmAbandon {
+			fmt.Printf("RPC   (abandontransaction per send unconfirmed for %s or more)\n", formatAge(cfg.StuckAfter))
+		}
+		printPlannedOutputs(cfg)
+		return nil
+	}
+
+	if cfg.FirstN > 0 || cfg.LastN > 0 {
+		printEdgeTransactions(os.Stdout, txList, cfg.FirstN, cfg.LastN)
+		return partialResult(partial)
+	}
+	if cfg.BalanceHistory {
+		printBalanceHistory(os.Stdout, txList, cfg.TimeField, len(truncated) > 0 || since != "" || len(cfg.FilterLabels) > 0)
+		return partialResult(partial)
 	}
 
-	fmt.Printf("%d transactions (wallet(s): %s)\n", len(txList), strings.Join(wallets, ", "))
-	var reportStats StatData
-	var dailyStats = make([]StatData, reportDays)
-	var hourlyStats = make([]StatData, 24)
-	var now = time.Now()
 	var nowDay = getDay(now)
-	var daysAgo = time.Duration(reportDays-1) * time.Hour * -24
-	var beginReport = nowDay.Add(daysAgo)
+	var beginReport = nowDay.AddDate(0, 0, -(reportDays - 1))
+	for _, w := range wallets {
+		var oldest, ok = truncated[w]
+		if !ok || !oldest.After(beginReport) {
+			continue
+		}
+		var through = getDay(oldest).Format(dateLayout)
+		fmt.Fprintf(os.Stderr, "WARNING: wallet %q returned the most transactions listtransactions is asked for (%d),\n", w, listCount)
+		fmt.Fprintf(os.Stderr, "and the oldest is from %s, inside the report window.  Totals for %s\n", oldest.Format(dateTimeLayout), beginReport.Format(dateLayout))
+		fmt.Fprintf(os.Stderr, "through %s are UNDER-COUNTED.\n", through)
+		partial = true
+	}
+	for _, w := range wallets {
+		var birth, ok = births[w]
+		if ok && birth.After(beginReport) {
+			fmt.Fprintf(os.Stderr, "WARNING: wallet %q was created at %s, inside the report window; it has no data before then.\n", w, birth.Format(dateTimeLayout))
+		}
+	}
+	if cfg.CompareWallet != "" {
+		if len(wallets) < 2 {
+			return failure(exitRPC, "Unable to compare %q and %q without both wallets", compareA, compareB)
+		}
+		printComparison(os.Stdout, compareA, compareB, compareWallets(txList, compareA, compareB, cfg.TimeField, beginReport, now))
+		return partialResult(partial)
+	}
+
+	var blocks []*Transaction
+	var sends []*Transaction
+	var unconfirmed []*Transaction
+	var acc = stats.NewAccumulator(beginReport, reportDays)
+	var minSats = toSatoshis(cfg.MinAmount)
+	var dust = &dustSummary{InTotals: cfg.DustInTotals}
 
 	for _, tx := range txList {
-		tx.dt = time.Unix(tx.TimeReceived, 0)
+		tx.dt = txTime(tx, cfg.TimeField)
+
+		if cfg.TxGraph && tx.Category == "send" && !tx.dt.Before(beginReport) {
+			sends = append(sends, tx)
+		}
+		if cfg.MonitorBroadcast && tx.Category == "send" && tx.Confirmations == 0 && !tx.dt.Before(beginReport) {
+			unconfirmed = append(unconfirmed, tx)
+		}
 
-		if !tx.Generated {
+		if !countable(tx, now) {
 			continue
 		}
-		if tx.Confirmations < 2 {
+		if blockAge != nil && blockAge.tooOld(tx) {
+			if !tx.dt.Before(beginReport) {
+				blockAge.record(tx.Amount)
+			}
 			continue
 		}
 
-		if tx.dt.Before(beginReport) {
+		var st = stats.Transaction{TXID: tx.TXID, Vout: tx.Vout, Amount: tx.Amount, Blockheight: tx.Blockheight, Time: tx.dt, Payout: tx.pool}
+		st.Dust = !tx.pool && minSats > 0 && toSatoshis(tx.Amount) < minSats
+		if goal != nil && (!st.Dust || cfg.DustInTotals) {
+			goal.add(tx)
+		}
+		if tx.pool {
+			acc.Add(st)
 			continue
 		}
+		if st.Dust && !cfg.DustInTotals {
+			if !tx.dt.Before(beginReport) {
+				dust.record(tx.Amount)
+			}
+			continue
+		}
+		if !acc.Add(st) {
+			continue
+		}
+		if st.Dust {
+			dust.record(tx.Amount)
+			continue
+		}
+		if cfg.BlockStats || cfg.BlockEnrich || cfg.ByAddrType || cfg.BlockFees || cfg.ShowSubsidy || cfg.RewardBreakdown || cfg.Hashrate || cfg.Profitability || cfg.CoinbaseTags {
+			blocks = append(blocks, tx)
+		}
+	}
 
-		reportStats.record(tx)
-
-		var dayIndex = int(tx.dt.Sub(beginReport) / time.Hour / 24)
-		dailyStats[dayIndex].record(tx)
+	var reorged = dropReorged(acc, removed, cfg.TimeField, beginReport, now)
 
-		if dayIndex == reportDays-1 {
-			hourlyStats[tx.dt.Hour()].record(tx)
+	var report = acc.Snapshot()
+	if err = report.Check(); err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: internal inconsistency, please report it: %s\n", err)
+	}
+	var reportStats = report.Total
+	var dailyStats = make([]stats.Bucket, len(report.Daily))
+	for i, day := range report.Daily {
+		dailyStats[i] = day.Bucket
+	}
+	var view = newReportView(cfg, wallets, txList, report, now)
+	if cfg.AllTime {
+		view.AllTime = newAllTimeStats(txList, truncated, now)
+		for _, w := range wallets {
+			if oldest, ok := truncated[w]; ok {
+				fmt.Fprintf(os.Stderr, "WARNING: wallet %q returned the most transactions listtransactions is asked for (%d); all-time stats only reach back to %s.\n", w, listCount, oldest.Format(dateTimeLayout))
+				partial = true
+			}
 		}
 	}
+	if cfg.AsOf != "" {
+		view.AsOf = &now
+	}
+	if len(cfg.SubsidySchedule) > 0 {
+		view.Subsidy, err = newSubsidyView(u, cfg.SubsidySchedule, view.DailyAverage, now)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to place the chain on the subsidy schedule: %s\n", err)
+			partial = true
+		}
+	}
+	view.ReorgedOut = reorged
+	if cfg.ReorgDetect {
+		view.Reorgs = detectReorgs(u, txList, beginReport, now)
+		if view.Reorgs.Failed > 0 {
+			partial = true
+		}
+	}
+	if len(cfg.PoolAddresses) > 0 || cfg.PoolMode {
+		view.addPoolPayouts(txList, now)
+	}
+	if cfg.PoolMode {
+		view.PoolStats = newPoolStats(txList, beginReport, now)
+		if poolHashrate > 0 {
+			var expected float64
+			expected, err = expectedSoloEarnings(u, poolHashrate, cfg.SubsidySchedule, now.Sub(beginReport))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Unable to estimate the pool fee: %s\n", err)
+				partial = true
+			} else {
+				view.PoolStats.setFee(expected)
+			}
+		}
+	}
+	if cfg.SplitCategories {
+		var layout = cfg.BucketFormat
+		if bucketSize < 24*time.Hour {
+			layout += " " + cfg.HourBucketFormat
+		}
+		view.Categories = newCategoryViews(txList, beginReport, reportDays, bucketSize, layout, now)
+	}
+	if bucketSize < 24*time.Hour {
+		view.BucketSize = cfg.Bucket
+		view.Buckets = bucketRows(acc.Intervals(bucketSize, now), bucketSize, cfg.BucketFormat+" "+cfg.HourBucketFormat, now)
+	}
+	if fiatRates != nil {
+		view.addFiat(fiatCurrencies, fiatRates)
+	}
+	view.Pruned = cfg.PrunedNode
+	if archive != nil {
+		view.Archive = archive.summary(txList, beginReport, now)
+	}
+	if rpcFailover != nil {
+		view.Endpoints = rpcFailover.servedBy()
+	}
+	view.Activity = walletActivities(txList, wallets, beginReport, now)
+	view.Coverage = coverage.coverage(beginReport, now)
+	view.markCoverageGaps()
+	if len(cfg.WalletDays) > 0 {
+		view.WalletWindows = walletWindows(txList, wallets, cfg.WalletDays, reportDays, now)
+	}
+	if len(mergeURLs) > 0 {
+		view.SourceTotals = sourceTotals(unmerged, sources, cfg.TimeField, beginReport, reportDays, now)
+	}
+	if cfg.AnomalyDetect {
+		view.markAnomalies(cfg.AnomalyThreshold)
+	}
+	if minSats > 0 {
+		view.Dust = dust
+	}
+	view.BlockAge = blockAge
+	view.Relabeled = relabeled
+	view.Picked = picked
+	if split != nil {
+		view.Split = newSplitView(split, view.Total, view.Daily, cfg.CoinPrice, cfg.SplitDaily)
+	}
+	if goal != nil {
+		goal.finish(now)
+		view.Goal = goal
+	}
+	if cfg.BlockFees {
+		view.BlockFees = sumBlockFees(u, blocks, cfg.SubsidySchedule)
+		if view.BlockFees.Failed > 0 {
+			partial = true
+		}
+	}
+	var headers = newHeaderCache(state, cfg.MaxHeaderLookups)
+	if cfg.BlockStats || cfg.Hashrate || cfg.Profitability {
+		headers.load(u, blocks)
+	}
+	var enriched *blockInfoCache
+	if cfg.BlockEnrich {
+		enriched = newBlockInfoCache()
+		enriched.load(u, blocks)
+	}
+	if cfg.Hashrate {
+		view.Hashrate, err = newHashrateView(u, headers, blocks, beginReport, now)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to estimate the hashrate: %s\n", err)
+			partial = true
+		}
+	}
+	if cfg.Profitability {
+		view.Profitability, err = newProfitabilityView(u, headers, blocks, view.Daily)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to fetch the difficulty for --profitability: %s\n", err)
+			partial = true
+		}
+	}
+	if cfg.ShowSubsidy {
+		view.Subsidies = expectedSubsidies(blocks, cfg.SubsidySchedule)
+	}
+	if cfg.RewardBreakdown {
+		view.Rewards = newRewardBreakdown(blocks, cfg.SubsidySchedule)
+	}
+	if cfg.Heatmap {
+		view.Heatmap = newHeatmapView(report, heatmapWeight)
+	}
+	if cfg.PropagationStats {
+		view.Propagation = newPropagationView(txList, beginReport, now)
+	}
+	if cfg.MonitorBroadcast {
+		view.Rebroadcast = rebroadcast(u, unconfirmed)
+	}
+	if cfg.AbandonStuck {
+		view.Abandon = abandonStuck(u, findStuckSends(txList, sources[0], cfg.StuckAfter, now), cfg.StuckAfter, cfg.ConfirmAbandon)
+		if view.Abandon.failed() > 0 {
+			partial = true
+		}
+	}
+	if cfg.ByAddrType {
+		var prefixes = map[string][]string(cfg.AddrPrefixes)
+		if len(prefixes) == 0 {
+			prefixes = defaultAddrPrefixes
+		}
+		view.AddrTypes = addrTypeRows(prefixes, blocks)
+	}
+	if cfg.EnrichAddresses {
+		var failed int
+		view.OutputTypes, failed = outputTypeRows(u, txList, beginReport, now)
+		partial = partial || failed > 0
+	}
+	if degradation != nil {
+		var covered = make(map[string]time.Time)
+		for _, w := range wallets {
+			var since = births[w]
+			if oldest, ok := truncated[w]; ok && oldest.After(since) {
+				since = oldest
+			}
+			if days, ok := cfg.WalletDays[w]; ok {
+				if cutoff := nowDay.AddDate(0, 0, -(days - 1)); cutoff.After(since) {
+					since = cutoff
+				}
+			}
+			covered[w] = since
+		}
+		view.Degradation = newDegradationView(degradation, txList, wallets, covered, now)
+	}
+	if cfg.StaleAfter > 0 {
+		view.Stale = newStaleAlert(txList, cfg.StaleAfter, now)
+	}
+	if cfg.CoinbaseTags {
+		var cache = make(map[string]coinbaseTagEntry)
+		if state != nil {
+			if state.CoinbaseTags == nil {
+				state.CoinbaseTags = cache
+			}
+			cache = state.CoinbaseTags
+		}
+		var failed int
+		view.CoinbaseTags, failed = coinbaseTagRows(u, blocks, cache, now)
+		partial = partial || failed > 0
+	}
 
-	var first = txList[0]
-	fmt.Printf("First tx was recorded at %s\n", first.dt.Format("2006-01-02 15:04:05"))
-	var total = reportStats.coins
-	fmt.Printf("Report period total: %0.2f\n", total)
-	fmt.Printf("Daily average: %0.2f\n", total/float64(reportDays))
-	fmt.Printf("Hourly average: %0.2f\n", total/float64(reportDays)/24.0)
-	fmt.Printf("Rough Block Win Percent: %0.4f%%\n", reportStats.roughPercent())
-
-	for i := 0; i < reportDays; i++ {
-		var projection = ""
-		var coins = dailyStats[i].coins
-		var hours = 24.0
-		var when = beginReport.Add(time.Hour * 24 * time.Duration(i)).Format("2006-01-02")
-		if i == reportDays-1 {
-			hours = float64(now.Hour()) + float64(now.Minute())/60.0
-			projection = fmt.Sprintf(" (~ %0.2f expected)", coins/hours*24)
+	var graph *txGraph
+	if cfg.TxGraph {
+		graph = buildTxGraph(u, sends)
+		if cfg.Output != "" {
+			var f *os.File
+			f, err = os.Create(cfg.Output)
+			if err == nil {
+				err = graph.writeDOT(f)
+				if cerr := f.Close(); err == nil {
+					err = cerr
+				}
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Unable to write transaction graph to %q: %s\n", cfg.Output, err)
+			}
 		}
-		fmt.Printf("%s:\t\t\t%8.2f\t\t%0.2f/h\t\tWin%%: %0.4f%%%s\n", when, coins, coins/hours, dailyStats[i].roughPercent(), projection)
 	}
 
-	for i := 0; i <= now.Hour(); i++ {
-		var projection = ""
-		var coins = hourlyStats[i].coins
-		var minutes = 60.0
-		var when = fmt.Sprintf("%s/%02d", getDay(now).Format("2006-01-02"), i)
-		if i == now.Hour() {
-			minutes = float64(now.Minute()) + float64(now.Second())/60
-			projection = fmt.Sprintf(" (~ %0.2f expected)", coins/minutes*60)
+	// An --as-of run is looking at the past, so it mustn't record snapshots
+	// as though they were taken live
+	var projections *reportState
+	if state != nil && cfg.AsOf != "" {
+		projections = state.report(wallets)
+	} else if state != nil {
+		projections = state.report(wallets)
+		for i := 0; i < reportDays-1; i++ {
+			projections.Actuals[beginReport.AddDate(0, 0, i).Format("2006-01-02")] = dailyStats[i].Coins
+		}
+		if today := view.Daily[reportDays-1]; today.Projected != nil {
+			projections.recordProjection(now, *today.Projected)
+		}
+		err = state.save(cfg.StateFile, now)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to save state file %q: %s\n", cfg.StateFile, err)
 		}
-		fmt.Printf("- %s:\t\t%8.2f\t\t%0.2f/m\t%s\n", when, coins, coins/minutes, projection)
 	}
-}
 
-func doPost(u *url.URL, data io.Reader, resp interface{}) error {
-	var r, err = http.Post(u.String(), "text/plain", data)
-	if err != nil {
-		return err
+	if cfg.SortBy != "date" || cfg.SortDir != "" {
+		view.sortBuckets(cfg.SortBy, sortDesc)
 	}
-	var body []byte
-	body, err = io.ReadAll(r.Body)
-	if err != nil {
-		return err
+
+	if cfg.TimeField == "timereceived" && cfg.RescanMinTxs > 0 {
+		warnRescan(rescanHeuristic{window: cfg.RescanWindow, minTxs: cfg.RescanMinTxs, minSpan: cfg.RescanMinSpan}, txList)
 	}
-	defer r.Body.Close()
 
-	err = json.Unmarshal(body, &resp)
-	if err != nil {
-		return err
+	view.Timing = newTimingView(started, walletTimings, headers)
+	// The text report is the one sink that can't be rendered from the view
+	// alone: several of its sections query the node as they print
+	var printText = func() {
+		var width = view.style.width
+
+		view.printSummary(os.Stdout)
+		view.printDaily(os.Stdout, width)
+
+		if len(view.WalletWindows) > 0 {
+			view.printWalletWindows(os.Stdout)
+		}
+		if cfg.IncludeReceives {
+			view.printReceives(os.Stdout)
+		}
+		if cfg.ShowSubsidy {
+			view.printSubsidies(os.Stdout)
+		}
+		if view.Rewards != nil {
+			view.Rewards.print(os.Stdout)
+		}
+
+		if cfg.DetailedBalance {
+			printDetailedBalances(u, wallets)
+		}
+
+		if cfg.LockedUTXOs {
+			printLockedUTXOs(u, wallets)
+		}
+
+		if len(cfg.WatchAddresses) > 0 {
+			printWatchedAddresses(u, wallets, cfg.WatchAddresses)
+		}
+
+		if graph != nil {
+			graph.printSummary()
+			if cfg.Output == "" {
+				fmt.Println()
+				graph.writeDOT(os.Stdout)
+			}
+		}
+
+		if projections != nil && cfg.ProjectionHistory > 0 {
+			printProjectionHistory(projections, nowDay, cfg.ProjectionHistory, cfg.ProjectionHour)
+		}
+
+		if cfg.BlockStats || cfg.BlockEnrich {
+			printBlockList(headers, enriched, blocks)
+		}
+		if cfg.BlockStats {
+			printBlockFees(u, blocks)
+		}
+		if cfg.ByAddrType {
+			view.printAddrTypes()
+		}
+		if cfg.EnrichAddresses {
+			view.printOutputTypes()
+		}
+		if cfg.CoinbaseTags {
+			view.printCoinbaseTags()
+		}
+		if view.Degradation != nil {
+			view.Degradation.print(os.Stdout)
+		}
+		if view.Profitability != nil {
+			view.Profitability.print(os.Stdout)
+		}
+		if view.Heatmap != nil {
+			view.Heatmap.print(os.Stdout)
+		}
+		if view.Propagation != nil {
+			view.Propagation.print(os.Stdout)
+		}
+		if cfg.Weekly {
+			printWeekly(beginReport, dailyStats, weekStartDay)
+		}
+		if cfg.Weekdays {
+			printWeekdays(beginReport, dailyStats, weekStartDay)
+		}
+
+		if power != nil {
+			printPowerCost(power, cfg.PowerWatts, beginReport, now, reportStats.Coins, cfg.CoinPrice)
+		}
+
+		view.printHourly(os.Stdout, width)
+		for i := range view.Categories {
+			view.Categories[i].print(os.Stdout)
+		}
+		if view.AllTime != nil {
+			view.AllTime.print(os.Stdout)
+		}
+		if cfg.Timing {
+			view.Timing.print(os.Stdout)
+		}
 	}
+	var failed = writeOutputs(outputs, view, printText)
+	if failed > 0 && failed == len(outputs) {
+		return exitWith(exitRPC)
+	}
+	if cfg.ESURL != "" {
+		var es = &ESBulkWriter{URL: cfg.ESURL, Index: cfg.ESIndex, APIKey: cfg.ESAPIKey}
+		err = indexReport(es, txList, view, beginReport, now)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to index the report into %s (%d documents indexed): %s\n", cfg.ESIndex, es.Indexed, err)
+			partial = true
+		}
+	}
+	// A drop in partial data may only be the part that's missing, so
+	// partial is the more honest exit
+	if partial || failed > 0 {
+		return exitWith(exitPartial)
+	}
+	if view.Stale != nil && view.Stale.Stale {
+		return exitWith(exitStale)
+	}
+	if view.Degradation != nil && view.Degradation.Degraded > 0 {
+		return exitWith(exitDegraded)
+	}
+	return nil
+}
 
+func partialResult(partial bool) error {
+	if partial {
+		return exitWith(exitPartial)
+	}
 	return nil
 }
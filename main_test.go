@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// A wallet that fills listtransactions' page with the oldest entry inside
+// the report window is under-counted, so the run warns and exits partial
+func TestTruncatedListing(t *testing.T) {
+	var saved = listTransactionsCount
+	listTransactionsCount = 3
+	defer func() { listTransactionsCount = saved }()
+
+	var now = time.Now()
+	var txs = []map[string]interface{}{
+		generation("aa", 800020, 5, now.Add(-50*time.Hour)),
+		generation("bb", 800060, 5, now.Add(-26*time.Hour)),
+		generation("cc", 800090, 5, now.Add(-3*time.Hour)),
+	}
+	var tests = []struct {
+		name string
+		txs  []map[string]interface{}
+		code int
+		warn bool
+	}{
+		{"full page", txs, exitPartial, true},
+		{"room to spare", txs[1:], exitOK, false},
+	}
+	for _, tt := range tests {
+		var node = newWalletNode(t, tt.txs)
+		var code int
+		var stderr = capture(t, &os.Stderr, func() {
+			capture(t, &os.Stdout, func() {
+				code = exitCode(run([]string{"--url", node.URL, "--user", "u", "--password", "p", "--wallet", "rig1", "--days", "5"}))
+			})
+		})
+		if code != tt.code {
+			t.Errorf("%s: exit %d, want %d", tt.name, code, tt.code)
+		}
+		if got := strings.Contains(stderr, "are UNDER-COUNTED"); got != tt.warn {
+			t.Errorf("%s: warned %v, want %v: %q", tt.name, got, tt.warn, stderr)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+)
+
+// blockAgeSummary counts the generations --max-block-age dropped.  It's the
+// blunt answer to a restored wallet whose old generations got the restore
+// date as their time: however recent the timestamp, a block far enough
+// behind the tip wasn't won in the window.
+type blockAgeSummary struct {
+	MaxAge    int64   `json:"max_age"`
+	TipHeight int64   `json:"tip_height"`
+	Count     int     `json:"count"`
+	Coins     float64 `json:"coins"`
+}
+
+// newBlockAgeSummary asks the node for the tip the age is counted from
+func newBlockAgeSummary(u *url.URL, maxAge int64) (*blockAgeSummary, error) {
+	var info blockchainInfo
+	var err = rpcCall(nodeURL(u), "getblockchaininfo", nil, &info)
+	if err != nil {
+		return nil, err
+	}
+	return &blockAgeSummary{MaxAge: maxAge, TipHeight: info.Blocks}, nil
+}
+
+// tooOld is true of a generation whose block is more than MaxAge behind the
+// tip.  Pool payouts and anything else that isn't generated are left alone.
+func (s *blockAgeSummary) tooOld(tx *Transaction) bool {
+	return tx.Generated && tx.Blockheight > 0 && s.TipHeight-tx.Blockheight > s.MaxAge
+}
+
+func (s *blockAgeSummary) record(amount float64) {
+	s.Count++
+	s.Coins += amount
+}
+
+func (s *blockAgeSummary) print(w io.Writer) {
+	fmt.Fprintf(w, "Generations over %d blocks behind the tip (%d) excluded: %d (%0.8f coins)\n", s.MaxAge, s.TipHeight, s.Count, s.Coins)
+}
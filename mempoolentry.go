@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// fetchMempoolEntry looks txid up in the node's mempool.  A nil entry with no
+// error means it isn't there: confirmed, evicted, or never seen.
+func fetchMempoolEntry(u *url.URL, txid string) (*MempoolEntry, error) {
+	var e *MempoolEntry
+	var err = rpcCall(nodeURL(u), "getmempoolentry", []interface{}{txid}, &e)
+	if isNotFound(err) {
+		return nil, nil
+	}
+	return e, err
+}
+
+// baseFee is the fee the transaction itself pays, in coins, before any
+// prioritisetransaction bump, from whichever field the node gave
+func (e MempoolEntry) baseFee() float64 {
+	if e.Fees.Base != 0 {
+		return e.Fees.Base
+	}
+	return e.Fee
+}
+
+// printMempoolEntry shows what the mempool knows of a transaction that's
+// waiting to confirm: how big it is and what it pays, how long it's been
+// waiting, and what it's tied to
+func printMempoolEntry(txid string, e *MempoolEntry, now time.Time) {
+	if e == nil {
+		fmt.Println("Transaction not in mempool")
+		return
+	}
+	var entered = time.Unix(e.Time, 0)
+	var depends = "none"
+	if len(e.Depends) > 0 {
+		depends = strings.Join(e.Depends, ", ")
+	}
+	fmt.Printf("Transaction:      %s\n", txid)
+	fmt.Printf("Size:             %d vB\n", e.vbytes())
+	fmt.Printf("Fee:              %0.8f (%0.2f sat/vB)\n", e.baseFee(), feeRate(e.baseFee(), e.vbytes()))
+	fmt.Printf("Modified fee:     %0.8f (%0.2f sat/vB)\n", e.fee(), feeRate(e.fee(), e.vbytes()))
+	fmt.Printf("Entered:          %s (%s ago)\n", entered.Format(dateTimeLayout), formatAge(now.Sub(entered)))
+	fmt.Printf("Height:           %d\n", e.Height)
+	fmt.Printf("Ancestors:        %d\n", e.AncestorCount)
+	fmt.Printf("Descendants:      %d\n", e.DescendantCount)
+	fmt.Printf("Depends on:       %s\n", depends)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Nerdmaster/dynamo-tx-stats/stats"
+)
+
+// parseMergeURLs reads --merge-urls.  A URL without credentials gets the
+// ones given for the main node.
+func parseMergeURLs(list, user, password string) ([]*url.URL, error) {
+	var urls []*url.URL
+	for _, raw := range strings.Split(list, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		var u, err = url.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL %q: %s", raw, err)
+		}
+		if u.User == nil {
+			u.User = url.UserPassword(user, password)
+		}
+		urls = append(urls, u)
+	}
+	return urls, nil
+}
+
+// dedupeTransactions drops entries that more than one node reported,
+// keeping the first, so the merged list counts each output once
+func dedupeTransactions(list []*Transaction) []*Transaction {
+	var seen = make(map[string]bool)
+	var out []*Transaction
+	for _, tx := range list {
+		var key = txDiffKey(tx)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, tx)
+	}
+	return out
+}
+
+// sourceTotal is one node's share of a --merge-urls report, as that node
+// sees it on its own
+type sourceTotal struct {
+	Source       string  `json:"source"`
+	Transactions int     `json:"transactions"`
+	Coins        float64 `json:"coins"`
+	Blocks       int64   `json:"blocks"`
+}
+
+// sourceTotals totals each node's countable generations in the window
+// separately, before the lists are merged, so a node that's missing blocks
+// the others have stands out
+func sourceTotals(list []*Transaction, sources []string, field string, begin time.Time, days int, now time.Time) []sourceTotal {
+	var accs = make(map[string]*stats.Accumulator)
+	var counts = make(map[string]int)
+	for _, s := range sources {
+		accs[s] = stats.NewAccumulator(begin, days)
+	}
+	for _, tx := range list {
+		counts[tx.source]++
+		tx.dt = txTime(tx, field)
+		if countable(tx, now) {
+			accs[tx.source].Add(stats.Transaction{TXID: tx.TXID, Vout: tx.Vout, Amount: tx.Amount, Blockheight: tx.Blockheight, Time: tx.dt})
+		}
+	}
+
+	var totals []sourceTotal
+	for _, s := range sources {
+		var total = accs[s].Snapshot().Total
+		totals = append(totals, sourceTotal{Source: s, Transactions: counts[s], Coins: total.Coins, Blocks: total.Blocks})
+	}
+	return totals
+}
+
+func (v *reportView) printSourceTotals(w io.Writer) {
+	fmt.Fprintln(w, "Per-source totals (before merging):")
+	for _, s := range v.SourceTotals {
+		fmt.Fprintf(w, "  %s:\t%8.2f\t%d blocks\t%d transactions\n", s.Source, s.Coins, s.Blocks, s.Transactions)
+	}
+	fmt.Fprintf(w, "  merged:\t%8.2f\n", v.Total)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// miningInfo is the part of getmininginfo --mining-info shows.  The
+// current block fields are only there once the node has built a block
+// template.
+type miningInfo struct {
+	Chain              string  `json:"chain"`
+	Blocks             int64   `json:"blocks"`
+	Difficulty         float64 `json:"difficulty"`
+	NetworkHashPS      float64 `json:"networkhashps"`
+	PooledTx           int64   `json:"pooledtx"`
+	CurrentBlockWeight *int64  `json:"currentblockweight"`
+	CurrentBlockTx     *int64  `json:"currentblocktx"`
+}
+
+func printMiningInfo(u *url.URL) error {
+	var info miningInfo
+	var err = rpcCall(nodeURL(u), "getmininginfo", nil, &info)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Chain:              %s\n", info.Chain)
+	fmt.Printf("Blocks:             %d\n", info.Blocks)
+	fmt.Printf("Difficulty:         %0.2f\n", info.Difficulty)
+	fmt.Printf("Network hashrate:   %s\n", formatHashrate(info.NetworkHashPS))
+	fmt.Printf("Mempool:            %d transactions\n", info.PooledTx)
+	if info.CurrentBlockWeight != nil && info.CurrentBlockTx != nil {
+		fmt.Printf("Last template:      %d transactions, %d WU\n", *info.CurrentBlockTx, *info.CurrentBlockWeight)
+	}
+	return nil
+}
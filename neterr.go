@@ -0,0 +1,100 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// netFailure is a request that never got an answer from the node, told in
+// terms of what to go and check.  The error it came from is kept for
+// errors.Is and errors.As.
+type netFailure struct {
+	msg string
+	err error
+}
+
+func (f *netFailure) Error() string { return f.msg }
+func (f *netFailure) Unwrap() error { return f.err }
+
+// classifyNetError sorts a failed request into a DNS, connection, or TLS
+// problem, so a mistyped host, a firewalled port, and a bad certificate
+// don't all read as the same "Post ... failed"
+func classifyNetError(u *url.URL, err error, elapsed time.Duration) error {
+	var elapsedStr = elapsed.Round(time.Millisecond).String()
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return &netFailure{fmt.Sprintf("unable to resolve host %q: %s; check the host name in the URL", dnsErr.Name, dnsErr.Err), err}
+	}
+
+	var unknownCA x509.UnknownAuthorityError
+	if errors.As(err, &unknownCA) {
+		return &netFailure{fmt.Sprintf("TLS: the certificate of %s (subject %q) is signed by an unknown authority; install its CA or check you're talking to the right server", u.Host, certSubject(unknownCA.Cert)), err}
+	}
+	var badHost x509.HostnameError
+	if errors.As(err, &badHost) {
+		return &netFailure{fmt.Sprintf("TLS: the certificate of %s (subject %q) isn't valid for %q; use the name it was issued for", u.Host, certSubject(badHost.Certificate), badHost.Host), err}
+	}
+	var invalid x509.CertificateInvalidError
+	if errors.As(err, &invalid) {
+		return &netFailure{fmt.Sprintf("TLS: the certificate of %s (subject %q) is invalid: %s", u.Host, certSubject(invalid.Cert), invalid.Error()), err}
+	}
+	// net/http turns a plain-HTTP reply to a TLS hello into an untyped error,
+	// so that one's known only by its message
+	var notTLS tls.RecordHeaderError
+	if errors.As(err, &notTLS) || strings.Contains(err.Error(), "server gave HTTP response to HTTPS client") {
+		return &netFailure{fmt.Sprintf("TLS handshake with %s failed: the server isn't speaking TLS; try http:// instead", u.Host), err}
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		var addr = u.Host
+		if opErr.Addr != nil {
+			addr = opErr.Addr.String()
+		}
+		switch {
+		case errors.Is(err, syscall.ECONNREFUSED):
+			return &netFailure{fmt.Sprintf("connection to %s refused after %s; is the node running and listening there (rpcport, rpcbind)?", addr, elapsedStr), err}
+		case opErr.Timeout():
+			return &netFailure{fmt.Sprintf("connection to %s timed out after %s; a firewall may be dropping it, or the node isn't listening on that address", addr, elapsedStr), err}
+		}
+		return &netFailure{fmt.Sprintf("unable to connect to %s after %s: %s", addr, elapsedStr, opErr.Err), err}
+	}
+	if errors.Is(err, syscall.ECONNRESET) {
+		return &netFailure{fmt.Sprintf("the connection to %s was reset after %s; a proxy or firewall may be cutting it off, or the node is overloaded (rpcworkqueue)", u.Host, elapsedStr), err}
+	}
+	var timeout interface{ Timeout() bool }
+	if errors.As(err, &timeout) && timeout.Timeout() {
+		return &netFailure{fmt.Sprintf("no reply from %s after %s; the node may be busy, or something between here and it is holding the connection", u.Host, elapsedStr), err}
+	}
+	return err
+}
+
+func certSubject(c *x509.Certificate) string {
+	if c == nil {
+		return "unknown"
+	}
+	return c.Subject.String()
+}
+
+// httpStatusHints explains the statuses that come without a JSON-RPC body
+var httpStatusHints = map[int]string{
+	http.StatusUnauthorized: "check the RPC username and password",
+	http.StatusForbidden:    "the node doesn't accept RPC from this address; check rpcallowip",
+	http.StatusNotFound:     "check the URL's path, and the wallet name in it",
+}
+
+// httpStatusError is the error for a reply that isn't JSON-RPC at all
+func httpStatusError(r *http.Response) error {
+	if hint, ok := httpStatusHints[r.StatusCode]; ok {
+		return fmt.Errorf("HTTP %s; %s", r.Status, hint)
+	}
+	return fmt.Errorf("HTTP %s; is the URL the node's RPC port?", r.Status)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// dialTimeout is the error a dial to an address that never answers gives
+type dialTimeout struct{}
+
+func (dialTimeout) Error() string   { return "i/o timeout" }
+func (dialTimeout) Timeout() bool   { return true }
+func (dialTimeout) Temporary() bool { return true }
+
+// Each class of failure names what failed and says what to check
+func TestNetErrorClasses(t *testing.T) {
+	var refused, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var refusedAddr = refused.Addr().String()
+	refused.Close()
+
+	var tlsNode = httptest.NewUnstartedServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	tlsNode.Config.ErrorLog = log.New(io.Discard, "", 0)
+	tlsNode.StartTLS()
+	defer tlsNode.Close()
+	var plainNode = httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer plainNode.Close()
+	var authNode = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer authNode.Close()
+
+	var tests = []struct {
+		name   string
+		url    string
+		net    bool
+		phrase []string
+	}{
+		{"dns", "http://no-such-node.invalid:9341/", true, []string{`unable to resolve host "no-such-node.invalid"`, "check the host name"}},
+		{"refused", "http://" + refusedAddr + "/", true, []string{"connection to " + refusedAddr + " refused after", "is the node running"}},
+		{"unknown CA", tlsNode.URL, true, []string{"signed by an unknown authority", `subject "O=Acme Co"`, "install its CA"}},
+		{"not TLS", strings.Replace(plainNode.URL, "http:", "https:", 1), true, []string{"isn't speaking TLS", "try http://"}},
+		{"HTTP", authNode.URL, false, []string{"HTTP 401 Unauthorized", "check the RPC username and password"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var u, _ = url.Parse(tt.url)
+			var err = rpcCall(u, "getblockchaininfo", nil, nil)
+			if err == nil {
+				t.Fatal("no error")
+			}
+			var nf *netFailure
+			if errors.As(err, &nf) != tt.net {
+				t.Errorf("netFailure %v, want %v: %v", !tt.net, tt.net, err)
+			}
+			for _, p := range tt.phrase {
+				if !strings.Contains(err.Error(), p) {
+					t.Errorf("%q doesn't say %q", err, p)
+				}
+			}
+		})
+	}
+}
+
+// A firewall dropping packets takes too long to provoke for real, so the
+// timeout is classified from the error a dial gives
+func TestNetErrorTimeout(t *testing.T) {
+	var u, _ = url.Parse("http://10.255.255.1:9341/")
+	var dialErr = &net.OpError{Op: "dial", Net: "tcp", Addr: &net.TCPAddr{IP: net.ParseIP("10.255.255.1"), Port: 9341}, Err: dialTimeout{}}
+	var err = classifyNetError(u, &url.Error{Op: "Post", URL: u.String(), Err: dialErr}, 30*time.Second)
+	var want = "connection to 10.255.255.1:9341 timed out after 30s; a firewall may be dropping it, or the node isn't listening on that address"
+	if err.Error() != want {
+		t.Errorf("got %q, want %q", err, want)
+	}
+	if !errors.Is(err, dialErr) {
+		t.Error("the dial error isn't kept")
+	}
+}
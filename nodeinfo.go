@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+)
+
+// staleBanAge is how old an active ban has to be before it's called out as
+// one somebody probably forgot about
+const staleBanAge = 30 * 24 * time.Hour
+
+type networkInfo struct {
+	Version         int64   `json:"version"`
+	Subversion      string  `json:"subversion"`
+	ProtocolVersion int64   `json:"protocolversion"`
+	Connections     int64   `json:"connections"`
+	ConnectionsIn   *int64  `json:"connections_in"`
+	RelayFee        float64 `json:"relayfee"`
+	IncrementalFee  float64 `json:"incrementalfee"`
+	LocalAddresses  []struct {
+		Address string `json:"address"`
+		Port    int64  `json:"port"`
+		Score   int64  `json:"score"`
+	} `json:"localaddresses"`
+}
+
+// satPerVB converts a fee rate in coins per kvB, as the node gives them,
+// to sat/vB
+func satPerVB(perKvB float64) float64 {
+	return perKvB * satoshisPerCoin / 1000
+}
+
+// printRelayFees shows the node's relay thresholds, and how far the
+// next-block fee estimate sits above the minimum relay fee: a transaction
+// paying near the minimum is relayed, but may wait a long time to be mined
+func printRelayFees(u *url.URL, net networkInfo) {
+	fmt.Printf("Relay fee:     %0.2f sat/vB (%0.8f/kvB)\n", satPerVB(net.RelayFee), net.RelayFee)
+	fmt.Printf("Incremental:   %0.2f sat/vB (%0.8f/kvB)\n", satPerVB(net.IncrementalFee), net.IncrementalFee)
+
+	var est struct {
+		FeeRate float64 `json:"feerate"`
+	}
+	var err = rpcCall(nodeURL(u), "estimatesmartfee", []interface{}{1}, &est)
+	switch {
+	case err != nil:
+		fmt.Printf("Fast fee:      unavailable (%s)\n", err)
+	case est.FeeRate <= 0:
+		fmt.Println("Fast fee:      unavailable (the node hasn't seen enough blocks to estimate)")
+	case net.RelayFee > 0:
+		fmt.Printf("Fast fee:      %0.2f sat/vB, %0.1fx the relay fee\n", satPerVB(est.FeeRate), est.FeeRate/net.RelayFee)
+	default:
+		fmt.Printf("Fast fee:      %0.2f sat/vB\n", satPerVB(est.FeeRate))
+	}
+}
+
+type bannedPeer struct {
+	Address     string `json:"address"`
+	BanCreated  int64  `json:"ban_created"`
+	BannedUntil int64  `json:"banned_until"`
+}
+
+func (b bannedPeer) stale(now time.Time) bool {
+	return now.Sub(time.Unix(b.BanCreated, 0)) > staleBanAge && time.Unix(b.BannedUntil, 0).After(now)
+}
+
+func fetchBanned(u *url.URL) ([]bannedPeer, error) {
+	var bans []bannedPeer
+	var err = rpcCall(nodeURL(u), "listbanned", nil, &bans)
+	return bans, err
+}
+
+func printNodeInfo(u *url.URL, showBanned, showPeers bool) error {
+	var net networkInfo
+	var err = rpcCall(nodeURL(u), "getnetworkinfo", nil, &net)
+	if err != nil {
+		return err
+	}
+	var chain blockchainInfo
+	err = rpcCall(nodeURL(u), "getblockchaininfo", nil, &chain)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Node version:  %d %s (protocol %d)\n", net.Version, net.Subversion, net.ProtocolVersion)
+	fmt.Printf("Chain:         %s\n", chain.Chain)
+	fmt.Printf("Blocks:        %d / %d headers (%0.4f%% verified)\n", chain.Blocks, chain.Headers, chain.VerificationProgress*100)
+	fmt.Printf("Connections:   %d\n", net.Connections)
+	printRelayFees(u, net)
+	if len(net.LocalAddresses) == 0 {
+		fmt.Println("Local addrs:   none")
+	}
+	for i, a := range net.LocalAddresses {
+		var label = ""
+		if i == 0 {
+			label = "Local addrs:"
+		}
+		fmt.Printf("%-14s %s:%d (score %d)\n", label, a.Address, a.Port, a.Score)
+	}
+
+	if showPeers {
+		err = printPeers(u)
+		if err != nil {
+			return err
+		}
+	}
+	if !showBanned {
+		return nil
+	}
+
+	var bans []bannedPeer
+	bans, err = fetchBanned(u)
+	if err != nil {
+		return err
+	}
+	var now = time.Now()
+	fmt.Println()
+	fmt.Printf("Banned peers: %d\n", len(bans))
+	for _, b := range bans {
+		var note = ""
+		if b.stale(now) {
+			note = "  [STALE: banned over 30 days ago and still active]"
+		}
+		fmt.Printf("  %-40s created %s  until %s%s\n", b.Address,
+			time.Unix(b.BanCreated, 0).Format("2006-01-02 15:04"),
+			time.Unix(b.BannedUntil, 0).Format("2006-01-02 15:04"), note)
+	}
+
+	return nil
+}
+
+type zmqNotification struct {
+	Type    string `json:"type"`
+	Address string `json:"address"`
+	HWM     int64  `json:"hwm"`
+}
+
+// printZMQInfo lists the node's ZMQ publishers.  A node built without ZMQ
+// doesn't have getzmqnotifications at all, which is the same answer as one
+// with nothing configured.
+func printZMQInfo(u *url.URL) error {
+	var topics []zmqNotification
+	var err = rpcCall(nodeURL(u), "getzmqnotifications", nil, &topics)
+	if isMethodNotFound(err) {
+		fmt.Println("ZMQ not configured (node has no ZMQ support)")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(topics) == 0 {
+		fmt.Println("ZMQ not configured")
+		return nil
+	}
+	for _, t := range topics {
+		fmt.Printf("%-16s %s (hwm %d)\n", t.Type, t.Address, t.HWM)
+	}
+	return nil
+}
+
+type txOutSetInfo struct {
+	Height          int64   `json:"height"`
+	BestBlock       string  `json:"bestblock"`
+	Transactions    int64   `json:"transactions"`
+	TxOuts          int64   `json:"txouts"`
+	BogoSize        int64   `json:"bogosize"`
+	TotalAmount     float64 `json:"total_amount"`
+	MuHash          string  `json:"muhash"`
+	HashSerialized2 string  `json:"hash_serialized_2"`
+	HashSerialized3 string  `json:"hash_serialized_3"`
+}
+
+// printUTXOSet prints gettxoutsetinfo.  Without the coinstatsindex the node
+// walks the whole UTXO set, which can take minutes; with it, only the muhash
+// is available, and the index must be enabled on the node.
+func printUTXOSet(u *url.URL, useIndex bool) error {
+	var params []interface{}
+	if useIndex {
+		params = []interface{}{"muhash", nil, true}
+	} else {
+		fmt.Fprintln(os.Stderr, "Scanning the UTXO set; this can take several minutes (--utxo-set-index uses the coinstatsindex instead)")
+	}
+
+	var info txOutSetInfo
+	var err = rpcCall(nodeURL(u), "gettxoutsetinfo", params, &info)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Height:        %d\n", info.Height)
+	fmt.Printf("Best block:    %s\n", info.BestBlock)
+	if info.Transactions > 0 {
+		fmt.Printf("Transactions:  %d\n", info.Transactions)
+	}
+	fmt.Printf("Txouts:        %d\n", info.TxOuts)
+	fmt.Printf("Bogosize:      %d\n", info.BogoSize)
+	fmt.Printf("Total amount:  %0.8f\n", info.TotalAmount)
+	switch {
+	case info.MuHash != "":
+		fmt.Printf("MuHash:        %s\n", info.MuHash)
+	case info.HashSerialized3 != "":
+		fmt.Printf("Hash (ser. 3): %s\n", info.HashSerialized3)
+	case info.HashSerialized2 != "":
+		fmt.Printf("Hash (ser. 2): %s\n", info.HashSerialized2)
+	}
+	return nil
+}
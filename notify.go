@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/Nerdmaster/dynamo-tx-stats/stats"
+)
+
+// blockWebhookSchema is the version of blockNotification.  Bump it whenever
+// a field changes meaning or goes away; adding fields doesn't need a bump.
+const blockWebhookSchema = 1
+
+// blockNotification is what --block-webhook posts for each newly found block
+type blockNotification struct {
+	Schema  int     `json:"schema"`
+	Wallet  string  `json:"wallet"`
+	TXID    string  `json:"txid"`
+	Height  int64   `json:"height"`
+	Amount  float64 `json:"amount"`
+	Time    int64   `json:"time"`
+	Message string  `json:"text"`
+
+	// DayTotal is the wallet's total for the block's day, this block included
+	DayTotal float64 `json:"day_total"`
+
+	// PreviousBlockAge is how long it had been since the wallet's previous
+	// block, in seconds, or absent if there isn't one in the window
+	PreviousBlockAge *int64 `json:"previous_block_age,omitempty"`
+
+	// DailyAverage is the wallet's average per day over the report window
+	DailyAverage float64 `json:"daily_average"`
+
+	// Difficulty is absent if the node wouldn't say
+	Difficulty *float64 `json:"difficulty,omitempty"`
+}
+
+// newBlockNotification fills in the context for one new block from the
+// wallet's current snapshot and the cache's earlier entries
+func newBlockNotification(u *url.URL, c *txCache, e cachedTx) blockNotification {
+	var n = blockNotification{
+		Schema: blockWebhookSchema,
+		Wallet: e.wallet,
+		TXID:   e.txid,
+		Height: e.height,
+		Amount: e.amount,
+		Time:   e.t.Unix(),
+	}
+
+	var report = c.snapshot(e.wallet)
+	for i := range report.Daily {
+		if getDay(e.t).Equal(report.Day(i)) {
+			n.DayTotal = report.Daily[i].Coins
+		}
+	}
+	if report.Days > 0 {
+		n.DailyAverage = report.Total.Coins / float64(report.Days)
+	}
+	if prev, ok := c.previous(e); ok {
+		var age = int64(e.t.Sub(prev.t).Seconds())
+		n.PreviousBlockAge = &age
+	}
+
+	var difficulty float64
+	var err = rpcCall(nodeURL(u), "getdifficulty", nil, &difficulty)
+	if err == nil {
+		n.Difficulty = &difficulty
+	}
+
+	n.Message = fmt.Sprintf("%s found block %d: %0.8f (%0.2f today, averaging %0.2f/day)", n.Wallet, n.Height, n.Amount, n.DayTotal, n.DailyAverage)
+	if n.PreviousBlockAge != nil {
+		n.Message += fmt.Sprintf(", %s since the last one", formatAge(time.Duration(*n.PreviousBlockAge)*time.Second))
+	}
+	return n
+}
+
+// notifyBlocks posts a notification for each new block to every webhook
+func notifyBlocks(u *url.URL, c *txCache, hooks []string, found []cachedTx) {
+	for _, e := range found {
+		var n = newBlockNotification(u, c, e)
+		for _, hook := range hooks {
+			var err = postJSON(hook, n)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: unable to send block %d notification to %s: %s\n", time.Now().Format("2006-01-02 15:04:05"), e.height, webhookSink{url: hook}.name(), err)
+				serveNotifyFailures.Add(1)
+				continue
+			}
+			serveNotifications.Add(1)
+		}
+	}
+}
+
+// walletAccumulators builds an Accumulator per wallet from the cache entries
+func walletAccumulators(entries []cachedTx, start time.Time, days int) map[string]*stats.Accumulator {
+	var accs = make(map[string]*stats.Accumulator)
+	for _, e := range entries {
+		var acc = accs[e.wallet]
+		if acc == nil {
+			acc = stats.NewAccumulator(start, days)
+			accs[e.wallet] = acc
+		}
+		acc.Add(stats.Transaction{TXID: e.txid, Vout: e.vout, Amount: e.amount, Blockheight: e.height, Time: e.t})
+	}
+	return accs
+}
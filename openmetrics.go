@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The two formats /metrics can answer in.  Only OpenMetrics has exemplars,
+// so a scraper gets them by asking for it, as Prometheus does when its
+// exemplar storage is on.
+const (
+	promTextType    = "text/plain; version=0.0.4; charset=utf-8"
+	openMetricsType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// foundCount is what serve has seen a wallet find since it started, and the
+// most recent block among it
+type foundCount struct {
+	blocks int64
+	coins  float64
+	last   cachedTx
+}
+
+// metricFamily is one metric and its series.  A counter's or info's name
+// leaves off the _total or _info its samples carry, as OpenMetrics names
+// families.
+type metricFamily struct {
+	name    string
+	help    string
+	kind    string
+	samples []metricSample
+}
+
+type metricSample struct {
+	labels   [][2]string
+	value    float64
+	exemplar *metricExemplar
+}
+
+// metricExemplar ties a counter's latest increment to the block behind it
+type metricExemplar struct {
+	labels [][2]string
+	value  float64
+	time   time.Time
+}
+
+// wantsOpenMetrics is whether an Accept header lists OpenMetrics among what
+// it takes.  Scrapers list it first when they want it, so its q-value isn't
+// weighed against the text format's.
+func wantsOpenMetrics(accept string) bool {
+	for _, r := range strings.Split(accept, ",") {
+		var t, _, err = mime.ParseMediaType(strings.TrimSpace(r))
+		if err == nil && t == "application/openmetrics-text" {
+			return true
+		}
+	}
+	return false
+}
+
+// serveFamilies is what /metrics exports: each wallet's coins in the window
+// and so far today, counters of the blocks and coins found since serve
+// started, each with the latest block as its exemplar, and that block again
+// as an info gauge for scrapers that don't keep exemplars
+func serveFamilies(c *txCache, wallets []string) []metricFamily {
+	var window = metricFamily{name: "txstats_window_coins", help: "Coins generated in the serve window", kind: "gauge"}
+	var today = metricFamily{name: "txstats_today_coins", help: "Coins generated so far today", kind: "gauge"}
+	var blocks = metricFamily{name: "txstats_blocks_found", help: "Blocks found since serve started", kind: "counter"}
+	var coins = metricFamily{name: "txstats_coins_found", help: "Coins in blocks found since serve started", kind: "counter"}
+	var last = metricFamily{name: "txstats_last_block", help: "The most recent block won in the serve window", kind: "info"}
+
+	for _, w := range wallets {
+		var r = c.snapshot(w)
+		var wallet = [][2]string{{"wallet", w}}
+		window.samples = append(window.samples, metricSample{labels: wallet, value: r.Total.Coins})
+		var t float64
+		if len(r.Daily) > 0 {
+			t = r.Daily[len(r.Daily)-1].Coins
+		}
+		today.samples = append(today.samples, metricSample{labels: wallet, value: t})
+	}
+
+	c.mu.RLock()
+	for _, w := range wallets {
+		var wallet = [][2]string{{"wallet", w}}
+		var n = c.found[w]
+		if n == nil {
+			blocks.samples = append(blocks.samples, metricSample{labels: wallet})
+			coins.samples = append(coins.samples, metricSample{labels: wallet})
+			continue
+		}
+		var block = [][2]string{{"txid", n.last.txid}, {"height", strconv.FormatInt(n.last.height, 10)}}
+		blocks.samples = append(blocks.samples, metricSample{labels: wallet, value: float64(n.blocks),
+			exemplar: &metricExemplar{labels: block, value: 1, time: n.last.t}})
+		coins.samples = append(coins.samples, metricSample{labels: wallet, value: n.coins,
+			exemplar: &metricExemplar{labels: block, value: n.last.amount, time: n.last.t}})
+	}
+	if len(c.entries) > 0 {
+		var e = c.entries[len(c.entries)-1]
+		last.samples = append(last.samples, metricSample{value: 1,
+			labels: [][2]string{{"txid", e.txid}, {"height", strconv.FormatInt(e.height, 10)}, {"wallet", e.wallet}}})
+	}
+	c.mu.RUnlock()
+
+	return []metricFamily{window, today, blocks, coins, last}
+}
+
+// writeExposition renders families in the OpenMetrics format, exemplars
+// and all, or else in the Prometheus text format, which has no place for
+// them
+func writeExposition(w io.Writer, families []metricFamily, openMetrics bool) {
+	for _, f := range families {
+		var sample, name, kind = f.name, f.name, f.kind
+		switch f.kind {
+		case "counter":
+			sample += "_total"
+		case "info":
+			// the text format has no info type; an info is a gauge of 1
+			sample += "_info"
+			if !openMetrics {
+				kind = "gauge"
+			}
+		}
+		if !openMetrics {
+			name = sample
+		}
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, f.help, name, kind)
+		for _, s := range f.samples {
+			fmt.Fprintf(w, "%s%s %s", sample, formatLabels(s.labels), formatValue(s.value))
+			if openMetrics && s.exemplar != nil {
+				var e = s.exemplar
+				fmt.Fprintf(w, " # %s %s %s", formatLabels(e.labels), formatValue(e.value), strconv.FormatFloat(float64(e.time.UnixNano())/1e9, 'f', 3, 64))
+			}
+			fmt.Fprintln(w)
+		}
+	}
+	if openMetrics {
+		fmt.Fprintln(w, "# EOF")
+	}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatLabels writes a label set as {name="value",...}, escaped the way
+// both formats read it, or nothing for an empty one
+func formatLabels(labels [][2]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	var parts = make([]string, len(labels))
+	for i, l := range labels {
+		parts[i] = fmt.Sprintf(`%s="%s"`, l[0], labelEscaper.Replace(l[1]))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestWantsOpenMetrics(t *testing.T) {
+	var tests = map[string]bool{
+		"": false,
+		"text/plain;version=0.0.4;q=0.5,*/*;q=0.1": false,
+		"application/openmetrics-text;version=1.0.0,application/openmetrics-text;version=0.0.1;q=0.75,text/plain;version=0.0.4;q=0.5,*/*;q=0.1": true,
+		"text/plain, application/openmetrics-text; version=1.0.0":                                                                               true,
+		"application/openmetrics-textual": false,
+	}
+	for accept, want := range tests {
+		if got := wantsOpenMetrics(accept); got != want {
+			t.Errorf("%q: got %v, want %v", accept, got, want)
+		}
+	}
+}
+
+// Exemplar syntax is easy to get wrong by hand, so both formats are pinned
+// to the byte
+func TestWriteExposition(t *testing.T) {
+	var at = time.Unix(1709510400, 250000000)
+	var block = [][2]string{{"txid", "c0ffee"}, {"height", "812345"}}
+	var families = []metricFamily{
+		{name: "txstats_today_coins", help: "Coins generated so far today", kind: "gauge", samples: []metricSample{
+			{labels: [][2]string{{"wallet", `rig "one"\n`}}, value: 10.5},
+			{labels: [][2]string{{"wallet", "rig2"}}, value: math.Inf(1)},
+		}},
+		{name: "txstats_blocks_found", help: "Blocks found since serve started", kind: "counter", samples: []metricSample{
+			{labels: [][2]string{{"wallet", "rig1"}}, value: 2, exemplar: &metricExemplar{labels: block, value: 1, time: at}},
+			{labels: [][2]string{{"wallet", "rig2"}}},
+		}},
+		{name: "txstats_last_block", help: "The most recent block won in the serve window", kind: "info", samples: []metricSample{
+			{labels: [][2]string{{"txid", "c0ffee"}, {"height", "812345"}, {"wallet", "rig1"}}, value: 1},
+		}},
+	}
+
+	var openMetrics = `# HELP txstats_today_coins Coins generated so far today
+# TYPE txstats_today_coins gauge
+txstats_today_coins{wallet="rig \"one\"\\n"} 10.5
+txstats_today_coins{wallet="rig2"} +Inf
+# HELP txstats_blocks_found Blocks found since serve started
+# TYPE txstats_blocks_found counter
+txstats_blocks_found_total{wallet="rig1"} 2 # {txid="c0ffee",height="812345"} 1 1709510400.250
+txstats_blocks_found_total{wallet="rig2"} 0
+# HELP txstats_last_block The most recent block won in the serve window
+# TYPE txstats_last_block info
+txstats_last_block_info{txid="c0ffee",height="812345",wallet="rig1"} 1
+# EOF
+`
+	var text = `# HELP txstats_today_coins Coins generated so far today
+# TYPE txstats_today_coins gauge
+txstats_today_coins{wallet="rig \"one\"\\n"} 10.5
+txstats_today_coins{wallet="rig2"} +Inf
+# HELP txstats_blocks_found_total Blocks found since serve started
+# TYPE txstats_blocks_found_total counter
+txstats_blocks_found_total{wallet="rig1"} 2
+txstats_blocks_found_total{wallet="rig2"} 0
+# HELP txstats_last_block_info The most recent block won in the serve window
+# TYPE txstats_last_block_info gauge
+txstats_last_block_info{txid="c0ffee",height="812345",wallet="rig1"} 1
+`
+	for _, tt := range []struct {
+		om   bool
+		want string
+	}{{true, openMetrics}, {false, text}} {
+		var buf bytes.Buffer
+		writeExposition(&buf, families, tt.om)
+		if buf.String() != tt.want {
+			t.Errorf("openMetrics %v: got\n%s\nwant\n%s", tt.om, buf.String(), tt.want)
+		}
+	}
+}
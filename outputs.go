@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// outputSpec is one --sink: a format and where it goes.  An empty target
+// (or "-") is stdout.
+type outputSpec struct {
+	kind   string
+	target string
+}
+
+func (s outputSpec) String() string {
+	if s.stdout() {
+		return s.kind + " to stdout"
+	}
+	return s.kind + " to " + s.target
+}
+
+func (s outputSpec) stdout() bool {
+	return s.target == "" || s.target == "-"
+}
+
+// parseOutputSpec reads a --sink value: text, json, or html with an
+// optional ":path", csv:path, or pushgateway:url
+func parseOutputSpec(s string) (outputSpec, error) {
+	var kind, target, _ = strings.Cut(s, ":")
+	var spec = outputSpec{kind: kind, target: target}
+	switch kind {
+	case "text", "json", "html":
+		return spec, nil
+	case "csv", "pushgateway", "textfile":
+		if spec.stdout() {
+			return spec, fmt.Errorf("%s sink needs a target, as in %s:%s", kind, kind, map[string]string{"csv": "path", "pushgateway": "url", "textfile": "path"}[kind])
+		}
+		return spec, nil
+	}
+	return spec, fmt.Errorf("unknown sink %q: expected text, json, html, csv, pushgateway, or textfile", kind)
+}
+
+// outputSpecs returns the configured sinks.  Without any --sink, --json or
+// --html picks the one stdout format, as it always has.  --textfile is one
+// more sink on top of either.
+func outputSpecs(cfg *config) ([]outputSpec, error) {
+	var specs, err = stdoutSpecs(cfg)
+	if err == nil && cfg.Textfile != "" {
+		specs = append(specs, outputSpec{kind: "textfile", target: cfg.Textfile})
+	}
+	return specs, err
+}
+
+func stdoutSpecs(cfg *config) ([]outputSpec, error) {
+	if len(cfg.Sinks) == 0 {
+		switch {
+		case cfg.HTML:
+			return []outputSpec{{kind: "html"}}, nil
+		case cfg.JSON:
+			return []outputSpec{{kind: "json"}}, nil
+		}
+		return []outputSpec{{kind: "text"}}, nil
+	}
+
+	var specs []outputSpec
+	var stdout int
+	for _, s := range cfg.Sinks {
+		var spec, err = parseOutputSpec(s)
+		if err != nil {
+			return nil, err
+		}
+		if spec.kind != "csv" && spec.kind != "pushgateway" && spec.kind != "textfile" && spec.stdout() {
+			stdout++
+		}
+		specs = append(specs, spec)
+	}
+	if stdout > 1 {
+		return nil, fmt.Errorf("only one sink can write to stdout")
+	}
+	return specs, nil
+}
+
+// writeOutputs hands the report to every sink.  printText renders the full
+// text report to stdout; a text sink writing to a file gets the summary,
+// daily, and hourly tables, since the other text sections query the node as
+// they print.  A failed sink is reported and the rest still run; the return
+// value is how many failed.
+func writeOutputs(specs []outputSpec, v *reportView, printText func()) int {
+	var failed int
+	for _, spec := range specs {
+		var err = writeOutput(spec, v, printText)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to write %s: %s\n", spec, err)
+			failed++
+		}
+	}
+	return failed
+}
+
+func writeOutput(spec outputSpec, v *reportView, printText func()) error {
+	switch spec.kind {
+	case "csv":
+		return appendCSVLog(spec.target, v)
+	case "pushgateway":
+		return pushMetrics(spec.target, v)
+	case "textfile":
+		return writeTextfile(spec.target, v)
+	}
+
+	if spec.kind == "text" && spec.stdout() {
+		printText()
+		return nil
+	}
+
+	var w io.Writer = os.Stdout
+	var f *os.File
+	if !spec.stdout() {
+		var err error
+		f, err = os.Create(spec.target)
+		if err != nil {
+			return err
+		}
+		w = f
+	}
+
+	var err error
+	switch spec.kind {
+	case "json":
+		err = v.writeJSON(w)
+	case "html":
+		err = v.writeHTML(w)
+	default:
+		v.printSummary(w)
+		v.printDaily(w, 0)
+		v.printHourly(w, 0)
+	}
+	if f != nil {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+var csvLogHeader = []string{"generated", "days", "total", "daily_average", "hourly_average", "win_percent", "today"}
+
+// appendCSVLog adds one row per run to the CSV file at path, writing the
+// header first if the file is new or empty.  --split adds a column per
+// party; a file whose header doesn't match the columns is left alone rather
+// than given rows that don't line up with it.
+func appendCSVLog(path string, v *reportView) error {
+	var header = csvLogHeader
+	var extra []string
+	if v.Split != nil {
+		var cols []string
+		cols, extra = v.Split.csvColumns()
+		header = append(append([]string(nil), csvLogHeader...), cols...)
+	}
+	var existing, err = readCSVHeader(path)
+	if err != nil {
+		return err
+	}
+	if existing != nil && strings.Join(existing, ",") != strings.Join(header, ",") {
+		return fmt.Errorf("its columns (%s) don't match this run's (%s); start a new file", strings.Join(existing, ","), strings.Join(header, ","))
+	}
+
+	var f *os.File
+	f, err = os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	var today float64
+	if len(v.Daily) > 0 {
+		today = v.Daily[len(v.Daily)-1].Coins
+		for _, row := range v.Daily {
+			if row.Projected != nil {
+				today = row.Coins
+			}
+		}
+	}
+
+	var cw = csv.NewWriter(f)
+	if existing == nil {
+		cw.Write(header)
+	}
+	cw.Write(append([]string{
+		v.Generated.Format(time.RFC3339),
+		fmt.Sprint(v.Days),
+		fmt.Sprintf("%0.8f", v.Total),
+		fmt.Sprintf("%0.8f", v.DailyAverage),
+		fmt.Sprintf("%0.8f", v.HourlyAverage),
+		fmt.Sprintf("%0.4f", v.WinPercent),
+		fmt.Sprintf("%0.8f", today),
+	}, extra...))
+	cw.Flush()
+	err = cw.Error()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// readCSVHeader returns the first row of the CSV file at path, or nil if
+// the file doesn't exist or is empty
+func readCSVHeader(path string) ([]string, error) {
+	var f, err = os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var header []string
+	header, err = csv.NewReader(f).Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	return header, err
+}
+
+// reportMetric is one gauge exported from the report
+type reportMetric struct {
+	name  string
+	help  string
+	value float64
+}
+
+// reportMetrics is the metric set every exporter writes, the pushgateway and
+// --textfile alike, so their names can't drift apart
+func reportMetrics(v *reportView) []reportMetric {
+	var blocks int64
+	for _, row := range v.Daily {
+		blocks += row.Blocks
+	}
+	var metrics = []reportMetric{
+		{"txstats_total_coins", "Coins generated in the report window", v.Total},
+		{"txstats_daily_average_coins", "Average coins generated per day", v.DailyAverage},
+		{"txstats_blocks", "Blocks won in the report window", float64(blocks)},
+		{"txstats_win_percent", "Rough share of blocks won", v.WinPercent},
+		{"txstats_generated_timestamp_seconds", "When the report was generated", float64(v.Generated.Unix())},
+	}
+	if v.Archive != nil && v.Archive.Audit != nil {
+		metrics = append(metrics, auditMetrics(v.Archive.Audit)...)
+	}
+	return metrics
+}
+
+// writeMetrics renders metrics in the Prometheus text format
+func writeMetrics(w io.Writer, metrics []reportMetric) {
+	for _, m := range metrics {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %g\n", m.name, m.help, m.name, m.name, m.value)
+	}
+}
+
+// pushMetrics sends the report's metrics to a Prometheus pushgateway at
+// base, under job "txstats"
+func pushMetrics(base string, v *reportView) error {
+	var buf bytes.Buffer
+	writeMetrics(&buf, reportMetrics(v))
+
+	var target = strings.TrimSuffix(base, "/") + "/metrics/job/txstats"
+	var req, err = http.NewRequest(http.MethodPut, target, &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	req.Header.Set("User-Agent", userAgent())
+
+	var r *http.Response
+	r, err = http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	r.Body.Close()
+	if r.StatusCode/100 != 2 {
+		return fmt.Errorf("%s", r.Status)
+	}
+	return nil
+}
+
+// writeTextfile writes the report's metrics, and when this run wrote them,
+// for node_exporter's textfile collector.  It's renamed into place, since
+// the collector may read the file at any moment.
+func writeTextfile(path string, v *reportView) error {
+	var buf bytes.Buffer
+	writeMetrics(&buf, append(reportMetrics(v),
+		reportMetric{"txstats_last_run_timestamp_seconds", "When txstats last wrote this file", float64(time.Now().Unix())}))
+	return writeFileAtomic(path, buf.Bytes(), 0644)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// scriptType names the standard output type of a scriptPubKey, given in
+// hex, or "other" for anything else
+func scriptType(spk string) string {
+	switch {
+	case len(spk) == 50 && strings.HasPrefix(spk, "76a914") && strings.HasSuffix(spk, "88ac"):
+		return "P2PKH"
+	case len(spk) == 46 && strings.HasPrefix(spk, "a914") && strings.HasSuffix(spk, "87"):
+		return "P2SH"
+	case len(spk) == 44 && strings.HasPrefix(spk, "0014"):
+		return "P2WPKH"
+	case len(spk) == 68 && strings.HasPrefix(spk, "0020"):
+		return "P2WSH"
+	case len(spk) == 68 && strings.HasPrefix(spk, "5120"):
+		return "P2TR"
+	}
+	return "other"
+}
+
+// fetchScriptType asks the wallet, via getaddressinfo, for an address's
+// scriptPubKey and names its type.  Any valid address has one, whether or
+// not the wallet owns it.
+func fetchScriptType(u *url.URL, wallet, addr string) (string, error) {
+	var info struct {
+		ScriptPubKey string `json:"scriptPubKey"`
+	}
+	var err = rpcCall(walletURL(u, wallet), "getaddressinfo", []interface{}{addr}, &info)
+	if err != nil {
+		return "", err
+	}
+	return scriptType(strings.ToLower(info.ScriptPubKey)), nil
+}
+
+// outputTypeRow is one output type's share of the window's transactions
+type outputTypeRow struct {
+	Type    string  `json:"type"`
+	Outputs int     `json:"outputs"`
+	Percent float64 `json:"percent"`
+}
+
+// outputTypeRows groups the window's transactions with an address by the
+// type of that address's output, most first.  Each address is looked up
+// once; one that can't be is left out and counted as failed.
+func outputTypeRows(u *url.URL, txList []*Transaction, begin, now time.Time) ([]outputTypeRow, int) {
+	var types = make(map[string]string)
+	var counts = make(map[string]int)
+	var total, failed int
+	for _, tx := range txList {
+		if tx.Address == "" || tx.dt.Before(begin) || tx.dt.After(now) {
+			continue
+		}
+		var kind, ok = types[tx.Address]
+		if !ok {
+			var err error
+			kind, err = fetchScriptType(u, tx.wallet, tx.Address)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Unable to look up address %s: %s\n", tx.Address, err)
+				failed++
+			}
+			types[tx.Address] = kind
+		}
+		if kind == "" {
+			continue
+		}
+		counts[kind]++
+		total++
+	}
+
+	var rows []outputTypeRow
+	for kind, n := range counts {
+		rows = append(rows, outputTypeRow{Type: kind, Outputs: n, Percent: float64(n) / float64(total) * 100})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Outputs != rows[j].Outputs {
+			return rows[i].Outputs > rows[j].Outputs
+		}
+		return rows[i].Type < rows[j].Type
+	})
+	return rows, failed
+}
+
+func (v *reportView) printOutputTypes() {
+	fmt.Println()
+	if len(v.OutputTypes) == 0 {
+		fmt.Println("Output Type Distribution: no addresses in the window")
+		return
+	}
+	var parts []string
+	for _, r := range v.OutputTypes {
+		parts = append(parts, fmt.Sprintf("%s: %0.1f%%", r.Type, r.Percent))
+	}
+	fmt.Printf("Output Type Distribution: %s\n", strings.Join(parts, ", "))
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+)
+
+// peerLatencyTop is how many of the lowest-latency peers are listed
+const peerLatencyTop = 5
+
+// peerHighLatency is the ping time, in seconds, past which a peer is
+// flagged as slow
+const peerHighLatency = 1.0
+
+// latency is the peer's best measure of how far away it is: its lowest ping
+// seen, or its last ping when it hasn't reported one yet.  A peer that
+// hasn't answered a ping has neither.
+func (p peerInfo) latency() (float64, bool) {
+	if p.MinPing > 0 {
+		return p.MinPing, true
+	}
+	return p.PingTime, p.PingTime > 0
+}
+
+// printPeerLatency ranks the connected peers by latency, the lowest being
+// the ones blocks reach quickest, and flags peers that are slow to answer
+// or haven't caught up with the headers they've announced
+func printPeerLatency(u *url.URL) error {
+	var peers []peerInfo
+	var err = rpcCall(nodeURL(u), "getpeerinfo", nil, &peers)
+	if err != nil {
+		return err
+	}
+
+	var ranked []peerInfo
+	for _, p := range peers {
+		if _, ok := p.latency(); ok {
+			ranked = append(ranked, p)
+		}
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		var a, _ = ranked[i].latency()
+		var b, _ = ranked[j].latency()
+		return a < b
+	})
+
+	fmt.Printf("Peers: %d (%d pinged)\n", len(peers), len(ranked))
+	fmt.Println()
+	fmt.Println("Lowest latency:")
+	for i, p := range ranked {
+		if i == peerLatencyTop {
+			break
+		}
+		fmt.Printf("  %4d %-40s min %s  last %s\n", p.ID, p.Addr, formatPing(p.MinPing), formatPing(p.PingTime))
+	}
+
+	fmt.Println()
+	fmt.Printf("High latency (ping over %0.1fs):\n", peerHighLatency)
+	var slow int
+	for _, p := range peers {
+		if p.PingTime > peerHighLatency {
+			fmt.Printf("  %4d %-40s last %s\n", p.ID, p.Addr, formatPing(p.PingTime))
+			slow++
+		}
+	}
+	if slow == 0 {
+		fmt.Println("  none")
+	}
+
+	fmt.Println()
+	fmt.Println("Not fully synced (blocks behind headers):")
+	var behind int
+	for _, p := range peers {
+		if p.SyncedHeaders != p.SyncedBlocks {
+			fmt.Printf("  %4d %-40s headers %d, blocks %d\n", p.ID, p.Addr, p.SyncedHeaders, p.SyncedBlocks)
+			behind++
+		}
+	}
+	if behind == 0 {
+		fmt.Println("  none")
+	}
+	return nil
+}
+
+// formatPing is a ping time in milliseconds, or "-" if there isn't one
+func formatPing(seconds float64) string {
+	if seconds <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%0.1fms", seconds*1000)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+)
+
+// peerHogFactor is how many times the median peer's headers or inv traffic
+// a peer has to send before it's called out, and peerHogFloor the least it
+// has to send at all, so a quiet node doesn't flag a peer over a few KB
+const (
+	peerHogFactor = 4
+	peerHogFloor  = 1 << 20
+)
+
+// peerTopMessages is how many message types each direction lists
+const peerTopMessages = 3
+
+type peerInfo struct {
+	ID              int64            `json:"id"`
+	Addr            string           `json:"addr"`
+	SubVer          string           `json:"subver"`
+	Inbound         bool             `json:"inbound"`
+	BytesSent       int64            `json:"bytessent"`
+	BytesRecv       int64            `json:"bytesrecv"`
+	BytesSentPerMsg map[string]int64 `json:"bytessent_per_msg"`
+	BytesRecvPerMsg map[string]int64 `json:"bytesrecv_per_msg"`
+	PingTime        float64          `json:"pingtime"`
+	MinPing         float64          `json:"minping"`
+	SyncedHeaders   int64            `json:"synced_headers"`
+	SyncedBlocks    int64            `json:"synced_blocks"`
+}
+
+// approxBytes is a byte count to one decimal place in the largest unit
+// that fits
+func approxBytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%0.1fGB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%0.1fMB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%0.1fKB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%dB", n)
+}
+
+// topMessages lists the message types taking the most bytes, largest first
+func topMessages(perMsg map[string]int64) string {
+	var types []string
+	for t := range perMsg {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if perMsg[types[i]] != perMsg[types[j]] {
+			return perMsg[types[i]] > perMsg[types[j]]
+		}
+		return types[i] < types[j]
+	})
+	if len(types) > peerTopMessages {
+		types = types[:peerTopMessages]
+	}
+	var out string
+	for i, t := range types {
+		if i > 0 {
+			out += ", "
+		}
+		out += t + " " + approxBytes(perMsg[t])
+	}
+	if out == "" {
+		return "nothing yet"
+	}
+	return out
+}
+
+// medianReceived is the median bytes of msg received across the peers
+func medianReceived(peers []peerInfo, msg string) int64 {
+	if len(peers) == 0 {
+		return 0
+	}
+	var sizes = make([]int64, len(peers))
+	for i, p := range peers {
+		sizes[i] = p.BytesRecvPerMsg[msg]
+	}
+	sort.Slice(sizes, func(i, j int) bool { return sizes[i] < sizes[j] })
+	return sizes[len(sizes)/2]
+}
+
+// printPeers lists each connected peer's traffic and the message types
+// behind it, marking peers whose headers or inv traffic is far above the
+// median peer's, the usual sign of a peer eating a capped link
+func printPeers(u *url.URL) error {
+	var peers []peerInfo
+	var err = rpcCall(nodeURL(u), "getpeerinfo", nil, &peers)
+	if err != nil {
+		return err
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].BytesRecv+peers[i].BytesSent > peers[j].BytesRecv+peers[j].BytesSent
+	})
+
+	var medians = map[string]int64{"headers": medianReceived(peers, "headers"), "inv": medianReceived(peers, "inv")}
+	fmt.Println()
+	fmt.Printf("Peers: %d\n", len(peers))
+	for _, p := range peers {
+		var dir = "out"
+		if p.Inbound {
+			dir = "in"
+		}
+		var note string
+		for _, msg := range []string{"headers", "inv"} {
+			var n = p.BytesRecvPerMsg[msg]
+			if n >= peerHogFloor && n > peerHogFactor*medians[msg] {
+				note += fmt.Sprintf("  [HEAVY %s: %s]", msg, approxBytes(n))
+			}
+		}
+		fmt.Printf("  %4d %-40s %-3s %s  sent %s, received %s%s\n", p.ID, p.Addr, dir, p.SubVer, approxBytes(p.BytesSent), approxBytes(p.BytesRecv), note)
+		fmt.Printf("       sent: %s\n", topMessages(p.BytesSentPerMsg))
+		fmt.Printf("       recv: %s\n", topMessages(p.BytesRecvPerMsg))
+	}
+	return nil
+}
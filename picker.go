@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// isTerminal reports whether f is a character device, which is as close as
+// the standard library gets to asking whether a person is on the other end
+func isTerminal(f *os.File) bool {
+	var info, err = f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// canPickWallets is whether a run that names no wallets can ask which to
+// use instead of giving up, which it only does with a person at the
+// keyboard; scripts still get "Not enough args"
+func canPickWallets(cfg *config) bool {
+	return !cfg.dryRun && isTerminal(os.Stdin) && isTerminal(os.Stderr)
+}
+
+// pickWallets lists the node's wallets and asks which to report on, until
+// it gets an answer it can use
+func pickWallets(u *url.URL, in io.Reader, out io.Writer) ([]string, error) {
+	var names, err = discoverWallets(u, nil, nil)
+	if err != nil {
+		return nil, failure(exitRPC, "Unable to list wallets: %s", err)
+	}
+	if len(names) == 0 {
+		return nil, failure(exitUsage, "No wallets are loaded on the node; load one with loadwallet or name it on the command line")
+	}
+
+	for i, name := range names {
+		fmt.Fprintf(out, "%3d) %s\n", i+1, name)
+	}
+	var r = bufio.NewReader(in)
+	for {
+		fmt.Fprint(out, "Select wallets (comma-separated, 'a' for all): ")
+		var line, readErr = r.ReadString('\n')
+		if strings.TrimSpace(line) == "" && readErr != nil {
+			fmt.Fprintln(out)
+			return nil, usageError("No wallets chosen")
+		}
+		var picked, err = parsePick(line, names)
+		if err == nil {
+			return picked, nil
+		}
+		fmt.Fprintf(out, "%s\n", err)
+	}
+}
+
+// parsePick reads a picker answer: "a" for every wallet, or numbers from
+// the list, each taken once in the order given
+func parsePick(line string, names []string) ([]string, error) {
+	line = strings.TrimSpace(line)
+	if strings.EqualFold(line, "a") {
+		return names, nil
+	}
+	var picked []string
+	var seen = make(map[int]bool)
+	for _, part := range strings.Split(line, ",") {
+		var n, err = strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || n < 1 || n > len(names) {
+			return nil, fmt.Errorf("%q is not a number from 1 to %d", strings.TrimSpace(part), len(names))
+		}
+		if !seen[n] {
+			seen[n] = true
+			picked = append(picked, names[n-1])
+		}
+	}
+	if len(picked) == 0 {
+		return nil, errors.New("no wallets chosen")
+	}
+	return picked, nil
+}
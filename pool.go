@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// markPoolPayouts flags the receives that come in to one of the
+// --pool-address addresses, or under a label naming one, as earnings.  A
+// pool pays out as an ordinary transaction, so without this a rig mining
+// to a pool doesn't show up at all.  With generated set, as --pool-mode
+// does, every generation is flagged too, for a pool that pays out by way
+// of the coinbase.  It returns how many were flagged.
+func markPoolPayouts(list []*Transaction, addrs []string, generated bool) int {
+	if len(addrs) == 0 && !generated {
+		return 0
+	}
+	var pool = make(map[string]bool)
+	for _, a := range addrs {
+		pool[a] = true
+	}
+	var n int
+	for _, tx := range list {
+		if (generated && tx.Generated) || tx.Category == "receive" && (pool[tx.Address] || pool[tx.Label]) {
+			tx.pool = true
+			n++
+		}
+	}
+	return n
+}
+
+// poolSummary is how much of the report came from pool payouts rather than
+// blocks won
+type poolSummary struct {
+	Promoted int     `json:"promoted"`
+	Coins    float64 `json:"coins"`
+}
+
+// addPoolPayouts tags each day's row with its pool payouts and totals them.
+// The days must still be in date order.
+func (v *reportView) addPoolPayouts(txList []*Transaction, now time.Time) {
+	var s = &poolSummary{}
+	for _, tx := range txList {
+		if !tx.pool || !countable(tx, now) || tx.dt.Before(v.Start) {
+			continue
+		}
+		var i = int(getDay(tx.dt).Sub(v.Start).Hours()/24 + 0.5)
+		if i < 0 || i >= len(v.Daily) {
+			continue
+		}
+		v.Daily[i].Pool += tx.Amount
+		s.Promoted++
+		s.Coins += tx.Amount
+	}
+	v.Pool = s
+}
+
+// poolTag is the daily row's note of how much of its total was pool payouts
+func (row reportRow) poolTag() string {
+	if row.Pool == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" [pool %0.2f]", row.Pool)
+}
+
+func (s *poolSummary) print(w io.Writer) {
+	fmt.Fprintf(w, "Pool payouts: %d receive(s) counted as earnings, %0.2f of the total\n", s.Promoted, s.Coins)
+}
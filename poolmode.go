@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PoolStats is --pool-mode's part of the summary: the generations in the
+// report window taken as pool payouts, many and small, rather than blocks
+// won outright
+type PoolStats struct {
+	Payouts    int     `json:"payouts"`
+	Coins      float64 `json:"coins"`
+	Average    float64 `json:"average"`
+	PerDay     float64 `json:"per_day"`
+	LongestGap float64 `json:"longest_gap_seconds"`
+
+	// Expected is what --pool-hashrate would have earned solo, at the
+	// node's current difficulty and subsidy, and EstimatedFee how far short
+	// of that the payouts fell, as a percentage; both are absent without a
+	// hashrate to go on
+	Expected     *float64 `json:"expected,omitempty"`
+	EstimatedFee *float64 `json:"estimated_fee_percent,omitempty"`
+
+	times []time.Time
+}
+
+// newPoolStats gathers the generations flagged by --pool-mode from begin
+// to now.  The fee is left for setFee, which has to ask the node.
+func newPoolStats(txList []*Transaction, begin, now time.Time) *PoolStats {
+	var p = &PoolStats{}
+	for _, tx := range txList {
+		if !tx.pool || !tx.Generated || !countable(tx, now) || tx.dt.Before(begin) {
+			continue
+		}
+		p.Payouts++
+		p.Coins += tx.Amount
+		p.times = append(p.times, tx.dt)
+	}
+	if p.Payouts > 0 {
+		p.Average = p.Coins / float64(p.Payouts)
+	}
+	if days := now.Sub(begin).Hours() / 24; days > 0 {
+		p.PerDay = float64(p.Payouts) / days
+	}
+	sort.Slice(p.times, func(i, j int) bool { return p.times[i].Before(p.times[j]) })
+	for i := 1; i < len(p.times); i++ {
+		p.LongestGap = math.Max(p.LongestGap, p.times[i].Sub(p.times[i-1]).Seconds())
+	}
+	return p
+}
+
+// setFee holds the payouts against what they'd have been expected to come
+// to solo; the shortfall is taken as the pool's cut
+func (p *PoolStats) setFee(expected float64) {
+	if expected <= 0 {
+		return
+	}
+	var fee = (1 - p.Coins/expected) * 100
+	p.Expected, p.EstimatedFee = &expected, &fee
+}
+
+// expectedSoloEarnings is what hashrate, in hashes per second, would be
+// expected to win over window at the node's current difficulty, paid the
+// subsidy of the next block.  Fees aren't counted, so a pool that passes
+// them on can look cheaper than it is.
+func expectedSoloEarnings(u *url.URL, hashrate float64, schedule subsidySchedule, window time.Duration) (float64, error) {
+	var info struct {
+		Blocks     int64   `json:"blocks"`
+		Difficulty float64 `json:"difficulty"`
+	}
+	var err = rpcCall(nodeURL(u), "getmininginfo", nil, &info)
+	if err != nil {
+		return 0, err
+	}
+	if info.Difficulty <= 0 {
+		return 0, fmt.Errorf("the node gave no difficulty")
+	}
+	var subsidy = defaultSubsidy(info.Blocks + 1)
+	if len(schedule) > 0 {
+		subsidy = schedule.rewardAt(info.Blocks + 1)
+	}
+	var blocks = hashrate * window.Seconds() / (info.Difficulty * (1 << 32))
+	return blocks * subsidy, nil
+}
+
+// parseHashrate reads a --pool-hashrate: hashes per second, optionally with
+// a k, M, G, T, P, or E multiplier and an "H/s" unit, as in "120T" or
+// "1.5 PH/s"
+func parseHashrate(s string) (float64, error) {
+	var t = strings.TrimSpace(s)
+	t = strings.TrimSuffix(strings.TrimSuffix(t, "/s"), "H")
+	t = strings.TrimSpace(t)
+	var mult = 1.0
+	if n := len(t); n > 0 {
+		var i = strings.IndexByte("kMGTPE", t[n-1])
+		if i >= 0 {
+			mult = math.Pow(1000, float64(i+1))
+			t = strings.TrimSpace(t[:n-1])
+		}
+	}
+	var v, err = strconv.ParseFloat(t, 64)
+	if err != nil || v <= 0 {
+		return 0, fmt.Errorf("expected a hashrate such as 120T or 1.5 PH/s, got %q", s)
+	}
+	return v * mult, nil
+}
+
+func (p *PoolStats) print(w io.Writer) {
+	fmt.Fprintf(w, "Pool payouts: %d, averaging %0.8f, %0.2f a day\n", p.Payouts, p.Average, p.PerDay)
+	if p.Payouts > 1 {
+		fmt.Fprintf(w, "  longest gap between payouts: %s\n", formatAge(time.Duration(p.LongestGap)*time.Second))
+	}
+	if p.EstimatedFee != nil {
+		fmt.Fprintf(w, "  estimated pool fee: %0.2f%% (%0.8f paid against %0.8f expected from the subsidy)\n", *p.EstimatedFee, p.Coins, *p.Expected)
+	}
+}
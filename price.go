@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// priceSource says where to look up the value of one coin: any JSON API, with
+// PricePath naming the value inside the response ("result.last", or
+// "data.0.price" to index an array).  PriceCoinGecko is a shortcut that
+// fills in CoinGecko's simple-price endpoint for the given coin id.  The URL
+// and path may leave the currency open as {currency} or {CURRENCY}, lower-
+// or upper-cased, so one source can give --fiat-currencies' every rate.
+type priceSource struct {
+	PriceURL       string            `json:"price_url,omitempty"`
+	PricePath      string            `json:"price_path,omitempty"`
+	PriceHeaders   map[string]string `json:"price_headers,omitempty"`
+	PriceCoinGecko string            `json:"price_coingecko,omitempty"`
+}
+
+// lookupPrice fetches the coin price from p unless one was given outright.
+// A price is a nice-to-have, so a failure is reported and the run goes on
+// without one.
+func lookupPrice(cfg *config, label string, price float64, p priceSource) float64 {
+	if price > 0 || !p.configured() {
+		return price
+	}
+	if cfg.dryRun {
+		var u, path = p.resolve([]string{defaultPriceCurrency})
+		fmt.Printf("HTTP  %s  (price at %s)\n", fillCurrency(u, defaultPriceCurrency), fillCurrency(path, defaultPriceCurrency))
+		return 0
+	}
+
+	var prices, err = p.FetchPrice([]string{defaultPriceCurrency})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to fetch %s coin price: %s\n", label, err)
+		return 0
+	}
+	return prices[defaultPriceCurrency]
+}
+
+// defaultPriceCurrency is the currency the coin price is looked up in when
+// the source leaves it open, as the CoinGecko preset does
+const defaultPriceCurrency = "USD"
+
+func (p priceSource) configured() bool {
+	return p.PriceURL != "" || p.PriceCoinGecko != ""
+}
+
+// resolve returns the URL and path to use for currencies, applying the
+// CoinGecko preset, which asks for them all at once.  Either may still hold
+// a currency placeholder for fillCurrency.
+func (p priceSource) resolve(currencies []string) (string, string) {
+	if p.PriceCoinGecko != "" && p.PriceURL == "" {
+		var id = url.QueryEscape(p.PriceCoinGecko)
+		var vs = url.QueryEscape(strings.ToLower(strings.Join(currencies, ",")))
+		return "https://api.coingecko.com/api/v3/simple/price?vs_currencies=" + vs + "&ids=" + id, p.PriceCoinGecko + ".{currency}"
+	}
+	return p.PriceURL, p.PricePath
+}
+
+// perCurrency reports whether the source gives a different price for each
+// currency, rather than the one price in whatever currency it quotes
+func (p priceSource) perCurrency() bool {
+	var u, path = p.resolve(nil)
+	return hasCurrency(u) || hasCurrency(path)
+}
+
+func hasCurrency(s string) bool {
+	return strings.Contains(s, "{currency}") || strings.Contains(s, "{CURRENCY}")
+}
+
+func fillCurrency(s, currency string) string {
+	return strings.NewReplacer("{currency}", strings.ToLower(currency), "{CURRENCY}", strings.ToUpper(currency)).Replace(s)
+}
+
+// FetchPrice makes priceSource a PriceFetcher.  Each URL is only asked once,
+// so a source whose URL doesn't depend on the currency takes one request
+// however many currencies are wanted.
+func (p priceSource) FetchPrice(currencies []string) (map[string]float64, error) {
+	var u, path = p.resolve(currencies)
+	if path == "" {
+		return nil, fmt.Errorf("no price path given for %s", u)
+	}
+	var docs = make(map[string]interface{})
+	var prices = make(map[string]float64)
+	for _, c := range currencies {
+		var cu = fillCurrency(u, c)
+		var doc, ok = docs[cu]
+		if !ok {
+			var err error
+			doc, err = p.get(cu)
+			if err != nil {
+				return nil, err
+			}
+			docs[cu] = doc
+		}
+		var price, err = extractPrice(doc, fillCurrency(path, c))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", cu, err)
+		}
+		prices[c] = price
+	}
+	return prices, nil
+}
+
+// get fetches and decodes one price document
+func (p priceSource) get(u string) (interface{}, error) {
+	var req, err = http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", userAgent())
+	for k, v := range p.PriceHeaders {
+		req.Header.Set(k, v)
+	}
+
+	var r *http.Response
+	r, err = http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+	if r.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", u, r.Status)
+	}
+
+	var doc interface{}
+	err = json.NewDecoder(io.LimitReader(r.Body, maxResponseSize)).Decode(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", u, err)
+	}
+	return doc, nil
+}
+
+// extractPrice walks a dot-separated path into a decoded JSON document.
+// Exchanges often quote prices as strings, so those are accepted too.
+func extractPrice(doc interface{}, path string) (float64, error) {
+	var node = doc
+	var parts = strings.Split(path, ".")
+	for i, part := range parts {
+		var at = strings.Join(parts[:i], ".")
+		if at == "" {
+			at = "(top level)"
+		}
+		switch n := node.(type) {
+		case map[string]interface{}:
+			var next, ok = n[part]
+			if !ok {
+				return 0, fmt.Errorf("price path %q: no %q in the object at %s", path, part, at)
+			}
+			node = next
+		case []interface{}:
+			var idx, err = strconv.Atoi(part)
+			if err != nil || idx < 0 || idx >= len(n) {
+				return 0, fmt.Errorf("price path %q: %q isn't an index into the %d-element array at %s", path, part, len(n), at)
+			}
+			node = n[idx]
+		default:
+			return 0, fmt.Errorf("price path %q: can't look up %q, the value at %s isn't an object or array", path, part, at)
+		}
+	}
+
+	switch v := node.(type) {
+	case float64:
+		return v, nil
+	case string:
+		var f, err = strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("price path %q: %q isn't a number", path, v)
+		}
+		return f, nil
+	}
+	return 0, fmt.Errorf("price path %q: value is %T, not a number", path, node)
+}
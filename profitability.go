@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+)
+
+// profitabilityRow is one day's earnings over the network difficulty that
+// day, which takes retargets out of the earnings trend: a day that earned
+// more per unit of difficulty was luckier, not just easier
+type profitabilityRow struct {
+	Label         string    `json:"label"`
+	Start         time.Time `json:"start"`
+	Coins         float64   `json:"coins"`
+	Difficulty    float64   `json:"difficulty"`
+	PerDifficulty float64   `json:"coins_per_difficulty"`
+
+	// Sampled is set for a day without block headers to average, which is
+	// given the node's current difficulty instead
+	Sampled bool `json:"sampled,omitempty"`
+}
+
+// profitabilityView is --profitability's table
+type profitabilityView struct {
+	Days    []profitabilityRow `json:"days"`
+	Current float64            `json:"current_difficulty"`
+	Average float64            `json:"average_coins_per_difficulty"`
+
+	// Incomplete notes headers left out for --max-header-lookups
+	Incomplete string `json:"incomplete,omitempty"`
+}
+
+// newProfitabilityView divides each day's earnings by the day's difficulty:
+// the average over the headers of the blocks won that day, as the hashrate
+// estimate weighs it, or for a day with none, getdifficulty sampled now.
+// days must still be in date order.
+func newProfitabilityView(u *url.URL, headers *headerCache, blocks []*Transaction, days []reportRow) (*profitabilityView, error) {
+	var v = &profitabilityView{Incomplete: headers.incomplete()}
+	var err = rpcCall(nodeURL(u), "getdifficulty", nil, &v.Current)
+	if err != nil {
+		return nil, err
+	}
+
+	var total = make([]float64, len(days))
+	var counted = make([]int, len(days))
+	var seen = make(map[string]bool)
+	for _, tx := range blocks {
+		if seen[tx.Blockhash] || len(days) == 0 {
+			continue
+		}
+		seen[tx.Blockhash] = true
+		var h = headers.header(tx.Blockhash)
+		var i = int(getDay(tx.dt).Sub(days[0].Start).Hours()/24 + 0.5)
+		if h == nil || i < 0 || i >= len(days) {
+			continue
+		}
+		total[i] += h.Difficulty
+		counted[i]++
+	}
+
+	for i, day := range days {
+		var row = profitabilityRow{Label: day.Label, Start: day.Start, Coins: day.Coins, Difficulty: v.Current, Sampled: true}
+		if counted[i] > 0 {
+			row.Difficulty, row.Sampled = total[i]/float64(counted[i]), false
+		}
+		if row.Difficulty > 0 {
+			row.PerDifficulty = row.Coins / row.Difficulty
+		}
+		v.Average += row.PerDifficulty
+		v.Days = append(v.Days, row)
+	}
+	if len(v.Days) > 0 {
+		v.Average /= float64(len(v.Days))
+	}
+	return v, nil
+}
+
+func (v *profitabilityView) print(w io.Writer) {
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Profitability (coins per unit of network difficulty):")
+	var cells [][]string
+	var sampled bool
+	for _, r := range v.Days {
+		var difficulty = fmt.Sprintf("%0.4g", r.Difficulty)
+		if r.Sampled {
+			difficulty += "*"
+			sampled = true
+		}
+		cells = append(cells, []string{r.Label, fmt.Sprintf("%0.2f", r.Coins), difficulty, fmt.Sprintf("%0.4e", r.PerDifficulty)})
+	}
+	printTable(w, []string{"day", "coins", "difficulty", "coins/difficulty"}, cells)
+	fmt.Fprintf(w, "Average: %0.4e per day\n", v.Average)
+	if sampled {
+		fmt.Fprintf(w, "(* no block header that day; the node's current difficulty, %0.4g, is used)\n", v.Current)
+	}
+	if v.Incomplete != "" {
+		fmt.Fprintf(w, "(%s)\n", v.Incomplete)
+	}
+}
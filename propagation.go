@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// propagationBuckets is how many one-minute histogram rows are shown before
+// the rest are lumped into a last "or more" row
+const propagationBuckets = 10
+
+// propagationView summarizes how long the window's transactions took to be
+// mined: each one's block time less the time the wallet first saw it.
+// Generated transactions are dated by their block, so they mostly add zeros.
+type propagationView struct {
+	Count   int     `json:"count"`
+	Min     float64 `json:"min_seconds"`
+	Average float64 `json:"average_seconds"`
+	Max     float64 `json:"max_seconds"`
+	P50     float64 `json:"p50_seconds"`
+	P95     float64 `json:"p95_seconds"`
+
+	// Histogram counts delays by minute; the last bucket holds everything
+	// from propagationBuckets minutes up, and Early those mined "before"
+	// they were seen, which block timestamps allow
+	Histogram []int `json:"histogram"`
+	Early     int   `json:"early,omitempty"`
+}
+
+func newPropagationView(txList []*Transaction, begin, now time.Time) *propagationView {
+	var delays []float64
+	for _, tx := range txList {
+		if tx.Blocktime == 0 || tx.dt.Before(begin) || tx.dt.After(now) {
+			continue
+		}
+		delays = append(delays, float64(tx.Blocktime-tx.Time))
+	}
+
+	var v = &propagationView{Count: len(delays), Histogram: make([]int, propagationBuckets+1)}
+	if len(delays) == 0 {
+		return v
+	}
+	sort.Float64s(delays)
+	var sum float64
+	for _, d := range delays {
+		sum += d
+		if d < 0 {
+			v.Early++
+			continue
+		}
+		var i = int(d / 60)
+		if i > propagationBuckets {
+			i = propagationBuckets
+		}
+		v.Histogram[i]++
+	}
+	v.Min, v.Max = delays[0], delays[len(delays)-1]
+	v.Average = sum / float64(len(delays))
+	v.P50, v.P95 = percentile(delays, 50), percentile(delays, 95)
+	return v
+}
+
+// percentile returns the nearest-rank percentile of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	var rank = int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func (v *propagationView) print(w io.Writer) {
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Confirmation delay (block time less time seen, %d confirmed transaction(s)):\n", v.Count)
+	if v.Count == 0 {
+		return
+	}
+	var secs = func(f float64) string { return formatAge(time.Duration(f) * time.Second) }
+	fmt.Fprintf(w, "min %s  avg %s  max %s  p50 %s  p95 %s\n", secs(v.Min), secs(math.Round(v.Average)), secs(v.Max), secs(v.P50), secs(v.P95))
+
+	var max = v.Early
+	for _, n := range v.Histogram {
+		if n > max {
+			max = n
+		}
+	}
+	var bar = func(n int) string {
+		return strings.Repeat("#", n*heatmapBarWidth/max)
+	}
+	if v.Early > 0 {
+		fmt.Fprintf(w, "%-10s %6d %s\n", "< 0m", v.Early, bar(v.Early))
+	}
+	for i, n := range v.Histogram {
+		var label = fmt.Sprintf("%dm-%dm", i, i+1)
+		if i == propagationBuckets {
+			label = fmt.Sprintf("%dm+", i)
+		}
+		fmt.Fprintf(w, "%-10s %6d %s\n", label, n, bar(n))
+	}
+}
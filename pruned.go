@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+)
+
+// checkPruned warns when --pruned-node doesn't match what the node says
+// about itself.  It's only a warning: a node that won't say is assumed to
+// be whatever it was told.
+func checkPruned(u *url.URL, cfg *config) {
+	var info blockchainInfo
+	var err = rpcCall(nodeURL(u), "getblockchaininfo", nil, &info)
+	if err != nil || cfg.dryRun {
+		return
+	}
+	switch {
+	case info.Pruned && !cfg.PrunedNode:
+		fmt.Fprintln(os.Stderr, "WARNING: the node is pruned; consider --pruned-node, since features that need old blocks or getrawtransaction may fail")
+	case !info.Pruned && cfg.PrunedNode:
+		fmt.Fprintln(os.Stderr, "WARNING: --pruned-node was given, but the node isn't pruned")
+	}
+}
+
+// skipPrunedFeatures turns off the features that need getrawtransaction,
+// which a pruned node without -txindex can't answer for older blocks
+func skipPrunedFeatures(cfg *config) {
+	var skip = func(on *bool, name string) {
+		if *on {
+			fmt.Fprintf(os.Stderr, "--%s needs getrawtransaction, which a pruned node can't answer; skipped\n", name)
+			*on = false
+		}
+	}
+	skip(&cfg.TxGraph, "tx-graph")
+	skip(&cfg.MonitorBroadcast, "monitor-broadcast")
+	skip(&cfg.BlockFees, "block-fees")
+}
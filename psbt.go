@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// parsePSBTOutputs checks a --create-psbt value is the outputs argument
+// walletcreatefundedpsbt takes: an array of {"address": amount} objects, or
+// one object of them, which the node also accepts
+func parsePSBTOutputs(s string) (json.RawMessage, error) {
+	var v interface{}
+	var err = json.Unmarshal([]byte(s), &v)
+	if err != nil {
+		return nil, err
+	}
+	switch outs := v.(type) {
+	case []interface{}:
+		if len(outs) == 0 {
+			return nil, errors.New("no outputs")
+		}
+	case map[string]interface{}:
+		if len(outs) == 0 {
+			return nil, errors.New("no outputs")
+		}
+	default:
+		return nil, errors.New("expected a JSON array or object of outputs")
+	}
+	return json.RawMessage(s), nil
+}
+
+// fundedPSBT is a wallet-funded, unsigned PSBT and what it would cost
+type fundedPSBT struct {
+	psbt      string
+	fee       float64
+	changePos int
+
+	// vsize is analyzepsbt's estimate of the signed transaction's size,
+	// and estimated whether it could give one; without it, vsize is the
+	// unsigned size
+	vsize     int64
+	estimated bool
+}
+
+// createFundedPSBT has the wallet pick inputs and a fee for the outputs,
+// as a send would, and size the result as it would be once signed.  The
+// PSBT is left unsigned and never broadcast.
+func createFundedPSBT(wu *url.URL, outputs json.RawMessage) (*fundedPSBT, error) {
+	var funded struct {
+		PSBT      string  `json:"psbt"`
+		Fee       float64 `json:"fee"`
+		ChangePos int     `json:"changepos"`
+	}
+	var err = rpcCall(wu, "walletcreatefundedpsbt", []interface{}{[]interface{}{}, outputs}, &funded)
+	if err != nil {
+		return nil, err
+	}
+	var p = &fundedPSBT{psbt: funded.PSBT, fee: funded.Fee, changePos: funded.ChangePos}
+
+	var analysis struct {
+		EstimatedVSize int64 `json:"estimated_vsize"`
+	}
+	err = rpcCall(nodeURL(wu), "analyzepsbt", []interface{}{funded.PSBT}, &analysis)
+	if err == nil && analysis.EstimatedVSize > 0 {
+		p.vsize, p.estimated = analysis.EstimatedVSize, true
+		return p, nil
+	}
+	var decoded struct {
+		Tx struct {
+			VSize int64 `json:"vsize"`
+		} `json:"tx"`
+	}
+	err = rpcCall(nodeURL(wu), "decodepsbt", []interface{}{funded.PSBT}, &decoded)
+	if err != nil {
+		return nil, err
+	}
+	p.vsize = decoded.Tx.VSize
+	return p, nil
+}
+
+// printFundedPSBT prints the PSBT with its fee, for signing elsewhere
+func printFundedPSBT(p *fundedPSBT) {
+	var change = "none"
+	if p.changePos >= 0 {
+		change = fmt.Sprintf("output %d", p.changePos)
+	}
+	var size = "estimated signed"
+	if !p.estimated {
+		size = "unsigned; the node couldn't estimate it signed"
+	}
+	fmt.Printf("Fee:         %14.8f\n", p.fee)
+	fmt.Printf("Fee rate:    %8.2f sat/vB\n", feeRate(p.fee, p.vsize))
+	fmt.Printf("Size:        %d vB (%s)\n", p.vsize, size)
+	fmt.Printf("Change:      %s\n", change)
+	fmt.Printf("PSBT:        %s\n", p.psbt)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// rpcLimit, when set by --rpc-rate-limit, spaces RPC requests out so a long
+// run of calls doesn't arrive at the node back to back
+var rpcLimit *rateLimiter
+
+// rateLimiter is a token bucket holding one token, refilled every interval:
+// a request waits until the previous one's interval has passed
+type rateLimiter struct {
+	interval time.Duration
+
+	mu     sync.Mutex
+	next   time.Time
+	waits  int
+	waited time.Duration
+}
+
+// newRateLimiter allows perSecond requests a second, or returns nil for no
+// limit
+func newRateLimiter(perSecond float64) *rateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+// wait blocks until the caller may send.  Each caller reserves its slot
+// before sleeping, so concurrent callers queue up in turn.
+func (l *rateLimiter) wait() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	var now = time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	var delay = l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	if delay > 0 {
+		l.waits++
+		l.waited += delay
+	}
+	l.mu.Unlock()
+	time.Sleep(delay)
+}
+
+// stats returns how many requests had to wait, and for how long in all
+func (l *rateLimiter) stats() (int, time.Duration) {
+	if l == nil {
+		return 0, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.waits, l.waited
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// walletActivity is one wallet's transactions in the window, split into the
+// block rewards the report counts and everything else
+type walletActivity struct {
+	Wallet   string  `json:"wallet"`
+	Mined    int     `json:"mined"`
+	Pool     int     `json:"pool,omitempty"`
+	Other    int     `json:"other"`
+	Receives int     `json:"receives"`
+	Received float64 `json:"received"`
+}
+
+// walletActivities tallies each wallet's transactions in the window
+func walletActivities(txList []*Transaction, wallets []string, begin, now time.Time) []walletActivity {
+	var byWallet = make(map[string]*walletActivity)
+	var rows = make([]walletActivity, len(wallets))
+	for i, w := range wallets {
+		rows[i].Wallet = w
+		byWallet[w] = &rows[i]
+	}
+	for _, tx := range txList {
+		var a = byWallet[tx.wallet]
+		if a == nil || tx.dt.Before(begin) || tx.dt.After(now) {
+			continue
+		}
+		if tx.Generated {
+			a.Mined++
+			continue
+		}
+		if tx.pool {
+			a.Pool++
+			continue
+		}
+		a.Other++
+		if tx.Category == "receive" {
+			a.Receives++
+			a.Received += tx.Amount
+		}
+	}
+	return rows
+}
+
+// commaInt formats n with thousands separators
+func commaInt(n int) string {
+	var sign string
+	if n < 0 {
+		sign, n = "-", -n
+	}
+	var s = strconv.Itoa(n)
+	var out = s[:(len(s)-1)%3+1]
+	for i := len(out); i < len(s); i += 3 {
+		out += "," + s[i:i+3]
+	}
+	return sign + out
+}
+
+// printIdleWallets explains the wallets that contribute nothing to the
+// report because none of their transactions in the window are block
+// rewards, rather than leaving their zeros unexplained
+func (v *reportView) printIdleWallets(w io.Writer) {
+	for _, a := range v.Activity {
+		if a.Mined == 0 && a.Pool == 0 {
+			fmt.Fprintf(w, "%s: no mined blocks in the last %d days (%s other transactions)\n", a.Wallet, v.Days, commaInt(a.Other))
+		}
+	}
+}
+
+// printReceives is the --include-receives section: what each wallet was
+// paid in the window other than block rewards
+func (v *reportView) printReceives(w io.Writer) {
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Receives:")
+	for _, a := range v.Activity {
+		fmt.Fprintf(w, "%s:\t%14.8f\t(%s transactions)\n", a.Wallet, a.Received, commaInt(a.Receives))
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// A wallet with only ordinary receives is explained rather than left at
+// zero, and --include-receives adds up what it was paid
+func TestReceivesOnlyWallet(t *testing.T) {
+	var now = time.Now()
+	var receive = func(txid string, amount float64, at time.Time) map[string]interface{} {
+		var tx = generation(txid, 800090, amount, at)
+		tx["category"], tx["generated"] = "receive", false
+		return tx
+	}
+	var node = newWalletNode(t, []map[string]interface{}{
+		receive("aa", 1.5, now.Add(-30*time.Hour)),
+		receive("bb", 0.25, now.Add(-2*time.Hour)),
+	})
+
+	var code int
+	var out = capture(t, &os.Stdout, func() {
+		capture(t, &os.Stderr, func() {
+			code = exitCode(run([]string{"--url", node.URL, "--user", "u", "--password", "p", "--wallet", "rig1", "--days", "3", "--include-receives"}))
+		})
+	})
+	if code != exitOK {
+		t.Errorf("exit %d", code)
+	}
+	for _, want := range []string{
+		"rig1: no mined blocks in the last 3 days (2 other transactions)",
+		"Receives:\nrig1:\t    1.75000000\t(2 transactions)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("no %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestCommaInt(t *testing.T) {
+	var tests = map[int]string{0: "0", 999: "999", 1000: "1,000", -1234567: "-1,234,567", 100000: "100,000"}
+	for n, want := range tests {
+		if got := commaInt(n); got != want {
+			t.Errorf("commaInt(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"time"
+)
+
+// reorgedTx is a confirmed transaction whose block is no longer the one the
+// chain has at its height
+type reorgedTx struct {
+	TXID        string `json:"txid"`
+	Blockhash   string `json:"blockhash"`
+	Blockheight int64  `json:"blockheight"`
+	ChainHash   string `json:"chain_hash"`
+}
+
+// reorgCheck is what --reorg-detect found in the window
+type reorgCheck struct {
+	Reorged []reorgedTx `json:"reorged"`
+	Failed  int         `json:"failed,omitempty"`
+}
+
+// detectReorgs compares each confirmed transaction's block hash in the
+// window with the hash the node has at that height now.  A wallet that
+// still shows confirmations for a block that lost a reorg hasn't caught up
+// with the chain.  Each height is only asked about once, and a transaction
+// seen in more than one wallet or output is only listed once.
+func detectReorgs(u *url.URL, txs []*Transaction, begin, now time.Time) *reorgCheck {
+	var c = &reorgCheck{}
+	var hashes = make(map[int64]string)
+	var failed = make(map[int64]bool)
+	var seen = make(map[string]bool)
+	for _, tx := range txs {
+		if tx.Confirmations <= 0 || tx.Blockhash == "" || tx.dt.Before(begin) || tx.dt.After(now) {
+			continue
+		}
+		if failed[tx.Blockheight] {
+			continue
+		}
+		var hash, ok = hashes[tx.Blockheight]
+		if !ok {
+			var err error
+			hash, err = fetchBlockHash(u, tx.Blockheight)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Unable to fetch the hash of block %d: %s\n", tx.Blockheight, err)
+				failed[tx.Blockheight] = true
+				c.Failed++
+				continue
+			}
+			hashes[tx.Blockheight] = hash
+		}
+		if hash != tx.Blockhash && !seen[tx.TXID] {
+			seen[tx.TXID] = true
+			c.Reorged = append(c.Reorged, reorgedTx{TXID: tx.TXID, Blockhash: tx.Blockhash, Blockheight: tx.Blockheight, ChainHash: hash})
+		}
+	}
+	return c
+}
+
+func (c *reorgCheck) print(w io.Writer) {
+	if len(c.Reorged) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "WARNING: %d transactions affected by chain reorganizations\n", len(c.Reorged))
+	for _, r := range c.Reorged {
+		fmt.Fprintf(w, "[REORG] %s in block %s at height %d (now %s)\n", r.TXID, r.Blockhash, r.Blockheight, r.ChainHash)
+	}
+}
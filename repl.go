@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// replHelp is what "help" prints
+const replHelp = `Commands:
+  stats             run the report for the configured wallets and days
+  wallets           list the node's wallets with their getwalletinfo details
+  balance [wallet]  show the balances of one wallet, or of each configured one
+  tx <txid>         look a transaction up in the configured wallets
+  health            run the health checks
+  help              show this list
+  exit              leave (as does end of input)`
+
+// runInteractive reads commands from stdin until exit or end of input.  Each
+// command is one of the tool's existing modes, run against the same node and
+// wallets; a command that fails says why and the loop carries on.
+func runInteractive(u *url.URL, cfg *config) error {
+	var wallets, err = interactiveWallets(u, cfg)
+	if err != nil {
+		return err
+	}
+
+	var in = bufio.NewReader(os.Stdin)
+	for {
+		fmt.Print("dynamo> ")
+		var line string
+		line, err = in.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return failure(exitUsage, "Unable to read a command: %s", err)
+		}
+		var eof = err != nil
+		var fields = strings.Fields(line)
+		if len(fields) == 0 {
+			if eof {
+				fmt.Println()
+				return nil
+			}
+			continue
+		}
+
+		var cmd, args = fields[0], fields[1:]
+		switch cmd {
+		case "exit", "quit":
+			return nil
+		case "help", "?":
+			fmt.Println(replHelp)
+		case "stats":
+			replStats(cfg)
+		case "wallets":
+			var names []string
+			names, err = discoverWallets(u, nil, nil)
+			if err != nil {
+				fmt.Printf("Unable to list wallets: %s\n", err)
+				break
+			}
+			describeWallets(u, names, false)
+		case "balance":
+			var which = wallets
+			if len(args) > 0 {
+				which = args
+			}
+			printDetailedBalances(u, which)
+		case "tx":
+			if len(args) != 1 {
+				fmt.Println("Usage: tx <txid>")
+				break
+			}
+			printTXIDReport(u, wallets, args, false)
+		case "health":
+			printHealthCheck(runHealthChecks(u, wallets))
+		default:
+			fmt.Printf("Unknown command %q; try help\n", cmd)
+		}
+		if eof {
+			return nil
+		}
+	}
+}
+
+// interactiveWallets is the configured wallets, or every wallet the node
+// has loaded when none were given
+func interactiveWallets(u *url.URL, cfg *config) ([]string, error) {
+	if len(cfg.Wallets) > 0 || cfg.WalletsFile != "" || cfg.AutoWallets {
+		return resolveWallets(u, cfg)
+	}
+	var wallets, err = discoverWallets(u, nil, nil)
+	if err != nil {
+		return nil, failure(exitRPC, "Unable to list wallets: %s", err)
+	}
+	return wallets, nil
+}
+
+// replStats runs the report as a one-off run with the same config would,
+// short of waiting out --splay again
+func replStats(cfg *config) {
+	if cfg.ReportDays == 0 {
+		fmt.Println("stats needs the report days on the command line")
+		return
+	}
+	var once = *cfg
+	once.Interactive, once.Splay = false, 0
+	var err = runConfig(&once)
+	var ee *exitError
+	if errors.As(err, &ee) && ee.message != "" {
+		fmt.Println(ee.message)
+	}
+}
@@ -0,0 +1,584 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Nerdmaster/dynamo-tx-stats/stats"
+)
+
+// reportRow is one line of the daily or hourly table.  Projected is only set
+// for the bucket that's still in progress, and Hours is only filled in for
+// days whose hourly breakdown is shown.
+type reportRow struct {
+	Label      string             `json:"label"`
+	Start      time.Time          `json:"start"`
+	Coins      float64            `json:"coins"`
+	Rate       float64            `json:"rate"`
+	Blocks     int64              `json:"blocks"`
+	WinPercent float64            `json:"win_percent"`
+	Projected  *float64           `json:"projected,omitempty"`
+	Need       *todayNeed         `json:"needs,omitempty"`
+	ZScore     *float64           `json:"z_score,omitempty"`
+	Anomaly    bool               `json:"anomaly,omitempty"`
+	Gap        bool               `json:"coverage_gap,omitempty"`
+	Pool       float64            `json:"pool,omitempty"`
+	Fiat       map[string]float64 `json:"fiat,omitempty"`
+	Hours      []reportRow        `json:"hours,omitempty"`
+}
+
+// reportView is the rendered-ready form of a report, shared by the text,
+// JSON, and HTML outputs so they can't drift apart
+type reportView struct {
+	Title         string          `json:"title,omitempty"`
+	Operator      string          `json:"operator,omitempty"`
+	Source        string          `json:"source,omitempty"`
+	Endpoints     []string        `json:"endpoints,omitempty"`
+	Archive       *archiveSummary `json:"archive,omitempty"`
+	Generated     time.Time       `json:"generated"`
+	AsOf          *time.Time      `json:"as_of,omitempty"`
+	Simulated     bool            `json:"simulated,omitempty"`
+	Pruned        bool            `json:"pruned,omitempty"`
+	Relabeled     int             `json:"relabeled,omitempty"`
+	Picked        []string        `json:"picked_wallets,omitempty"`
+	Version       string          `json:"version"`
+	Wallets       []string        `json:"wallets"`
+	Transactions  int             `json:"transactions"`
+	FirstTx       *time.Time      `json:"first_tx,omitempty"`
+	Days          int             `json:"days"`
+	Start         time.Time       `json:"start"`
+	Total         float64         `json:"total"`
+	DailyAverage  float64         `json:"daily_average"`
+	HourlyAverage float64         `json:"hourly_average"`
+	WinPercent    float64         `json:"win_percent"`
+	Daily         []reportRow     `json:"daily"`
+
+	// FiatRates are --fiat-currencies' rates, and FiatTotal the report
+	// period total at them; each row has its own earnings at them too
+	FiatRates []fiatRate         `json:"fiat_rates,omitempty"`
+	FiatTotal map[string]float64 `json:"fiat_total,omitempty"`
+
+	// Buckets is the detail table at --bucket's size, when that's finer
+	// than a day
+	BucketSize string      `json:"bucket_size,omitempty"`
+	Buckets    []reportRow `json:"buckets,omitempty"`
+
+	// ReorgedOut counts generations in the window that a reorg took back
+	ReorgedOut int `json:"reorged_out,omitempty"`
+	// Reorgs lists, with --reorg-detect, transactions whose block lost a
+	// reorg after the wallet saw it
+	Reorgs *reorgCheck `json:"reorgs,omitempty"`
+
+	SourceTotals  []sourceTotal    `json:"source_totals,omitempty"`
+	WalletWindows []walletWindow   `json:"wallet_windows,omitempty"`
+	Activity      []walletActivity `json:"activity,omitempty"`
+	Coverage      []walletCoverage `json:"coverage,omitempty"`
+
+	// The hourly table's text rendering: how many rows it may run to before
+	// empty hours are collapsed, or collapseEmpty to decide regardless
+	hourLayout    string
+	hourRowLimit  int
+	collapseEmpty *bool
+
+	// sparkline is --sparkline, for the text daily table
+	sparkline bool
+
+	// style is what the text rendering may use beyond plain ASCII
+	style style
+
+	AnomalyThreshold float64 `json:"anomaly_threshold,omitempty"`
+
+	Rebroadcast   *rebroadcastSummary `json:"rebroadcast,omitempty"`
+	Abandon       *abandonSummary     `json:"abandon,omitempty"`
+	AddrTypes     []addrTypeRow       `json:"addr_types,omitempty"`
+	OutputTypes   []outputTypeRow     `json:"output_types,omitempty"`
+	Degradation   *degradationView    `json:"degradation,omitempty"`
+	Stale         *staleAlert         `json:"stale,omitempty"`
+	CoinbaseTags  []coinbaseTagRow    `json:"coinbase_tags,omitempty"`
+	Subsidy       *subsidyView        `json:"subsidy,omitempty"`
+	Heatmap       *heatmapView        `json:"heatmap,omitempty"`
+	Propagation   *propagationView    `json:"propagation,omitempty"`
+	Timing        *timingView         `json:"timing,omitempty"`
+	BlockFees     *blockFeeSummary    `json:"block_fees,omitempty"`
+	Subsidies     []expectedSubsidy   `json:"expected_subsidy,omitempty"`
+	Rewards       *rewardBreakdown    `json:"reward_breakdown,omitempty"`
+	Hashrate      *hashrateView       `json:"hashrate,omitempty"`
+	Profitability *profitabilityView  `json:"profitability,omitempty"`
+	Dust          *dustSummary        `json:"dust,omitempty"`
+	BlockAge      *blockAgeSummary    `json:"block_age,omitempty"`
+	Goal          *goalView           `json:"goal,omitempty"`
+	AllTime       *allTimeStats       `json:"all_time,omitempty"`
+	Categories    []categoryView      `json:"categories,omitempty"`
+	Split         *splitView          `json:"split,omitempty"`
+	Pool          *poolSummary        `json:"pool,omitempty"`
+	PoolStats     *PoolStats          `json:"pool_stats,omitempty"`
+}
+
+func newReportView(cfg *config, wallets []string, txList []*Transaction, report stats.Report, now time.Time) *reportView {
+	var v = &reportView{
+		Title:         cfg.Title,
+		Operator:      cfg.Operator,
+		Generated:     now,
+		Simulated:     cfg.simulate,
+		Version:       versionString(),
+		Wallets:       wallets,
+		Transactions:  len(txList),
+		Days:          report.Days,
+		Start:         report.Start,
+		Total:         report.Total.Coins,
+		DailyAverage:  report.Total.Coins / float64(report.Days),
+		HourlyAverage: report.Total.Coins / float64(report.Days) / 24.0,
+		WinPercent:    report.Total.RoughPercent(),
+		hourLayout:    cfg.HourBucketFormat,
+		hourRowLimit:  cfg.HourRows,
+		collapseEmpty: cfg.CollapseEmpty,
+		sparkline:     cfg.Sparkline,
+		style:         newStyle(cfg),
+	}
+	if len(txList) > 0 {
+		var first = txList[0].dt
+		v.FirstTx = &first
+	}
+
+	for i, day := range report.Daily {
+		var b = day.Bucket
+		var hours = 24.0
+		var row = reportRow{Label: report.Day(i).Format(cfg.BucketFormat), Start: report.Day(i), Coins: b.Coins, Blocks: b.Blocks, WinPercent: b.RoughPercent()}
+		if i == report.Days-1 {
+			hours = float64(now.Hour()) + float64(now.Minute())/60.0
+		}
+		if hours > 0 {
+			row.Rate = b.Coins / hours
+			if i == report.Days-1 {
+				var projected = row.Rate * 24
+				row.Projected = &projected
+			}
+		}
+		if i == report.Days-1 {
+			row.Hours = hourRows(report.Day(i), day, now, cfg.HourBucketFormat)
+		}
+		v.Daily = append(v.Daily, row)
+	}
+	v.setTodayNeed(report.Total, now)
+
+	return v
+}
+
+// checkBucketFormats rejects bucket label layouts that would give two rows
+// of the report the same label, such as a daily layout without the day of
+// the month, or an hourly one that only shows minutes
+func checkBucketFormats(cfg *config, now time.Time) error {
+	var seen = make(map[string]bool)
+	var begin = getDay(now).AddDate(0, 0, -(cfg.ReportDays - 1))
+	for i := 0; i < cfg.ReportDays; i++ {
+		var label = begin.AddDate(0, 0, i).Format(cfg.BucketFormat)
+		if seen[label] {
+			return usageError(fmt.Sprintf("Invalid --bucket-format %q: more than one day is labeled %q", cfg.BucketFormat, label))
+		}
+		seen[label] = true
+	}
+
+	seen = make(map[string]bool)
+	var day = getDay(now)
+	for h := 0; h < 24; h++ {
+		var label = time.Date(day.Year(), day.Month(), day.Day(), h, 0, 0, 0, day.Location()).Format(cfg.HourBucketFormat)
+		if seen[label] {
+			return usageError(fmt.Sprintf("Invalid --hour-bucket-format %q: more than one hour is labeled %q", cfg.HourBucketFormat, label))
+		}
+		seen[label] = true
+	}
+	return nil
+}
+
+// hourRows builds the hour-of-day rows for a day, stopping at the current
+// hour if the day is still in progress
+func hourRows(dayStart time.Time, day stats.Day, now time.Time, layout string) []reportRow {
+	var rows []reportRow
+	for i := 0; i < 24; i++ {
+		var start = time.Date(dayStart.Year(), dayStart.Month(), dayStart.Day(), i, 0, 0, 0, dayStart.Location())
+		if start.After(now) {
+			break
+		}
+
+		var b = day.Hours[i]
+		var minutes = 60.0
+		var current = now.Sub(start) < time.Hour
+		if current {
+			minutes = float64(now.Minute()) + float64(now.Second())/60
+		}
+		var row = reportRow{Label: start.Format(layout), Start: start, Coins: b.Coins, Blocks: b.Blocks, WinPercent: b.RoughPercent()}
+		if minutes > 0 {
+			row.Rate = b.Coins / minutes
+			if current {
+				var projected = row.Rate * 60
+				row.Projected = &projected
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// parseSortOrder checks --sort-by and --sort-dir and reports whether the
+// rows should be descending.  Without --sort-dir, amounts and rates sort
+// largest first and dates oldest first.
+func parseSortOrder(by, dir string) (bool, error) {
+	var desc bool
+	switch by {
+	case "date":
+	case "amount", "rate":
+		desc = true
+	default:
+		return false, fmt.Errorf("Invalid --sort-by %q: expected date, amount, or rate", by)
+	}
+	switch dir {
+	case "":
+	case "asc":
+		desc = false
+	case "desc":
+		desc = true
+	default:
+		return false, fmt.Errorf("Invalid --sort-dir %q: expected asc or desc", dir)
+	}
+	return desc, nil
+}
+
+// sortRows orders rows by the given key, falling back to date so ties keep
+// a stable order
+func sortRows(rows []reportRow, by string, desc bool) {
+	var key = func(r reportRow) float64 {
+		switch by {
+		case "amount":
+			return r.Coins
+		case "rate":
+			return r.Rate
+		}
+		return float64(r.Start.UnixNano())
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		var a, b = key(rows[i]), key(rows[j])
+		if a == b {
+			return rows[i].Start.Before(rows[j].Start)
+		}
+		return (a < b) != desc
+	})
+}
+
+// sortBuckets reorders the days, and each day's hours, for display.  Each
+// row carries its own projection, so the current day keeps its estimate
+// wherever it lands.
+func (v *reportView) sortBuckets(by string, desc bool) {
+	sortRows(v.Daily, by, desc)
+	sortRows(v.Buckets, by, desc)
+	for i := range v.Daily {
+		sortRows(v.Daily[i].Hours, by, desc)
+	}
+}
+
+// header returns the "Operator — Title — timestamp" line, or "" when neither
+// a title nor an operator was configured
+func (v *reportView) header() string {
+	var parts []string
+	if v.Operator != "" {
+		parts = append(parts, v.Operator)
+	}
+	if v.Title != "" {
+		parts = append(parts, v.Title)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	parts = append(parts, v.Generated.Format(dateTimeLayout))
+	if !v.style.unicode {
+		return strings.Join(parts, " - ")
+	}
+	return strings.Join(parts, " — ")
+}
+
+func (v *reportView) printSummary(w io.Writer) {
+	if h := v.header(); h != "" {
+		fmt.Fprintln(w, h)
+	}
+	if len(v.Picked) > 0 {
+		fmt.Fprintf(w, "Wallets picked: %s\n", strings.Join(v.Picked, ", "))
+	}
+	if v.Pruned {
+		fmt.Fprintln(w, "[pruned mode]")
+	}
+	if v.Relabeled > 0 {
+		fmt.Fprintf(w, "Relabeled %d addresses\n", v.Relabeled)
+	}
+	if v.Simulated {
+		fmt.Fprintln(w, "*** SIMULATED DATA, not from a node ***")
+	}
+	if v.AsOf != nil {
+		fmt.Fprintf(w, "*** Report as of %s, not live data ***\n", v.AsOf.Format(dateTimeLayout))
+	}
+	if v.Source != "" {
+		fmt.Fprintf(w, "Source: %s\n", v.Source)
+	}
+	if len(v.Endpoints) > 0 {
+		fmt.Fprintln(w, endpointLine(v.Endpoints))
+	}
+	if v.Archive != nil {
+		fmt.Fprintln(w, v.Archive.line())
+	}
+	fmt.Fprintf(w, "%d transactions (wallet(s): %s)\n", v.Transactions, strings.Join(v.Wallets, ", "))
+	if v.FirstTx != nil {
+		fmt.Fprintf(w, "First tx was recorded at %s\n", v.FirstTx.Format(dateTimeLayout))
+	}
+	fmt.Fprintf(w, "Report period total: %0.2f%s\n", v.Total, v.roundingNote())
+	if len(v.FiatRates) > 0 {
+		v.printFiat(w)
+	}
+	fmt.Fprintf(w, "Daily average: %0.2f\n", v.DailyAverage)
+	fmt.Fprintf(w, "Hourly average: %0.2f\n", v.HourlyAverage)
+	fmt.Fprintf(w, "Rough Block Win Percent: %0.4f%%\n", v.WinPercent)
+	v.printIdleWallets(w)
+	if !v.fullCoverage() {
+		v.printCoverage(w)
+	}
+	if len(v.SourceTotals) > 0 {
+		v.printSourceTotals(w)
+	}
+	if v.ReorgedOut > 0 {
+		fmt.Fprintf(w, "Reorged out: %d generation(s), not counted\n", v.ReorgedOut)
+	}
+	if v.Reorgs != nil {
+		v.Reorgs.print(w)
+	}
+	if v.Dust != nil {
+		v.Dust.print(w)
+	}
+	if v.BlockAge != nil {
+		v.BlockAge.print(w)
+	}
+	if v.Pool != nil {
+		v.Pool.print(w)
+	}
+	if v.PoolStats != nil {
+		v.PoolStats.print(w)
+	}
+	if v.Goal != nil {
+		v.Goal.print(w)
+	}
+	if v.Split != nil {
+		v.Split.print(w)
+	}
+	if v.Hashrate != nil {
+		v.printHashrate(w)
+	}
+	if v.BlockFees != nil {
+		fmt.Fprintf(w, "Block fee income: %0.2f across %d blocks\n", v.BlockFees.Fees, v.BlockFees.Blocks)
+	}
+	if v.Subsidy != nil {
+		v.Subsidy.print(w)
+	}
+	if v.Rebroadcast != nil {
+		fmt.Fprintf(w, "Re-broadcast: %d accepted, %d rejected\n", v.Rebroadcast.Accepted, len(v.Rebroadcast.Rejected))
+		var txids []string
+		for txid := range v.Rebroadcast.Rejected {
+			txids = append(txids, txid)
+		}
+		sort.Strings(txids)
+		for _, txid := range txids {
+			fmt.Fprintf(w, "  %s: %s\n", txid, v.Rebroadcast.Rejected[txid])
+		}
+	}
+	if v.Abandon != nil {
+		v.Abandon.print(w)
+	}
+	if v.Stale != nil {
+		v.Stale.print(w)
+	}
+}
+
+// narrow reports whether width calls for the narrow layout: short dates, no
+// rate column, and projections on a line of their own
+func narrow(width int) bool {
+	return width > 0 && width < narrowWidth
+}
+
+func (v *reportView) printDaily(w io.Writer, width int) {
+	if len(v.Buckets) > 0 {
+		v.printBuckets(w, width)
+		return
+	}
+	if narrow(width) {
+		for _, row := range v.Daily {
+			fmt.Fprintf(w, "%s %9.2f%s  Win%% %0.2f%%%s\n", row.Start.Format("01-02"), row.Coins, v.sparkTag(row), row.WinPercent, row.poolTag()+row.gapTag()+row.anomalyTag()+v.fiatTag(row))
+			if row.Projected != nil {
+				fmt.Fprintf(w, "      ~ %0.2f expected%s\n", *row.Projected, row.needTag())
+			}
+		}
+		return
+	}
+	for _, row := range v.Daily {
+		var projection = ""
+		if row.Projected != nil {
+			projection = fmt.Sprintf(" (~ %0.2f expected)%s", *row.Projected, row.needTag())
+		}
+		fmt.Fprintf(w, "%s:\t\t\t%8.2f%s\t\t%0.2f/h\t\tWin%%: %0.4f%%%s%s\n", row.Label, row.Coins, v.sparkTag(row), row.Rate, row.WinPercent, projection, row.poolTag()+row.gapTag()+row.anomalyTag()+v.fiatTag(row))
+	}
+	if v.AnomalyThreshold > 0 {
+		v.printAnomalyCount(w)
+	}
+}
+
+// emptyHour reports whether an hourly row has nothing in it at all
+func emptyHour(row reportRow) bool {
+	return row.Blocks == 0 && row.Coins == 0 && row.Projected == nil
+}
+
+// collapseHours reports whether the text hourly table should fold runs of
+// empty hours into one line: when it would otherwise run past the row
+// limit, unless --collapse-empty says otherwise
+func (v *reportView) collapseHours() bool {
+	if v.collapseEmpty != nil {
+		return *v.collapseEmpty
+	}
+	var rows int
+	for _, day := range v.Daily {
+		rows += len(day.Hours)
+	}
+	return v.hourRowLimit > 0 && rows > v.hourRowLimit
+}
+
+// emptyRun returns how many empty hours, one after another in time, start
+// at rows[i].  Sorted tables only collapse where the order happens to be by
+// time.
+func emptyRun(rows []reportRow, i int) int {
+	var n = 0
+	for i+n < len(rows) && emptyHour(rows[i+n]) {
+		if n > 0 && !rows[i+n].Start.Equal(rows[i+n-1].Start.Add(time.Hour)) {
+			break
+		}
+		n++
+	}
+	return n
+}
+
+// emptyRange is the line standing in for a run of empty hours
+func (v *reportView) emptyRange(run []reportRow) string {
+	var end = run[len(run)-1].Start.Add(time.Hour)
+	return fmt.Sprintf("%s%s%s  (no blocks)", run[0].Label, v.style.dash(), end.Format(v.hourLayout))
+}
+
+// printHourly prints each day's hours in order, under that day's date.  Only
+// this rendering collapses empty hours; JSON and the other outputs always
+// get every hour.
+func (v *reportView) printHourly(w io.Writer, width int) {
+	var collapse = v.collapseHours()
+	if narrow(width) {
+		for _, day := range v.Daily {
+			for i := 0; i < len(day.Hours); i++ {
+				var row = day.Hours[i]
+				if n := emptyRun(day.Hours, i); collapse && n > 1 {
+					fmt.Fprintf(w, "%s %s\n", day.Start.Format("01-02"), v.emptyRange(day.Hours[i:i+n]))
+					i += n - 1
+					continue
+				}
+				fmt.Fprintf(w, "%s %s %9.2f\n", day.Start.Format("01-02"), row.Label, row.Coins)
+				if row.Projected != nil {
+					fmt.Fprintf(w, "      ~ %0.2f expected\n", *row.Projected)
+				}
+			}
+		}
+		return
+	}
+	for _, day := range v.Daily {
+		for i := 0; i < len(day.Hours); i++ {
+			var row = day.Hours[i]
+			if n := emptyRun(day.Hours, i); collapse && n > 1 {
+				fmt.Fprintf(w, "- %s %s\n", day.Label, v.emptyRange(day.Hours[i:i+n]))
+				i += n - 1
+				continue
+			}
+			var projection = ""
+			if row.Projected != nil {
+				projection = fmt.Sprintf(" (~ %0.2f expected)", *row.Projected)
+			}
+			fmt.Fprintf(w, "- %s %s:\t\t%8.2f\t\t%0.2f/m\t%s\n", day.Label, row.Label, row.Coins, row.Rate, projection)
+		}
+	}
+}
+
+func (v *reportView) writeJSON(w io.Writer) error {
+	var enc = json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"coins":    func(f float64) string { return fmt.Sprintf("%0.2f", f) },
+	"pct":      func(f float64) string { return fmt.Sprintf("%0.4f%%", f) },
+	"datetime": func(t time.Time) string { return t.Format(dateTimeLayout) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{with .Header}}{{.}}{{else}}Transaction report{{end}}</title>
+</head>
+<body>
+<h1>{{with .Header}}{{.}}{{else}}Transaction report{{end}}</h1>
+{{if .View.Simulated}}<p><strong>SIMULATED DATA, not from a node</strong></p>
+{{end}}{{with .View.AsOf}}<p><strong>Report as of {{datetime .}}, not live data</strong></p>
+{{end}}<p>{{.View.Transactions}} transactions (wallet(s): {{range $i, $w := .View.Wallets}}{{if $i}}, {{end}}{{$w}}{{end}})</p>
+<ul>
+<li>Report period total: {{coins .View.Total}}</li>
+<li>Daily average: {{coins .View.DailyAverage}}</li>
+<li>Hourly average: {{coins .View.HourlyAverage}}</li>
+<li>Rough block win percent: {{pct .View.WinPercent}}</li>
+</ul>
+{{if .View.Buckets}}<h2>By {{.View.BucketSize}}</h2>
+<table>
+<tr><th>Start</th><th>Coins</th><th>Per hour</th><th>Win %</th><th>Expected</th></tr>
+{{range .View.Buckets}}<tr><td>{{.Label}}</td><td>{{coins .Coins}}</td><td>{{coins .Rate}}</td><td>{{pct .WinPercent}}</td><td>{{with .Projected}}{{coins .}}{{end}}</td></tr>
+{{end}}</table>
+{{else}}<h2>Daily</h2>
+<table>
+<tr><th>Day</th><th>Coins</th><th>Per hour</th><th>Win %</th><th>Expected</th></tr>
+{{range .View.Daily}}<tr><td>{{.Label}}</td><td>{{coins .Coins}}</td><td>{{coins .Rate}}</td><td>{{pct .WinPercent}}</td><td>{{with .Projected}}{{coins .}}{{end}}</td></tr>
+{{end}}</table>
+{{end}}
+<h2>By hour</h2>
+<table>
+<tr><th>Hour</th><th>Coins</th><th>Per minute</th><th>Expected</th></tr>
+{{range .View.Daily}}{{$day := .Label}}{{range .Hours}}<tr><td>{{$day}} {{.Label}}</td><td>{{coins .Coins}}</td><td>{{coins .Rate}}</td><td>{{with .Projected}}{{coins .}}{{end}}</td></tr>
+{{end}}{{end}}</table>
+<footer>Generated {{datetime .View.Generated}} by dynamo-tx-stats {{.View.Version}}</footer>
+</body>
+</html>
+`))
+
+func (v *reportView) writeHTML(w io.Writer) error {
+	return htmlReport.Execute(w, struct {
+		Header string
+		View   *reportView
+	}{v.header(), v})
+}
+
+// roundingNote explains, when it matters, why the rows printed to two
+// places don't add up to the total printed to two places.  The total is
+// the exact sum; each row is rounded on its own, and enough of them can
+// drift apart from it by a displayed cent or more.
+func (v *reportView) roundingNote() string {
+	var rows = v.Daily
+	if len(v.Buckets) > 0 {
+		rows = v.Buckets
+	}
+	var shown int64
+	for _, row := range rows {
+		shown += int64(math.Round(row.Coins * 100))
+	}
+	if shown == int64(math.Round(v.Total*100)) {
+		return ""
+	}
+	return fmt.Sprintf(" (the rows below, each rounded, add up to %0.2f)", float64(shown)/100)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Nerdmaster/dynamo-tx-stats/stats"
+)
+
+// A day's hours come out in order, labelled HH:00, up to the hour in
+// progress, which alone gets a projection
+func TestHourRows(t *testing.T) {
+	var start = time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	var acc = stats.NewAccumulator(start, 1)
+	acc.Add(stats.Transaction{TXID: "a", Amount: 5, Blockheight: 100, Time: start.Add(2 * time.Hour)})
+	acc.Add(stats.Transaction{TXID: "b", Amount: 3, Blockheight: 101, Time: start.Add(11*time.Hour + 5*time.Minute)})
+	var day = acc.Snapshot().Daily[0]
+
+	var now = start.Add(11*time.Hour + 15*time.Minute)
+	var rows = hourRows(start, day, now, "15:04")
+	if len(rows) != 12 {
+		t.Fatalf("got %d rows, want 12, through 11:00", len(rows))
+	}
+	for i, row := range rows {
+		var label = fmt.Sprintf("%02d:00", i)
+		if row.Label != label || !row.Start.Equal(start.Add(time.Duration(i)*time.Hour)) {
+			t.Errorf("row %d: got %q starting %s, want %q", i, row.Label, row.Start, label)
+		}
+		if (row.Projected != nil) != (i == 11) {
+			t.Errorf("row %d: projected %v", i, row.Projected)
+		}
+	}
+	if rows[2].Coins != 5 || rows[2].Rate != 5.0/60 {
+		t.Errorf("02:00: got %+v", rows[2])
+	}
+	if rows[11].Rate != 3.0/15 || *rows[11].Projected != 12 {
+		t.Errorf("11:00: got rate %v, projected %v; want 0.2 and 12", rows[11].Rate, *rows[11].Projected)
+	}
+
+	// A finished day has all 24
+	rows = hourRows(start, day, start.Add(30*time.Hour), "15:04")
+	if len(rows) != 24 || rows[23].Label != "23:00" || rows[23].Projected != nil {
+		t.Errorf("finished day: got %d rows, the last %+v", len(rows), rows[len(rows)-1])
+	}
+}
+
+// Three days of 0.334 each print as 0.33 apiece, but the total prints as
+// 1.00; the summary owns up to the cent the rows lose
+func TestRoundingNote(t *testing.T) {
+	var v = &reportView{Total: 1.002, Daily: []reportRow{{Coins: 0.334}, {Coins: 0.334}, {Coins: 0.334}}}
+	var want = " (the rows below, each rounded, add up to 0.99)"
+	if got := v.roundingNote(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	v = &reportView{Total: 1.5, Daily: []reportRow{{Coins: 0.25}, {Coins: 1.25}}}
+	if got := v.roundingNote(); got != "" {
+		t.Errorf("rows that add up: got %q", got)
+	}
+
+	// With --bucket, the bucket rows are the ones printed
+	v = &reportView{Total: 1.002, Daily: []reportRow{{Coins: 1.002}},
+		Buckets: []reportRow{{Coins: 0.334}, {Coins: 0.334}, {Coins: 0.334}}}
+	if got := v.roundingNote(); got != want {
+		t.Errorf("buckets: got %q, want %q", got, want)
+	}
+}
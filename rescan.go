@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// rescanHeuristic describes what a wallet rescan looks like: rescanned
+// transactions all get a timereceived of when the rescan ran, so a burst of
+// at least minTxs received within window, whose blocks were mined over at
+// least minSpan, is taken to be one
+type rescanHeuristic struct {
+	window  time.Duration
+	minTxs  int
+	minSpan time.Duration
+}
+
+// detectRescan returns how many transactions fall in rescan-looking bursts
+func (h rescanHeuristic) detectRescan(txList []*Transaction) int {
+	var sorted = make([]*Transaction, 0, len(txList))
+	for _, tx := range txList {
+		if tx.Blocktime != 0 {
+			sorted = append(sorted, tx)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].TimeReceived < sorted[j].TimeReceived })
+
+	var affected int
+	var start = 0
+	for start < len(sorted) {
+		var end = start
+		var oldest, newest = sorted[start].Blocktime, sorted[start].Blocktime
+		for end < len(sorted) && time.Duration(sorted[end].TimeReceived-sorted[start].TimeReceived)*time.Second <= h.window {
+			if sorted[end].Blocktime < oldest {
+				oldest = sorted[end].Blocktime
+			}
+			if sorted[end].Blocktime > newest {
+				newest = sorted[end].Blocktime
+			}
+			end++
+		}
+
+		if end-start >= h.minTxs && time.Duration(newest-oldest)*time.Second >= h.minSpan {
+			affected += end - start
+			start = end
+			continue
+		}
+		start++
+	}
+	return affected
+}
+
+func warnRescan(h rescanHeuristic, txList []*Transaction) {
+	var n = h.detectRescan(txList)
+	if n == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "WARNING: %d transaction(s) look like they were picked up by a wallet rescan: their timereceived\n", n)
+	fmt.Fprintf(os.Stderr, "values are within %s of each other but their blocks span %s or more.  Daily totals may\n", h.window, h.minSpan)
+	fmt.Fprintln(os.Stderr, "be shifted; consider --time-field blocktime.")
+}
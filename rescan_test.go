@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDetectRescan(t *testing.T) {
+	var h = rescanHeuristic{window: 5 * time.Minute, minTxs: 5, minSpan: 7 * 24 * time.Hour}
+	var rescan = time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC).Unix()
+	var mined = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+
+	var txs = func(n int, received func(i int) int64, blocktime func(i int) int64) []*Transaction {
+		var list []*Transaction
+		for i := 0; i < n; i++ {
+			list = append(list, &Transaction{TXID: fmt.Sprint(i), TimeReceived: received(i), Blocktime: blocktime(i)})
+		}
+		return list
+	}
+	var tests = []struct {
+		name string
+		list []*Transaction
+		want int
+	}{
+		// Received together, mined two days apart each: a rescan
+		{"rescan", txs(8, func(i int) int64 { return rescan + int64(i) }, func(i int) int64 { return mined + int64(i)*2*86400 }), 8},
+		// Received as they were mined: nothing to see
+		{"live", txs(8, func(i int) int64 { return mined + int64(i)*2*86400 + 30 }, func(i int) int64 { return mined + int64(i)*2*86400 }), 0},
+		// A burst of blocks found close together is just luck
+		{"lucky burst", txs(8, func(i int) int64 { return rescan + int64(i)*20 }, func(i int) int64 { return rescan + int64(i)*20 }), 0},
+		// Too few to call it
+		{"too few", txs(4, func(i int) int64 { return rescan + int64(i) }, func(i int) int64 { return mined + int64(i)*3*86400 }), 0},
+		// Unconfirmed transactions have no block time to go on
+		{"unconfirmed", txs(8, func(i int) int64 { return rescan + int64(i) }, func(int) int64 { return 0 }), 0},
+	}
+	for _, tt := range tests {
+		if got := h.detectRescan(tt.list); got != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// rescanPollInterval is how often getwalletinfo is asked how a rescan is
+// getting on
+const rescanPollInterval = 5 * time.Second
+
+// scanProgress reads getwalletinfo's "scanning", which is false when no scan
+// is running and an object with the fraction done when one is
+type scanProgress struct {
+	Duration int64   `json:"duration"`
+	Progress float64 `json:"progress"`
+}
+
+func fetchScanProgress(u *url.URL) (*scanProgress, int64, error) {
+	var info struct {
+		TxCount  int64           `json:"txcount"`
+		Scanning json.RawMessage `json:"scanning"`
+	}
+	var err = rpcCall(u, "getwalletinfo", nil, &info)
+	if err != nil {
+		return nil, 0, err
+	}
+	var p scanProgress
+	if json.Unmarshal(info.Scanning, &p) != nil {
+		return nil, info.TxCount, nil
+	}
+	return &p, info.TxCount, nil
+}
+
+// rescanWallet runs rescanblockchain from height, which doesn't return until
+// the scan is done, and prints the wallet's progress meanwhile.  With abort
+// set, an interrupt asks the node to stop the scan instead of killing the
+// run.  Without progress, only the outcome is printed.
+func rescanWallet(u *url.URL, wallet string, height int64, abort, progress bool) error {
+	var wu = walletURL(u, wallet)
+	var _, before, err = fetchScanProgress(wu)
+	if err != nil {
+		return err
+	}
+
+	var done = make(chan error, 1)
+	go func() {
+		done <- rpcCall(wu, "rescanblockchain", []interface{}{height}, nil)
+	}()
+
+	var interrupt = make(chan os.Signal, 1)
+	if abort {
+		signal.Notify(interrupt, os.Interrupt)
+		defer signal.Stop(interrupt)
+	}
+	var tick = time.NewTicker(rescanPollInterval)
+	defer tick.Stop()
+	for {
+		select {
+		case err = <-done:
+			if err != nil {
+				return err
+			}
+			var after int64
+			_, after, err = fetchScanProgress(wu)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s: Rescan complete: %d transactions found\n", wallet, after-before)
+			return nil
+		case <-interrupt:
+			fmt.Fprintf(os.Stderr, "%s: interrupted, aborting the rescan\n", wallet)
+			var aborted bool
+			err = rpcCall(wu, "abortrescan", nil, &aborted)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: unable to abort the rescan: %s\n", wallet, err)
+			}
+		case <-tick.C:
+			var p, _, err = fetchScanProgress(wu)
+			if err == nil && p != nil && progress {
+				fmt.Fprintf(os.Stderr, "%s: rescanning, %0.1f%% done (%ds)\n", wallet, p.Progress*100, p.Duration)
+			}
+		}
+	}
+}
+
+// runRescan rescans each wallet in turn.  A wallet that fails doesn't stop
+// the others, but the run then exits with exitPartial.
+func runRescan(u *url.URL, cfg *config, wallets []string) error {
+	if cfg.RescanFrom < 0 {
+		return usageError(fmt.Sprintf("Invalid --rescan-from %d", cfg.RescanFrom))
+	}
+	if cfg.dryRun {
+		for _, w := range wallets {
+			rpcCall(walletURL(u, w), "rescanblockchain", []interface{}{cfg.RescanFrom}, nil)
+		}
+		printPlannedOutputs(cfg)
+		return nil
+	}
+
+	var failed int
+	for _, w := range wallets {
+		var err = rescanWallet(u, w, cfg.RescanFrom, cfg.RescanAbort, newStyle(cfg).progress)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to rescan wallet %q: %s\n", w, err)
+			failed++
+		}
+	}
+	if failed == len(wallets) {
+		return exitWith(exitRPC)
+	}
+	return partialResult(failed > 0)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// rewardRow is one block won, its reward split into the subsidy due at its
+// height and the fees that make up the rest
+type rewardRow struct {
+	Height   int64   `json:"height"`
+	Amount   float64 `json:"amount"`
+	Subsidy  float64 `json:"subsidy"`
+	Fees     float64 `json:"fees"`
+	FeeShare float64 `json:"fee_share"`
+}
+
+// rewardBreakdown is --reward-breakdown's table and its totals
+type rewardBreakdown struct {
+	Blocks   []rewardRow `json:"blocks"`
+	Subsidy  float64     `json:"subsidy"`
+	Fees     float64     `json:"fees"`
+	FeeShare float64     `json:"fee_share"`
+
+	// Short counts blocks that paid the wallet less than the subsidy, as a
+	// pool's share of a block does, so their fees come out below zero
+	Short int `json:"short,omitempty"`
+}
+
+// newRewardBreakdown takes each block won's fees to be what the wallet was
+// paid over the subsidy at its height, from the configured schedule if
+// there is one.  Unlike --block-fees it asks the node nothing, so it's only
+// right when the wallet was paid the whole coinbase; a block paid to
+// several of the wallet's outputs is added up first.
+func newRewardBreakdown(blocks []*Transaction, schedule subsidySchedule) *rewardBreakdown {
+	var rows = make(map[string]*rewardRow)
+	var order []string
+	for _, tx := range blocks {
+		var r = rows[tx.Blockhash]
+		if r == nil {
+			r = &rewardRow{Height: tx.Blockheight, Subsidy: defaultSubsidy(tx.Blockheight)}
+			if len(schedule) > 0 {
+				r.Subsidy = schedule.rewardAt(tx.Blockheight)
+			}
+			rows[tx.Blockhash] = r
+			order = append(order, tx.Blockhash)
+		}
+		r.Amount += tx.Amount
+	}
+
+	var b = &rewardBreakdown{}
+	var total float64
+	for _, hash := range order {
+		var r = rows[hash]
+		r.Fees = float64(toSatoshis(r.Amount)-toSatoshis(r.Subsidy)) / satoshisPerCoin
+		if r.Amount > 0 {
+			r.FeeShare = r.Fees / r.Amount * 100
+		}
+		if r.Fees < 0 {
+			b.Short++
+		}
+		b.Subsidy += r.Subsidy
+		b.Fees += r.Fees
+		total += r.Amount
+		b.Blocks = append(b.Blocks, *r)
+	}
+	if total > 0 {
+		b.FeeShare = b.Fees / total * 100
+	}
+	sort.SliceStable(b.Blocks, func(i, j int) bool { return b.Blocks[i].Height < b.Blocks[j].Height })
+	return b
+}
+
+func (b *rewardBreakdown) print(w io.Writer) {
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Block rewards, subsidy against fees:")
+	var cells [][]string
+	for _, r := range b.Blocks {
+		cells = append(cells, []string{fmt.Sprintf("%d", r.Height), fmt.Sprintf("%0.8f", r.Subsidy), fmt.Sprintf("%0.8f", r.Fees), fmt.Sprintf("%0.2f%%", r.FeeShare)})
+	}
+	printTable(w, []string{"block_height", "subsidy", "fees", "fee_share%"}, cells)
+	fmt.Fprintf(w, "Total: %0.8f subsidy, %0.8f fees (%0.2f%% of rewards) across %d blocks\n", b.Subsidy, b.Fees, b.FeeShare, len(b.Blocks))
+	if b.Short > 0 {
+		fmt.Fprintf(w, "(%d block(s) paid less than the subsidy, as a pool's share would, so their fees are below zero)\n", b.Short)
+	}
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      string        `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+// RPCError is the error object a node returns in place of a result
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// rpcErrorHints explains the codes people actually run into, since the
+// node's own messages assume you know what state it's in
+var rpcErrorHints = map[int]string{
+	-1:     "general error",
+	-4:     "wallet error",
+	-5:     "invalid address or key",
+	-8:     "invalid parameter",
+	-13:    "wallet is locked; unlock it with walletpassphrase",
+	-18:    "wallet not found or not loaded; check the name or loadwallet it",
+	-19:    "several wallets are loaded and no wallet was named",
+	-28:    "node is still starting up; try again once it has loaded",
+	-32601: "method not found; the node may be too old, or the method disabled",
+}
+
+func (e *RPCError) Error() string {
+	if hint, ok := rpcErrorHints[e.Code]; ok {
+		return fmt.Sprintf("%s (code %d: %s)", e.Message, e.Code, hint)
+	}
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// RPCResponse is the envelope of every reply.  The result is left raw until
+// the error has been checked, since an error reply's result is null, or
+// anything at all from a misbehaving proxy.
+type RPCResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *RPCError       `json:"error"`
+}
+
+// rpcRecorder, when set, is handed each RPC call instead of it being sent
+// to the node.  --dry-run uses this to print the calls a run would make.
+var rpcRecorder func(u *url.URL, method string, params []interface{})
+
+// maxResponseSize caps how much of a response body is read.  A real RPC
+// endpoint never gets near it; this just stops a wrong URL (a file
+// download, a chatty proxy) from being slurped into memory.
+var maxResponseSize int64 = 64 << 20
+
+// rpcIDPrefix, when set, replaces the fixed "curltest" request ID with the
+// prefix and a per-run counter, so each call can be found in the node's
+// debug log
+var rpcIDPrefix string
+
+var rpcIDCounter uint64
+
+func nextRPCID() string {
+	if rpcIDPrefix == "" {
+		return "curltest"
+	}
+	return fmt.Sprintf("%s-%04d", rpcIDPrefix, atomic.AddUint64(&rpcIDCounter, 1))
+}
+
+// walletAuth holds per-wallet credentials from --wallet-auth, for nodes
+// whose rpcauth users can each only see their own wallet
+var walletAuth = make(map[string]*url.Userinfo)
+
+// parseWalletAuth reads a "wallet:user:pass" --wallet-auth value.  The
+// password is everything after the second colon, so it may contain colons.
+func parseWalletAuth(s string) (string, *url.Userinfo, error) {
+	var parts = strings.SplitN(s, ":", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+		return "", nil, fmt.Errorf("expected wallet:user:pass, got %q", s)
+	}
+	return parts[0], url.UserPassword(parts[1], parts[2]), nil
+}
+
+// rpcPathPrefix is --path-prefix, for a node behind a reverse proxy that
+// serves its RPC somewhere other than the root, such as "/bitcoin/rpc"
+var rpcPathPrefix string
+
+// cleanPathPrefix gives a --path-prefix a leading slash and no trailing one
+func cleanPathPrefix(p string) string {
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return ""
+	}
+	return "/" + p
+}
+
+// walletPath is the path of the given wallet's RPC endpoint
+func walletPath(wallet string) string {
+	return rpcPathPrefix + "/wallet/" + wallet
+}
+
+// walletURL returns a copy of u pointed at the given wallet's RPC endpoint,
+// with that wallet's own credentials if it has any
+func walletURL(u *url.URL, wallet string) *url.URL {
+	var wu = *u
+	wu.Path = walletPath(wallet)
+	if auth, ok := walletAuth[wallet]; ok {
+		wu.User = auth
+	}
+	return &wu
+}
+
+// nodeURL returns a copy of u pointed at the node-level RPC endpoint
+func nodeURL(u *url.URL) *url.URL {
+	var nu = *u
+	nu.Path = rpcPathPrefix + "/"
+	return &nu
+}
+
+// rpcCall runs a single JSON-RPC method and decodes its result into result
+func rpcCall(u *url.URL, method string, params []interface{}, result interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	if rpcRecorder != nil {
+		rpcRecorder(u, method, params)
+		return nil
+	}
+
+	var id = nextRPCID()
+	var body, err = json.Marshal(rpcRequest{JSONRPC: "1.0", ID: id, Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+
+	var resp RPCResponse
+	if rpcReplay != nil {
+		err = rpcReplay.answer(u, method, params, &resp)
+	} else if rpcSimulation != nil {
+		err = rpcSimulation.answer(u, method, params, &resp)
+	} else {
+		err = doPost(u, method, bytes.NewReader(body), &resp)
+	}
+	rpcCapture.record(u, method, params, &resp, err)
+	if err != nil && rpcIDPrefix != "" {
+		return fmt.Errorf("%s (id %s): %w", method, id, err)
+	}
+	if err != nil {
+		return err
+	}
+	if resp.Error != nil && rpcIDPrefix != "" {
+		return fmt.Errorf("%s (id %s): %w", method, id, resp.Error)
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("%s: %w", method, resp.Error)
+	}
+
+	if result == nil || len(resp.Result) == 0 {
+		return nil
+	}
+	err = json.Unmarshal(resp.Result, result)
+	if err != nil {
+		return fmt.Errorf("%s: unexpected result: %w", method, err)
+	}
+	return nil
+}
+
+// batchResponse is one reply in a JSON-RPC batch, which may come back in
+// any order
+type batchResponse struct {
+	RPCResponse
+	ID string `json:"id"`
+}
+
+// rpcBatch runs method once for each set of params in a single JSON-RPC
+// batch request, decoding each result into the matching results entry.  The
+// returned slice holds each call's own error; the error is for the batch as
+// a whole, such as a proxy that won't pass batches, and means none of it
+// can be trusted.  Under --dry-run, --debug-capture, replay, and --simulate,
+// the calls go one at a time, so each is printed, written, or answered on
+// its own.
+func rpcBatch(u *url.URL, method string, params [][]interface{}, results []interface{}) ([]error, error) {
+	var errs = make([]error, len(params))
+	if rpcRecorder != nil || rpcReplay != nil || rpcCapture != nil || rpcSimulation != nil {
+		for i := range params {
+			errs[i] = rpcCall(u, method, params[i], results[i])
+		}
+		return errs, nil
+	}
+
+	var base = nextRPCID()
+	var reqs = make([]rpcRequest, len(params))
+	var index = make(map[string]int)
+	for i, p := range params {
+		if p == nil {
+			p = []interface{}{}
+		}
+		var id = fmt.Sprintf("%s.%d", base, i)
+		reqs[i] = rpcRequest{JSONRPC: "1.0", ID: id, Method: method, Params: p}
+		index[id] = i
+	}
+	var body, err = json.Marshal(reqs)
+	if err != nil {
+		return nil, err
+	}
+	var resps []batchResponse
+	err = doPost(u, method+" (batch)", bytes.NewReader(body), &resps)
+	if err != nil {
+		return nil, err
+	}
+
+	var answered = make([]bool, len(params))
+	for _, r := range resps {
+		var i, ok = index[r.ID]
+		if !ok {
+			return nil, fmt.Errorf("%s: reply to unknown id %q", method, r.ID)
+		}
+		answered[i] = true
+		if r.Error != nil {
+			errs[i] = fmt.Errorf("%s: %w", method, r.Error)
+			continue
+		}
+		if results[i] == nil || len(r.Result) == 0 {
+			continue
+		}
+		err = json.Unmarshal(r.Result, results[i])
+		if err != nil {
+			errs[i] = fmt.Errorf("%s: unexpected result: %w", method, err)
+		}
+	}
+	for i, ok := range answered {
+		if !ok {
+			errs[i] = fmt.Errorf("%s: no reply in batch", method)
+		}
+	}
+	return errs, nil
+}
+
+// doPost sends one request, recording its time and size under method
+func doPost(u *url.URL, method string, data io.Reader, resp interface{}) error {
+	if rpcFailover != nil && rpcFailover.forNode(u) {
+		return rpcFailover.post(u, method, data, resp)
+	}
+	var _, err = postOnce(u, method, data, resp)
+	return err
+}
+
+// postOnce is doPost to u alone.  down is whether u failed rather than
+// answered: it couldn't be reached, or gave a server error without a
+// JSON-RPC reply to explain it.
+func postOnce(u *url.URL, method string, data io.Reader, resp interface{}) (down bool, err error) {
+	var req *http.Request
+	req, err = http.NewRequest(http.MethodPost, u.String(), data)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set("User-Agent", userAgent())
+
+	rpcLimit.wait()
+	var trace = &requestTrace{start: time.Now()}
+	if traceRPC {
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace.clientTrace()))
+	}
+
+	var r *http.Response
+	r, err = http.DefaultClient.Do(req)
+	if err != nil {
+		rpcStats.record(method, 0, time.Since(trace.start))
+		return true, classifyNetError(u, err, time.Since(trace.start))
+	}
+	defer r.Body.Close()
+	noteNodeDate(r.Header.Get("Date"), trace.start, time.Now())
+	var body []byte
+	body, err = io.ReadAll(io.LimitReader(r.Body, maxResponseSize+1))
+	rpcStats.record(method, int64(len(body)), time.Since(trace.start))
+	if traceRPC {
+		trace.print(method, int64(len(body)))
+	}
+	if err != nil {
+		return true, err
+	}
+	if int64(len(body)) > maxResponseSize {
+		return false, fmt.Errorf("response exceeded %s size limit — is this really the RPC endpoint?", formatByteSize(maxResponseSize))
+	}
+
+	// The node answers errors with a non-200 status and a JSON body, so the
+	// status only matters when there's no JSON to explain it (a 401 for bad
+	// credentials has an empty body)
+	err = json.Unmarshal(body, resp)
+	if err != nil && r.StatusCode != http.StatusOK {
+		return r.StatusCode >= 500, httpStatusError(r)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return false, nil
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// capture runs fn with *f, os.Stdout or os.Stderr, sent to a pipe, and
+// returns what was written to it
+func capture(t *testing.T, f **os.File, fn func()) string {
+	var r, w, err = os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved = *f
+	*f = w
+	var out = make(chan string)
+	go func() {
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		out <- buf.String()
+	}()
+	fn()
+	*f = saved
+	w.Close()
+	return <-out
+}
+
+// testServer is an httptest server that can wait for its connections to
+// close, so a test can change a global its goroutines read, time.Local
+// above all, without racing them
+type testServer struct {
+	*httptest.Server
+
+	mu    sync.Mutex
+	idle  *sync.Cond
+	conns int
+}
+
+func newTestServer(t *testing.T, h http.Handler) *testServer {
+	var s = &testServer{Server: httptest.NewUnstartedServer(h)}
+	s.idle = sync.NewCond(&s.mu)
+	s.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		switch state {
+		case http.StateNew:
+			s.conns++
+		case http.StateClosed, http.StateHijacked:
+			s.conns--
+			s.idle.Broadcast()
+		}
+	}
+	s.Start()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// drain closes the client's idle connections and waits until the server
+// has seen every one of its own close
+func (s *testServer) drain() {
+	http.DefaultClient.CloseIdleConnections()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.conns > 0 {
+		s.idle.Wait()
+	}
+}
+
+// fakeMethod answers one RPC method; path is the endpoint it was called on,
+// "/" or "/wallet/<name>"
+type fakeMethod func(path string, params []interface{}) (interface{}, *RPCError)
+
+// fakeNode is an httptest node answering the methods it's given, and
+// "method not found" to the rest, singly or in a batch
+type fakeNode struct {
+	*testServer
+
+	mu      sync.Mutex
+	methods map[string]fakeMethod
+	calls   []string
+}
+
+func newFakeNode(t *testing.T, methods map[string]fakeMethod) *fakeNode {
+	var n = &fakeNode{methods: methods}
+	n.testServer = newTestServer(t, http.HandlerFunc(n.serve))
+	return n
+}
+
+// url is the node's URL with credentials, as --url would give it
+func (n *fakeNode) url() *url.URL {
+	var u, _ = url.Parse(n.URL)
+	u.User = url.UserPassword("u", "p")
+	return u
+}
+
+// called counts the calls made to method
+func (n *fakeNode) called(method string) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	var count int
+	for _, m := range n.calls {
+		if m == method {
+			count++
+		}
+	}
+	return count
+}
+
+func (n *fakeNode) answer(path string, req rpcRequest) interface{} {
+	n.mu.Lock()
+	n.calls = append(n.calls, req.Method)
+	var m = n.methods[req.Method]
+	n.mu.Unlock()
+
+	var resp = map[string]interface{}{"id": req.ID, "result": nil, "error": nil}
+	if m == nil {
+		resp["error"] = &RPCError{Code: -32601, Message: "Method not found"}
+		return resp
+	}
+	var result, rpcErr = m(path, req.Params)
+	if rpcErr != nil {
+		resp["error"] = rpcErr
+		return resp
+	}
+	resp["result"] = result
+	return resp
+}
+
+func (n *fakeNode) serve(w http.ResponseWriter, r *http.Request) {
+	var body, _ = io.ReadAll(r.Body)
+	if strings.HasPrefix(strings.TrimSpace(string(body)), "[") {
+		var reqs []rpcRequest
+		json.Unmarshal(body, &reqs)
+		var resps []interface{}
+		for _, req := range reqs {
+			resps = append(resps, n.answer(r.URL.Path, req))
+		}
+		json.NewEncoder(w).Encode(resps)
+		return
+	}
+	var req rpcRequest
+	json.Unmarshal(body, &req)
+	json.NewEncoder(w).Encode(n.answer(r.URL.Path, req))
+}
+
+// newWalletNode is a node with one wallet, "rig1", holding txs, newest
+// last, with enough of the rest of the node for a plain report run
+func newWalletNode(t *testing.T, txs []map[string]interface{}) *fakeNode {
+	return newFakeNode(t, map[string]fakeMethod{
+		"getblockchaininfo": func(string, []interface{}) (interface{}, *RPCError) {
+			return map[string]interface{}{"chain": "main", "blocks": 800100, "pruned": false}, nil
+		},
+		"listtransactions": func(path string, params []interface{}) (interface{}, *RPCError) {
+			if path != "/wallet/rig1" {
+				return nil, &RPCError{Code: -18, Message: "Requested wallet does not exist or is not loaded"}
+			}
+			return txs, nil
+		},
+	})
+}
+
+// generation is a mature block reward of amount at height, received at t
+func generation(txid string, height int64, amount float64, t time.Time) map[string]interface{} {
+	return map[string]interface{}{"txid": txid, "vout": 0, "category": "generate", "generated": true, "amount": amount,
+		"confirmations": 800101 - height, "blockhash": fmt.Sprintf("%064x", height), "blockheight": height, "blockindex": 0,
+		"blocktime": t.Unix(), "time": t.Unix(), "timereceived": t.Unix()}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+)
+
+// scanResult is what scantxoutset reports for a descriptor
+type scanResult struct {
+	Success     bool    `json:"success"`
+	TxOuts      int64   `json:"txouts"`
+	Height      int64   `json:"height"`
+	BestBlock   string  `json:"bestblock"`
+	TotalAmount float64 `json:"total_amount"`
+	Unspents    []struct {
+		TXID     string  `json:"txid"`
+		Vout     int64   `json:"vout"`
+		Desc     string  `json:"desc"`
+		Amount   float64 `json:"amount"`
+		Coinbase bool    `json:"coinbase"`
+		Height   int64   `json:"height"`
+	} `json:"unspents"`
+}
+
+// scanDescriptor runs scantxoutset for one output descriptor.  The scan
+// reads the whole UTXO set, so it's slow, and the node only runs one at a
+// time.
+func scanDescriptor(u *url.URL, desc string) (*scanResult, error) {
+	var r scanResult
+	var err = rpcCall(nodeURL(u), "scantxoutset", []interface{}{"start", []interface{}{map[string]string{"desc": desc}}}, &r)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// printScanResult prints the totals of a scan and then each UTXO it found,
+// oldest first
+func printScanResult(r *scanResult) {
+	sort.Slice(r.Unspents, func(i, j int) bool { return r.Unspents[i].Height < r.Unspents[j].Height })
+	fmt.Printf("Height:        %d\n", r.Height)
+	fmt.Printf("Best block:    %s\n", r.BestBlock)
+	fmt.Printf("Txouts read:   %d\n", r.TxOuts)
+	fmt.Printf("UTXOs:         %d\n", len(r.Unspents))
+	fmt.Printf("Total amount:  %0.8f\n", r.TotalAmount)
+	if len(r.Unspents) == 0 {
+		return
+	}
+	fmt.Println()
+	for _, utxo := range r.Unspents {
+		var note string
+		if utxo.Coinbase {
+			note = "\tcoinbase"
+		}
+		fmt.Printf("%d\t%s:%d\t%14.8f%s\n", utxo.Height, utxo.TXID, utxo.Vout, utxo.Amount, note)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// sendOutput is one address:amount of --estimate-send-fee
+type sendOutput struct {
+	address string
+	amount  float64
+}
+
+// parseSendOutputs reads a comma-separated address:amount list.  An address
+// may only appear once, as createrawtransaction won't take it twice.
+func parseSendOutputs(s string) ([]sendOutput, error) {
+	var outs []sendOutput
+	var seen = make(map[string]bool)
+	for _, part := range strings.Split(s, ",") {
+		var addr, amt, ok = strings.Cut(strings.TrimSpace(part), ":")
+		if !ok || addr == "" || amt == "" {
+			return nil, fmt.Errorf("%q is not address:amount", part)
+		}
+		var amount, err = strconv.ParseFloat(amt, 64)
+		if err != nil || amount <= 0 {
+			return nil, fmt.Errorf("invalid amount %q for %s", amt, addr)
+		}
+		if seen[addr] {
+			return nil, fmt.Errorf("%s is given more than once", addr)
+		}
+		seen[addr] = true
+		outs = append(outs, sendOutput{address: addr, amount: amount})
+	}
+	if len(outs) == 0 {
+		return nil, errors.New("no outputs")
+	}
+	return outs, nil
+}
+
+// sendFeeEstimate is what the wallet would pay to send the outputs
+type sendFeeEstimate struct {
+	outputs []sendOutput
+	fee     float64
+	vsize   int64
+	inputs  int
+	change  bool
+}
+
+// estimateSendFee builds an unsigned transaction paying the outputs and has
+// the wallet fund it, which picks the inputs and sets the fee the way a real
+// send would.  Nothing is signed, so nothing can be broadcast.
+func estimateSendFee(wu *url.URL, outs []sendOutput) (*sendFeeEstimate, error) {
+	var outputs = make(map[string]json.Number)
+	for _, o := range outs {
+		outputs[o.address] = json.Number(strconv.FormatFloat(o.amount, 'f', 8, 64))
+	}
+	var raw string
+	var err = rpcCall(wu, "createrawtransaction", []interface{}{[]interface{}{}, outputs}, &raw)
+	if err != nil {
+		return nil, err
+	}
+	var funded struct {
+		Hex       string  `json:"hex"`
+		Fee       float64 `json:"fee"`
+		ChangePos int     `json:"changepos"`
+	}
+	err = rpcCall(wu, "fundrawtransaction", []interface{}{raw}, &funded)
+	if err != nil {
+		return nil, err
+	}
+	var decoded struct {
+		VSize int64             `json:"vsize"`
+		Vin   []json.RawMessage `json:"vin"`
+	}
+	err = rpcCall(nodeURL(wu), "decoderawtransaction", []interface{}{funded.Hex}, &decoded)
+	if err != nil {
+		return nil, err
+	}
+	return &sendFeeEstimate{outputs: outs, fee: funded.Fee, vsize: decoded.VSize, inputs: len(decoded.Vin), change: funded.ChangePos >= 0}, nil
+}
+
+// printSendFee prints the fee a send would cost.  The size is the unsigned
+// transaction's, so the rate over it runs a little high; the fee itself
+// already allows for the signatures.
+func printSendFee(e *sendFeeEstimate) {
+	var total float64
+	for _, o := range e.outputs {
+		fmt.Printf("Send:        %14.8f to %s\n", o.amount, o.address)
+		total += o.amount
+	}
+	var change = "no change"
+	if e.change {
+		change = "plus change"
+	}
+	fmt.Printf("Total:       %14.8f (%d outputs, %s)\n", total, len(e.outputs), change)
+	fmt.Printf("Inputs:      %d\n", e.inputs)
+	fmt.Printf("Fee:         %14.8f\n", e.fee)
+	fmt.Printf("Fee rate:    %8.2f sat/vB over %d vB unsigned\n", feeRate(e.fee, e.vsize), e.vsize)
+}
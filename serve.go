@@ -0,0 +1,420 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Nerdmaster/dynamo-tx-stats/stats"
+)
+
+// maxServePoints caps how many buckets one series can be cut into, however
+// small an interval Grafana asks for
+const maxServePoints = 10000
+
+type cachedTx struct {
+	wallet string
+	txid   string
+	vout   int64
+	height int64
+	t      time.Time
+	amount float64
+}
+
+func (e cachedTx) key() string {
+	return fmt.Sprintf("%s:%d", e.txid, e.vout)
+}
+
+// txCache keeps the report window's block rewards in memory so queries
+// don't each cost a round of listtransactions calls.  Each wallet also gets
+// an Accumulator over the same window for block notifications.
+type txCache struct {
+	mu      sync.RWMutex
+	entries []cachedTx
+	accs    map[string]*stats.Accumulator
+	updated time.Time
+
+	// reorged counts entries a reorg has taken back since serve started
+	reorged int
+
+	// found counts, per wallet, the entries refreshes have brought in
+	// since serve started, for /metrics; a reorg doesn't take them back
+	found map[string]*foundCount
+}
+
+// refresh reloads the cache and returns the entries that weren't there
+// before, oldest first, and those that were counted before but have since
+// been reorged out.  The first load returns nothing, since none of it is
+// news.
+func (c *txCache) refresh(u *url.URL, wallets []string, days int, field string) ([]cachedTx, []cachedTx, error) {
+	var now = time.Now()
+	var cutoff = getDay(now).AddDate(0, 0, -(days - 1))
+	var seen = make(map[string]bool)
+	var listed, conflicted = make(map[string]bool), make(map[string]bool)
+	var entries []cachedTx
+	for _, w := range wallets {
+		var list, err = listTransactions(walletURL(u, w))
+		if err != nil {
+			serveRefreshFailures.Add(1)
+			return nil, nil, fmt.Errorf("wallet %q: %w", w, err)
+		}
+		for _, tx := range list {
+			tx.dt = txTime(tx, field)
+			var key = fmt.Sprintf("%s:%d", tx.TXID, tx.Vout)
+			listed[key] = true
+			if tx.Confirmations < 0 || tx.Category == "orphan" {
+				conflicted[key] = true
+			}
+			if !countable(tx, now) || tx.dt.Before(cutoff) || seen[key] {
+				continue
+			}
+			seen[key] = true
+			entries = append(entries, cachedTx{wallet: w, txid: tx.TXID, vout: tx.Vout, height: tx.Blockheight, t: tx.dt, amount: tx.Amount})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].t.Before(entries[j].t) })
+	var accs = walletAccumulators(entries, cutoff, days)
+
+	c.mu.Lock()
+	var found, removed []cachedTx
+	if !c.updated.IsZero() {
+		var known = make(map[string]bool, len(c.entries))
+		for _, e := range c.entries {
+			known[e.key()] = true
+		}
+		for _, e := range entries {
+			if !known[e.key()] {
+				found = append(found, e)
+			}
+		}
+		removed = reorgedOut(c.entries, seen, listed, conflicted, cutoff)
+	}
+	c.entries, c.accs, c.updated = entries, accs, now
+	c.reorged += len(removed)
+	if c.found == nil {
+		c.found = make(map[string]*foundCount)
+	}
+	for _, e := range found {
+		var n = c.found[e.wallet]
+		if n == nil {
+			n = &foundCount{}
+			c.found[e.wallet] = n
+		}
+		n.blocks++
+		n.coins += e.amount
+		n.last = e
+	}
+	c.mu.Unlock()
+	serveRefreshes.Add(1)
+	serveLastRefresh.Set(time.Since(now).Seconds())
+	return found, removed, nil
+}
+
+// reorgedOut returns the old entries that are no longer counted because the
+// wallet now lists them as conflicted, or doesn't list them at all.  Entries
+// that have aged out of the window aren't news.
+func reorgedOut(old []cachedTx, counted, listed, conflicted map[string]bool, cutoff time.Time) []cachedTx {
+	var removed []cachedTx
+	for _, e := range old {
+		if counted[e.key()] || e.t.Before(cutoff) {
+			continue
+		}
+		if conflicted[e.key()] || !listed[e.key()] {
+			removed = append(removed, e)
+		}
+	}
+	return removed
+}
+
+// snapshot returns the wallet's current report, which is empty if the
+// wallet has nothing in the window
+func (c *txCache) snapshot(wallet string) stats.Report {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var acc = c.accs[wallet]
+	if acc == nil {
+		return stats.Report{}
+	}
+	return acc.Snapshot()
+}
+
+// previous returns the wallet's last entry before e
+func (c *txCache) previous(e cachedTx) (cachedTx, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for i := len(c.entries) - 1; i >= 0; i-- {
+		var p = c.entries[i]
+		if p.wallet == e.wallet && p.t.Before(e.t) {
+			return p, true
+		}
+	}
+	return cachedTx{}, false
+}
+
+// metrics lists the series /search offers: the totals, then each wallet's
+func serveMetrics(wallets []string) []string {
+	var m = []string{"amount", "blocks"}
+	var sorted = append([]string(nil), wallets...)
+	sort.Strings(sorted)
+	for _, w := range sorted {
+		m = append(m, "amount."+w, "blocks."+w)
+	}
+	return m
+}
+
+type grafanaQuery struct {
+	Range struct {
+		From time.Time `json:"from"`
+		To   time.Time `json:"to"`
+	} `json:"range"`
+	IntervalMs int64 `json:"intervalMs"`
+	Targets    []struct {
+		Target string `json:"target"`
+	} `json:"targets"`
+}
+
+type grafanaSeries struct {
+	Target     string       `json:"target"`
+	Datapoints [][2]float64 `json:"datapoints"`
+}
+
+// series re-buckets the cache into [value, unix ms] points covering the
+// query's range at its interval.  Empty buckets are included as zero so
+// graphs don't interpolate across quiet periods.
+func (c *txCache) series(target string, from, to time.Time, interval time.Duration) (grafanaSeries, error) {
+	var kind, wallet, _ = strings.Cut(target, ".")
+	if kind != "amount" && kind != "blocks" {
+		return grafanaSeries{}, fmt.Errorf("unknown metric %q", target)
+	}
+	if to.Before(from) {
+		return grafanaSeries{}, fmt.Errorf("range ends before it starts")
+	}
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	if span := to.Sub(from); span/interval > maxServePoints {
+		interval = span / maxServePoints
+	}
+
+	var n = int(to.Sub(from)/interval) + 1
+	var values = make([]float64, n)
+	c.mu.RLock()
+	for _, e := range c.entries {
+		if e.t.Before(from) || e.t.After(to) || (wallet != "" && e.wallet != wallet) {
+			continue
+		}
+		var i = int(e.t.Sub(from) / interval)
+		if kind == "amount" {
+			values[i] += e.amount
+		} else {
+			values[i]++
+		}
+	}
+	c.mu.RUnlock()
+
+	var s = grafanaSeries{Target: target, Datapoints: make([][2]float64, n)}
+	for i, v := range values {
+		var ts = from.Add(time.Duration(i) * interval)
+		s.Datapoints[i] = [2]float64{v, float64(ts.UnixNano() / int64(time.Millisecond))}
+	}
+	return s, nil
+}
+
+// logReorged reports each entry a refresh found reorged out, with the
+// running count since serve started
+func logReorged(c *txCache, removed []cachedTx) {
+	if len(removed) == 0 {
+		return
+	}
+	c.mu.RLock()
+	var total = c.reorged
+	c.mu.RUnlock()
+	var now = time.Now().Format("2006-01-02 15:04:05")
+	for _, e := range removed {
+		fmt.Fprintf(os.Stderr, "%s: block %d (%s) in wallet %q was reorged out; %0.8f no longer counted\n", now, e.height, e.txid, e.wallet, e.amount)
+	}
+	fmt.Fprintf(os.Stderr, "%s: %d reorged out since serve started\n", now, total)
+}
+
+func writeServeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// runServe implements "serve", which answers Grafana's JSON datasource API
+// (/, /search, /query) from a cache refreshed every --refresh, lists the
+// --block-log at /blocks, and exports metrics for Prometheus at /metrics
+func runServe(args []string) error {
+	var cfg, err = parseConfig(args)
+	if err != nil {
+		return err
+	}
+	err = applyRPCSettings(cfg)
+	if err != nil {
+		return err
+	}
+	var u *url.URL
+	u, err = nodeFromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	if cfg.ReportDays < 1 {
+		return usageError("Not enough args")
+	}
+	if cfg.Refresh <= 0 {
+		return usageError(fmt.Sprintf("Invalid --refresh %s", cfg.Refresh))
+	}
+	var wallets []string
+	wallets, err = resolveWallets(u, cfg)
+	if err != nil {
+		return err
+	}
+
+	if cfg.DailyReport != "" {
+		var hour, minute, err = parseClock(cfg.DailyReport)
+		if err != nil {
+			return usageError("Invalid --daily-report: " + err.Error())
+		}
+		if len(configuredSinks(cfg)) == 0 {
+			return usageError("--daily-report needs somewhere to send it: --daily-report-dir or --daily-report-webhook")
+		}
+		go runDailyReports(u, cfg, wallets, hour, minute)
+	}
+
+	if cfg.BlockLog < 0 {
+		return usageError(fmt.Sprintf("Invalid --block-log %d", cfg.BlockLog))
+	}
+	var blocks *blockLog
+	if cfg.BlockLog > 0 {
+		blocks = newBlockLog(cfg.BlockLog)
+	}
+
+	if cfg.DebugPprof && cfg.DebugListen == "" {
+		return usageError("--debug-pprof needs --debug-listen")
+	}
+	if cfg.DebugListen != "" {
+		err = checkDebugListen(cfg.DebugListen, cfg.Listen)
+		if err != nil {
+			return usageError("Invalid --debug-listen: " + err.Error())
+		}
+	}
+
+	var audits *auditTracker
+	if cfg.AuditEvery < 0 {
+		return usageError(fmt.Sprintf("Invalid --audit-every %s", cfg.AuditEvery))
+	}
+	if cfg.AuditEvery > 0 {
+		if cfg.TxArchive == "" {
+			return usageError("--audit-every needs a --tx-archive to check")
+		}
+		if cfg.AuditDays < 1 {
+			return usageError(fmt.Sprintf("Invalid --audit-days %d", cfg.AuditDays))
+		}
+		audits = &auditTracker{}
+		go audits.run(u, cfg, wallets, cfg.AuditEvery)
+	}
+
+	var cache = &txCache{}
+	_, _, err = cache.refresh(u, wallets, cfg.ReportDays, cfg.TimeField)
+	if err != nil {
+		return failure(exitRPC, "Unable to load transactions: %s", err)
+	}
+	var fifo *fifoWriter
+	if cfg.FIFO != "" {
+		fifo, err = openFIFO(cfg.FIFO)
+		if err != nil {
+			return failure(exitUsage, "Unable to set up FIFO %q: %s", cfg.FIFO, err)
+		}
+		fifo.send(fifoLine(cache, wallets, cfg.ReportDays, time.Now()))
+	}
+	if cfg.DebugListen != "" {
+		publishServeVars(cache)
+		go serveDebug(cfg.DebugListen, cfg.DebugPprof)
+	}
+	if cfg.Splay < 0 {
+		return usageError(fmt.Sprintf("Invalid --splay %s", cfg.Splay))
+	}
+	go func() {
+		// --splay jitters each refresh rather than delaying startup
+		for {
+			time.Sleep(cfg.Refresh + splayDelay(cfg.Splay, cfg.SplayRandom))
+			var found, removed, err = cache.refresh(u, wallets, cfg.ReportDays, cfg.TimeField)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: refresh failed, serving the previous data: %s\n", time.Now().Format("2006-01-02 15:04:05"), err)
+				continue
+			}
+			logReorged(cache, removed)
+			if blocks != nil {
+				var since = getDay(time.Now()).AddDate(0, 0, -(cfg.ReportDays - 1))
+				for _, e := range blocks.add(found, since) {
+					fmt.Println(blockLogLine(e))
+				}
+			}
+			notifyBlocks(u, cache, cfg.BlockWebhook, found)
+			if fifo != nil {
+				fifo.send(fifoLine(cache, wallets, cfg.ReportDays, time.Now()))
+			}
+		}
+	}()
+
+	var mux = http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintln(w, "OK")
+	})
+	mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		writeServeJSON(w, serveMetrics(wallets))
+	})
+	mux.HandleFunc("/blocks", func(w http.ResponseWriter, r *http.Request) {
+		if blocks == nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		blocks.write(w)
+	})
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		var om = wantsOpenMetrics(r.Header.Get("Accept"))
+		if om {
+			w.Header().Set("Content-Type", openMetricsType)
+		} else {
+			w.Header().Set("Content-Type", promTextType)
+		}
+		var families = serveFamilies(cache, wallets)
+		if audits != nil {
+			families = append(families, audits.families()...)
+		}
+		writeExposition(w, families, om)
+	})
+	mux.HandleFunc("/query", func(w http.ResponseWriter, r *http.Request) {
+		var q grafanaQuery
+		var err = json.NewDecoder(r.Body).Decode(&q)
+		if err != nil {
+			http.Error(w, "bad query: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		var out = make([]grafanaSeries, 0, len(q.Targets))
+		for _, t := range q.Targets {
+			var s, err = cache.series(t.Target, q.Range.From, q.Range.To, time.Duration(q.IntervalMs)*time.Millisecond)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			out = append(out, s)
+		}
+		writeServeJSON(w, out)
+	})
+
+	fmt.Fprintf(os.Stderr, "Serving %d wallet(s) on %s\n", len(wallets), cfg.Listen)
+	err = http.ListenAndServe(cfg.Listen, mux)
+	return failure(exitUsage, "Unable to serve on %s: %s", cfg.Listen, err)
+}
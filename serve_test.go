@@ -0,0 +1,102 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// series buckets the cache over the query's range, zero-filling empty
+// buckets, and narrows to one wallet for "amount.<wallet>"
+func TestServeSeries(t *testing.T) {
+	var from = time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	var c = &txCache{entries: []cachedTx{
+		{wallet: "rig1", txid: "a", t: from.Add(10 * time.Minute), amount: 5},
+		{wallet: "rig2", txid: "b", t: from.Add(50 * time.Minute), amount: 2.5},
+		{wallet: "rig1", txid: "c", t: from.Add(150 * time.Minute), amount: 5},
+		{wallet: "rig1", txid: "d", t: from.Add(-time.Minute), amount: 100},
+	}}
+	var ms = func(h int) float64 {
+		return float64(from.Add(time.Duration(h)*time.Hour).UnixNano() / int64(time.Millisecond))
+	}
+
+	var tests = []struct {
+		target string
+		want   [][2]float64
+	}{
+		{"amount", [][2]float64{{7.5, ms(0)}, {0, ms(1)}, {5, ms(2)}, {0, ms(3)}}},
+		{"blocks", [][2]float64{{2, ms(0)}, {0, ms(1)}, {1, ms(2)}, {0, ms(3)}}},
+		{"amount.rig2", [][2]float64{{2.5, ms(0)}, {0, ms(1)}, {0, ms(2)}, {0, ms(3)}}},
+	}
+	for _, tt := range tests {
+		var s, err = c.series(tt.target, from, from.Add(3*time.Hour), time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Target != tt.target || !reflect.DeepEqual(s.Datapoints, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.target, s.Datapoints, tt.want)
+		}
+	}
+
+	// However small the interval asked for, a series is capped
+	var s, err = c.series("amount", from, from.Add(24*time.Hour), time.Millisecond)
+	if err != nil || len(s.Datapoints) != maxServePoints+1 {
+		t.Errorf("1ms over a day: %d points, %v", len(s.Datapoints), err)
+	}
+
+	if _, err = c.series("coins", from, from.Add(time.Hour), time.Hour); err == nil {
+		t.Error("unknown metric accepted")
+	}
+	if _, err = c.series("amount", from, from.Add(-time.Hour), time.Hour); err == nil {
+		t.Error("backwards range accepted")
+	}
+	if got := serveMetrics([]string{"rig2", "rig1"}); !reflect.DeepEqual(got, []string{"amount", "blocks", "amount.rig1", "blocks.rig1", "amount.rig2", "blocks.rig2"}) {
+		t.Errorf("serveMetrics: got %v", got)
+	}
+}
+
+// A refresh reports the generations it newly found, and those counted
+// before that the wallet now lists as conflicted or no longer lists
+func TestServeRefreshReorgs(t *testing.T) {
+	var now = time.Now()
+	var txs = []map[string]interface{}{
+		generation("aa", 800020, 5, now.Add(-5*time.Hour)),
+		generation("bb", 800060, 5, now.Add(-4*time.Hour)),
+		generation("cc", 800080, 5, now.Add(-3*time.Hour)),
+	}
+	var node = newFakeNode(t, map[string]fakeMethod{
+		"listtransactions": func(string, []interface{}) (interface{}, *RPCError) { return txs, nil },
+	})
+	var c = &txCache{}
+	var found, removed, err = c.refresh(node.url(), []string{"rig1"}, 2, "timereceived")
+	if err != nil || len(found) != 0 || len(removed) != 0 {
+		t.Fatalf("first load: found %v, removed %v, %v; want nothing", found, removed, err)
+	}
+
+	// bb is conflicted by a reorg, cc gone altogether, and dd newly won
+	var conflicted = generation("bb", 800060, 5, now.Add(-4*time.Hour))
+	conflicted["confirmations"], conflicted["category"] = -1, "orphan"
+	txs = []map[string]interface{}{txs[0], conflicted, generation("dd", 800095, 5, now.Add(-time.Hour))}
+	found, removed, err = c.refresh(node.url(), []string{"rig1"}, 2, "timereceived")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0].txid != "dd" {
+		t.Errorf("found %v, want dd", found)
+	}
+	var gone []string
+	for _, e := range removed {
+		gone = append(gone, e.txid)
+	}
+	sort.Strings(gone)
+	if !reflect.DeepEqual(gone, []string{"bb", "cc"}) {
+		t.Errorf("reorged out %v, want bb and cc", gone)
+	}
+	if c.reorged != 2 || c.found["rig1"].blocks != 1 {
+		t.Errorf("running counts: %d reorged, %d found; want 2 and 1", c.reorged, c.found["rig1"].blocks)
+	}
+	if r := c.snapshot("rig1"); r.Total.Blocks != 2 || r.Total.Coins != 10 {
+		t.Errorf("snapshot after the reorg: %+v, want aa and dd", r.Total)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// parseSetLabel reads a --set-label value, "address:label".  The label is
+// everything after the first colon, so it may hold colons of its own, and an
+// empty one clears the address's label.
+func parseSetLabel(s string) (string, string, error) {
+	var addr, label, ok = strings.Cut(s, ":")
+	if !ok || addr == "" {
+		return "", "", errors.New("expected address:label")
+	}
+	return addr, label, nil
+}
+
+// labelOwner is the wallet an address belongs to, by getaddressinfo's
+// ismine, or the first wallet if none claims it; setlabel on a wallet that
+// doesn't own the address only files it in that wallet's address book
+func labelOwner(u *url.URL, wallets []string, addr string) string {
+	for _, w := range wallets {
+		var info struct {
+			IsMine bool `json:"ismine"`
+		}
+		if rpcCall(walletURL(u, w), "getaddressinfo", []interface{}{addr}, &info) == nil && info.IsMine {
+			return w
+		}
+	}
+	return wallets[0]
+}
+
+// setLabels runs setlabel for each --set-label pair before any transactions
+// are fetched, so the report sees the new labels, and returns how many took.
+// Every pair is checked before any is sent.
+func setLabels(u *url.URL, wallets []string, pairs []string) (int, error) {
+	for _, p := range pairs {
+		var _, _, err = parseSetLabel(p)
+		if err != nil {
+			return 0, usageError(fmt.Sprintf("Invalid --set-label %q: %s", p, err))
+		}
+	}
+
+	var n int
+	if len(wallets) == 0 {
+		return n, nil
+	}
+	for _, p := range pairs {
+		var addr, label, _ = parseSetLabel(p)
+		var w = labelOwner(u, wallets, addr)
+		var err = rpcCall(walletURL(u, w), "setlabel", []interface{}{addr, label}, nil)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to label %s in wallet %q: %s\n", addr, w, err)
+			continue
+		}
+		n++
+	}
+	return n, nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// expectedSubsidy is one generated transaction of --show-subsidy, beside
+// what the block's subsidy alone would have paid
+type expectedSubsidy struct {
+	TXID     string  `json:"txid"`
+	Height   int64   `json:"height"`
+	Amount   float64 `json:"amount"`
+	Expected float64 `json:"expected"`
+	Differs  bool    `json:"differs,omitempty"`
+}
+
+// expectedSubsidies works out the subsidy at each block's height, from the
+// configured schedule if there is one.  Anything more than a satoshi off is
+// flagged: above the subsidy is usually the block's fees, below it a pool
+// paying out only a share.
+func expectedSubsidies(blocks []*Transaction, schedule subsidySchedule) []expectedSubsidy {
+	var rows []expectedSubsidy
+	for _, tx := range blocks {
+		var expected = defaultSubsidy(tx.Blockheight)
+		if len(schedule) > 0 {
+			expected = schedule.rewardAt(tx.Blockheight)
+		}
+		var diff = toSatoshis(tx.Amount) - toSatoshis(expected)
+		rows = append(rows, expectedSubsidy{
+			TXID:     tx.TXID,
+			Height:   tx.Blockheight,
+			Amount:   tx.Amount,
+			Expected: expected,
+			Differs:  diff > 1 || diff < -1,
+		})
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].Height < rows[j].Height })
+	return rows
+}
+
+// printSubsidies lists each generated transaction's amount against its
+// expected subsidy, marking the ones that differ
+func (v *reportView) printSubsidies(w io.Writer) {
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Generated transactions against the expected subsidy:")
+	var differ int
+	for _, s := range v.Subsidies {
+		var mark = " "
+		if s.Differs {
+			mark = "*"
+			differ++
+		}
+		fmt.Fprintf(w, "%s height %d:\t%14.8f\tExpected subsidy: %.4f BTC\n", mark, s.Height, s.Amount, s.Expected)
+	}
+	if differ > 0 {
+		fmt.Fprintf(w, "* %d differ from the subsidy by more than a satoshi (fees in the block, or a share of the reward)\n", differ)
+	}
+}
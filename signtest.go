@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// signInputError is one input signrawtransactionwithwallet couldn't sign
+type signInputError struct {
+	TXID  string `json:"txid"`
+	Vout  int64  `json:"vout"`
+	Error string `json:"error"`
+}
+
+// signTest is what the wallet could sign of a raw transaction
+type signTest struct {
+	complete bool
+	inputs   int
+	errors   []signInputError
+}
+
+// checkRawHex checks a --sign-test value is hex, before the node is asked
+// to make sense of it
+func checkRawHex(s string) error {
+	if s == "" || len(s)%2 != 0 {
+		return errors.New("not a raw transaction in hex")
+	}
+	if _, err := hex.DecodeString(s); err != nil {
+		return errors.New("not a raw transaction in hex")
+	}
+	return nil
+}
+
+// runSignTest has the wallet sign rawHex as far as its keys go, to see
+// whether it holds the keys a multisig or PSBT workflow expects of it.  The
+// signed transaction is thrown away, never printed or broadcast.
+func runSignTest(wu *url.URL, rawHex string) (*signTest, error) {
+	var decoded struct {
+		Vin []struct{} `json:"vin"`
+	}
+	var err = rpcCall(nodeURL(wu), "decoderawtransaction", []interface{}{rawHex}, &decoded)
+	if err != nil {
+		return nil, err
+	}
+	var signed struct {
+		Complete bool             `json:"complete"`
+		Errors   []signInputError `json:"errors"`
+	}
+	err = rpcCall(wu, "signrawtransactionwithwallet", []interface{}{rawHex}, &signed)
+	if err != nil {
+		return nil, err
+	}
+	return &signTest{complete: signed.Complete, inputs: len(decoded.Vin), errors: signed.Errors}, nil
+}
+
+func printSignTest(t *signTest) {
+	fmt.Printf("complete: %t\n", t.complete)
+	fmt.Printf("Inputs:   %d signed, %d unsigned, of %d\n", t.inputs-len(t.errors), len(t.errors), t.inputs)
+	for _, e := range t.errors {
+		fmt.Printf("  %s:%d: %s\n", e.TXID, e.Vout, e.Error)
+	}
+}
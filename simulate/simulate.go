@@ -0,0 +1,223 @@
+// Package simulate makes up plausible wallet histories, for developing and
+// demonstrating reports without a node.  Blocks are found as a Poisson
+// process at a set rate, each paying a set reward plus a little in fees,
+// with the odd ordinary payment in and out among them.  Histories start at
+// a UTC midnight and blocks fall at fixed times, so the same Params give the
+// same history, and the same seed on the same day gives the same history
+// up to the time it's asked for; a simulated report can be reproduced or
+// used as a fixture.
+package simulate
+
+import (
+	"encoding/hex"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// BlockInterval is the target time between blocks on the simulated chain
+const BlockInterval = 10 * time.Minute
+
+// Maturity is how many confirmations a generation needs before it's
+// spendable, and listed as "generate" rather than "immature"
+const Maturity = 100
+
+// receivesPerDay and sendsPerDay are how often the made-up wallets are paid
+// or pay someone other than by mining
+const (
+	receivesPerDay = 0.3
+	sendsPerDay    = 0.2
+)
+
+// Params describe the history to make up
+type Params struct {
+	// Wallets are the wallets to make histories for; each gets its own,
+	// which doesn't change when wallets are added or removed
+	Wallets []string
+
+	// Days is how far back the histories go from Now
+	Days int
+	Now  time.Time
+
+	// BlocksPerDay is each wallet's average rate of blocks found, and
+	// Reward what each pays before fees
+	BlocksPerDay float64
+	Reward       float64
+
+	Seed int64
+}
+
+// Transaction is one listtransactions entry, in the node's JSON form
+type Transaction struct {
+	Address       string  `json:"address"`
+	Category      string  `json:"category"`
+	Amount        float64 `json:"amount"`
+	Fee           float64 `json:"fee,omitempty"`
+	Vout          int64   `json:"vout"`
+	Label         string  `json:"label,omitempty"`
+	Confirmations int64   `json:"confirmations"`
+	Generated     bool    `json:"generated,omitempty"`
+	Blockhash     string  `json:"blockhash"`
+	Blockheight   int64   `json:"blockheight"`
+	Blockindex    int64   `json:"blockindex"`
+	Blocktime     int64   `json:"blocktime"`
+	TXID          string  `json:"txid"`
+	Time          int64   `json:"time"`
+	TimeReceived  int64   `json:"timereceived"`
+}
+
+// genesis is when the simulated chain starts, the real chain's genesis, so
+// heights and subsidies look familiar
+var genesis = time.Date(2009, 1, 3, 18, 15, 5, 0, time.UTC)
+
+// Chain is the simulated chain the histories sit on, a block every
+// BlockInterval since genesis, up to Tip, the last block found by the time
+// it was made
+type Chain struct {
+	Tip     int64
+	TipTime time.Time
+}
+
+// NewChain is the chain as it stands at now
+func NewChain(now time.Time) Chain {
+	var tip = int64(now.Sub(genesis) / BlockInterval)
+	return Chain{Tip: tip, TipTime: genesis.Add(time.Duration(tip) * BlockInterval)}
+}
+
+// Height is the block found at t
+func (c Chain) Height(t time.Time) int64 {
+	return c.Tip - int64(c.TipTime.Sub(t)/BlockInterval)
+}
+
+// Time is when the block at height was found
+func (c Chain) Time(height int64) time.Time {
+	return c.TipTime.Add(-time.Duration(c.Tip-height) * BlockInterval)
+}
+
+// Hash is a made-up but stable hash for the block at height
+func (c Chain) Hash(height int64) string {
+	var r = rand.New(rand.NewSource(height))
+	return randomHex(r)
+}
+
+// Generate makes up each wallet's history, oldest first, as listtransactions
+// would give it
+func Generate(p Params) map[string][]Transaction {
+	var chain = NewChain(p.Now)
+	var out = make(map[string][]Transaction, len(p.Wallets))
+	for _, w := range p.Wallets {
+		out[w] = generateWallet(p, chain, w)
+	}
+	return out
+}
+
+func generateWallet(p Params, chain Chain, wallet string) []Transaction {
+	var begin = p.Now.UTC().Truncate(24*time.Hour).AddDate(0, 0, -p.Days)
+
+	var list []Transaction
+	var r = stream(p.Seed, wallet, "generate")
+	for _, t := range arrivals(r, begin, chain.TipTime, p.BlocksPerDay) {
+		var height = chain.Height(t)
+		var fees = p.Reward * 0.02 * r.Float64()
+		list = append(list, confirmed(chain, height, Transaction{
+			Address:   randomAddress(r),
+			Category:  "generate",
+			Amount:    sats(p.Reward + fees),
+			Generated: true,
+			TXID:      randomHex(r),
+		}))
+	}
+	r = stream(p.Seed, wallet, "receive")
+	for _, t := range arrivals(r, begin, chain.TipTime, receivesPerDay) {
+		list = append(list, confirmed(chain, chain.Height(t), Transaction{
+			Address:    randomAddress(r),
+			Category:   "receive",
+			Amount:     sats(0.001 + r.ExpFloat64()*0.05),
+			Vout:       int64(r.Intn(3)),
+			Blockindex: 1 + int64(r.Intn(3000)),
+			TXID:       randomHex(r),
+		}))
+	}
+	r = stream(p.Seed, wallet, "send")
+	for _, t := range arrivals(r, begin, chain.TipTime, sendsPerDay) {
+		list = append(list, confirmed(chain, chain.Height(t), Transaction{
+			Address:    randomAddress(r),
+			Category:   "send",
+			Amount:     -sats(0.01 + r.ExpFloat64()*p.Reward/4),
+			Fee:        -sats(0.00001 + r.Float64()*0.0001),
+			Blockindex: 1 + int64(r.Intn(3000)),
+			TXID:       randomHex(r),
+		}))
+	}
+
+	for i := range list {
+		if list[i].Generated && list[i].Confirmations < Maturity {
+			list[i].Category = "immature"
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].TimeReceived < list[j].TimeReceived })
+	return list
+}
+
+// stream is the random source for one kind of a wallet's transactions.
+// Each kind has its own, so that a history asked for later, with more of
+// one kind in it, still has the same ones of the others.
+func stream(seed int64, wallet, kind string) *rand.Rand {
+	var h = fnv.New64a()
+	h.Write([]byte(wallet + "\x00" + kind))
+	return rand.New(rand.NewSource(seed ^ int64(h.Sum64())))
+}
+
+// arrivals are the times of a Poisson process at perDay events a day after
+// begin, up to end
+func arrivals(r *rand.Rand, begin, end time.Time, perDay float64) []time.Time {
+	if perDay <= 0 {
+		return nil
+	}
+	var mean = float64(24*time.Hour) / perDay
+	var times []time.Time
+	for t := begin.Add(time.Duration(r.ExpFloat64() * mean)); !t.After(end); t = t.Add(time.Duration(r.ExpFloat64() * mean)) {
+		times = append(times, t)
+	}
+	return times
+}
+
+// confirmed puts tx in the block at height.  The wallet hears of a block it
+// found the moment it's found, and of anything else a little before.
+func confirmed(chain Chain, height int64, tx Transaction) Transaction {
+	var blockTime = chain.Time(height).Unix()
+	tx.Confirmations = chain.Tip - height + 1
+	tx.Blockheight = height
+	tx.Blockhash = chain.Hash(height)
+	tx.Blocktime = blockTime
+	tx.Time, tx.TimeReceived = blockTime, blockTime
+	if !tx.Generated {
+		tx.Time -= 60 + tx.Blockindex%300
+		tx.TimeReceived = tx.Time
+	}
+	return tx
+}
+
+// sats rounds coins to whole satoshis
+func sats(coins float64) float64 {
+	return math.Round(coins*1e8) / 1e8
+}
+
+func randomHex(r *rand.Rand) string {
+	var b = make([]byte, 32)
+	r.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// randomAddress is a made-up bech32-looking address; it's only ever shown,
+// never checked
+func randomAddress(r *rand.Rand) string {
+	const charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+	var b = []byte("bc1q")
+	for i := 0; i < 38; i++ {
+		b = append(b, charset[r.Intn(len(charset))])
+	}
+	return string(b)
+}
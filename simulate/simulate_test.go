@@ -0,0 +1,69 @@
+package simulate
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+var testParams = Params{
+	Wallets:      []string{"rig1", "rig2"},
+	Days:         3,
+	Now:          time.Date(2024, 3, 4, 15, 0, 0, 0, time.UTC),
+	BlocksPerDay: 2,
+	Reward:       50,
+	Seed:         42,
+}
+
+// The same Params give the same history, down to these pinned entries, so
+// a simulated report can be used as a fixture
+func TestGenerateDeterministic(t *testing.T) {
+	var a, b = Generate(testParams), Generate(testParams)
+	if !reflect.DeepEqual(a, b) {
+		t.Fatal("two runs with the same Params differ")
+	}
+
+	var pinned = []struct {
+		wallet   string
+		i        int
+		txid     string
+		category string
+		height   int64
+		amount   float64
+	}{
+		{"rig1", 0, "09aab3541e2918e2df5e375baaefedb3ad0872ef5752f6c77b9c4deff37702bb", "send", 797147, -1.85742783},
+		{"rig1", 1, "49d07daa5a8455990353928fa243f5ad7e3f30d3ea1cb33ecef0242ee63b7bb0", "generate", 797230, 50.68498613},
+		{"rig2", 8, "d22804444edc63b3849515b73a47e3cda0f99aad6817baa8a1033edc6dee59ab", "immature", 797559, 50.14079635},
+	}
+	if len(a["rig1"]) != 6 || len(a["rig2"]) != 10 {
+		t.Fatalf("got %d and %d transactions, want 6 and 10", len(a["rig1"]), len(a["rig2"]))
+	}
+	for _, p := range pinned {
+		var tx = a[p.wallet][p.i]
+		if tx.TXID != p.txid || tx.Category != p.category || tx.Blockheight != p.height || tx.Amount != p.amount {
+			t.Errorf("%s[%d]: got %s %s at %d for %v, want %s %s at %d for %v", p.wallet, p.i,
+				tx.TXID, tx.Category, tx.Blockheight, tx.Amount, p.txid, p.category, p.height, p.amount)
+		}
+	}
+
+	var other = testParams
+	other.Seed++
+	if reflect.DeepEqual(Generate(other)["rig1"], a["rig1"]) {
+		t.Error("another seed gives the same history")
+	}
+}
+
+// Adding or removing a wallet leaves every other wallet's history alone
+func TestGenerateWalletsIndependent(t *testing.T) {
+	var both = Generate(testParams)
+	var p = testParams
+	p.Wallets = []string{"rig0", "rig2", "rig3"}
+	var more = Generate(p)
+	if !reflect.DeepEqual(more["rig2"], both["rig2"]) {
+		t.Error("rig2's history changed when rig1 was dropped and rig0 and rig3 added")
+	}
+	p.Wallets = []string{"rig1"}
+	if !reflect.DeepEqual(Generate(p)["rig1"], both["rig1"]) {
+		t.Error("rig1's history changed when rig2 was dropped")
+	}
+}
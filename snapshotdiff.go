@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// loadSnapshot reads a saved transaction list: either listtransactions'
+// output as-is, which is taken to be one unnamed wallet, or an object of
+// such lists keyed by wallet name
+func loadSnapshot(path string) (map[string][]*Transaction, error) {
+	var data, err = os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var list []*Transaction
+	err = json.Unmarshal(data, &list)
+	if err == nil {
+		return map[string][]*Transaction{"": list}, nil
+	}
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "[") {
+		return nil, err
+	}
+	var wallets map[string][]*Transaction
+	err = json.Unmarshal(data, &wallets)
+	if err != nil {
+		return nil, fmt.Errorf("expected a listtransactions array or an object of them by wallet: %w", err)
+	}
+	return wallets, nil
+}
+
+// diffSnapshots diffs each wallet found in either snapshot, tagging every
+// difference with its wallet
+func diffSnapshots(a, b map[string][]*Transaction) []txDiff {
+	var names = make(map[string]bool)
+	for w := range a {
+		names[w] = true
+	}
+	for w := range b {
+		names[w] = true
+	}
+	var wallets []string
+	for w := range names {
+		wallets = append(wallets, w)
+	}
+	sort.Strings(wallets)
+
+	var diffs []txDiff
+	for _, w := range wallets {
+		for _, d := range diffTransactions(a[w], b[w]) {
+			d.wallet = w
+			diffs = append(diffs, d)
+		}
+	}
+	sort.SliceStable(diffs, func(i, j int) bool { return diffs[i].when().Before(diffs[j].when()) })
+	return diffs
+}
+
+// printWalletDiffSummary prints a per-wallet count of each kind of difference
+func printWalletDiffSummary(diffs []txDiff) {
+	var wallets []string
+	var counts = make(map[string][]int)
+	for _, d := range diffs {
+		if counts[d.wallet] == nil {
+			counts[d.wallet] = make([]int, len(txDiffKindNames))
+			wallets = append(wallets, d.wallet)
+		}
+		counts[d.wallet][d.kind]++
+	}
+	sort.Strings(wallets)
+
+	for _, w := range wallets {
+		var name = w
+		if name == "" {
+			name = "(unnamed)"
+		}
+		fmt.Printf("%s:", name)
+		for kind, n := range counts[w] {
+			fmt.Printf("\t%s %d", txDiffKind(kind), n)
+		}
+		fmt.Println()
+	}
+}
+
+// runDiff implements "diff <a.json> <b.json>", comparing two saved
+// transaction lists, e.g. a backup against the live wallet or yesterday's
+// export against today's to spot a reorg.  Differences are an exitAssertion
+// failure, as with compare-nodes.
+func runDiff(args []string) error {
+	var fs = flag.NewFlagSet("diff", flag.ContinueOnError)
+	var verbose = fs.Bool("v", false, "list every difference, not just the per-day and per-wallet summaries")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s diff [flags] <a.json> <b.json>\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "Each file is listtransactions output, or an object of it keyed by wallet name")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Flags:")
+		fs.PrintDefaults()
+	}
+	var err = fs.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return exitWith(exitUsage)
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return exitWith(exitUsage)
+	}
+
+	var snaps [2]map[string][]*Transaction
+	for i, path := range fs.Args() {
+		snaps[i], err = loadSnapshot(path)
+		if err != nil {
+			return failure(exitUsage, "Unable to read snapshot %q: %s", path, err)
+		}
+	}
+
+	var diffs = diffSnapshots(snaps[0], snaps[1])
+	fmt.Printf("%s vs %s: %d difference(s)\n", fs.Arg(0), fs.Arg(1), len(diffs))
+	if len(diffs) == 0 {
+		return nil
+	}
+
+	fmt.Println()
+	fmt.Println("By day:")
+	printDiffSummary(diffs)
+	fmt.Println()
+	fmt.Println("By wallet:")
+	printWalletDiffSummary(diffs)
+	if *verbose {
+		fmt.Println()
+		for _, d := range diffs {
+			if d.wallet != "" {
+				fmt.Printf("%s: ", d.wallet)
+			}
+			fmt.Println(d.describe())
+		}
+	}
+	return exitWith(exitAssertion)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// diff takes either a bare listtransactions array or an object of them by
+// wallet, and exits with the assertion code when the two differ
+func TestRunDiff(t *testing.T) {
+	var dir = t.TempDir()
+	var write = func(name, data string) string {
+		var path = filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	var bare = write("bare.json", `[{"txid": "aa", "vout": 0, "category": "generate", "amount": 5, "confirmations": 10, "timereceived": 1709510400}]`)
+	var before = write("before.json", `{
+		"rig1": [{"txid": "aa", "vout": 0, "category": "generate", "amount": 5, "confirmations": 10, "timereceived": 1709510400}],
+		"rig2": [{"txid": "bb", "vout": 1, "category": "generate", "amount": 2, "confirmations": 3, "timereceived": 1709596800}]
+	}`)
+	var after = write("after.json", `{
+		"rig1": [{"txid": "aa", "vout": 0, "category": "generate", "amount": 5, "confirmations": 10, "timereceived": 1709510400}],
+		"rig2": [{"txid": "bb", "vout": 1, "category": "orphan", "amount": 2, "confirmations": -1, "timereceived": 1709596800}]
+	}`)
+
+	var tests = []struct {
+		args []string
+		code int
+		want []string
+	}{
+		{[]string{bare, bare}, exitOK, []string{"0 difference(s)"}},
+		{[]string{before, before}, exitOK, []string{"0 difference(s)"}},
+		{[]string{"-v", before, after}, exitAssertion, []string{
+			"2 difference(s)",
+			"rig2:\tonly-a 0\tonly-b 0\tamount 0\tcategory 1\tconfirmations 1",
+			"rig2: ", "bb:1:in category generate vs orphan",
+		}},
+		{[]string{bare, filepath.Join(dir, "missing.json")}, exitUsage, nil},
+	}
+	for _, tt := range tests {
+		var code int
+		var out = capture(t, &os.Stdout, func() {
+			capture(t, &os.Stderr, func() { code = exitCode(run(append([]string{"diff"}, tt.args...))) })
+		})
+		if code != tt.code {
+			t.Errorf("%v: exit %d, want %d", tt.args, code, tt.code)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(out, want) {
+				t.Errorf("%v: no %q in:\n%s", tt.args, want, out)
+			}
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Nerdmaster/dynamo-tx-stats/stats"
+)
+
+// sourceConfig is one daemon in a multi-source report.  Sources may be
+// different chains, so each carries its own coin price.
+type sourceConfig struct {
+	Label     string   `json:"label"`
+	URL       string   `json:"url"`
+	User      string   `json:"user"`
+	Password  string   `json:"password"`
+	Wallets   []string `json:"wallets"`
+	CoinPrice float64  `json:"coin_price"`
+	priceSource
+}
+
+type sourceReport struct {
+	source sourceConfig
+	view   *reportView
+}
+
+// collectReport fetches the given wallets and accumulates their matured
+// block rewards into a report for the days ending at now
+func collectReport(u *url.URL, wallets []string, now time.Time, days int, field string) ([]*Transaction, stats.Report, error) {
+	var txList []*Transaction
+	for _, w := range wallets {
+		var list, err = listTransactions(walletURL(u, w))
+		if err != nil {
+			return nil, stats.Report{}, fmt.Errorf("wallet %q: %w", w, err)
+		}
+		txList = append(txList, list...)
+	}
+
+	var acc = stats.NewAccumulator(getDay(now).AddDate(0, 0, -(days-1)), days)
+	for _, tx := range txList {
+		tx.dt = txTime(tx, field)
+		if countable(tx, now) {
+			acc.Add(stats.Transaction{TXID: tx.TXID, Vout: tx.Vout, Amount: tx.Amount, Blockheight: tx.Blockheight, Time: tx.dt})
+		}
+	}
+	return txList, acc.Snapshot(), nil
+}
+
+// runSources builds one report per configured source plus a combined daily
+// table.  Coins from different sources aren't fungible, so they're only
+// added together when --sum-sources says so; fiat values always can be.
+func runSources(cfg *config, now time.Time) error {
+	if cfg.ReportDays < 2 {
+		return usageError("Reporting days must be at least 2")
+	}
+	var err = checkBucketFormats(cfg, now)
+	if err != nil {
+		return err
+	}
+	if cfg.HTML {
+		return usageError("--html isn't supported with multiple sources")
+	}
+
+	var reports []sourceReport
+	var labels = make(map[string]bool)
+	for i, src := range cfg.Sources {
+		if src.Label == "" || src.URL == "" || len(src.Wallets) == 0 {
+			return usageError(fmt.Sprintf("Source %d needs a label, url, and at least one wallet", i+1))
+		}
+		if labels[src.Label] {
+			return usageError(fmt.Sprintf("Duplicate source label %q", src.Label))
+		}
+		labels[src.Label] = true
+
+		var u *url.URL
+		u, err = url.Parse(src.URL)
+		if err != nil {
+			return usageError(fmt.Sprintf("Invalid URL %q for source %q: %s", src.URL, src.Label, err))
+		}
+		u.User = url.UserPassword(src.User, src.Password)
+		src.CoinPrice = lookupPrice(cfg, src.Label, src.CoinPrice, src.priceSource)
+
+		var txList []*Transaction
+		var report stats.Report
+		txList, report, err = collectReport(u, src.Wallets, now, cfg.ReportDays, cfg.TimeField)
+		if err != nil {
+			return failure(exitRPC, "Unable to fetch source %q: %s", src.Label, err)
+		}
+		var view = newReportView(cfg, src.Wallets, txList, report, now)
+		view.Source = src.Label
+		if cfg.AsOf != "" {
+			view.AsOf = &now
+		}
+		reports = append(reports, sourceReport{source: src, view: view})
+	}
+	if cfg.dryRun {
+		printPlannedOutputs(cfg)
+		return nil
+	}
+
+	if cfg.JSON {
+		var out struct {
+			Sources []*reportView `json:"sources"`
+			Summed  bool          `json:"summed"`
+		}
+		out.Summed = cfg.SumSources
+		for _, r := range reports {
+			out.Sources = append(out.Sources, r.view)
+		}
+		var enc = json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(out)
+		if err != nil {
+			return failure(exitRPC, "Unable to write report: %s", err)
+		}
+		return nil
+	}
+
+	for i, r := range reports {
+		if i > 0 {
+			fmt.Println()
+		}
+		r.view.printSummary(os.Stdout)
+		r.view.printDaily(os.Stdout, r.view.style.width)
+	}
+	printCombinedSources(reports, cfg.SumSources)
+	return nil
+}
+
+// printCombinedSources prints a day-by-source table.  The value column is
+// only shown when every source has a coin price.
+func printCombinedSources(reports []sourceReport, sum bool) {
+	var priced = true
+	var heading = []string{"Day       "}
+	for _, r := range reports {
+		heading = append(heading, fmt.Sprintf("%12s", r.source.Label))
+		priced = priced && r.source.CoinPrice > 0
+	}
+	if sum {
+		heading = append(heading, fmt.Sprintf("%12s", "coins"))
+	}
+	if priced {
+		heading = append(heading, fmt.Sprintf("%12s", "value"))
+	}
+
+	fmt.Println()
+	if sum {
+		fmt.Println("Combined daily totals (coins summed across sources):")
+	} else {
+		fmt.Println("Combined daily totals (coins per source; not summed, use --sum-sources to add them):")
+	}
+	fmt.Println(strings.Join(heading, "\t"))
+
+	var totals = make([]float64, len(reports))
+	var printRow = func(label string, coins []float64) {
+		var cols = []string{fmt.Sprintf("%-10s", label)}
+		var total, value float64
+		for i, c := range coins {
+			cols = append(cols, fmt.Sprintf("%12.2f", c))
+			total += c
+			value += c * reports[i].source.CoinPrice
+		}
+		if sum {
+			cols = append(cols, fmt.Sprintf("%12.2f", total))
+		}
+		if priced {
+			cols = append(cols, fmt.Sprintf("%12.2f", value))
+		}
+		fmt.Println(strings.Join(cols, "\t"))
+	}
+
+	for day := range reports[0].view.Daily {
+		var coins = make([]float64, len(reports))
+		for i, r := range reports {
+			coins[i] = r.view.Daily[day].Coins
+			totals[i] += coins[i]
+		}
+		printRow(reports[0].view.Daily[day].Label, coins)
+	}
+	printRow("Total", totals)
+}
@@ -0,0 +1,47 @@
+package main
+
+// sparkRunes are the Unicode block elements U+2581 to U+2588, lowest first,
+// and asciiSparkRunes the same eight steps for when unicode is off
+var (
+	sparkRunes      = []rune("▁▂▃▄▅▆▇█")
+	asciiSparkRunes = []rune("_.-:=+*#")
+)
+
+// sparklineRune places value between min and max on the eight steps of
+// runes.  A flat range gets the middle height, so a window of identical days
+// doesn't read as all lows.
+func sparklineRune(runes []rune, value, min, max float64) rune {
+	if max <= min {
+		return runes[len(runes)/2]
+	}
+	var i = int((value - min) / (max - min) * float64(len(runes)-1))
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(runes) {
+		i = len(runes) - 1
+	}
+	return runes[i]
+}
+
+// sparkTag returns the daily row's sparkline cell for --sparkline, scaled
+// over the window's days, or "" when it's off
+func (v *reportView) sparkTag(row reportRow) string {
+	if !v.sparkline || len(v.Daily) == 0 {
+		return ""
+	}
+	var min, max = v.Daily[0].Coins, v.Daily[0].Coins
+	for _, r := range v.Daily[1:] {
+		if r.Coins < min {
+			min = r.Coins
+		}
+		if r.Coins > max {
+			max = r.Coins
+		}
+	}
+	var runes = sparkRunes
+	if !v.style.unicode {
+		runes = asciiSparkRunes
+	}
+	return " " + string(sparklineRune(runes, row.Coins, min, max))
+}
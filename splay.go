@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"os"
+	"time"
+)
+
+var splayRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+// splayDelay picks how long to wait, up to limit.  By default it's fixed for
+// the host, from a hash of its name, so a fleet started by cron spreads out
+// the same way every time; random picks afresh on each call.
+func splayDelay(limit time.Duration, random bool) time.Duration {
+	if limit <= 0 {
+		return 0
+	}
+	if random {
+		return time.Duration(splayRand.Int63n(int64(limit)))
+	}
+	var host, _ = os.Hostname()
+	var h = fnv.New64a()
+	h.Write([]byte(host))
+	return time.Duration(h.Sum64() % uint64(limit))
+}
+
+// splay waits out --splay before the run's first RPC call.  It happens
+// before the report's clock is read, so a live report is as of when it
+// actually ran; --as-of isn't affected.  Nothing has been started yet, so
+// Ctrl-C during the wait just ends the run.
+func splay(cfg *config) {
+	var d = splayDelay(cfg.Splay, cfg.SplayRandom)
+	if d == 0 {
+		return
+	}
+	if cfg.dryRun {
+		fmt.Printf("SLEEP %s (splay)\n", d.Round(time.Millisecond))
+		return
+	}
+	if cfg.VerboseTiming {
+		fmt.Fprintf(os.Stderr, "Splay: waiting %s before contacting the node\n", d.Round(time.Millisecond))
+	}
+	time.Sleep(d)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The host's splay is the same on every run and within the limit; a random
+// one is within the limit too, and no limit means no wait
+func TestSplayDelay(t *testing.T) {
+	var limit = 10 * time.Minute
+	var fixed = splayDelay(limit, false)
+	if fixed < 0 || fixed >= limit {
+		t.Errorf("fixed splay %s outside [0, %s)", fixed, limit)
+	}
+	for i := 0; i < 5; i++ {
+		if d := splayDelay(limit, false); d != fixed {
+			t.Errorf("fixed splay changed from %s to %s", fixed, d)
+		}
+		if d := splayDelay(limit, true); d < 0 || d >= limit {
+			t.Errorf("random splay %s outside [0, %s)", d, limit)
+		}
+	}
+	if d := splayDelay(0, true); d != 0 {
+		t.Errorf("no --splay: waited %s", d)
+	}
+
+	// --dry-run says how long it would wait instead of waiting
+	var started = time.Now()
+	var out = capture(t, &os.Stdout, func() { splay(&config{Splay: time.Hour, SplayRandom: true, dryRun: true}) })
+	if time.Since(started) > time.Second || !strings.HasPrefix(out, "SLEEP ") || !strings.HasSuffix(out, " (splay)\n") {
+		t.Errorf("dry run: %q after %s", out, time.Since(started))
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// splitPart is one name=percent of --split.  The percentage is kept in
+// hundredths of a percent, so shares can be checked to sum to exactly 100.
+type splitPart struct {
+	name      string
+	hundredth int64
+}
+
+// parseSplit reads a comma-separated name=percent list.  Percentages may
+// have up to two decimal places and must add up to exactly 100.
+func parseSplit(s string) ([]splitPart, error) {
+	var parts []splitPart
+	var seen = make(map[string]bool)
+	var sum int64
+	for _, item := range strings.Split(s, ",") {
+		var name, pct, ok = strings.Cut(strings.TrimSpace(item), "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%q is not name=percent", item)
+		}
+		var p, err = strconv.ParseFloat(strings.TrimSpace(pct), 64)
+		var h = math.Round(p * 100)
+		if err != nil || p <= 0 || math.Abs(h-p*100) > 1e-6 {
+			return nil, fmt.Errorf("invalid percentage %q for %s", pct, name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%s is given more than once", name)
+		}
+		seen[name] = true
+		parts = append(parts, splitPart{name: name, hundredth: int64(h)})
+		sum += int64(h)
+	}
+	if len(parts) == 0 {
+		return nil, errors.New("no shares")
+	}
+	if sum != 100*100 {
+		return nil, fmt.Errorf("shares add up to %s%%, not 100%%", strconv.FormatFloat(float64(sum)/100, 'f', -1, 64))
+	}
+	return parts, nil
+}
+
+// divide shares total, in whole units, between the parts by the largest
+// remainder method: each gets its exact share rounded down, and the units
+// left over go one each to the largest fractions, earlier parts first on a
+// tie, so the shares always add back up to total
+func divide(total int64, parts []splitPart) []int64 {
+	var sign int64 = 1
+	if total < 0 {
+		sign, total = -1, -total
+	}
+	var shares = make([]int64, len(parts))
+	var rems = make([]int64, len(parts))
+	var given int64
+	for i, p := range parts {
+		shares[i] = total / 10000 * p.hundredth
+		var rest = total % 10000 * p.hundredth
+		shares[i] += rest / 10000
+		rems[i] = rest % 10000
+		given += shares[i]
+	}
+	var order = make([]int, len(parts))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return rems[order[a]] > rems[order[b]] })
+	for i := 0; given < total; i++ {
+		shares[order[i%len(order)]]++
+		given++
+	}
+	for i := range shares {
+		shares[i] *= sign
+	}
+	return shares
+}
+
+// splitShare is one party's cut.  Fiat is there when the coin price is
+// known, and is split on its own, in cents, so it too adds up exactly.
+type splitShare struct {
+	Name    string   `json:"name"`
+	Percent float64  `json:"percent"`
+	Coins   float64  `json:"coins"`
+	Fiat    *float64 `json:"fiat,omitempty"`
+}
+
+// splitDay is one day's cuts, in the order of the shares
+type splitDay struct {
+	Label string    `json:"label"`
+	Coins []float64 `json:"coins"`
+	Fiat  []float64 `json:"fiat,omitempty"`
+}
+
+// splitView is --split's section: the report period total divided between
+// the parties, and with --split-daily each day's too
+type splitView struct {
+	Total  float64      `json:"total"`
+	Shares []splitShare `json:"shares"`
+	Daily  []splitDay   `json:"daily,omitempty"`
+}
+
+// splitAmounts divides coins, and at price its value, between the parts
+func splitAmounts(parts []splitPart, coins, price float64) ([]float64, []float64) {
+	var sats = divide(toSatoshis(coins), parts)
+	var out = make([]float64, len(parts))
+	for i, s := range sats {
+		out[i] = float64(s) / satoshisPerCoin
+	}
+	if price <= 0 {
+		return out, nil
+	}
+	var cents = divide(int64(math.Round(coins*price*100)), parts)
+	var fiat = make([]float64, len(parts))
+	for i, c := range cents {
+		fiat[i] = float64(c) / 100
+	}
+	return out, fiat
+}
+
+func newSplitView(parts []splitPart, total float64, daily []reportRow, price float64, perDay bool) *splitView {
+	var v = &splitView{Total: total}
+	var coins, fiat = splitAmounts(parts, total, price)
+	for i, p := range parts {
+		var s = splitShare{Name: p.name, Percent: float64(p.hundredth) / 100, Coins: coins[i]}
+		if fiat != nil {
+			s.Fiat = &fiat[i]
+		}
+		v.Shares = append(v.Shares, s)
+	}
+	if !perDay {
+		return v
+	}
+	for _, row := range daily {
+		var d = splitDay{Label: row.Label}
+		d.Coins, d.Fiat = splitAmounts(parts, row.Coins, price)
+		v.Daily = append(v.Daily, d)
+	}
+	return v
+}
+
+func (v *splitView) print(w io.Writer) {
+	fmt.Fprintf(w, "Split of %0.8f:\n", v.Total)
+	var width = 0
+	for _, s := range v.Shares {
+		if len(s.Name) > width {
+			width = len(s.Name)
+		}
+	}
+	for _, s := range v.Shares {
+		var fiat string
+		if s.Fiat != nil {
+			fiat = fmt.Sprintf("  (%0.2f)", *s.Fiat)
+		}
+		fmt.Fprintf(w, "  %-*s %6s%%  %16.8f%s\n", width, s.Name, strconv.FormatFloat(s.Percent, 'f', -1, 64), s.Coins, fiat)
+	}
+	if len(v.Daily) == 0 {
+		return
+	}
+	var header = []string{"day"}
+	for _, s := range v.Shares {
+		header = append(header, s.Name)
+	}
+	var rows [][]string
+	for _, d := range v.Daily {
+		var row = []string{d.Label}
+		for i, c := range d.Coins {
+			var cell = strconv.FormatFloat(c, 'f', 8, 64)
+			if d.Fiat != nil {
+				cell += fmt.Sprintf(" (%0.2f)", d.Fiat[i])
+			}
+			row = append(row, cell)
+		}
+		rows = append(rows, row)
+	}
+	printTable(w, header, rows)
+}
+
+// csvColumns are the split's extra columns in the csv sink: each party's
+// coins, then their fiat when the price is known
+func (v *splitView) csvColumns() ([]string, []string) {
+	var header, values []string
+	for _, s := range v.Shares {
+		header = append(header, "split_"+s.Name)
+		values = append(values, fmt.Sprintf("%0.8f", s.Coins))
+	}
+	for _, s := range v.Shares {
+		if s.Fiat != nil {
+			header = append(header, "split_"+s.Name+"_fiat")
+			values = append(values, fmt.Sprintf("%0.2f", *s.Fiat))
+		}
+	}
+	return header, values
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// staleAlert is --stale-after: how long it's been since the wallets last won
+// a block, and whether that's longer than allowed.  LastBlock is empty when
+// nothing in the report window was won at all.
+type staleAlert struct {
+	After     int64      `json:"after_seconds"`
+	LastBlock *time.Time `json:"last_block,omitempty"`
+	Age       int64      `json:"age_seconds,omitempty"`
+	Stale     bool       `json:"stale"`
+}
+
+// newStaleAlert looks for the newest generation in txList.  One that's only
+// just been mined counts, as the block was won even if it isn't mature yet;
+// pool payouts don't, since they aren't blocks won, and neither do orphans.
+func newStaleAlert(txList []*Transaction, after time.Duration, now time.Time) *staleAlert {
+	var s = &staleAlert{After: int64(after / time.Second)}
+	var last time.Time
+	for _, tx := range txList {
+		if !tx.Generated || tx.Confirmations < 1 || tx.dt.After(now) {
+			continue
+		}
+		if tx.dt.After(last) {
+			last = tx.dt
+		}
+	}
+	if last.IsZero() {
+		s.Stale = true
+		return s
+	}
+	s.LastBlock = &last
+	s.Age = int64(now.Sub(last) / time.Second)
+	s.Stale = now.Sub(last) > after
+	return s
+}
+
+func (s *staleAlert) print(w io.Writer) {
+	if !s.Stale {
+		return
+	}
+	var after = formatAge(time.Duration(s.After) * time.Second)
+	if s.LastBlock == nil {
+		fmt.Fprintf(w, "STALE: no block won in the report window (allowed: %s)\n", after)
+		return
+	}
+	fmt.Fprintf(w, "STALE: last block won %s ago, at %s (allowed: %s)\n", formatAge(time.Duration(s.Age)*time.Second), s.LastBlock.Format("2006-01-02 15:04:05"), after)
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// stateSchema is bumped whenever the state file layout changes in a way
+// older code couldn't read.  loadState migrates anything older and refuses
+// anything newer, so a downgrade never silently clobbers newer data.
+const stateSchema = 1
+
+// stateRetention is how long per-day state is kept around
+const stateRetention = 400 * 24 * time.Hour
+
+type stateFile struct {
+	Schema  int                     `json:"schema"`
+	Reports map[string]*reportState `json:"reports"`
+
+	// CoinbaseTags caches --coinbase-tags lookups by txid, since a
+	// coinbase never changes and fetching one is slow
+	CoinbaseTags map[string]coinbaseTagEntry `json:"coinbase_tags,omitempty"`
+
+	// Headers caches block headers for the difficulty features by hash.
+	// They're never pruned: a header doesn't change, and only blocks won
+	// are kept.
+	Headers map[string]*BlockHeader `json:"headers,omitempty"`
+}
+
+// coinbaseTagEntry is one cached coinbase tag, with when it was last in a
+// report window so it can age out with the rest of the state
+type coinbaseTagEntry struct {
+	Tag  string `json:"tag"`
+	Seen int64  `json:"seen"`
+}
+
+// reportState is the history kept for one set of wallets, since projections
+// for "rig1" say nothing about "rig1,rig2"
+type reportState struct {
+	Projections map[string][]projectionSnapshot `json:"projections"`
+	Actuals     map[string]float64              `json:"actuals"`
+
+	// LastDailyReport is the last day serve's --daily-report was sent for
+	LastDailyReport string `json:"last_daily_report,omitempty"`
+}
+
+type projectionSnapshot struct {
+	Hour      int     `json:"hour"`
+	Taken     int64   `json:"taken"`
+	Projected float64 `json:"projected"`
+}
+
+func loadState(path string) (*stateFile, error) {
+	var s = &stateFile{Schema: stateSchema, Reports: make(map[string]*reportState)}
+	var data, err = os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, s)
+	if err != nil {
+		return nil, err
+	}
+	if s.Schema > stateSchema {
+		return nil, fmt.Errorf("state file schema %d is newer than this build understands (%d)", s.Schema, stateSchema)
+	}
+	if s.Reports == nil {
+		s.Reports = make(map[string]*reportState)
+	}
+	s.Schema = stateSchema
+
+	return s, nil
+}
+
+// save writes the state via a temp file and rename so an interrupted run
+// can't leave a truncated file behind
+func (s *stateFile) save(path string, now time.Time) error {
+	var cutoff = getDay(now.Add(-stateRetention)).Format("2006-01-02")
+	for _, r := range s.Reports {
+		for day := range r.Projections {
+			if day < cutoff {
+				delete(r.Projections, day)
+			}
+		}
+		for day := range r.Actuals {
+			if day < cutoff {
+				delete(r.Actuals, day)
+			}
+		}
+	}
+
+	for txid, e := range s.CoinbaseTags {
+		if time.Unix(e.Seen, 0).Before(now.Add(-stateRetention)) {
+			delete(s.CoinbaseTags, txid)
+		}
+	}
+
+	var data, err = json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'), 0600)
+}
+
+// writeFileAtomic writes data to a temporary file beside path and renames it
+// into place, so a reader never sees the file half written
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	var tmp, err = os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Chmod(perm)
+	}
+	if err == nil {
+		err = tmp.Close()
+	} else {
+		tmp.Close()
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *stateFile) report(wallets []string) *reportState {
+	var sorted = append([]string(nil), wallets...)
+	sort.Strings(sorted)
+	var key = strings.Join(sorted, ",")
+
+	var r = s.Reports[key]
+	if r == nil {
+		r = &reportState{}
+		s.Reports[key] = r
+	}
+	if r.Projections == nil {
+		r.Projections = make(map[string][]projectionSnapshot)
+	}
+	if r.Actuals == nil {
+		r.Actuals = make(map[string]float64)
+	}
+	return r
+}
+
+// recordProjection keeps at most one snapshot per hour of the day; a later
+// run in the same hour replaces the earlier one
+func (r *reportState) recordProjection(now time.Time, projected float64) {
+	var day = now.Format("2006-01-02")
+	var snap = projectionSnapshot{Hour: now.Hour(), Taken: now.Unix(), Projected: projected}
+	for i, existing := range r.Projections[day] {
+		if existing.Hour == snap.Hour {
+			r.Projections[day][i] = snap
+			return
+		}
+	}
+	r.Projections[day] = append(r.Projections[day], snap)
+}
+
+// snapshotNear returns the day's snapshot taken closest to the given hour
+func (r *reportState) snapshotNear(day string, hour int) (projectionSnapshot, bool) {
+	var best projectionSnapshot
+	var found bool
+	for _, snap := range r.Projections[day] {
+		if !found || abs(snap.Hour-hour) < abs(best.Hour-hour) {
+			best, found = snap, true
+		}
+	}
+	return best, found
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// printProjectionHistory compares the recorded projection for each of the
+// last few completed days against what the day actually produced
+func printProjectionHistory(r *reportState, today time.Time, days, hour int) {
+	fmt.Println()
+	fmt.Printf("Projection history (projected at ~%02d:00):\n", hour)
+
+	var totalErr float64
+	var counted int
+	for i := days; i >= 1; i-- {
+		var day = today.AddDate(0, 0, -i).Format("2006-01-02")
+		var actual, haveActual = r.Actuals[day]
+		var snap, haveSnap = r.snapshotNear(day, hour)
+		switch {
+		case !haveSnap:
+			fmt.Printf("%s:\t\tno projection recorded\n", day)
+		case !haveActual:
+			fmt.Printf("%s:\t\tprojected %8.2f (at %02d:00)\tactual unknown\n", day, snap.Projected, snap.Hour)
+		case actual == 0:
+			fmt.Printf("%s:\t\tprojected %8.2f (at %02d:00)\tactual %8.2f\n", day, snap.Projected, snap.Hour, actual)
+		default:
+			var pctErr = math.Abs(snap.Projected-actual) / actual * 100
+			totalErr += pctErr
+			counted++
+			fmt.Printf("%s:\t\tprojected %8.2f (at %02d:00)\tactual %8.2f\terror %0.2f%%\n", day, snap.Projected, snap.Hour, actual, pctErr)
+		}
+	}
+
+	if counted > 0 {
+		fmt.Printf("Mean absolute percentage error over %d day(s): %0.2f%%\n", counted, totalErr/float64(counted))
+	}
+}
@@ -0,0 +1,63 @@
+package stats
+
+import (
+	"math"
+	"time"
+)
+
+// hashesPerDifficulty is the expected number of hashes to find a block at
+// difficulty 1
+const hashesPerDifficulty = 1 << 32
+
+// HashrateEstimate is the hashrate that finding Blocks blocks at the given
+// average difficulty over Window implies, with a 95% interval.  Block finds
+// are a Poisson process, so a handful of blocks says little: the interval
+// is honest about that rather than the point estimate pretending otherwise.
+type HashrateEstimate struct {
+	Blocks     int64
+	Difficulty float64
+	Window     time.Duration
+
+	// Hashrate, Low, and High are in hashes per second
+	Hashrate float64
+	Low      float64
+	High     float64
+}
+
+// EstimateHashrate inverts the expected block count: blocks found times the
+// hashes each one takes at difficulty, over the window.  The interval comes
+// from the Poisson interval on the block count.
+func EstimateHashrate(blocks int64, difficulty float64, window time.Duration) HashrateEstimate {
+	var e = HashrateEstimate{Blocks: blocks, Difficulty: difficulty, Window: window}
+	var seconds = window.Seconds()
+	if seconds <= 0 || difficulty <= 0 {
+		return e
+	}
+	var perBlock = difficulty * hashesPerDifficulty / seconds
+	var low, high = PoissonInterval(blocks)
+	e.Hashrate = float64(blocks) * perBlock
+	e.Low = low * perBlock
+	e.High = high * perBlock
+	return e
+}
+
+// PoissonInterval is the approximate 95% confidence interval on the mean of
+// a Poisson process that produced k events, using the Wilson-Hilferty
+// approximation of the exact chi-squared bounds.  That's within a percent
+// or so of exact from four events up; below that its lower bound runs low,
+// by 7% at two events, which only widens the interval.  The lower bound at
+// one event and the upper at none have simple exact forms and are used as
+// such.
+func PoissonInterval(k int64) (float64, float64) {
+	const z = 1.959964
+	var wh = func(n float64, sign float64) float64 {
+		return n * math.Pow(1-1/(9*n)+sign*z/(3*math.Sqrt(n)), 3)
+	}
+	switch k {
+	case 0:
+		return 0, -math.Log(0.025)
+	case 1:
+		return -math.Log(0.975), wh(2, 1)
+	}
+	return wh(float64(k), -1), wh(float64(k+1), 1)
+}
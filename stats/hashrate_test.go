@@ -0,0 +1,49 @@
+package stats
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// The intervals are checked against the exact chi-squared bounds to the
+// accuracy the approximation promises: a percent or so from four events up, and
+// below that a lower bound that may be low but never high
+func TestPoissonInterval(t *testing.T) {
+	var tests = []struct {
+		k         int64
+		low, high float64
+	}{
+		{0, 0, 3.689},
+		{1, 0.0253, 5.572},
+		{2, 0.2422, 7.225},
+		{3, 0.6187, 8.767},
+		{4, 1.090, 10.24},
+		{10, 4.795, 18.39},
+		{100, 81.36, 121.63},
+	}
+	for _, tt := range tests {
+		var low, high = PoissonInterval(tt.k)
+		var lowOK = math.Abs(low-tt.low) <= 0.015*tt.low+0.001
+		if tt.k > 1 && tt.k < 4 {
+			lowOK = low <= tt.low && low >= 0.9*tt.low
+		}
+		if !lowOK || math.Abs(high-tt.high) > 0.015*tt.high {
+			t.Errorf("PoissonInterval(%d) = %0.4f, %0.4f; want about %g, %g", tt.k, low, high, tt.low, tt.high)
+		}
+	}
+}
+
+func TestEstimateHashrate(t *testing.T) {
+	// 144 blocks a day at difficulty 1 takes 2^32 hashes every 600s
+	var e = EstimateHashrate(144, 1, 24*time.Hour)
+	if math.Abs(e.Hashrate-(1<<32)/600.0) > 1e-6 || e.Low >= e.Hashrate || e.High <= e.Hashrate {
+		t.Errorf("got %+v", e)
+	}
+	if e = EstimateHashrate(5, 1, 0); e.Hashrate != 0 || e.High != 0 {
+		t.Errorf("empty window: got %+v", e)
+	}
+	if e = EstimateHashrate(0, 1e6, time.Hour); e.Hashrate != 0 || e.High <= 0 {
+		t.Errorf("no blocks: got %+v, want only an upper bound", e)
+	}
+}
@@ -0,0 +1,76 @@
+package stats
+
+import (
+	"sort"
+	"time"
+)
+
+// Interval is one bucket of a window split into fixed-size steps.  End is
+// the next interval's Start, or now for the interval still in progress.
+type Interval struct {
+	Bucket
+	Start time.Time
+	End   time.Time
+}
+
+// Intervals splits the window, up to now, into steps of size counted on the
+// wall clock from each local midnight, so 4h buckets always start at 00:00,
+// 04:00, and so on.  On a DST day the bucket holding the change is an hour
+// shorter or longer than the rest; a boundary the clock skips is dropped
+// rather than giving an empty bucket.  size should divide a day evenly; a
+// day or more gives one interval per day.
+func (a *Accumulator) Intervals(size time.Duration, now time.Time) []Interval {
+	var bounds = a.boundaries(size, now)
+	if len(bounds) == 0 {
+		return nil
+	}
+	var rows = make([]Interval, len(bounds))
+	for i, b := range bounds {
+		rows[i].Start = b
+		if i+1 < len(bounds) {
+			rows[i].End = bounds[i+1]
+		} else {
+			rows[i].End = now
+		}
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	for _, tx := range a.txs {
+		var i = sort.Search(len(bounds), func(i int) bool { return bounds[i].After(tx.Time) }) - 1
+		if i < 0 || tx.Time.After(now) {
+			continue
+		}
+		if tx.Dust || tx.Payout {
+			rows[i].AddCoins(tx.Amount)
+			continue
+		}
+		rows[i].Record(tx.Blockheight, tx.Amount)
+	}
+	return rows
+}
+
+// boundaries lists the start of every interval in the window that has begun
+// by now
+func (a *Accumulator) boundaries(size time.Duration, now time.Time) []time.Time {
+	var steps = int(24 * time.Hour / size)
+	if steps < 1 {
+		steps = 1
+	}
+	var bounds []time.Time
+	for d := 0; d < a.days; d++ {
+		var day = a.start.AddDate(0, 0, d)
+		for s := 0; s < steps; s++ {
+			var offset = time.Duration(s) * size
+			var b = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, int(offset), day.Location())
+			if b.After(now) {
+				return bounds
+			}
+			if len(bounds) > 0 && !b.After(bounds[len(bounds)-1]) {
+				continue
+			}
+			bounds = append(bounds, b)
+		}
+	}
+	return bounds
+}
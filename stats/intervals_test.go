@@ -0,0 +1,100 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+// Buckets start on the local wall clock across both DST changes: the one
+// holding the change is an hour short or long, and the rest keep their
+// usual starts
+func TestIntervalsDST(t *testing.T) {
+	var loc, err = time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	var tests = []struct {
+		name    string
+		day     time.Time
+		size    time.Duration
+		starts  []string
+		lengths []time.Duration
+	}{
+		{
+			name:    "spring forward, 4h",
+			day:     time.Date(2024, 3, 10, 0, 0, 0, 0, loc),
+			size:    4 * time.Hour,
+			starts:  []string{"00:00", "04:00", "08:00", "12:00", "16:00", "20:00"},
+			lengths: []time.Duration{3 * time.Hour, 4 * time.Hour, 4 * time.Hour, 4 * time.Hour, 4 * time.Hour, 4 * time.Hour},
+		},
+		{
+			name:    "fall back, 4h",
+			day:     time.Date(2024, 11, 3, 0, 0, 0, 0, loc),
+			size:    4 * time.Hour,
+			starts:  []string{"00:00", "04:00", "08:00", "12:00", "16:00", "20:00"},
+			lengths: []time.Duration{5 * time.Hour, 4 * time.Hour, 4 * time.Hour, 4 * time.Hour, 4 * time.Hour, 4 * time.Hour},
+		},
+		{
+			name:    "spring forward, 1h drops the skipped hour",
+			day:     time.Date(2024, 3, 10, 0, 0, 0, 0, loc),
+			size:    time.Hour,
+			starts:  []string{"00:00", "01:00", "03:00", "04:00"},
+			lengths: []time.Duration{time.Hour, time.Hour, time.Hour, time.Hour},
+		},
+		{
+			name:    "a day per interval",
+			day:     time.Date(2024, 11, 3, 0, 0, 0, 0, loc),
+			size:    24 * time.Hour,
+			starts:  []string{"00:00"},
+			lengths: []time.Duration{25 * time.Hour},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var acc = NewAccumulator(tt.day, 2)
+			var rows = acc.Intervals(tt.size, tt.day.AddDate(0, 0, 1).Add(time.Minute))
+			for i, want := range tt.starts {
+				if i >= len(rows) {
+					t.Fatalf("only %d intervals", len(rows))
+				}
+				if got := rows[i].Start.Format("15:04"); got != want {
+					t.Errorf("interval %d starts at %s, want %s", i, got, want)
+				}
+				if got := rows[i].End.Sub(rows[i].Start); got != tt.lengths[i] {
+					t.Errorf("interval %d at %s lasts %v, want %v", i, tt.starts[i], got, tt.lengths[i])
+				}
+			}
+		})
+	}
+}
+
+// Every transaction lands in the interval holding its time, and the one
+// still in progress ends at now
+func TestIntervalsPlacement(t *testing.T) {
+	var start = time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	var now = start.Add(29*time.Hour + 30*time.Minute)
+	var acc = NewAccumulator(start, 3)
+	acc.Add(Transaction{TXID: "a", Amount: 5, Blockheight: 100, Time: start.Add(5*time.Hour + 59*time.Minute)})
+	acc.Add(Transaction{TXID: "b", Amount: 5, Blockheight: 101, Time: start.Add(6 * time.Hour)})
+	acc.Add(Transaction{TXID: "c", Amount: 0.5, Time: start.Add(29 * time.Hour), Payout: true})
+	acc.Add(Transaction{TXID: "d", Amount: 5, Blockheight: 102, Time: now.Add(time.Minute)})
+
+	var rows = acc.Intervals(6*time.Hour, now)
+	if len(rows) != 5 {
+		t.Fatalf("got %d intervals, want 5", len(rows))
+	}
+	var want = []Bucket{
+		{FirstBlock: 100, LastBlock: 100, Blocks: 1, Coins: 5},
+		{FirstBlock: 101, LastBlock: 101, Blocks: 1, Coins: 5},
+		{}, {},
+		{Coins: 0.5},
+	}
+	for i, b := range want {
+		if rows[i].Bucket != b {
+			t.Errorf("interval %d: got %+v, want %+v", i, rows[i].Bucket, b)
+		}
+	}
+	if !rows[4].End.Equal(now) {
+		t.Errorf("the last interval ends at %v, want now", rows[4].End)
+	}
+}
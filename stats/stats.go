@@ -0,0 +1,223 @@
+// Package stats aggregates generated transactions into per-day and per-hour
+// buckets over a report window.
+package stats
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Transaction is the subset of a wallet transaction the aggregation needs
+type Transaction struct {
+	TXID        string
+	Vout        int64
+	Amount      float64
+	Blockheight int64
+	Time        time.Time
+
+	// Dust outputs add to the coin totals but aren't counted as blocks
+	Dust bool
+
+	// Payouts, from a pool rather than a block won, likewise only add coins
+	Payout bool
+}
+
+func (tx Transaction) key() string {
+	return fmt.Sprintf("%s:%d", tx.TXID, tx.Vout)
+}
+
+// Bucket is the aggregate of the transactions in one time period
+type Bucket struct {
+	FirstBlock int64
+	LastBlock  int64
+	Blocks     int64
+	Coins      float64
+}
+
+// Record adds a single block's reward to the bucket
+func (b *Bucket) Record(height int64, amount float64) {
+	if b.FirstBlock == 0 || height < b.FirstBlock {
+		b.FirstBlock = height
+	}
+	if height > b.LastBlock {
+		b.LastBlock = height
+	}
+	b.Coins += amount
+	b.Blocks++
+}
+
+// AddCoins adds to the bucket's total without counting a block
+func (b *Bucket) AddCoins(amount float64) {
+	b.Coins += amount
+}
+
+// Merge folds another bucket's totals into b.  A bucket with no blocks can
+// still hold coins, from payouts or dust, so only the block range skips it.
+func (b *Bucket) Merge(o Bucket) {
+	b.Coins += o.Coins
+	if o.Blocks == 0 {
+		return
+	}
+	if b.FirstBlock == 0 || o.FirstBlock < b.FirstBlock {
+		b.FirstBlock = o.FirstBlock
+	}
+	if o.LastBlock > b.LastBlock {
+		b.LastBlock = o.LastBlock
+	}
+	b.Blocks += o.Blocks
+}
+
+// RoughPercent estimates the share of blocks won between the first and last
+// block seen in the bucket
+func (b Bucket) RoughPercent() float64 {
+	if b.Blocks == 0 {
+		return 0
+	}
+	return 100.0 * (float64(b.Blocks) / float64(b.LastBlock-b.FirstBlock+1))
+}
+
+// Day is one calendar day's bucket along with its breakdown by hour of day.
+// On DST days the repeated hour shares a bucket and the skipped hour is
+// empty.
+type Day struct {
+	Bucket
+	Hours [24]Bucket
+}
+
+// Report is an immutable view of an Accumulator's buckets at one moment
+type Report struct {
+	Start time.Time
+	Days  int
+	Total Bucket
+	Daily []Day
+}
+
+// Day returns midnight of the report's i'th day
+func (r Report) Day(i int) time.Time {
+	return r.Start.AddDate(0, 0, i)
+}
+
+// Accumulator collects transactions for a report window of whole days
+// starting at midnight.  It's safe for concurrent use, so one goroutine can
+// keep adding transactions while others take snapshots.
+type Accumulator struct {
+	mu    sync.RWMutex
+	start time.Time
+	days  int
+	txs   map[string]Transaction
+}
+
+// NewAccumulator returns an Accumulator for the given number of days, the
+// first of which begins at start (which should be a local midnight)
+func NewAccumulator(start time.Time, days int) *Accumulator {
+	return &Accumulator{start: start, days: days, txs: make(map[string]Transaction)}
+}
+
+// dayIndex returns which day of the window t falls on, or -1 if it's outside
+// the window.  Days are counted on the calendar rather than in 24h steps so
+// DST changes don't shift the boundaries.
+func (a *Accumulator) dayIndex(t time.Time) int {
+	var loc = a.start.Location()
+	var lt = t.In(loc)
+	var day = time.Date(lt.Year(), lt.Month(), lt.Day(), 0, 0, 0, 0, loc)
+	var i = int(math.Round(day.Sub(a.start).Hours() / 24))
+	if t.Before(a.start) || i >= a.days {
+		return -1
+	}
+	return i
+}
+
+// Add records a transaction, replacing any earlier copy of the same output.
+// It reports whether the transaction fell inside the window.
+func (a *Accumulator) Add(tx Transaction) bool {
+	if a.dayIndex(tx.Time) < 0 {
+		return false
+	}
+	a.mu.Lock()
+	a.txs[tx.key()] = tx
+	a.mu.Unlock()
+	return true
+}
+
+// Remove drops every output of the given transaction, e.g. after a reorg,
+// and reports whether anything was removed
+func (a *Accumulator) Remove(txid string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var removed bool
+	for k, tx := range a.txs {
+		if tx.TXID == txid {
+			delete(a.txs, k)
+			removed = true
+		}
+	}
+	return removed
+}
+
+// Len returns how many transaction outputs are held
+func (a *Accumulator) Len() int {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return len(a.txs)
+}
+
+// Snapshot aggregates the current transactions into a new Report.  The
+// report shares nothing with the Accumulator, so it can be read freely while
+// more transactions are added.
+func (a *Accumulator) Snapshot() Report {
+	var r = Report{
+		Start: a.start,
+		Days:  a.days,
+		Daily: make([]Day, a.days),
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	for _, tx := range a.txs {
+		var i = a.dayIndex(tx.Time)
+		if tx.Dust || tx.Payout {
+			r.Total.AddCoins(tx.Amount)
+			r.Daily[i].AddCoins(tx.Amount)
+			r.Daily[i].Hours[tx.Time.In(a.start.Location()).Hour()].AddCoins(tx.Amount)
+			continue
+		}
+		r.Total.Record(tx.Blockheight, tx.Amount)
+		r.Daily[i].Record(tx.Blockheight, tx.Amount)
+		r.Daily[i].Hours[tx.Time.In(a.start.Location()).Hour()].Record(tx.Blockheight, tx.Amount)
+	}
+
+	return r
+}
+
+// HourOfDay folds every day's hours together, giving the window's totals for
+// each hour of the day
+func (r Report) HourOfDay() [24]Bucket {
+	var hours [24]Bucket
+	for _, day := range r.Daily {
+		for h, b := range day.Hours {
+			hours[h].Merge(b)
+		}
+	}
+	return hours
+}
+
+// Check confirms that the days add up to the total, to the satoshi and the
+// block.  They're built from the same transactions, so a difference means a
+// transaction landed in the total but not in a day, or vice versa.
+func (r Report) Check() error {
+	var coins, blocks int64
+	for _, d := range r.Daily {
+		coins += int64(math.Round(d.Coins * 1e8))
+		blocks += d.Blocks
+	}
+	if total := int64(math.Round(r.Total.Coins * 1e8)); coins != total {
+		return fmt.Errorf("days add up to %d satoshis, but the total is %d", coins, total)
+	}
+	if blocks != r.Total.Blocks {
+		return fmt.Errorf("days add up to %d blocks, but the total is %d", blocks, r.Total.Blocks)
+	}
+	return nil
+}
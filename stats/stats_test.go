@@ -0,0 +1,118 @@
+package stats
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMergeKeepsCoinsWithoutBlocks(t *testing.T) {
+	var b = Bucket{FirstBlock: 100, LastBlock: 110, Blocks: 2, Coins: 12.5}
+	b.Merge(Bucket{Coins: 0.75})
+	b.Merge(Bucket{FirstBlock: 90, LastBlock: 95, Blocks: 1, Coins: 6.25})
+
+	var want = Bucket{FirstBlock: 90, LastBlock: 110, Blocks: 3, Coins: 19.5}
+	if b != want {
+		t.Errorf("got %+v, want %+v", b, want)
+	}
+}
+
+// A pool payout only adds coins, so an hour with payouts and no blocks must
+// still show up when the days are folded together
+func TestHourOfDayPayoutOnly(t *testing.T) {
+	var start = time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	var acc = NewAccumulator(start, 2)
+	acc.Add(Transaction{TXID: "a", Amount: 0.5, Time: start.Add(9 * time.Hour), Payout: true})
+	acc.Add(Transaction{TXID: "b", Amount: 0.25, Time: start.Add(33 * time.Hour), Payout: true})
+
+	var hours = acc.Snapshot().HourOfDay()
+	if hours[9].Coins != 0.75 || hours[9].Blocks != 0 {
+		t.Errorf("hour 9: got %+v, want 0.75 coins and no blocks", hours[9])
+	}
+}
+
+// Snapshots taken while transactions are still being added and removed
+// must each be consistent; run under -race this also checks the locking
+func TestConcurrentAddSnapshot(t *testing.T) {
+	var start = time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	var acc = NewAccumulator(start, 7)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				var tx = Transaction{TXID: fmt.Sprintf("%d-%d", w, i), Amount: 1.25, Blockheight: int64(i),
+					Time: start.Add(time.Duration(i) * 17 * time.Minute), Payout: i%5 == 0}
+				acc.Add(tx)
+				if i%7 == 0 {
+					acc.Remove(tx.TXID)
+				}
+			}
+		}(w)
+	}
+	var done = make(chan struct{})
+	var snapshots = make(chan error, 1)
+	go func() {
+		for {
+			select {
+			case <-done:
+				snapshots <- nil
+				return
+			default:
+			}
+			if err := acc.Snapshot().Check(); err != nil {
+				snapshots <- err
+				return
+			}
+		}
+	}()
+	wg.Wait()
+	close(done)
+	if err := <-snapshots; err != nil {
+		t.Fatal(err)
+	}
+
+	var r = acc.Snapshot()
+	var err = r.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acc.Len() != 4*(500-72) {
+		t.Errorf("holding %d outputs, want %d", acc.Len(), 4*(500-72))
+	}
+	if r.Total.Blocks+int64(4*(100-15)) != int64(acc.Len()) {
+		t.Errorf("%d blocks out of %d outputs, want all but the %d payouts", r.Total.Blocks, acc.Len(), 4*(100-15))
+	}
+}
+
+func TestCheck(t *testing.T) {
+	var start = time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	var acc = NewAccumulator(start, 3)
+	acc.Add(Transaction{TXID: "a", Amount: 0.1, Blockheight: 10, Time: start.Add(time.Hour)})
+	acc.Add(Transaction{TXID: "b", Amount: 0.2, Blockheight: 20, Time: start.Add(30 * time.Hour)})
+	acc.Add(Transaction{TXID: "c", Amount: 0.00000546, Time: start.Add(50 * time.Hour), Dust: true})
+
+	var r = acc.Snapshot()
+	var err = r.Check()
+	if err != nil {
+		t.Fatalf("a fresh snapshot failed: %v", err)
+	}
+
+	var coins = r
+	coins.Daily = append([]Day(nil), r.Daily...)
+	coins.Daily[1].Coins += 0.00000001
+	err = coins.Check()
+	if err == nil || err.Error() != "days add up to 30000547 satoshis, but the total is 30000546" {
+		t.Errorf("a satoshi too many: got %v", err)
+	}
+
+	var blocks = r
+	blocks.Total.Blocks++
+	err = blocks.Check()
+	if err == nil || err.Error() != "days add up to 2 blocks, but the total is 3" {
+		t.Errorf("a block too many: got %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import "os"
+
+// style is what the text output is allowed to do beyond plain ASCII, worked
+// out once from the flags, the environment, and the terminal.  Everything
+// that draws something fancier checks here rather than deciding for itself.
+type style struct {
+	// color is whether ANSI color may be used: stdout is a terminal, and
+	// neither --plain nor NO_COLOR (https://no-color.org) says otherwise
+	color bool
+
+	// unicode is whether glyphs beyond ASCII may be used
+	unicode bool
+
+	// progress is whether progress lines may be written while waiting
+	progress bool
+
+	// width is the width the report is rendered for, as outputWidth
+	width int
+}
+
+// newStyle works out the style for cfg.  --plain turns off everything but
+// the width, for dumb terminals and logs that the output is appended to.
+func newStyle(cfg *config) style {
+	var s = style{color: true, unicode: true, progress: true, width: outputWidth(cfg)}
+	if os.Getenv("NO_COLOR") != "" || terminalWidth(os.Stdout) == 0 {
+		s.color = false
+	}
+	if cfg.Plain {
+		s.color, s.unicode, s.progress = false, false, false
+	}
+	return s
+}
+
+// dash is the separator for ranges and headings: an en dash, or a hyphen
+// when unicode is off
+func (s style) dash() string {
+	if s.unicode {
+		return "–"
+	}
+	return "-"
+}
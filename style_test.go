@@ -0,0 +1,54 @@
+package main
+
+import "testing"
+
+// Tests don't run on a terminal, so color is off whatever NO_COLOR says;
+// --plain also turns off unicode and progress lines, but not the width
+func TestNewStyle(t *testing.T) {
+	t.Setenv("COLUMNS", "80")
+	t.Setenv("NO_COLOR", "")
+	var s = newStyle(&config{})
+	if s.color || !s.unicode || !s.progress || s.width != 80 || s.dash() != "–" {
+		t.Errorf("default: got %+v", s)
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if s = newStyle(&config{}); s.color || !s.unicode {
+		t.Errorf("NO_COLOR: got %+v", s)
+	}
+
+	s = newStyle(&config{Plain: true})
+	if s.color || s.unicode || s.progress || s.width != 80 || s.dash() != "-" {
+		t.Errorf("--plain: got %+v", s)
+	}
+}
+
+// --sparkline draws with block elements, or ASCII under --plain, with a
+// flat window drawn mid-height
+func TestSparkTag(t *testing.T) {
+	var v = &reportView{sparkline: true, style: style{unicode: true}, Daily: []reportRow{{Coins: 0}, {Coins: 5}, {Coins: 10}}}
+	var got string
+	for _, row := range v.Daily {
+		got += v.sparkTag(row)
+	}
+	if got != " ▁ ▄ █" {
+		t.Errorf("unicode: got %q", got)
+	}
+
+	v.style.unicode = false
+	got = ""
+	for _, row := range v.Daily {
+		got += v.sparkTag(row)
+	}
+	if got != " _ : #" {
+		t.Errorf("--plain: got %q", got)
+	}
+
+	if r := sparklineRune(sparkRunes, 3, 3, 3); r != '▅' {
+		t.Errorf("flat window: got %q", r)
+	}
+	v.sparkline = false
+	if tag := v.sparkTag(v.Daily[0]); tag != "" {
+		t.Errorf("without --sparkline: got %q", tag)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// subsidyStep is one entry of the block reward schedule: blocks from Height
+// on pay Reward, until the next step
+type subsidyStep struct {
+	Height int64   `json:"height"`
+	Reward float64 `json:"reward"`
+}
+
+type subsidySchedule []subsidyStep
+
+// subsidyIntervalBlocks is how far back the recent block interval is
+// measured when estimating when a height will be reached
+const subsidyIntervalBlocks = 1000
+
+func (s subsidySchedule) validate() error {
+	for i := 1; i < len(s); i++ {
+		if s[i].Height <= s[i-1].Height {
+			return fmt.Errorf("subsidy schedule heights must increase (%d follows %d)", s[i].Height, s[i-1].Height)
+		}
+	}
+	return nil
+}
+
+// rewardAt returns the subsidy of the block at height; heights before the
+// first step pay nothing
+func (s subsidySchedule) rewardAt(height int64) float64 {
+	var i = sort.Search(len(s), func(i int) bool { return s[i].Height > height })
+	if i == 0 {
+		return 0
+	}
+	return s[i-1].Reward
+}
+
+// next returns the first step after height, if there is one
+func (s subsidySchedule) next(height int64) (subsidyStep, bool) {
+	var i = sort.Search(len(s), func(i int) bool { return s[i].Height > height })
+	if i == len(s) {
+		return subsidyStep{}, false
+	}
+	return s[i], true
+}
+
+// subsidyView is the schedule's part of the report
+type subsidyView struct {
+	Height        int64      `json:"height"`
+	CurrentReward float64    `json:"current_reward"`
+	NextHeight    int64      `json:"next_height,omitempty"`
+	NextReward    float64    `json:"next_reward,omitempty"`
+	NextETA       *time.Time `json:"next_eta,omitempty"`
+	RestOfMonth   float64    `json:"rest_of_month"`
+	MonthEnd      time.Time  `json:"month_end"`
+}
+
+// blockInterval estimates the current time between blocks from the last
+// subsidyIntervalBlocks headers
+func blockInterval(u *url.URL, tip int64) (time.Duration, error) {
+	var back = int64(subsidyIntervalBlocks)
+	if tip < back {
+		back = tip
+	}
+	if back == 0 {
+		return 0, fmt.Errorf("not enough blocks")
+	}
+
+	var times [2]int64
+	for i, height := range []int64{tip - back, tip} {
+		var hash, err = fetchBlockHash(u, height)
+		if err != nil {
+			return 0, err
+		}
+		var h *BlockHeader
+		h, err = fetchBlockHeader(u, hash)
+		if err != nil {
+			return 0, err
+		}
+		times[i] = h.Time
+	}
+	return time.Duration(times[1]-times[0]) * time.Second / time.Duration(back), nil
+}
+
+// newSubsidyView places the chain tip on the schedule, estimates when the
+// next step lands, and projects the rest of the month from the daily
+// average, scaled down for any part of it that falls after the step
+func newSubsidyView(u *url.URL, s subsidySchedule, dailyAverage float64, now time.Time) (*subsidyView, error) {
+	var info blockchainInfo
+	var err = rpcCall(nodeURL(u), "getblockchaininfo", nil, &info)
+	if err != nil {
+		return nil, err
+	}
+
+	var v = &subsidyView{Height: info.Blocks, CurrentReward: s.rewardAt(info.Blocks)}
+	v.MonthEnd = time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, now.Location())
+	var before, after = v.MonthEnd.Sub(now), time.Duration(0)
+
+	if step, ok := s.next(info.Blocks); ok {
+		v.NextHeight, v.NextReward = step.Height, step.Reward
+		var interval time.Duration
+		interval, err = blockInterval(u, info.Blocks)
+		if err == nil && interval > 0 {
+			var eta = now.Add(interval * time.Duration(step.Height-info.Blocks))
+			v.NextETA = &eta
+			if eta.Before(v.MonthEnd) {
+				before, after = eta.Sub(now), v.MonthEnd.Sub(eta)
+			}
+		}
+	}
+
+	var perDay = float64(24 * time.Hour)
+	v.RestOfMonth = dailyAverage * float64(before) / perDay
+	if after > 0 && v.CurrentReward > 0 {
+		v.RestOfMonth += dailyAverage * v.NextReward / v.CurrentReward * float64(after) / perDay
+	}
+	return v, nil
+}
+
+func (v *subsidyView) print(w io.Writer) {
+	fmt.Fprintf(w, "Block reward: %g at height %d\n", v.CurrentReward, v.Height)
+	if v.NextHeight != 0 {
+		var eta = "date unknown"
+		if v.NextETA != nil {
+			eta = "~" + v.NextETA.Format("Jan 2")
+		}
+		fmt.Fprintf(w, "Next halving: height %d, %s (reward %g)\n", v.NextHeight, eta, v.NextReward)
+	}
+	fmt.Fprintf(w, "Rest-of-month projection: %0.2f (to %s)\n", v.RestOfMonth, v.MonthEnd.AddDate(0, 0, -1).Format(dateLayout))
+}
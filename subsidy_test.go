@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSubsidySchedule(t *testing.T) {
+	var s = subsidySchedule{{Height: 1, Reward: 50}, {Height: 210000, Reward: 25}, {Height: 420000, Reward: 12.5}}
+	if err := s.validate(); err != nil {
+		t.Fatal(err)
+	}
+	var rewards = map[int64]float64{0: 0, 1: 50, 209999: 50, 210000: 25, 419999: 25, 420000: 12.5, 9000000: 12.5}
+	for height, want := range rewards {
+		if got := s.rewardAt(height); got != want {
+			t.Errorf("rewardAt(%d) = %g, want %g", height, got, want)
+		}
+	}
+	if step, ok := s.next(210000); !ok || step.Height != 420000 {
+		t.Errorf("next(210000) = %+v, %v; want the step at 420000", step, ok)
+	}
+	if _, ok := s.next(420000); ok {
+		t.Error("next(420000) found a step past the last")
+	}
+
+	var bad = subsidySchedule{{Height: 100, Reward: 5}, {Height: 100, Reward: 2.5}}
+	if err := bad.validate(); err == nil {
+		t.Error("a repeated height was accepted")
+	}
+}
+
+// With blocks ten minutes apart, a halving 144 blocks off lands a day from
+// now, and the rest of the month after it is projected at half the average
+func TestNewSubsidyView(t *testing.T) {
+	var genesis = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	var node = newFakeNode(t, map[string]fakeMethod{
+		"getblockchaininfo": func(string, []interface{}) (interface{}, *RPCError) {
+			return map[string]interface{}{"chain": "main", "blocks": 5000}, nil
+		},
+		"getblockhash": func(_ string, params []interface{}) (interface{}, *RPCError) {
+			return fmt.Sprintf("%064x", int64(params[0].(float64))), nil
+		},
+		"getblockheader": func(_ string, params []interface{}) (interface{}, *RPCError) {
+			var height int64
+			fmt.Sscanf(params[0].(string), "%x", &height)
+			return map[string]interface{}{"hash": params[0], "height": height, "time": genesis + height*600}, nil
+		},
+	})
+
+	var now = time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC)
+	var s = subsidySchedule{{Height: 0, Reward: 10}, {Height: 5144, Reward: 5}}
+	var v, err = newSubsidyView(node.url(), s, 100, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.CurrentReward != 10 || v.NextHeight != 5144 || v.NextReward != 5 {
+		t.Errorf("got %+v", v)
+	}
+	if v.NextETA == nil || !v.NextETA.Equal(now.Add(24*time.Hour)) {
+		t.Errorf("next halving due %v, want %s", v.NextETA, now.Add(24*time.Hour))
+	}
+	// A day at 100, then ten and a half at 50
+	if math.Abs(v.RestOfMonth-625) > 1e-9 {
+		t.Errorf("rest of month %g, want 625", v.RestOfMonth)
+	}
+}
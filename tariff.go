@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// tariffBand prices the hours [Start, End) on each of Days.  A band whose
+// End is before its Start wraps past midnight, so {Start: 23, End: 7} covers
+// 23:00-24:00 and 00:00-07:00 on the listed days.  No days means every day.
+type tariffBand struct {
+	Name  string   `json:"name"`
+	Days  []string `json:"days"`
+	Start int      `json:"start"`
+	End   int      `json:"end"`
+	Price float64  `json:"price"`
+}
+
+type tariff struct {
+	bands []tariffBand
+	slots [7][24]int
+}
+
+// newTariff validates a schedule and builds the hour lookup table.  Every
+// hour of the week must be covered by exactly one band.
+func newTariff(bands []tariffBand) (*tariff, error) {
+	if len(bands) == 0 {
+		return nil, fmt.Errorf("tariff has no bands")
+	}
+
+	var t = &tariff{bands: bands}
+	for d := range t.slots {
+		for h := range t.slots[d] {
+			t.slots[d][h] = -1
+		}
+	}
+
+	for i, b := range bands {
+		if b.Name == "" {
+			return nil, fmt.Errorf("tariff band %d has no name", i+1)
+		}
+		if b.Start < 0 || b.Start > 23 || b.End < 0 || b.End > 24 || b.Start == b.End {
+			return nil, fmt.Errorf("tariff band %q: invalid hours %d-%d", b.Name, b.Start, b.End)
+		}
+
+		var days []time.Weekday
+		for _, name := range b.Days {
+			var d, err = parseWeekday(name)
+			if err != nil {
+				return nil, fmt.Errorf("tariff band %q: %w", b.Name, err)
+			}
+			days = append(days, d)
+		}
+		if len(days) == 0 {
+			for d := time.Sunday; d <= time.Saturday; d++ {
+				days = append(days, d)
+			}
+		}
+
+		for _, d := range days {
+			for h := b.Start; h != b.End; h = (h + 1) % 24 {
+				if prev := t.slots[d][h]; prev != -1 {
+					return nil, fmt.Errorf("tariff bands %q and %q overlap on %s at %02d:00", bands[prev].Name, b.Name, d, h)
+				}
+				t.slots[d][h] = i
+				if b.End == 24 && h == 23 {
+					break
+				}
+			}
+		}
+	}
+
+	for d := range t.slots {
+		for h, i := range t.slots[d] {
+			if i == -1 {
+				return nil, fmt.Errorf("tariff has no band covering %s at %02d:00", time.Weekday(d), h)
+			}
+		}
+	}
+
+	return t, nil
+}
+
+func flatTariff(price float64) *tariff {
+	var t, _ = newTariff([]tariffBand{{Name: "flat", Start: 0, End: 24, Price: price}})
+	return t
+}
+
+func loadTariffFile(path string) ([]tariffBand, error) {
+	var data, err = os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var bands []tariffBand
+	err = json.Unmarshal(data, &bands)
+	return bands, err
+}
+
+func (t *tariff) band(tm time.Time) int {
+	return t.slots[tm.Weekday()][tm.Hour()]
+}
+
+// printPowerCost accrues the rig's power cost hour by hour across the report
+// window, since the power is burned whether or not a block lands.
+func printPowerCost(t *tariff, watts float64, begin, now time.Time, coins, coinPrice float64) {
+	var kwh = make([]float64, len(t.bands))
+	var cost = make([]float64, len(t.bands))
+	for hour := begin; hour.Before(now); hour = hour.Add(time.Hour) {
+		var used = time.Hour
+		if now.Sub(hour) < used {
+			used = now.Sub(hour)
+		}
+		var i = t.band(hour)
+		var e = watts / 1000 * used.Hours()
+		kwh[i] += e
+		cost[i] += e * t.bands[i].Price
+	}
+
+	var totalKWh, totalCost float64
+	for i := range t.bands {
+		totalKWh += kwh[i]
+		totalCost += cost[i]
+	}
+
+	fmt.Println()
+	fmt.Printf("Power cost (%0.0f W, %0.2f kWh): %0.2f\n", watts, totalKWh, totalCost)
+	if len(t.bands) > 1 {
+		fmt.Println("Cost by tariff band:")
+		for i, b := range t.bands {
+			fmt.Printf("  %-16s %8.2f kWh @ %0.4f\t%8.2f\n", b.Name+":", kwh[i], b.Price, cost[i])
+		}
+	}
+	if coinPrice > 0 {
+		var revenue = coins * coinPrice
+		fmt.Printf("Revenue at %g/coin: %0.2f\n", coinPrice, revenue)
+		fmt.Printf("Net profit: %0.2f\n", revenue-totalCost)
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"os"
+	"strconv"
+)
+
+// narrowWidth is the terminal width below which the narrow layout is used;
+// the wide daily table needs about this much room
+const narrowWidth = 100
+
+// outputWidth decides the width the report is rendered for: 0 means "don't
+// care", which is what --wide and non-terminal output get
+func outputWidth(cfg *config) int {
+	switch {
+	case cfg.Narrow:
+		return narrowWidth - 1
+	case cfg.Wide:
+		return 0
+	}
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	return terminalWidth(os.Stdout)
+}
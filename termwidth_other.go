@@ -0,0 +1,11 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package main
+
+import "os"
+
+// terminalWidth can't tell on this platform, so only COLUMNS, --narrow, and
+// --wide pick the layout
+func terminalWidth(f *os.File) int {
+	return 0
+}
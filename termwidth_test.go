@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestOutputWidth(t *testing.T) {
+	var tests = []struct {
+		name    string
+		cfg     config
+		columns string
+		want    int
+		narrow  bool
+	}{
+		{"--narrow", config{Narrow: true}, "200", narrowWidth - 1, true},
+		{"--wide", config{Wide: true}, "60", 0, false},
+		{"narrow COLUMNS", config{}, "80", 80, true},
+		{"wide COLUMNS", config{}, "120", 120, false},
+		// Test output isn't a terminal, so without COLUMNS the width is
+		// left to the wide layout
+		{"no terminal", config{}, "", 0, false},
+		{"bad COLUMNS", config{}, "wide", 0, false},
+	}
+	for _, tt := range tests {
+		t.Setenv("COLUMNS", tt.columns)
+		var got = outputWidth(&tt.cfg)
+		if got != tt.want || narrow(got) != tt.narrow {
+			t.Errorf("%s: width %d (narrow %v), want %d (narrow %v)", tt.name, got, narrow(got), tt.want, tt.narrow)
+		}
+	}
+}
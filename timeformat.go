@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// dateLayout and dateTimeLayout are the Go time layouts, set by --date-format
+// and --datetime-format, for dates and times shown to people.  Machine keys,
+// like the state file's days and the daily report file names, keep their
+// fixed layouts whatever these say.
+var (
+	dateLayout     = "2006-01-02"
+	dateTimeLayout = "2006-01-02 15:04:05"
+)
+
+// layoutCheckStart is where the layout check starts counting; any year would
+// do, but one with a leap day covers February 29th
+var layoutCheckStart = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// checkTimeLayouts rejects a date layout that gives two days of a two-year
+// span the same text, or a date-time layout that does so for two seconds of
+// a day or two days, so a custom layout can't quietly drop the year or the
+// seconds and leave rows that can't be told apart
+func checkTimeLayouts(cfg *config) error {
+	var label, ok = distinctOver(cfg.DateFormat, 24*time.Hour, 2*366)
+	if !ok {
+		return usageError(fmt.Sprintf("Invalid --date-format %q: more than one day is shown as %q", cfg.DateFormat, label))
+	}
+	label, ok = distinctOver(cfg.DateTimeFormat, time.Second, 86400)
+	if ok {
+		label, ok = distinctOver(cfg.DateTimeFormat, 24*time.Hour, 2*366)
+	}
+	if !ok {
+		return usageError(fmt.Sprintf("Invalid --datetime-format %q: more than one second is shown as %q", cfg.DateTimeFormat, label))
+	}
+	return nil
+}
+
+// distinctOver formats n points step apart from layoutCheckStart, giving
+// back the first text seen twice
+func distinctOver(layout string, step time.Duration, n int) (string, bool) {
+	var seen = make(map[string]bool, n)
+	for i := 0; i < n; i++ {
+		var label = layoutCheckStart.Add(time.Duration(i) * step).Format(layout)
+		if seen[label] {
+			return label, false
+		}
+		seen[label] = true
+	}
+	return "", true
+}
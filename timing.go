@@ -0,0 +1,164 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http/httptrace"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// rpcTimings totals every RPC call the run makes.  It's always collected,
+// since the cost is a clock read per call, and shown with --timing.
+type rpcTimings struct {
+	mu       sync.Mutex
+	calls    int
+	bytes    int64
+	elapsed  time.Duration
+	byMethod map[string]*methodTiming
+}
+
+var rpcStats = &rpcTimings{byMethod: make(map[string]*methodTiming)}
+
+// traceRPC, when set by --verbose-timing, prints each request's connect,
+// TLS, and first-byte times to stderr as it completes
+var traceRPC bool
+
+type methodTiming struct {
+	Method  string  `json:"method"`
+	Calls   int     `json:"calls"`
+	Bytes   int64   `json:"bytes"`
+	Seconds float64 `json:"seconds"`
+}
+
+func (t *rpcTimings) record(method string, bytes int64, elapsed time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.calls++
+	t.bytes += bytes
+	t.elapsed += elapsed
+	var m = t.byMethod[method]
+	if m == nil {
+		m = &methodTiming{Method: method}
+		t.byMethod[method] = m
+	}
+	m.Calls++
+	m.Bytes += bytes
+	m.Seconds += elapsed.Seconds()
+}
+
+// count returns how many calls have been made so far
+func (t *rpcTimings) count() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.calls
+}
+
+// requestTrace collects the phases of one request for --verbose-timing
+type requestTrace struct {
+	start, connStart, connDone, tlsDone, firstByte time.Time
+}
+
+func (rt *requestTrace) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		ConnectStart:         func(string, string) { rt.connStart = time.Now() },
+		ConnectDone:          func(string, string, error) { rt.connDone = time.Now() },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { rt.tlsDone = time.Now() },
+		GotFirstResponseByte: func() { rt.firstByte = time.Now() },
+	}
+}
+
+// print writes the request's phases.  A reused connection has no connect or
+// TLS phase, so those are left out.
+func (rt *requestTrace) print(method string, bytes int64) {
+	var since = func(t time.Time) string {
+		return t.Sub(rt.start).Round(time.Microsecond).String()
+	}
+	var line = fmt.Sprintf("RPC %s:", method)
+	if !rt.connDone.IsZero() {
+		line += " connect " + rt.connDone.Sub(rt.connStart).Round(time.Microsecond).String() + ","
+	}
+	if !rt.tlsDone.IsZero() {
+		line += " tls done " + since(rt.tlsDone) + ","
+	}
+	if !rt.firstByte.IsZero() {
+		line += " first byte " + since(rt.firstByte) + ","
+	}
+	fmt.Fprintf(os.Stderr, "%s total %s, %s\n", line, since(time.Now()), formatByteSize(bytes))
+}
+
+type walletTiming struct {
+	Wallet   string  `json:"wallet"`
+	Seconds  float64 `json:"seconds"`
+	RPCCalls int     `json:"rpc_calls"`
+}
+
+// timingView is the --timing footer, and the "timing" object in JSON
+type timingView struct {
+	Seconds  float64 `json:"seconds"`
+	RPCCalls int     `json:"rpc_calls"`
+	RPCBytes int64   `json:"rpc_bytes"`
+	RPCTime  float64 `json:"rpc_seconds"`
+
+	// RateWaits and RateWaited are how many requests --rpc-rate-limit held
+	// back, and for how long in all
+	RateWaits  int     `json:"rate_limit_waits,omitempty"`
+	RateWaited float64 `json:"rate_limit_seconds,omitempty"`
+
+	// HeaderHits and HeaderMisses are block headers found in the cache and
+	// fetched from the node, in HeaderBatches batched requests
+	HeaderHits    int `json:"header_cache_hits,omitempty"`
+	HeaderMisses  int `json:"header_cache_misses,omitempty"`
+	HeaderBatches int `json:"header_batches,omitempty"`
+
+	Wallets []walletTiming  `json:"wallets"`
+	Methods []*methodTiming `json:"methods"`
+}
+
+// newTimingView snapshots the RPC totals, busiest method first, and the
+// block header cache's counts
+func newTimingView(started time.Time, wallets []walletTiming, headers *headerCache) *timingView {
+	rpcStats.mu.Lock()
+	defer rpcStats.mu.Unlock()
+	var v = &timingView{
+		Seconds:  time.Since(started).Seconds(),
+		RPCCalls: rpcStats.calls,
+		RPCBytes: rpcStats.bytes,
+		RPCTime:  rpcStats.elapsed.Seconds(),
+		Wallets:  wallets,
+	}
+	var waits, waited = rpcLimit.stats()
+	v.RateWaits, v.RateWaited = waits, waited.Seconds()
+	v.HeaderHits, v.HeaderMisses, v.HeaderBatches = headers.hits, headers.misses, headers.batches
+	for _, m := range rpcStats.byMethod {
+		var c = *m
+		v.Methods = append(v.Methods, &c)
+	}
+	sort.Slice(v.Methods, func(i, j int) bool {
+		if v.Methods[i].Seconds != v.Methods[j].Seconds {
+			return v.Methods[i].Seconds > v.Methods[j].Seconds
+		}
+		return v.Methods[i].Method < v.Methods[j].Method
+	})
+	return v
+}
+
+func (v *timingView) print(w io.Writer) {
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Timing: %0.3fs total, %d RPC call(s) taking %0.3fs, %s received\n", v.Seconds, v.RPCCalls, v.RPCTime, formatByteSize(v.RPCBytes))
+	if v.RateWaits > 0 {
+		fmt.Fprintf(w, "  rate limit: %d call(s) held back, %0.3fs waiting\n", v.RateWaits, v.RateWaited)
+	}
+	if v.HeaderHits+v.HeaderMisses > 0 {
+		fmt.Fprintf(w, "  block headers: %d cached, %d fetched in %d batch(es)\n", v.HeaderHits, v.HeaderMisses, v.HeaderBatches)
+	}
+	for _, wt := range v.Wallets {
+		fmt.Fprintf(w, "  wallet %s: %0.3fs, %d call(s)\n", wt.Wallet, wt.Seconds, wt.RPCCalls)
+	}
+	for _, m := range v.Methods {
+		fmt.Fprintf(w, "  %s: %d call(s), %0.3fs, %s\n", m.Method, m.Calls, m.Seconds, formatByteSize(m.Bytes))
+	}
+}
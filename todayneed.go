@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Nerdmaster/dynamo-tx-stats/stats"
+)
+
+// todayNeed is how far today is short of the window's daily average, and
+// how many blocks of the window's average size would make it up by
+// midnight.  SecondsLeft is only there to say how long that is; nothing is
+// divided by it, so the last minutes of the day are no different.
+type todayNeed struct {
+	Blocks      int64   `json:"blocks"`
+	Coins       float64 `json:"coins"`
+	SecondsLeft float64 `json:"seconds_left"`
+}
+
+// setTodayNeed works out today's todayNeed, leaving it unset once today has
+// already reached the average, or when the window has no blocks to take an
+// average block from.  The days must still be in date order.
+func (v *reportView) setTodayNeed(total stats.Bucket, now time.Time) {
+	if len(v.Daily) == 0 || total.Blocks == 0 {
+		return
+	}
+	var today = &v.Daily[len(v.Daily)-1]
+	if today.Projected == nil {
+		return
+	}
+	var short = v.DailyAverage - today.Coins
+	if toSatoshis(short) <= 0 {
+		return
+	}
+	var perBlock = total.Coins / float64(total.Blocks)
+	today.Need = &todayNeed{
+		Blocks:      int64(math.Ceil(short / perBlock)),
+		Coins:       short,
+		SecondsLeft: getDay(now).AddDate(0, 0, 1).Sub(now).Seconds(),
+	}
+}
+
+func (row reportRow) needTag() string {
+	if row.Need == nil {
+		return ""
+	}
+	var blocks = "blocks"
+	if row.Need.Blocks == 1 {
+		blocks = "block"
+	}
+	return fmt.Sprintf(" (needs %d more %s / %0.2f by midnight)", row.Need.Blocks, blocks, row.Need.Coins)
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+)
+
+type rawTransaction struct {
+	TXID string `json:"txid"`
+	Vin  []struct {
+		TXID     string `json:"txid"`
+		Vout     int64  `json:"vout"`
+		Coinbase string `json:"coinbase"`
+	} `json:"vin"`
+}
+
+func fetchRawTransaction(u *url.URL, txid string) (*rawTransaction, error) {
+	var raw rawTransaction
+	var err = rpcCall(nodeURL(u), "getrawtransaction", []interface{}{txid, true}, &raw)
+	if err != nil {
+		return nil, err
+	}
+	return &raw, nil
+}
+
+// txGraph is a directed graph of spends: an edge from A to B means B spends
+// one of A's outputs
+type txGraph struct {
+	nodes map[string]bool
+	sends map[string]bool
+	edges map[string]map[string]bool
+	in    map[string]int
+}
+
+func buildTxGraph(u *url.URL, sends []*Transaction) *txGraph {
+	var g = &txGraph{
+		nodes: make(map[string]bool),
+		sends: make(map[string]bool),
+		edges: make(map[string]map[string]bool),
+		in:    make(map[string]int),
+	}
+
+	for _, tx := range sends {
+		if g.sends[tx.TXID] {
+			continue
+		}
+		g.sends[tx.TXID] = true
+		g.nodes[tx.TXID] = true
+
+		var raw, err = fetchRawTransaction(u, tx.TXID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to fetch raw transaction %s: %s\n", tx.TXID, err)
+			continue
+		}
+		for _, vin := range raw.Vin {
+			if vin.TXID == "" {
+				continue
+			}
+			g.nodes[vin.TXID] = true
+			if g.edges[vin.TXID] == nil {
+				g.edges[vin.TXID] = make(map[string]bool)
+			}
+			if !g.edges[vin.TXID][tx.TXID] {
+				g.edges[vin.TXID][tx.TXID] = true
+				g.in[tx.TXID]++
+			}
+		}
+	}
+
+	return g
+}
+
+func (g *txGraph) sortedNodes() []string {
+	var list []string
+	for n := range g.nodes {
+		list = append(list, n)
+	}
+	sort.Strings(list)
+	return list
+}
+
+func (g *txGraph) writeDOT(w io.Writer) error {
+	var _, err = fmt.Fprintln(w, "digraph transactions {")
+	if err != nil {
+		return err
+	}
+	for _, n := range g.sortedNodes() {
+		var shape = "ellipse"
+		if g.sends[n] {
+			shape = "box"
+		}
+		fmt.Fprintf(w, "  %q [shape=%s];\n", n, shape)
+	}
+	for _, from := range g.sortedNodes() {
+		var targets []string
+		for to := range g.edges[from] {
+			targets = append(targets, to)
+		}
+		sort.Strings(targets)
+		for _, to := range targets {
+			fmt.Fprintf(w, "  %q -> %q;\n", from, to)
+		}
+	}
+	_, err = fmt.Fprintln(w, "}")
+	return err
+}
+
+// depth returns the longest chain of spends ending at n.  Spends can't form
+// cycles, so plain memoized recursion over the reversed edges is enough.
+func (g *txGraph) depth(n string, parents map[string][]string, memo map[string]int) int {
+	if d, ok := memo[n]; ok {
+		return d
+	}
+	var best = 0
+	for _, p := range parents[n] {
+		if d := g.depth(p, parents, memo) + 1; d > best {
+			best = d
+		}
+	}
+	memo[n] = best
+	return best
+}
+
+func (g *txGraph) printSummary() {
+	var parents = make(map[string][]string)
+	var edgeCount int
+	for from, targets := range g.edges {
+		for to := range targets {
+			parents[to] = append(parents[to], from)
+			edgeCount++
+		}
+	}
+
+	var maxDepth int
+	var memo = make(map[string]int)
+	for n := range g.nodes {
+		if d := g.depth(n, parents, memo); d > maxDepth {
+			maxDepth = d
+		}
+	}
+
+	// Weakly connected components, via union-find
+	var parent = make(map[string]string)
+	var find func(string) string
+	find = func(n string) string {
+		if parent[n] == "" || parent[n] == n {
+			return n
+		}
+		parent[n] = find(parent[n])
+		return parent[n]
+	}
+	for from, targets := range g.edges {
+		for to := range targets {
+			parent[find(from)] = find(to)
+		}
+	}
+	var sendsPerComponent = make(map[string]int)
+	for n := range g.sends {
+		sendsPerComponent[find(n)]++
+	}
+	var isolated, connected int
+	for _, count := range sendsPerComponent {
+		if count == 1 {
+			isolated++
+		} else {
+			connected++
+		}
+	}
+
+	var fanIn float64
+	var avgFanOut float64
+	if len(g.sends) > 0 {
+		fanIn = float64(edgeCount) / float64(len(g.sends))
+	}
+	if len(g.edges) > 0 {
+		avgFanOut = float64(edgeCount) / float64(len(g.edges))
+	}
+
+	fmt.Println()
+	fmt.Println("Transaction graph:")
+	fmt.Printf("Send transactions: %d (%d nodes, %d edges)\n", len(g.sends), len(g.nodes), edgeCount)
+	fmt.Printf("Max depth: %d\n", maxDepth)
+	fmt.Printf("Average fan-in: %0.2f\n", fanIn)
+	fmt.Printf("Average fan-out: %0.2f\n", avgFanOut)
+	fmt.Printf("Components: %d connected, %d isolated\n", connected, isolated)
+}
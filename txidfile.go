@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+)
+
+const rpcInvalidAddressOrKey = -5
+
+type walletTransactionDetail struct {
+	Address  string  `json:"address"`
+	Category string  `json:"category"`
+	Amount   float64 `json:"amount"`
+	Label    string  `json:"label"`
+	Vout     int64   `json:"vout"`
+}
+
+// WalletTransaction is gettransaction's view of a single transaction
+type WalletTransaction struct {
+	TXID          string                    `json:"txid"`
+	Amount        float64                   `json:"amount"`
+	Fee           float64                   `json:"fee"`
+	Confirmations int64                     `json:"confirmations"`
+	Blockhash     string                    `json:"blockhash"`
+	Blockheight   int64                     `json:"blockheight"`
+	Blocktime     int64                     `json:"blocktime"`
+	Time          int64                     `json:"time"`
+	Details       []walletTransactionDetail `json:"details"`
+}
+
+// label returns the first non-empty label among the transaction's outputs
+func (tx *WalletTransaction) label() string {
+	for _, d := range tx.Details {
+		if d.Label != "" {
+			return d.Label
+		}
+	}
+	return ""
+}
+
+// isNotFound reports whether err is the node saying the txid isn't in the
+// wallet
+func isNotFound(err error) bool {
+	var rerr *RPCError
+	return errors.As(err, &rerr) && rerr.Code == rpcInvalidAddressOrKey
+}
+
+// lookupTXID asks each wallet in turn for the transaction and returns the
+// first that knows it.  A nil transaction with no error means no wallet did.
+func lookupTXID(u *url.URL, wallets []string, id string) (string, *WalletTransaction, error) {
+	for _, w := range wallets {
+		var tx *WalletTransaction
+		var err = rpcCall(walletURL(u, w), "gettransaction", []interface{}{id}, &tx)
+		if isNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return w, nil, err
+		}
+		if tx != nil {
+			return w, tx, nil
+		}
+	}
+	return "", nil, nil
+}
+
+// printTXIDReport prints a line for each txid.  A txid no wallet knows is
+// reported as NOT FOUND; any other error is reported on its line, and the
+// return value says whether there were any.
+func printTXIDReport(u *url.URL, wallets []string, txids []string, quiet bool) bool {
+	var failed bool
+	for _, id := range txids {
+		var w, tx, err = lookupTXID(u, wallets, id)
+		switch {
+		case quiet:
+		case err != nil:
+			fmt.Printf("%s\t%s: ERROR: %s\n", id, w, err)
+			failed = true
+		case tx == nil:
+			fmt.Printf("%s\tNOT FOUND\n", id)
+		default:
+			var block = "unconfirmed"
+			if tx.Blockhash != "" {
+				block = fmt.Sprintf("block %d (%s) at %s", tx.Blockheight, tx.Blockhash, time.Unix(tx.Blocktime, 0).Format(dateTimeLayout))
+			}
+			var label = tx.label()
+			if label == "" {
+				label = "-"
+			}
+			fmt.Printf("%s\t%s: amount %0.8f, %d confirmations, label %s, %s\n", id, w, tx.Amount, tx.Confirmations, label, block)
+		}
+	}
+	return failed
+}
+
+func runTXIDFile(u *url.URL, cfg *config, wallets []string) error {
+	var txids, err = readListFile(cfg.TXIDFile)
+	if err != nil {
+		return failure(exitUsage, "Unable to read txid file %q: %s", cfg.TXIDFile, err)
+	}
+	var failed = printTXIDReport(u, wallets, txids, cfg.dryRun)
+	if cfg.dryRun {
+		printPlannedOutputs(cfg)
+		return nil
+	}
+	if failed {
+		fmt.Fprintln(os.Stderr, "Some transactions could not be looked up")
+	}
+	return partialResult(failed)
+}
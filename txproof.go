@@ -0,0 +1,89 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// txProof is a merkle proof that a transaction is in a block, and what the
+// node made of it
+type txProof struct {
+	txid  string
+	proof string
+	block string
+	valid bool
+}
+
+// fetchTxProof gets a proof for txid and has the node check it.  Without
+// -txindex the node can only find a transaction it still has unspent outputs
+// of, so when that fails and a wallet is given, the wallet is asked which
+// block the transaction is in, and the proof is asked for again from that
+// block.
+func fetchTxProof(u *url.URL, txid, wallet string) (*txProof, error) {
+	var p = &txProof{txid: txid}
+	var err = rpcCall(nodeURL(u), "gettxoutproof", []interface{}{[]string{txid}}, &p.proof)
+	if err != nil && wallet != "" {
+		var tx struct {
+			BlockHash string `json:"blockhash"`
+		}
+		if rpcCall(walletURL(u, wallet), "gettransaction", []interface{}{txid}, &tx) == nil && tx.BlockHash != "" {
+			err = rpcCall(nodeURL(u), "gettxoutproof", []interface{}{[]string{txid}, tx.BlockHash}, &p.proof)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var proven []string
+	err = rpcCall(nodeURL(u), "verifytxoutproof", []interface{}{p.proof}, &proven)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range proven {
+		if id == txid {
+			p.valid = true
+		}
+	}
+	if rpcRecorder == nil {
+		p.block, err = proofBlockHash(p.proof)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// proofBlockHash is the hash of the block a proof is for: a proof starts
+// with the block's 80-byte header, and a block's hash is the header's
+// double SHA-256, shown byte-reversed
+func proofBlockHash(proof string) (string, error) {
+	var raw, err = hex.DecodeString(proof)
+	if err != nil {
+		return "", fmt.Errorf("proof isn't hex: %w", err)
+	}
+	if len(raw) < 80 {
+		return "", errors.New("proof is too short to hold a block header")
+	}
+	var first = sha256.Sum256(raw[:80])
+	var hash = sha256.Sum256(first[:])
+	for i, j := 0, len(hash)-1; i < j; i, j = i+1, j-1 {
+		hash[i], hash[j] = hash[j], hash[i]
+	}
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// printTxProof prints the proof, for handing to whoever needs convincing;
+// they can check it with verifytxoutproof, or against the header alone
+func printTxProof(p *txProof) {
+	var valid = "no; the node doesn't vouch for this proof"
+	if p.valid {
+		valid = "yes"
+	}
+	fmt.Printf("Transaction:  %s\n", p.txid)
+	fmt.Printf("Block:        %s\n", p.block)
+	fmt.Printf("Valid:        %s\n", valid)
+	fmt.Printf("Proof:        %s\n", p.proof)
+}
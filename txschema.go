@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// verboseSchema, when set by --verbose-schema, reports each transaction
+// field the node sends that txstats neither reads nor knows to ignore, so
+// a change in the node's output shows up instead of being dropped silently
+var verboseSchema bool
+
+// requiredTxFields are sent for every listtransactions entry by every node
+// version txstats knows of; confirmedTxFields only once it's in a block
+var (
+	requiredTxFields  = []string{"amount", "category", "confirmations", "time", "timereceived", "txid", "vout"}
+	confirmedTxFields = []string{"blockhash", "blockheight", "blockindex", "blocktime"}
+)
+
+// ignoredTxFields are the listtransactions fields txstats knows about but
+// has no use for
+var ignoredTxFields = map[string]bool{
+	"account": true, "bip125-replaceable": true, "comment": true,
+	"mempoolconflicts": true, "otheraccount": true, "parent_descs": true,
+	"replaced_by_txid": true, "replaces_txid": true, "to": true, "trusted": true,
+	"walletconflicts": true, "wtxid": true,
+}
+
+// txSchemaNotes makes sure each missing or unknown field is only mentioned
+// once a run, however many transactions share it
+var txSchemaNotes = struct {
+	mu   sync.Mutex
+	seen map[string]bool
+}{seen: make(map[string]bool)}
+
+func noteTxSchema(key, format string, args ...interface{}) {
+	txSchemaNotes.mu.Lock()
+	defer txSchemaNotes.mu.Unlock()
+	if txSchemaNotes.seen[key] {
+		return
+	}
+	txSchemaNotes.seen[key] = true
+	fmt.Fprintf(os.Stderr, format, args...)
+}
+
+// UnmarshalJSON decodes a listtransactions entry leniently, since node
+// versions and the proxies in front of them don't agree on its shape: a
+// number may come as a string, a missing field is taken as zero with a
+// warning, and a field nobody expected is noted with --verbose-schema.  A
+// field that can't be read as its type at all is still an error, naming
+// the field.  The amounts go by way of json.Number so that one with more
+// significant digits than a float64 holds can be caught rather than
+// silently rounded.
+func (tx *Transaction) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	var err = json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+
+	var amount, fee json.Number
+	var fields = map[string]interface{}{
+		"address":           &tx.Address,
+		"category":          &tx.Category,
+		"amount":            &amount,
+		"fee":               &fee,
+		"vout":              &tx.Vout,
+		"label":             &tx.Label,
+		"confirmations":     &tx.Confirmations,
+		"generated":         &tx.Generated,
+		"blockhash":         &tx.Blockhash,
+		"blockheight":       &tx.Blockheight,
+		"blockindex":        &tx.Blockindex,
+		"blocktime":         &tx.Blocktime,
+		"txid":              &tx.TXID,
+		"involvesWatchonly": &tx.IsWatchonly,
+		"abandoned":         &tx.Abandoned,
+		"time":              &tx.Time,
+		"timereceived":      &tx.TimeReceived,
+	}
+	for name, into := range fields {
+		var value, ok = raw[name]
+		if !ok {
+			continue
+		}
+		err = decodeLenient(value, into)
+		if err != nil {
+			return fmt.Errorf("transaction field %q: %w", name, err)
+		}
+	}
+	var lossy bool
+	tx.Amount, lossy, err = decodeAmount(amount)
+	if err != nil {
+		return fmt.Errorf("transaction field \"amount\": %w", err)
+	}
+	tx.imprecise = lossy
+	tx.Fee, lossy, err = decodeAmount(fee)
+	if err != nil {
+		return fmt.Errorf("transaction field \"fee\": %w", err)
+	}
+	tx.imprecise = tx.imprecise || lossy
+
+	var expected = requiredTxFields
+	if tx.Confirmations > 0 {
+		expected = append(append([]string(nil), requiredTxFields...), confirmedTxFields...)
+	}
+	for _, name := range expected {
+		if _, ok := raw[name]; !ok {
+			noteTxSchema("missing "+name, "WARNING: the node's transactions don't all have a %q field; taking it as zero or empty where it's missing\n", name)
+		}
+	}
+	if verboseSchema {
+		var unknown []string
+		for name := range raw {
+			if fields[name] == nil && !ignoredTxFields[name] {
+				unknown = append(unknown, name)
+			}
+		}
+		sort.Strings(unknown)
+		for _, name := range unknown {
+			noteTxSchema("unknown "+name, "schema: the node sends a transaction field txstats doesn't know: %q, e.g. %s\n", name, raw[name])
+		}
+	}
+	return nil
+}
+
+// decodeLenient decodes value into a *string, *json.Number, *int64, or
+// *bool, taking null as zero and a quoted number or boolean as the thing
+// itself
+func decodeLenient(value json.RawMessage, into interface{}) error {
+	var s = strings.TrimSpace(string(value))
+	if s == "null" {
+		return nil
+	}
+	if p, ok := into.(*string); ok {
+		return json.Unmarshal(value, p)
+	}
+	if strings.HasPrefix(s, `"`) {
+		var err = json.Unmarshal(value, &s)
+		if err != nil {
+			return err
+		}
+		s = strings.TrimSpace(s)
+	}
+
+	var err error
+	var want string
+	switch p := into.(type) {
+	case *json.Number:
+		want = "number"
+		if _, err = strconv.ParseFloat(s, 64); err == nil {
+			*p = json.Number(s)
+		}
+	case *int64:
+		want = "whole number"
+		*p, err = strconv.ParseInt(s, 10, 64)
+	case *bool:
+		want = "boolean"
+		*p, err = strconv.ParseBool(s)
+	default:
+		return fmt.Errorf("can't decode into %T", into)
+	}
+	if err != nil {
+		return fmt.Errorf("%s isn't a %s", value, want)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+// resetTxSchemaNotes forgets which fields have been warned about, as a new
+// run would
+func resetTxSchemaNotes() {
+	txSchemaNotes.mu.Lock()
+	txSchemaNotes.seen = make(map[string]bool)
+	txSchemaNotes.mu.Unlock()
+}
+
+// The testdata files are listtransactions as two node versions send it:
+// the older has no blockheight, which is warned about once for both blocks
+// without it, and the newer adds wtxid, parent_descs,
+// and here a field txstats has never heard of
+func TestDecodeNodeVersions(t *testing.T) {
+	var tests = []struct {
+		file    string
+		want    []Transaction
+		warning string
+		unknown string
+	}{
+		{
+			file: "testdata/listtransactions-older.json",
+			want: []Transaction{
+				{Category: "generate", Amount: 5, Vout: 0, Confirmations: 1204, Generated: true, Blockheight: 0, Time: 1709251200, TimeReceived: 1709251203},
+				{Category: "generate", Amount: 5, Vout: 0, Confirmations: 1100, Generated: true, Blockheight: 0, Time: 1709280000, TimeReceived: 1709280002},
+				{Category: "receive", Amount: 0.25, Vout: 1, Confirmations: 0, Time: 1709337600, TimeReceived: 1709337600},
+			},
+			warning: `WARNING: the node's transactions don't all have a "blockheight" field`,
+		},
+		{
+			file: "testdata/listtransactions-newer.json",
+			want: []Transaction{
+				{Category: "immature", Amount: 5.25, Vout: 0, Confirmations: 12, Generated: true, Blockheight: 812345, Time: 1709510400, TimeReceived: 1709510401},
+			},
+			unknown: `schema: the node sends a transaction field txstats doesn't know: "miningpool", e.g. "solo"`,
+		},
+	}
+	verboseSchema = true
+	defer func() { verboseSchema = false }()
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			resetTxSchemaNotes()
+			var data, err = os.ReadFile(tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var txs []Transaction
+			var stderr = capture(t, &os.Stderr, func() { err = json.Unmarshal(data, &txs) })
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(txs) != len(tt.want) {
+				t.Fatalf("got %d transactions, want %d", len(txs), len(tt.want))
+			}
+			for i, want := range tt.want {
+				var got = txs[i]
+				if got.Category != want.Category || got.Amount != want.Amount || got.Vout != want.Vout ||
+					got.Confirmations != want.Confirmations || got.Generated != want.Generated || got.Blockheight != want.Blockheight ||
+					got.Time != want.Time || got.TimeReceived != want.TimeReceived || len(got.TXID) != 64 {
+					t.Errorf("transaction %d: got %+v", i, got)
+				}
+			}
+
+			var lines = strings.Split(strings.TrimSpace(stderr), "\n")
+			if stderr == "" {
+				lines = nil
+			}
+			var want []string
+			for _, s := range []string{tt.warning, tt.unknown} {
+				if s != "" {
+					want = append(want, s)
+				}
+			}
+			if len(lines) != len(want) {
+				t.Fatalf("got %d notes, want %d:\n%s", len(lines), len(want), stderr)
+			}
+			for i, s := range want {
+				if !strings.HasPrefix(lines[i], s) {
+					t.Errorf("note %d: got %q, want %q", i, lines[i], s)
+				}
+			}
+		})
+	}
+}
+
+// Behind some proxies numbers come quoted; they read the same as bare
+func TestDecodeQuotedNumbers(t *testing.T) {
+	resetTxSchemaNotes()
+	var tx Transaction
+	var err error
+	capture(t, &os.Stderr, func() {
+		err = json.Unmarshal([]byte(`{"txid": "aa", "vout": "1", "category": "generate", "amount": "5.25", "fee": null,
+			"confirmations": " 101 ", "generated": "true", "time": "1709510400", "timereceived": 1709510400,
+			"blockhash": "bb", "blockheight": "812345", "blockindex": 0, "blocktime": 1709510400}`), &tx)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Vout != 1 || tx.Amount != 5.25 || tx.Confirmations != 101 || !tx.Generated || tx.Time != 1709510400 || tx.Blockheight != 812345 {
+		t.Errorf("got %+v", tx)
+	}
+
+	err = json.Unmarshal([]byte(`{"txid": "aa", "vout": 0, "amount": 1, "confirmations": "lots"}`), &tx)
+	if err == nil || err.Error() != `transaction field "confirmations": "lots" isn't a whole number` {
+		t.Errorf("got error %v", err)
+	}
+}
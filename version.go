@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// version can be set at build time, e.g.:
+//
+//	go build -ldflags "-X main.version=v1.2.3"
+//
+// When it's left empty we fall back to whatever the Go toolchain embedded.
+var version string
+
+func versionString() string {
+	var v = version
+	var commit, dirty string
+
+	var info, ok = debug.ReadBuildInfo()
+	if ok {
+		if v == "" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+			v = info.Main.Version
+		}
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				commit = s.Value
+			case "vcs.modified":
+				if s.Value == "true" {
+					dirty = "-dirty"
+				}
+			}
+		}
+	}
+	if v == "" {
+		v = "dev"
+	}
+
+	if len(commit) > 12 {
+		commit = commit[:12]
+	}
+	if commit != "" && !strings.Contains(v, commit) {
+		v += " (" + commit + dirty + ")"
+	}
+
+	return v
+}
+
+func userAgent() string {
+	return "github.com/Nerdmaster/dynamo-tx-stats/" + versionString()
+}
+
+func printVersion() {
+	fmt.Printf("txstats %s %s %s/%s\n", versionString(), runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/Nerdmaster/dynamo-tx-stats/internal/paging"
+	"github.com/Nerdmaster/dynamo-tx-stats/stats"
+)
+
+// listTransactionsPageSize is how many transactions each call asks for when
+// only the recent part of a wallet's history is wanted
+const listTransactionsPageSize = 1000
+
+// listTransactionsSince pages back through the wallet's history, newest
+// first, until a page reaches back before since, so a wallet with a short
+// window doesn't cost a full listtransactions.  Anything older than since is
+// left out.  paging.Pager copes with the wallet changing between pages.
+func listTransactionsSince(u *url.URL, since time.Time, field string) ([]*Transaction, error) {
+	var p = paging.Pager[*Transaction]{
+		Fetch: func(skip, count int) ([]*Transaction, error) {
+			var page []*Transaction
+			var err = rpcCall(u, "listtransactions", []interface{}{"*", count, skip, includeWatchonly}, &page)
+			return page, err
+		},
+		Key:      pageKey,
+		Older:    func(tx *Transaction) bool { return txTime(tx, field).Before(since) },
+		PageSize: listTransactionsPageSize,
+		Limit:    listTransactionsCount,
+		Shifted: func(skip int) {
+			fmt.Fprintf(os.Stderr, "WARNING: %s: the wallet changed while it was paged through; transactions around %d back may be missing\n", u.Redacted(), skip)
+		},
+	}
+	return p.All()
+}
+
+// pageKey tells listtransactions entries apart: a transaction has one per
+// output it touches, and a send to oneself is listed as both
+func pageKey(tx *Transaction) string {
+	return fmt.Sprintf("%s:%d:%s", tx.TXID, tx.Vout, tx.Category)
+}
+
+// widestWindow checks the config file's per-wallet windows for the wallets
+// in the report, and returns the longest of them and the global window
+func widestWindow(days int, wallets []string, walletDays map[string]int) (int, error) {
+	for _, w := range wallets {
+		var d, ok = walletDays[w]
+		if !ok {
+			continue
+		}
+		if d < 1 {
+			return 0, fmt.Errorf("wallet_days for %q must be at least 1, got %d", w, d)
+		}
+		if d > days {
+			days = d
+		}
+	}
+	return days, nil
+}
+
+// walletWindow is one wallet's totals over its own report window
+type walletWindow struct {
+	Wallet       string  `json:"wallet"`
+	Days         int     `json:"days"`
+	Coins        float64 `json:"coins"`
+	Blocks       int64   `json:"blocks"`
+	DailyAverage float64 `json:"daily_average"`
+}
+
+// walletWindows totals each wallet over its configured window, or the
+// global one, ending today
+func walletWindows(txList []*Transaction, wallets []string, walletDays map[string]int, days int, now time.Time) []walletWindow {
+	var accs = make(map[string]*stats.Accumulator)
+	var windows = make(map[string]int)
+	for _, w := range wallets {
+		var d, ok = walletDays[w]
+		if !ok {
+			d = days
+		}
+		windows[w] = d
+		accs[w] = stats.NewAccumulator(getDay(now).AddDate(0, 0, -(d-1)), d)
+	}
+	for _, tx := range txList {
+		if acc := accs[tx.wallet]; acc != nil && countable(tx, now) {
+			acc.Add(stats.Transaction{TXID: tx.TXID, Vout: tx.Vout, Amount: tx.Amount, Blockheight: tx.Blockheight, Time: tx.dt})
+		}
+	}
+
+	var rows []walletWindow
+	for _, w := range wallets {
+		var total = accs[w].Snapshot().Total
+		rows = append(rows, walletWindow{Wallet: w, Days: windows[w], Coins: total.Coins, Blocks: total.Blocks, DailyAverage: total.Coins / float64(windows[w])})
+	}
+	return rows
+}
+
+// printWalletWindows lists each wallet over its own window.  The totals
+// cover different spans, so the span is on every line, along with a note
+// for any wallet that only fills part of the combined table.
+func (v *reportView) printWalletWindows(w io.Writer) {
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Per-wallet windows (totals cover different spans; compare daily averages, not totals):")
+	for _, ww := range v.WalletWindows {
+		var note string
+		if ww.Days < v.Days {
+			note = fmt.Sprintf("  [only the last %d of the %d days above]", ww.Days, v.Days)
+		}
+		fmt.Fprintf(w, "%s (%d days):\t%8.2f\t%0.2f/d\t%d blocks%s\n", ww.Wallet, ww.Days, ww.Coins, ww.DailyAverage, ww.Blocks, note)
+	}
+}
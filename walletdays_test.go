@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// pagedHistory is a wallet's listtransactions, oldest first, that change
+// runs against after each call, as a block or a reorg landing between pages
+// would
+type pagedHistory struct {
+	mu      sync.Mutex
+	txs     []map[string]interface{}
+	pages   int
+	changes func(h *pagedHistory, page int)
+}
+
+func historyTx(i int, t time.Time) map[string]interface{} {
+	return map[string]interface{}{"txid": fmt.Sprintf("%064x", i), "vout": 0, "category": "generate", "amount": 1.0,
+		"confirmations": 120, "time": t.Unix(), "timereceived": t.Unix()}
+}
+
+func (h *pagedHistory) listtransactions(_ string, params []interface{}) (interface{}, *RPCError) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var count, skip = int(params[1].(float64)), int(params[2].(float64))
+	var end = len(h.txs) - skip
+	if end < 0 {
+		end = 0
+	}
+	var start = end - count
+	if start < 0 {
+		start = 0
+	}
+	var page = append([]map[string]interface{}(nil), h.txs[start:end]...)
+	h.pages++
+	if h.changes != nil {
+		h.changes(h, h.pages)
+	}
+	return page, nil
+}
+
+// Transactions that land or are reorged away between pages mustn't make
+// listTransactionsSince list one twice or skip one.  The overlap with the
+// last page is what catches it; the block heights couldn't, as a wallet's
+// blocks are rarely contiguous, so a gap in them says nothing.
+func TestListTransactionsSinceHistoryChanges(t *testing.T) {
+	var since = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var now = since.Add(30 * 24 * time.Hour)
+	var total = 2*listTransactionsPageSize + 500
+
+	var tests = []struct {
+		name    string
+		changes func(h *pagedHistory, page int)
+		warning bool
+	}{
+		{name: "unchanged"},
+		{
+			name: "new transaction after the first page",
+			changes: func(h *pagedHistory, page int) {
+				if page == 1 {
+					h.txs = append(h.txs, historyTx(total, now))
+				}
+			},
+		},
+		{
+			name: "reorg after the first page",
+			changes: func(h *pagedHistory, page int) {
+				if page == 1 {
+					h.txs = h.txs[:len(h.txs)-1]
+				}
+			},
+		},
+		{
+			name: "more than a page of new transactions",
+			changes: func(h *pagedHistory, page int) {
+				if page == 1 {
+					for i := 0; i < listTransactionsPageSize+10; i++ {
+						h.txs = append(h.txs, historyTx(total+i, now))
+					}
+				}
+			},
+			warning: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var h = &pagedHistory{changes: tt.changes}
+			h.txs = append(h.txs, historyTx(-1, since.Add(-time.Hour)))
+			for i := 0; i < total; i++ {
+				h.txs = append(h.txs, historyTx(i, since.Add(time.Duration(i)*time.Minute)))
+			}
+			var node = newFakeNode(t, map[string]fakeMethod{"listtransactions": h.listtransactions})
+
+			var txs []*Transaction
+			var err error
+			var stderr = capture(t, &os.Stderr, func() {
+				txs, err = listTransactionsSince(walletURL(node.url(), "rig1"), since, "timereceived")
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Contains(stderr, "the wallet changed while it was paged through"); got != tt.warning {
+				t.Errorf("warned %v, want %v: %q", got, tt.warning, stderr)
+			}
+
+			var seen = make(map[string]bool)
+			for _, tx := range txs {
+				if seen[tx.TXID] {
+					t.Errorf("%s listed twice", tx.TXID)
+				}
+				seen[tx.TXID] = true
+				if txTime(tx, "timereceived").Before(since) {
+					t.Errorf("%s is older than since", tx.TXID)
+				}
+			}
+			if tt.warning {
+				return
+			}
+			for i := 0; i < total; i++ {
+				if !seen[fmt.Sprintf("%064x", i)] {
+					t.Errorf("transaction %d skipped", i)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// walletError is a wallet fetch that failed, for --no-exit-on-error's
+// closing list
+type walletError struct {
+	wallet string
+	source string
+	err    error
+}
+
+// printWalletErrors lists the failed fetches after everything else, so in a
+// run over many wallets they aren't lost in the scrollback above the report
+func printWalletErrors(w io.Writer, errs []walletError) {
+	if len(errs) == 0 {
+		return
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "--- Errors ---")
+	for _, e := range errs {
+		fmt.Fprintf(w, "%s (%s): %s\n", e.wallet, e.source, e.err)
+	}
+}
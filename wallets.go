@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// discoverWallets asks the node for its loaded wallets and returns those
+// matching include (if set) and not matching exclude (if set)
+func discoverWallets(u *url.URL, include, exclude *regexp.Regexp) ([]string, error) {
+	var names []string
+	var err = rpcCall(nodeURL(u), "listwallets", nil, &names)
+	if err != nil {
+		return nil, err
+	}
+
+	var wallets []string
+	for _, name := range names {
+		if include != nil && !include.MatchString(name) {
+			continue
+		}
+		if exclude != nil && exclude.MatchString(name) {
+			continue
+		}
+		wallets = append(wallets, name)
+	}
+	return wallets, nil
+}
+
+// globWallets asks the node for every wallet in its wallet directory, loaded
+// or not (via listwalletdir), and returns those whose names match pattern
+func globWallets(u *url.URL, pattern string) ([]string, error) {
+	var dir struct {
+		Wallets []struct {
+			Name string `json:"name"`
+		} `json:"wallets"`
+	}
+	var err = rpcCall(nodeURL(u), "listwalletdir", nil, &dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var wallets []string
+	for _, w := range dir.Wallets {
+		if ok, _ := path.Match(pattern, w.Name); ok {
+			wallets = append(wallets, w.Name)
+		}
+	}
+	return wallets, nil
+}
+
+func compileWalletPattern(flagName, pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	var re, err = regexp.Compile(pattern)
+	if err != nil {
+		return nil, usageError("Invalid --" + flagName + " pattern: " + err.Error())
+	}
+	return re, nil
+}
+
+// dedupeWallets drops repeated wallet names, keeping the first of each in
+// order, and returns the names that were repeated
+func dedupeWallets(names []string) ([]string, []string) {
+	var seen = make(map[string]int)
+	var unique, dupes []string
+	for _, w := range names {
+		seen[w]++
+		switch seen[w] {
+		case 1:
+			unique = append(unique, w)
+		case 2:
+			dupes = append(dupes, w)
+		}
+	}
+	return unique, dupes
+}
+
+// readListFile reads one entry per line, such as a wallet name or txid,
+// skipping blank lines and lines starting with "#"
+func readListFile(path string) ([]string, error) {
+	var f, err = os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var wallets []string
+	var scanner = bufio.NewScanner(f)
+	for scanner.Scan() {
+		var line = strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		wallets = append(wallets, line)
+	}
+	return wallets, scanner.Err()
+}
+
+// describeWallets fetches getwalletinfo for each discovered wallet and
+// prints the lot as one table, to stderr so stdout stays the report's.
+// With skipEmpty, wallets that have never had a transaction are dropped
+// from the list.  A wallet whose info can't be fetched is kept and shown
+// as unknown; the report will say what's wrong with it.
+func describeWallets(u *url.URL, wallets []string, skipEmpty bool) []string {
+	var kept []string
+	var rows [][]string
+	for _, w := range wallets {
+		var info walletInfo
+		var err = rpcCall(walletURL(u, w), "getwalletinfo", nil, &info)
+		if err != nil {
+			rows = append(rows, []string{w, "?", "?", "?", "?"})
+			kept = append(kept, w)
+			continue
+		}
+		var scanning = "no"
+		var p scanProgress
+		if json.Unmarshal(info.Scanning, &p) == nil {
+			scanning = fmt.Sprintf("%0.0f%%", p.Progress*100)
+		}
+		var format = info.Format
+		if format == "" {
+			format = "legacy"
+		}
+		rows = append(rows, []string{w, format, strconv.FormatInt(info.KeypoolSize, 10), strconv.FormatInt(info.TxCount, 10), scanning})
+		if skipEmpty && info.TxCount == 0 {
+			continue
+		}
+		kept = append(kept, w)
+	}
+	if rpcRecorder == nil {
+		printTable(os.Stderr, []string{"wallet_name", "format", "keypoolsize", "txcount", "scanning"}, rows)
+		if len(kept) < len(wallets) {
+			fmt.Fprintf(os.Stderr, "(skipping %d wallet(s) with no transactions)\n", len(wallets)-len(kept))
+		}
+		fmt.Fprintln(os.Stderr)
+	}
+	return kept
+}
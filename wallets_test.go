@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// A wallet named twice, on the command line or across it and --wallets-file,
+// is counted once in the order first named, with a warning, or refused
+// under --strict
+func TestResolveWalletsDuplicates(t *testing.T) {
+	var file = filepath.Join(t.TempDir(), "wallets")
+	var err = os.WriteFile(file, []byte("rig3\nrig1\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg = &config{Wallets: []string{"rig2", "rig1", "rig2"}, WalletsFile: file}
+	var wallets []string
+	var stderr = capture(t, &os.Stderr, func() { wallets, err = resolveWallets(nil, cfg) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(wallets, []string{"rig2", "rig1", "rig3"}) {
+		t.Errorf("got %v, want rig2, rig1, rig3", wallets)
+	}
+	if !strings.Contains(stderr, "named more than once, counting each once: rig2, rig1") {
+		t.Errorf("warning %q", stderr)
+	}
+
+	cfg.Strict = true
+	var code int
+	capture(t, &os.Stderr, func() {
+		_, err = resolveWallets(nil, cfg)
+		code = exitCode(err)
+	})
+	if err == nil || !strings.Contains(err.Error(), "Wallet(s) named more than once: rig2, rig1") || code != exitUsage {
+		t.Errorf("--strict: got %v", err)
+	}
+}
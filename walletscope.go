@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// scopeMatch is two wallets whose listings are too alike to be two wallets
+type scopeMatch struct {
+	a, b    string
+	shared  int
+	overlap float64
+}
+
+// entryKeys is the set of a wallet's listing entries.  Category is part of
+// each key, so a payment from one wallet to another, a send in one and a
+// receive in the other, doesn't make them look alike.
+func entryKeys(list []*Transaction) map[string]bool {
+	var keys = make(map[string]bool, len(list))
+	for _, tx := range list {
+		keys[fmt.Sprintf("%s:%d:%s", tx.TXID, tx.Vout, tx.Category)] = true
+	}
+	return keys
+}
+
+// scopeMatches compares every pair of wallets' full listings, and returns
+// the pairs whose overlap (shared entries over all entries between them)
+// is at least threshold.  A wallet with nothing in it matches nothing.
+func scopeMatches(wallets []string, lists map[string][]*Transaction, threshold float64) []scopeMatch {
+	var keys = make([]map[string]bool, len(wallets))
+	for i, w := range wallets {
+		keys[i] = entryKeys(lists[w])
+	}
+	var matches []scopeMatch
+	for i := range wallets {
+		for j := i + 1; j < len(wallets); j++ {
+			if len(keys[i]) == 0 || len(keys[j]) == 0 {
+				continue
+			}
+			var shared int
+			for k := range keys[i] {
+				if keys[j][k] {
+					shared++
+				}
+			}
+			var overlap = float64(shared) / float64(len(keys[i])+len(keys[j])-shared)
+			if overlap >= threshold {
+				matches = append(matches, scopeMatch{a: wallets[i], b: wallets[j], shared: shared, overlap: overlap})
+			}
+		}
+	}
+	return matches
+}
+
+// checkWalletScoping catches an endpoint that ignores the /wallet/<name>
+// path, such as a reverse proxy that strips it, and so answers every wallet
+// with the default wallet's transactions.  That's an error, since the
+// report would count the same coins once per wallet, unless lenient, when
+// it's only a warning.
+func checkWalletScoping(wallets []string, lists map[string][]*Transaction, threshold float64, lenient bool) error {
+	if threshold <= 0 || len(wallets) < 2 {
+		return nil
+	}
+	var matches = scopeMatches(wallets, lists, threshold)
+	if len(matches) == 0 {
+		return nil
+	}
+	var level = "ERROR"
+	if lenient {
+		level = "WARNING"
+	}
+	for _, m := range matches {
+		fmt.Fprintf(os.Stderr, "%s: wallets %q and %q listed %d of the same transactions (%0.0f%% overlap)\n", level, m.a, m.b, m.shared, m.overlap*100)
+	}
+	fmt.Fprintln(os.Stderr, "The endpoint may be ignoring the /wallet/<name> path (a reverse proxy stripping it?) and answering every wallet with the default wallet's transactions, which would count them once per wallet.")
+	if lenient {
+		return nil
+	}
+	return failure(exitAssertion, "Check the proxy's path handling, turn the check off with --wallet-overlap-threshold 0 if these wallets really do list the same transactions, or add --lenient to report anyway")
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Nerdmaster/dynamo-tx-stats/stats"
+)
+
+func parseWeekday(s string) (time.Weekday, error) {
+	var name = strings.ToLower(s)
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		var full = strings.ToLower(d.String())
+		if name == full || name == full[:3] {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid weekday %q", s)
+}
+
+// weekStart returns midnight of the first day of the week containing t
+func weekStart(t time.Time, start time.Weekday) time.Time {
+	var day = getDay(t)
+	var offset = (int(day.Weekday()) - int(start) + 7) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// weekLabel renders the week containing t.  Monday-start weeks use ISO week
+// numbers; any other start uses the week's first date, since an ISO number
+// would be ambiguous for a week that straddles two ISO weeks.
+func weekLabel(t time.Time, start time.Weekday) string {
+	var ws = weekStart(t, start)
+	if start == time.Monday {
+		var year, week = ws.ISOWeek()
+		return fmt.Sprintf("%04d-W%02d", year, week)
+	}
+	return ws.Format(dateLayout) + " (wk)"
+}
+
+func printWeekly(beginReport time.Time, dailyStats []stats.Bucket, start time.Weekday) {
+	var labels []string
+	var weeks = make(map[string]*stats.Bucket)
+	var days = make(map[string]int)
+	for i := range dailyStats {
+		var label = weekLabel(beginReport.AddDate(0, 0, i), start)
+		var ws, ok = weeks[label]
+		if !ok {
+			ws = &stats.Bucket{}
+			weeks[label] = ws
+			labels = append(labels, label)
+		}
+		ws.Merge(dailyStats[i])
+		days[label]++
+	}
+
+	fmt.Println()
+	fmt.Println("Weekly totals:")
+	for _, label := range labels {
+		var ws = weeks[label]
+		var partial = ""
+		if days[label] < 7 {
+			partial = fmt.Sprintf(" (%d days)", days[label])
+		}
+		fmt.Printf("%s:\t\t%8.2f\t\t%0.2f/d\t\tWin%%: %0.4f%%%s\n", label, ws.Coins, ws.Coins/float64(days[label]), ws.RoughPercent(), partial)
+	}
+}
+
+func printWeekdays(beginReport time.Time, dailyStats []stats.Bucket, start time.Weekday) {
+	var totals [7]float64
+	var counts [7]int
+	for i := range dailyStats {
+		var wd = beginReport.AddDate(0, 0, i).Weekday()
+		totals[wd] += dailyStats[i].Coins
+		counts[wd]++
+	}
+
+	fmt.Println()
+	fmt.Println("Day-of-week averages:")
+	for i := 0; i < 7; i++ {
+		var wd = time.Weekday((int(start) + i) % 7)
+		if counts[wd] == 0 {
+			continue
+		}
+		fmt.Printf("%s:\t\t%8.2f\t\t(%d days)\n", wd.String()[:3], totals[wd]/float64(counts[wd]), counts[wd])
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Nerdmaster/dynamo-tx-stats/stats"
+)
+
+// weeklyDays is ten days from Wednesday 6 March 2024, day i earning i+1
+// coins, with blocks won on the first and third days and the eighth
+func weeklyDays() (time.Time, []stats.Bucket) {
+	var begin = time.Date(2024, 3, 6, 0, 0, 0, 0, time.Local)
+	var days = make([]stats.Bucket, 10)
+	for i := range days {
+		days[i].AddCoins(float64(i + 1))
+	}
+	days[0] = stats.Bucket{}
+	days[0].Record(100, 1)
+	days[2] = stats.Bucket{}
+	days[2].Record(103, 3)
+	days[7] = stats.Bucket{}
+	days[7].Record(110, 8)
+	return begin, days
+}
+
+func TestPrintWeekly(t *testing.T) {
+	var tests = []struct {
+		start time.Weekday
+		want  string
+	}{
+		{time.Monday, `
+Weekly totals:
+2024-W10:		   15.00		3.00/d		Win%: 50.0000% (5 days)
+2024-W11:		   40.00		8.00/d		Win%: 100.0000% (5 days)
+`},
+		{time.Sunday, `
+Weekly totals:
+2024-03-03 (wk):		   10.00		2.50/d		Win%: 50.0000% (4 days)
+2024-03-10 (wk):		   45.00		7.50/d		Win%: 100.0000% (6 days)
+`},
+		// A week starting on the report's first day is whole, and the
+		// partial one is the last
+		{time.Wednesday, `
+Weekly totals:
+2024-03-06 (wk):		   28.00		4.00/d		Win%: 50.0000%
+2024-03-13 (wk):		   27.00		9.00/d		Win%: 100.0000% (3 days)
+`},
+	}
+	var begin, days = weeklyDays()
+	for _, tt := range tests {
+		var got = capture(t, &os.Stdout, func() { printWeekly(begin, days, tt.start) })
+		if got != tt.want {
+			t.Errorf("weeks starting %s:\ngot:%s\nwant:%s", tt.start, got, tt.want)
+		}
+	}
+}
+
+func TestPrintWeekdays(t *testing.T) {
+	var tests = []struct {
+		start time.Weekday
+		want  string
+	}{
+		{time.Monday, `
+Day-of-week averages:
+Mon:		    6.00		(1 days)
+Tue:		    7.00		(1 days)
+Wed:		    4.50		(2 days)
+Thu:		    5.50		(2 days)
+Fri:		    6.50		(2 days)
+Sat:		    4.00		(1 days)
+Sun:		    5.00		(1 days)
+`},
+		{time.Sunday, `
+Day-of-week averages:
+Sun:		    5.00		(1 days)
+Mon:		    6.00		(1 days)
+Tue:		    7.00		(1 days)
+Wed:		    4.50		(2 days)
+Thu:		    5.50		(2 days)
+Fri:		    6.50		(2 days)
+Sat:		    4.00		(1 days)
+`},
+	}
+	var begin, days = weeklyDays()
+	for _, tt := range tests {
+		var got = capture(t, &os.Stdout, func() { printWeekdays(begin, days, tt.start) })
+		if got != tt.want {
+			t.Errorf("weeks starting %s:\ngot:%s\nwant:%s", tt.start, got, tt.want)
+		}
+	}
+
+	// A weekday the window doesn't reach is left out
+	var got = capture(t, &os.Stdout, func() { printWeekdays(begin, days[:3], time.Monday) })
+	var want = `
+Day-of-week averages:
+Wed:		    1.00		(1 days)
+Thu:		    2.00		(1 days)
+Fri:		    3.00		(1 days)
+`
+	if got != want {
+		t.Errorf("three days:\ngot:%s\nwant:%s", got, want)
+	}
+}
+
+func TestWeekStart(t *testing.T) {
+	var tests = []struct {
+		day   string
+		start time.Weekday
+		want  string
+		label string
+	}{
+		{"2024-03-10", time.Monday, "2024-03-04", "2024-W10"},
+		{"2024-03-11", time.Monday, "2024-03-11", "2024-W11"},
+		{"2024-03-09", time.Sunday, "2024-03-03", "2024-03-03 (wk)"},
+		{"2024-03-10", time.Sunday, "2024-03-10", "2024-03-10 (wk)"},
+		{"2024-12-30", time.Monday, "2024-12-30", "2025-W01"},
+		{"2025-01-01", time.Saturday, "2024-12-28", "2024-12-28 (wk)"},
+	}
+	for _, tt := range tests {
+		var day, _ = time.ParseInLocation("2006-01-02", tt.day, time.Local)
+		var got = weekStart(day.Add(15*time.Hour), tt.start).Format("2006-01-02")
+		if got != tt.want {
+			t.Errorf("weekStart(%s, %s) = %s, want %s", tt.day, tt.start, got, tt.want)
+		}
+		if label := weekLabel(day, tt.start); label != tt.label {
+			t.Errorf("weekLabel(%s, %s) = %s, want %s", tt.day, tt.start, label, tt.label)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Package window reads the lengths of time the command line takes, so every
+// flag accepts the same forms and says the same thing about a bad one: a
+// Go duration ("36h", "1h30m"), a number of days, weeks, or months ("10d",
+// "2w", "3mo"), or, for a flag whose unit goes without saying, such as
+// --days, a plain number.
+package window
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Day, Week, and Month are the units the suffixes stand for.  A month is
+// taken as 30 days, since a window has to be a fixed length.
+const (
+	Day   = 24 * time.Hour
+	Week  = 7 * Day
+	Month = 30 * Day
+)
+
+var suffixes = []struct {
+	suffix string
+	unit   time.Duration
+}{{"mo", Month}, {"w", Week}, {"d", Day}}
+
+// Parse reads s as a length of time.  A plain number is taken in bare
+// units, or refused when bare is zero, except for 0 itself, which needs no
+// unit.
+func Parse(s string, bare time.Duration) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, errors.New("no length given")
+	}
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		if n == 0 {
+			return 0, nil
+		}
+		if bare == 0 {
+			return 0, fmt.Errorf("%q needs a unit, such as %sm, %sh, or %sd", s, s, s, s)
+		}
+		return scale(s, n, bare)
+	}
+	for _, u := range suffixes {
+		if !strings.HasSuffix(s, u.suffix) {
+			continue
+		}
+		if n, err := strconv.ParseFloat(strings.TrimSuffix(s, u.suffix), 64); err == nil {
+			return scale(s, n, u.unit)
+		}
+	}
+	var d, err = time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("%q isn't a length of time; give a duration such as 90m or 36h, or days, weeks, or months such as 10d, 2w, or 3mo", s)
+	}
+	return d, nil
+}
+
+// scale is n units, as long as that fits in a time.Duration
+func scale(s string, n float64, unit time.Duration) (time.Duration, error) {
+	var d = n * float64(unit)
+	if math.IsNaN(d) || math.Abs(d) >= math.MaxInt64 {
+		return 0, fmt.Errorf("%q is too long", s)
+	}
+	return time.Duration(math.Round(d)), nil
+}
+
+// Days reads s as a whole number of days, a plain number being days
+func Days(s string) (int, error) {
+	var d, err = Parse(s, Day)
+	if err != nil {
+		return 0, err
+	}
+	if d%Day != 0 {
+		return 0, fmt.Errorf("%q isn't a whole number of days", s)
+	}
+	return int(d / Day), nil
+}
+
+type durationValue struct{ p *time.Duration }
+
+func (v durationValue) String() string {
+	if v.p == nil {
+		return time.Duration(0).String()
+	}
+	return v.p.String()
+}
+
+func (v durationValue) Set(s string) error {
+	var d, err = Parse(s, 0)
+	if err == nil {
+		*v.p = d
+	}
+	return err
+}
+
+type daysValue struct{ p *int }
+
+func (v daysValue) String() string {
+	if v.p == nil {
+		return "0"
+	}
+	return strconv.Itoa(*v.p)
+}
+
+func (v daysValue) Set(s string) error {
+	var n, err = Days(s)
+	if err == nil {
+		*v.p = n
+	}
+	return err
+}
+
+// DurationVar defines a duration flag like flag.DurationVar's, that also
+// takes day, week, and month suffixes
+func DurationVar(fs *flag.FlagSet, p *time.Duration, name string, value time.Duration, usage string) {
+	*p = value
+	fs.Var(durationValue{p}, name, usage)
+}
+
+// DaysVar defines a flag for a whole number of days, given as a plain
+// number or any length of time that comes to whole days, such as 2w
+func DaysVar(fs *flag.FlagSet, p *int, name string, value int, usage string) {
+	*p = value
+	fs.Var(daysValue{p}, name, usage)
+}
@@ -0,0 +1,120 @@
+package window
+
+import (
+	"flag"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	var tests = []struct {
+		in   string
+		bare time.Duration
+		want time.Duration
+		err  string
+	}{
+		{"36h", 0, 36 * time.Hour, ""},
+		{"1h30m", 0, 90 * time.Minute, ""},
+		{" 90m ", 0, 90 * time.Minute, ""},
+		{"10d", 0, 10 * Day, ""},
+		{"1.5d", 0, 36 * time.Hour, ""},
+		{"2w", 0, 14 * Day, ""},
+		{"3mo", 0, 90 * Day, ""},
+		{"0", 0, 0, ""},
+		{"7", Day, 7 * Day, ""},
+		{"7", time.Hour, 7 * time.Hour, ""},
+		{"7", 0, 0, `"7" needs a unit, such as 7m, 7h, or 7d`},
+		{"", Day, 0, "no length given"},
+		{"soon", Day, 0, `"soon" isn't a length of time; give a duration such as 90m or 36h, or days, weeks, or months such as 10d, 2w, or 3mo`},
+		{"3y", Day, 0, `"3y" isn't a length of time; give a duration such as 90m or 36h, or days, weeks, or months such as 10d, 2w, or 3mo`},
+		{"999999999mo", 0, 0, `"999999999mo" is too long`},
+	}
+	for _, tt := range tests {
+		var got, err = Parse(tt.in, tt.bare)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("Parse(%q, %v): got error %v, want %q", tt.in, tt.bare, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Parse(%q, %v) = %v, %v; want %v", tt.in, tt.bare, got, err, tt.want)
+		}
+	}
+}
+
+func TestDays(t *testing.T) {
+	var tests = []struct {
+		in   string
+		want int
+		err  string
+	}{
+		{"30", 30, ""},
+		{"2w", 14, ""},
+		{"1mo", 30, ""},
+		{"48h", 2, ""},
+		{"0", 0, ""},
+		{"36h", 0, `"36h" isn't a whole number of days`},
+		{"1.5", 0, `"1.5" isn't a whole number of days`},
+	}
+	for _, tt := range tests {
+		var got, err = Days(tt.in)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("Days(%q): got error %v, want %q", tt.in, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Days(%q) = %d, %v; want %d", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+// A bad value is reported by the flag package under the flag's own name
+func TestDurationVar(t *testing.T) {
+	var tests = []struct {
+		args []string
+		want time.Duration
+		err  string
+	}{
+		{nil, 24 * time.Hour, ""},
+		{[]string{"--stuck-after", "2d"}, 48 * time.Hour, ""},
+		{[]string{"--stuck-after", "90m"}, 90 * time.Minute, ""},
+		{[]string{"--stuck-after", "3"}, 0, `invalid value "3" for flag -stuck-after: "3" needs a unit, such as 3m, 3h, or 3d`},
+		{[]string{"--stuck-after", "later"}, 0, `invalid value "later" for flag -stuck-after: "later" isn't a length of time; give a duration such as 90m or 36h, or days, weeks, or months such as 10d, 2w, or 3mo`},
+	}
+	for _, tt := range tests {
+		var fs = flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		var d time.Duration
+		DurationVar(fs, &d, "stuck-after", 24*time.Hour, "")
+		var err = fs.Parse(tt.args)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("%v: got error %v, want %q", tt.args, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || d != tt.want {
+			t.Errorf("%v: got %v, %v; want %v", tt.args, d, err, tt.want)
+		}
+	}
+}
+
+func TestDaysVar(t *testing.T) {
+	var fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var days int
+	DaysVar(fs, &days, "days", 7, "")
+	var err = fs.Parse([]string{"--days", "2w"})
+	if err != nil || days != 14 {
+		t.Errorf("--days 2w: got %d, %v; want 14", days, err)
+	}
+	err = fs.Parse([]string{"--days", "12h"})
+	var want = `invalid value "12h" for flag -days: "12h" isn't a whole number of days`
+	if err == nil || err.Error() != want {
+		t.Errorf("--days 12h: got error %v, want %q", err, want)
+	}
+}